@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/auth"
+	"github.com/ml0-1337/claude-gate/internal/ui"
+)
+
+// AuthAccountsCmd lists the stored accounts, marking the one selected by --account
+// or CLAUDE_GATE_ACCOUNT
+type AuthAccountsCmd struct {
+	Account string `help:"Account to mark as active (default: CLAUDE_GATE_ACCOUNT, else the default account)" placeholder:"ALIAS"`
+}
+
+func (cmd *AuthAccountsCmd) Run() error {
+	cfg, err := authConfig(cmd.Account)
+	if err != nil {
+		return err
+	}
+
+	factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
+	storage, err := factory.Create()
+	if err != nil {
+		return fmt.Errorf("failed to create storage: %w", err)
+	}
+
+	accounts, err := auth.ListAccounts(storage)
+	if err != nil {
+		return fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	out := ui.NewOutput()
+	out.Title("Stored Accounts")
+	if len(accounts) == 0 {
+		out.Info("No accounts stored. Run 'claude-gate auth login --account ALIAS' to add one.")
+		return nil
+	}
+
+	active := accountName(cfg.Account)
+	rows := make([][]string, 0, len(accounts))
+	for _, account := range accounts {
+		marker := ""
+		if account == active {
+			marker = "*"
+		}
+		rows = append(rows, []string{marker, account, accountTokenStatus(storage, account)})
+	}
+	out.Table([]string{"Active", "Account", "Token"}, rows)
+
+	if !slices.Contains(accounts, active) {
+		out.Warning("The active account %s has no stored token; run '%s'", active, loginCommand(active))
+	}
+	out.Info("Select an account with --account or CLAUDE_GATE_ACCOUNT")
+	return nil
+}
+
+// accountTokenStatus describes the stored token of an account
+func accountTokenStatus(storage auth.StorageBackend, account string) string {
+	token, err := storage.Get(auth.AccountKey(account))
+	switch {
+	case err != nil:
+		return "Error reading token"
+	case token == nil:
+		return "No token"
+	case token.Type != "oauth":
+		return token.Type + " key"
+	case token.IsExpired():
+		return "Expired, refreshed on next use"
+	default:
+		return "Valid until " + time.Unix(token.ExpiresAt, 0).Format("2006-01-02 15:04")
+	}
+}
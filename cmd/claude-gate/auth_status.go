@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/auth"
+	"github.com/ml0-1337/claude-gate/internal/config"
+	"github.com/ml0-1337/claude-gate/internal/ui"
+)
+
+// authStatus is what `auth status` reports about the active account
+type authStatus struct {
+	Account          string `json:"account"`
+	Authenticated    bool   `json:"authenticated"`
+	Type             string `json:"type,omitempty"`
+	ExpiresAt        string `json:"expires_at,omitempty"`
+	ExpiresInSeconds *int64 `json:"expires_in_seconds,omitempty"`
+	Expired          bool   `json:"expired"`
+	UpstreamURL      string `json:"upstream_url"`
+	LoginCommand     string `json:"login_command,omitempty"`
+}
+
+// newAuthStatus describes token, the stored token of the account configured in cfg
+// (nil when there is none), as of now
+func newAuthStatus(cfg *config.Config, token *auth.TokenInfo, now time.Time) authStatus {
+	status := authStatus{
+		Account:     accountName(cfg.Account),
+		UpstreamURL: redactURL(upstreamURL(cfg)),
+	}
+	if token == nil {
+		status.LoginCommand = loginCommand(cfg.Account)
+		return status
+	}
+
+	status.Authenticated = true
+	status.Type = token.Type
+	if token.Type == "oauth" && token.ExpiresAt != 0 {
+		expires := time.Unix(token.ExpiresAt, 0)
+		remaining := int64(expires.Sub(now).Seconds())
+		status.ExpiresAt = expires.UTC().Format(time.RFC3339)
+		status.ExpiresInSeconds = &remaining
+		status.Expired = remaining <= 0
+		if status.Expired {
+			status.LoginCommand = loginCommand(cfg.Account)
+		}
+	}
+	return status
+}
+
+// formatRemaining renders a token's remaining validity to the minute
+func formatRemaining(seconds int64) string {
+	if seconds < 60 {
+		return "less than a minute"
+	}
+	d := (time.Duration(seconds) * time.Second).Truncate(time.Minute)
+	return strings.TrimSuffix(d.String(), "0s")
+}
+
+func (s *StatusCmd) Run() error {
+	cfg, err := authConfig(s.Account)
+	if err != nil {
+		return err
+	}
+
+	// Create storage using factory
+	factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
+
+	storage, err := factory.Create()
+	if err != nil {
+		return fmt.Errorf("failed to create storage: %w", err)
+	}
+
+	token, _ := storage.Get(auth.AccountKey(cfg.Account))
+	status := newAuthStatus(cfg, token, time.Now())
+
+	if s.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	out := ui.NewOutput()
+
+	out.Title("Claude Gate Status")
+
+	// Check authentication
+	out.Info("Account: %s", status.Account)
+	out.Info("Upstream: %s", status.UpstreamURL)
+	if !status.Authenticated {
+		out.Error("Authentication: Not configured")
+		out.Info("Run '%s' to authenticate", status.LoginCommand)
+		return nil
+	}
+
+	if token.Type == "oauth" {
+		out.Success("OAuth Authentication: Configured")
+		switch {
+		case status.ExpiresInSeconds == nil:
+			out.Info("Token has no recorded expiry")
+		case status.Expired:
+			out.Warning("Token expired at %s", time.Unix(token.ExpiresAt, 0).Format("2006-01-02 15:04:05"))
+			out.Info("It is refreshed on next use; if that fails, run '%s'", status.LoginCommand)
+		case token.NeedsRefresh():
+			out.Warning("Token expires in %s and will be refreshed on next use", formatRemaining(*status.ExpiresInSeconds))
+		default:
+			out.Info("Token expires: %s (in %s)", time.Unix(token.ExpiresAt, 0).Format("2006-01-02 15:04:05"), formatRemaining(*status.ExpiresInSeconds))
+		}
+	} else {
+		out.Warning("API Key Authentication: Configured")
+		out.Info("Consider using OAuth for free usage")
+	}
+
+	// Show proxy configuration
+	out.Subtitle("\nProxy Configuration")
+	headers := []string{"Setting", "Value"}
+	rows := [][]string{
+		{"Default host", cfg.Host},
+		{"Default port", fmt.Sprintf("%d", cfg.Port)},
+		{"Auth required", func() string {
+			if len(cfg.LocalAPIKeys()) > 0 {
+				return "Yes"
+			}
+			return "No"
+		}()},
+		{"Log level", cfg.LogLevel},
+	}
+	out.Table(headers, rows)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/auth"
+	"github.com/ml0-1337/claude-gate/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthStatus(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("should report the remaining validity of a token", func(t *testing.T) {
+		// Arrange
+		cfg := config.DefaultConfig()
+		cfg.Account = "work"
+		token := &auth.TokenInfo{Type: "oauth", AccessToken: "secret", ExpiresAt: now.Add(2 * time.Hour).Unix()}
+
+		// Act
+		status := newAuthStatus(cfg, token, now)
+
+		// Assert
+		assert.Equal(t, "work", status.Account)
+		assert.True(t, status.Authenticated)
+		assert.False(t, status.Expired)
+		require.NotNil(t, status.ExpiresInSeconds)
+		assert.Equal(t, int64(7200), *status.ExpiresInSeconds)
+		assert.Equal(t, "2023-11-15T00:13:20Z", status.ExpiresAt)
+		assert.Equal(t, "https://api.anthropic.com", status.UpstreamURL)
+		assert.Empty(t, status.LoginCommand)
+	})
+
+	t.Run("should suggest logging in again once expired", func(t *testing.T) {
+		token := &auth.TokenInfo{Type: "oauth", ExpiresAt: now.Add(-time.Minute).Unix()}
+
+		status := newAuthStatus(config.DefaultConfig(), token, now)
+
+		assert.True(t, status.Expired)
+		assert.Equal(t, "claude-gate auth login", status.LoginCommand)
+	})
+
+	t.Run("should report a missing token", func(t *testing.T) {
+		status := newAuthStatus(config.DefaultConfig(), nil, now)
+
+		assert.Equal(t, "default", status.Account)
+		assert.False(t, status.Authenticated)
+		assert.Nil(t, status.ExpiresInSeconds)
+		assert.Equal(t, "claude-gate auth login", status.LoginCommand)
+	})
+}
+
+func TestFormatRemaining(t *testing.T) {
+	t.Run("should round down to the minute", func(t *testing.T) {
+		assert.Equal(t, "less than a minute", formatRemaining(59))
+		assert.Equal(t, "5m", formatRemaining(5*60+59))
+		assert.Equal(t, "2h13m", formatRemaining(2*3600+13*60+5))
+	})
+}
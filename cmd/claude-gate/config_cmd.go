@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ml0-1337/claude-gate/internal/config"
+)
+
+// ConfigCmd groups configuration commands
+type ConfigCmd struct {
+	Export ConfigExportCmd `cmd:"" help:"Print the effective configuration as environment variables or flags"`
+}
+
+// ConfigExportCmd prints the effective configuration for reproducing a setup elsewhere
+type ConfigExportCmd struct {
+	Format string `help:"Output format (env, flags)" enum:"env,flags" default:"env"`
+}
+
+func (c *ConfigExportCmd) Run() error {
+	cfg := config.DefaultConfig()
+	cfg.LoadFromEnv()
+
+	return c.write(os.Stdout, cfg)
+}
+
+// write prints cfg in the selected format; secrets are always redacted
+func (c *ConfigExportCmd) write(w io.Writer, cfg *config.Config) error {
+	lines := cfg.ExportEnv()
+	if c.Format == "flags" {
+		lines = cfg.ExportFlags()
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,20 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ml0-1337/claude-gate/internal/audit"
 	"github.com/ml0-1337/claude-gate/internal/auth"
 	"github.com/ml0-1337/claude-gate/internal/config"
 	"github.com/ml0-1337/claude-gate/internal/logger"
+	"github.com/ml0-1337/claude-gate/internal/pricing"
 	"github.com/ml0-1337/claude-gate/internal/proxy"
 	"github.com/ml0-1337/claude-gate/internal/ui"
 	"github.com/ml0-1337/claude-gate/internal/ui/components"
@@ -34,40 +40,450 @@ func createStorageFactoryConfig(cfg *config.Config) auth.StorageFactoryConfig {
 	}
 }
 
+// newTokenProvider serves the pre-issued access token when one is configured,
+// otherwise the stored OAuth login
+func newTokenProvider(cfg *config.Config, storage auth.StorageBackend, auditLog *audit.Logger) proxy.TokenProvider {
+	if cfg.AccessToken != "" {
+		return proxy.NewStaticTokenProvider(cfg.AccessToken)
+	}
+	provider := auth.NewOAuthTokenProviderForAccount(storage, cfg.Account)
+	provider.SetAuditLogger(auditLog)
+	return provider
+}
+
+// loginCommand is the command that logs in to an account
+func loginCommand(account string) string {
+	if account == "" || account == auth.DefaultAccount {
+		return "claude-gate auth login"
+	}
+	return "claude-gate auth login --account " + account
+}
+
+// autoTLSHosts are the names the self-signed certificate of --auto-tls is valid for:
+// the loopback names, and host when the proxy binds a specific one
+func autoTLSHosts(host string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if host != "" && host != "0.0.0.0" && host != "::" && !slices.Contains(hosts, host) {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// createProxyConfig creates a ProxyConfig from the main Config
+func createProxyConfig(cfg *config.Config, tokenProvider proxy.TokenProvider, log *slog.Logger) (*proxy.ProxyConfig, error) {
+	if err := auth.ValidateAccount(cfg.Account); err != nil {
+		return nil, err
+	}
+	
+	postProcess, err := proxy.ParseFinishReasonPostProcess(cfg.FinishReasonPostProcess)
+	if err != nil {
+		return nil, err
+	}
+	
+	emptyResponse, err := proxy.ParseEmptyResponseMode(cfg.EmptyResponse)
+	if err != nil {
+		return nil, err
+	}
+	
+	systemMerge, err := proxy.ParseSystemMergeStrategy(cfg.SystemMerge)
+	if err != nil {
+		return nil, err
+	}
+	
+	accessLogFormat, err := proxy.ParseAccessLogFormat(cfg.AccessLog)
+	if err != nil {
+		return nil, err
+	}
+	
+	upstreamProxy, err := proxy.ParseUpstreamProxy(cfg.UpstreamProxy)
+	if err != nil {
+		return nil, err
+	}
+	
+	plainHTTP, err := proxy.ParsePlainHTTP(cfg.PlainHTTP)
+	if err != nil {
+		return nil, err
+	}
+	
+	socketMode, err := proxy.ParseSocketMode(cfg.SocketMode)
+	if err != nil {
+		return nil, err
+	}
+	
+	tlsConfig, err := proxy.LoadTLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.AutoTLS, autoTLSHosts(cfg.Host))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AutoTLS {
+		log.Info("Generated self-signed TLS certificate", "sha256_fingerprint", proxy.CertificateFingerprint(tlsConfig))
+	}
+	
+	transformer := proxy.NewRequestTransformer()
+	transformer.SetLogger(log)
+	transformer.SetFinishReasonPostProcess(postProcess)
+	transformer.SetSystemMergeStrategy(systemMerge)
+	transformer.SetLocale(cfg.Locale)
+	transformer.SetTrimWhitespace(cfg.TrimWhitespace)
+	transformer.SetCacheTools(cfg.CacheTools)
+	transformer.SetRepairToolArguments(cfg.RepairToolArgs)
+	transformer.SetContentFilterFinishReason(cfg.ContentFilterFinishReason)
+	if len(cfg.AllowedBetas) > 0 {
+		allowed := cfg.AllowedBetas
+		if len(allowed) == 1 && strings.EqualFold(allowed[0], "none") {
+			allowed = nil
+		}
+		transformer.SetAllowedBetas(allowed, cfg.RejectDisallowedBetas)
+	} else if cfg.RejectDisallowedBetas {
+		transformer.SetAllowedBetas(proxy.DefaultAllowedBetas, true)
+	}
+	transformer.SetAllowBetaHeader(cfg.AllowBetaHeader)
+	
+	modelVersions, err := proxy.ParseModelVersions(cfg.ModelAnthropicVersions)
+	if err != nil {
+		return nil, err
+	}
+	transformer.SetAnthropicVersions(cfg.AnthropicVersion, modelVersions)
+	
+	accountUpstreams, err := proxy.ParseAccountUpstreams(cfg.AccountBaseURLs, cfg.AccountAnthropicVersions, cfg.AccountBetas)
+	if err != nil {
+		return nil, err
+	}
+	
+	modelTimeouts, err := proxy.ParseModelTimeouts(cfg.ModelTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	
+	priceOverrides, err := pricing.ParseOverrides(cfg.ModelPrices)
+	if err != nil {
+		return nil, err
+	}
+	
+	tokenBudgets, err := proxy.ParseTokenBudgets(cfg.TokenBudgets, cfg.TokenBudgetPeriod)
+	if err != nil {
+		return nil, err
+	}
+	
+	var sessions *proxy.SessionStore
+	if cfg.Sessions {
+		sessions = proxy.NewSessionStore(cfg.SessionTTL, cfg.SessionMaxTurns)
+	}
+	
+	var imageFetcher *proxy.ImageFetcher
+	if cfg.FetchImages {
+		imageFetcher = proxy.NewImageFetcher(nil)
+	}
+	
+	var rateLimiter proxy.RateLimiter
+	if cfg.EnableRateLimit {
+		if cfg.RateLimitPerMinute <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %d: requests per minute must be positive", cfg.RateLimitPerMinute)
+		}
+		rateLimiter = proxy.NewMemoryRateLimiter(cfg.RateLimitPerMinute, cfg.RateLimitBurst)
+	}
+	
+	autoRouter := proxy.NewAutoModelRouter()
+	if err := autoRouter.SetThresholds(cfg.AutoModelMediumThreshold, cfg.AutoModelLargeThreshold); err != nil {
+		return nil, err
+	}
+	transformer.SetAutoModelRouter(autoRouter)
+	
+	return &proxy.ProxyConfig{
+		UpstreamURL:              cfg.AnthropicBaseURL,
+		UpstreamProxy:            upstreamProxy,
+		TokenProvider:            tokenProvider,
+		Transformer:              transformer,
+		Timeout:                  cfg.RequestTimeout,
+		ModelTimeouts:            modelTimeouts,
+		StreamMaxDuration:        cfg.StreamMaxDuration,
+		StreamIdleTimeout:        cfg.StreamIdleTimeout,
+		PartialOnTimeout:         cfg.PartialOnTimeout,
+		Logger:                   log,
+		AccessLogFormat:          accessLogFormat,
+		AccessLogLevel:           logger.ParseLevel(cfg.AccessLogLevel).Slog(),
+		AccessLogBodySize:        cfg.AccessLogBodySize,
+		TagKeys:                  cfg.TagKeys,
+		DisableMetrics:           cfg.DisableMetrics,
+		EmptyResponse:            emptyResponse,
+		Account:                  cfg.Account,
+		AccountUpstreams:         accountUpstreams,
+		MaxConnections:           cfg.MaxConnections,
+		TLS:                      tlsConfig,
+		PlainHTTP:                plainHTTP,
+		Socket:                   cfg.Socket,
+		SocketMode:               socketMode,
+		MaxHeaderBytes:           cfg.MaxHeaderBytes,
+		WarmupUpstream:           cfg.WarmupUpstream,
+		ReadinessCheckUpstream:   cfg.ReadyzUpstream,
+		MaxStreamsPerClient:      cfg.MaxStreamsPerClient,
+		RateLimiter:              rateLimiter,
+		LocalAPIKeys:             cfg.LocalAPIKeys(),
+		CoalesceStreams:          cfg.CoalesceStreams,
+		Sessions:                 sessions,
+		ImageFetcher:             imageFetcher,
+		UpstreamRPS:              cfg.UpstreamRPS,
+		UpstreamQueueTimeout:     cfg.UpstreamQueueTimeout,
+		RetryAfterMaxWait:        cfg.RetryAfterMaxWait,
+		UpstreamRetries:          cfg.UpstreamRetries,
+		UpstreamRetryDelay:       cfg.UpstreamRetryDelay,
+		AdminKey:                 cfg.AdminKey,
+		Betas:                    cfg.Betas,
+		CORSAllowedOrigins:       cfg.CORSAllowOrigins,
+		CORSAllowCredentials:     cfg.CORSAllowCredentials,
+		Passthrough:              cfg.Passthrough,
+		PassthroughMethods:       cfg.PassthroughMethods,
+		Mock:                     cfg.Mock,
+		MockResponse:             cfg.MockResponse,
+		AllowDebugHeaders:        cfg.DebugHeaders,
+		Debug:                    cfg.Debug,
+		ResponseWarnings:         cfg.ResponseWarnings,
+		ValidateRequests:         cfg.ValidateRequests,
+		MaxMessages:              cfg.MaxMessages,
+		TokenBudgets:             tokenBudgets,
+		IncludeModelCapabilities: cfg.ModelsIncludeCapabilities,
+		ModelsCacheTTL:           cfg.ModelsCacheTTL,
+		ModelsTimeout:            cfg.ModelsTimeout,
+		ModelsCacheFile:          cfg.ModelsCacheFile,
+		ModelsEmptyNote:          cfg.ModelsEmptyNote,
+		AllowedModels:            cfg.ModelsAllowlist,
+		Pricing:                  pricing.Default().WithOverrides(priceOverrides),
+	}, nil
+}
+
 type CLI struct {
 	Start     StartCmd     `cmd:"" help:"Start the Claude OAuth proxy server"`
 	Dashboard DashboardCmd `cmd:"" help:"Start server with interactive dashboard"`
 	Auth      AuthCmd      `cmd:"" help:"Authentication management commands"`
 	Test      TestCmd      `cmd:"" help:"Test the proxy connection"`
 	Version   VersionCmd   `cmd:"" help:"Show version information"`
+	Config    ConfigCmd    `cmd:"" help:"Configuration commands"`
+	SelfUpdate SelfUpdateCmd `cmd:"" name:"self-update" help:"Update claude-gate to the latest release"`
 }
 
 type StartCmd struct {
+	Config    string `help:"Read settings from this file of CLAUDE_GATE_* assignments, as written by 'config export'; re-read on SIGHUP" env:"CLAUDE_GATE_CONFIG" type:"path" placeholder:"FILE"`
 	Host      string `help:"Host to bind the proxy server" default:"127.0.0.1"`
 	Port      int    `help:"Port to bind the proxy server" default:"5789"`
-	AuthToken string `help:"Enable proxy authentication with this token" env:"CLAUDE_GATE_PROXY_AUTH_TOKEN"`
+	Socket string `help:"Listen on this Unix domain socket instead of --host and --port; removed on shutdown" type:"path" placeholder:"PATH"`
+	SocketMode string `help:"File permissions of the --socket file, in octal" default:"0660"`
+	MaxConnections int `help:"Maximum simultaneous client connections; more wait in the listen queue (0 = unlimited)" default:"0"`
+	MaxHeaderBytes int `help:"Largest request header block accepted, in bytes; larger requests get 431" default:"65536"`
+	ShutdownGracePeriod time.Duration `help:"How long shutdown waits for in-flight requests and streams before cancelling them" default:"30s"`
+	TLSCert string `help:"Serve HTTPS with this PEM certificate file; needs --tls-key" type:"path" placeholder:"FILE"`
+	TLSKey string `help:"Private key file (PEM) of --tls-cert" type:"path" placeholder:"FILE"`
+	AutoTLS bool `help:"Serve HTTPS with a self-signed certificate generated at startup, for local use"`
+	PlainHTTP string `help:"Answer to plain HTTP requests on the TLS port (reject, redirect)" enum:"reject,redirect" default:"reject"`
+	WarmupUpstream bool `help:"Open the connection to Anthropic at startup with a cheap model list request, so the first request is faster"`
+	ReadyzUpstream bool `help:"Make the /readyz readiness probe also check that Anthropic is reachable"`
+	UpstreamProxy string `help:"HTTP(S) proxy for requests to Anthropic, overriding HTTPS_PROXY/NO_PROXY" placeholder:"URL"`
+	AccessToken string `help:"Use this pre-issued OAuth access token instead of the stored login; it is never refreshed" env:"CLAUDE_GATE_ACCESS_TOKEN"`
+	AuthToken string `help:"Require clients to send this local API key as a bearer token" env:"CLAUDE_GATE_PROXY_AUTH_TOKEN"`
+	APIKeys []string `help:"Further local API keys clients may send instead of --auth-token (comma-separated)" env:"CLAUDE_GATE_API_KEYS" placeholder:"KEY,..."`
+	AdminKey  string `help:"Enable operator endpoints such as /streams, protected by this key" env:"CLAUDE_GATE_ADMIN_KEY"`
 	LogLevel  string `help:"Logging level (DEBUG, INFO, WARNING, ERROR)" default:"INFO"`
+	LogFormat string `help:"Log format on stderr; json logs startup as a single structured event (text, json)" enum:"text,json" default:"text"`
+	AccessLog string `help:"Write an access log line per request to stdout in Common or Combined Log Format, or as JSON (none, common, combined, json)" enum:"none,common,combined,json" default:"none"`
+	AccessLogLevel string `help:"Lowest level of JSON access log lines; 4xx log at WARNING, 5xx at ERROR (DEBUG, INFO, WARNING, ERROR)" default:"INFO"`
+	AccessLogBodySize bool `help:"Add the request body size to JSON access log lines (bodies are never logged)"`
+	AuditLog string `help:"Record authentication events such as token refreshes and rejected keys as JSON lines (stdout, stderr or a file path)" env:"CLAUDE_GATE_AUDIT_LOG"`
+	TagKeys []string `help:"Request tag keys, from OpenAI metadata or the X-Claude-Gate-Tags header, to add to logs and metrics" placeholder:"KEY,..."`
+	DisableMetrics bool `help:"Remove the Prometheus /metrics endpoint"`
+	DebugHeaders bool `help:"Let clients request transform summary response headers with X-Claude-Gate-Debug: transform"`
+	Debug bool `help:"Enable debugging endpoints such as /v1/debug/translate (not for production)"`
+	ResponseWarnings bool `help:"List request adjustments (dropped params, clamped values, substituted models) in OpenAI responses under x_claude_gate_warnings"`
 	SkipAuthCheck bool `help:"Skip OAuth authentication check"`
+	ValidateRequests bool `help:"Validate chat completion requests against the OpenAI schema before translating them"`
+	MaxMessages int `help:"Reject requests with more messages than this (0 = unlimited)" default:"0"`
+	ModelTimeouts []string `help:"Per-model request timeouts, matched by model prefix, overriding the global timeout" placeholder:"MODEL=DURATION,..."`
+	StreamMaxDuration time.Duration `help:"Maximum duration of a stream, overriding per-model and global timeouts (0 = not set)" default:"0"`
+	StreamIdleTimeout time.Duration `help:"End a stream when Anthropic sends nothing for this long (0 = no limit)" default:"0"`
+	PartialOnTimeout bool `help:"Stream non-streaming chat completions internally so one that times out returns its output so far with finish_reason length"`
+	TokenBudgets []string `help:"Token budgets per client API key, tracked in memory and reset on restart" placeholder:"KEY=TOKENS[/PERIOD],..." env:"CLAUDE_GATE_TOKEN_BUDGETS"`
+	TokenBudgetPeriod time.Duration `help:"Budget period for --token-budgets entries without one" default:"720h"`
+	MaxStreamsPerClient int `help:"Maximum concurrent streams per client, by API key or IP (0 = unlimited)" default:"0"`
+	EnableRateLimit bool `help:"Limit the requests of each client, by API key or IP; over the limit they get 429"`
+	RateLimitPerMinute int `help:"Requests each client may send per minute with --enable-rate-limit" default:"60"`
+	RateLimitBurst int `help:"Requests each client may send at once with --enable-rate-limit" default:"10"`
+	CoalesceStreams bool `help:"Share one upstream call between identical in-flight streaming requests at temperature 0"`
+	Sessions bool `help:"Keep conversation turns in memory for clients sending only new messages with an X-Claude-Gate-Session header; lost on restart"`
+	SessionTTL time.Duration `help:"How long an idle session is kept with --sessions" default:"30m"`
+	SessionMaxTurns int `help:"Turns kept per session with --sessions; older ones are dropped" default:"20"`
+	UpstreamRps float64 `name:"upstream-rps" help:"Maximum requests per second sent to Anthropic across all clients (0 = unlimited)" default:"0"`
+	UpstreamQueueTimeout time.Duration `help:"How long a request may queue behind --upstream-rps before failing with 429" default:"30s"`
+	RetryAfterMaxWait time.Duration `help:"Longest Retry-After to wait out and retry once when a stream is rate limited before it starts (0 = never retry)" default:"5s"`
+	UpstreamRetries int `help:"Retries of upstream requests failing transiently; completions only retry connection errors (0 = none)" default:"2"`
+	UpstreamRetryDelay time.Duration `help:"First upstream retry backoff, doubled per attempt with jitter" default:"500ms"`
+	AllowedOrigins []string `help:"Origins given CORS headers: exact origins, * for any, or wildcard subdomains such as https://*.example.com" default:"*" placeholder:"ORIGIN,..."`
+	CORSCredentials bool `name:"cors-credentials" help:"Allow credentialed CORS requests from the allowed origins (ignored for *)"`
+	FinishReasonPostprocess string `help:"Post-process responses truncated by the token limit (none, trim-to-sentence, append-notice)" enum:"none,trim-to-sentence,append-notice" default:"none"`
+	EmptyResponse string `help:"Answer non-streaming chat completions without content with the empty string, a single space or a 502 error (pass, space, error)" enum:"pass,space,error" default:"pass"`
+	SystemMerge string `help:"How multiple OpenAI system messages are merged (blocks, newline, space)" enum:"blocks,newline,space" default:"blocks"`
+	Locale string `help:"Ask for every response in this language, e.g. fr or Japanese, unless the request sets X-Claude-Gate-Locale" placeholder:"LOCALE"`
+	TrimWhitespace bool `help:"Trim leading and trailing whitespace from OpenAI response content, including streams"`
+	CacheTools bool `help:"Mark the tool definitions of OpenAI requests cacheable so a reused tool set is billed at the prompt cache rate"`
+	RepairToolArgs bool `help:"Complete streamed tool call arguments that end as truncated JSON instead of reporting an error"`
+	ContentFilterFinishReason bool `help:"Report Anthropic refusals with finish_reason content_filter instead of stop"`
+	FetchImages bool `help:"Download remote image URLs in OpenAI requests and send them inline; without it only data URLs are accepted"`
+	ModelCapabilities bool `help:"Include context_window and max_output_tokens in /v1/models (not part of the OpenAI schema)"`
+	ModelsCacheTtl time.Duration `name:"models-cache-ttl" help:"Cache the live Anthropic model list for this long; the static list is served if a fetch fails (0 = static list only)" default:"1h"`
+	ModelsTimeout time.Duration `help:"Give up on a live Anthropic model list fetch after this long" default:"30s"`
+	ModelsCacheFile string `help:"Persist the model cache to this file so restarts serve it immediately" type:"path"`
+	ModelsEmptyNote bool `help:"Explain an empty /v1/models list in an x_note field (not part of the OpenAI schema)"`
+	ModelsAllowlist []string `help:"Only list these model IDs at /v1/models (default all)" placeholder:"MODEL,..."`
+	ModelPrices []string `help:"Override prices served at /v1/models/pricing, in USD per million tokens, matched by model prefix" placeholder:"MODEL=INPUT/OUTPUT[/CACHE_WRITE/CACHE_READ],..."`
+	Passthrough bool `help:"Forward /v1/ paths the proxy does not handle itself to Anthropic"`
+	PassthroughMethods []string `help:"HTTP methods forwarded by --passthrough (default GET,HEAD)" placeholder:"METHOD,..."`
+	Mock bool `help:"Answer requests with canned, deterministic responses instead of calling Anthropic (no authentication needed)"`
+	MockResponse string `help:"Reply text returned by --mock (default: echo the last user message)" placeholder:"TEXT"`
+	AnthropicVersion string `help:"Default anthropic-version header sent upstream" placeholder:"VERSION"`
+	ModelAnthropicVersions []string `help:"Per-model anthropic-version overrides, matched by model prefix" placeholder:"MODEL=VERSION,..."`
+	Betas []string `help:"anthropic-beta values sent with every upstream request besides the OAuth beta" placeholder:"BETA,..."`
+	AllowedBetas []string `help:"Beta features the proxy may enable automatically ('none' to disable all)" placeholder:"BETA,..."`
+	RejectDisallowedBetas bool `help:"Reject requests that need a beta feature outside --allowed-betas instead of sending them without it"`
+	AllowBetaHeader bool `help:"Let clients add anthropic-beta values per request with their anthropic-beta or X-Claude-Gate-Beta header (trusted clients only)"`
+	AutoModelMediumThreshold int `help:"Estimated input tokens at which claude-auto moves from Haiku to Sonnet" default:"2000"`
+	AutoModelLargeThreshold int `help:"Estimated input tokens at which claude-auto moves from Sonnet to Opus" default:"20000"`
+	Account string `help:"Stored account to serve, as named at 'auth login --account' (default: the default account)" placeholder:"ALIAS"`
+	AccountBaseURLs []string `help:"Anthropic base URL per account, replacing the global one when that account is served" placeholder:"ACCOUNT=URL,..."`
+	AccountAnthropicVersions []string `help:"Default anthropic-version per account; per-model overrides still apply" placeholder:"ACCOUNT=VERSION,..."`
+	AccountBetas []string `help:"Beta allowlist per account, replacing --allowed-betas ('none' to disable all)" placeholder:"ACCOUNT=BETA+BETA,..."`
+
+	// explicit holds the flags given on the command line, which override the config
+	// file and environment, also when the file is reloaded
+	explicit map[string]bool
 }
 
 type DashboardCmd struct {
 	Host      string `help:"Host to bind the proxy server" default:"127.0.0.1"`
 	Port      int    `help:"Port to bind the proxy server" default:"5789"`
-	AuthToken string `help:"Enable proxy authentication with this token" env:"CLAUDE_GATE_PROXY_AUTH_TOKEN"`
+	Socket string `help:"Listen on this Unix domain socket instead of --host and --port; removed on shutdown" type:"path" placeholder:"PATH"`
+	SocketMode string `help:"File permissions of the --socket file, in octal" default:"0660"`
+	MaxConnections int `help:"Maximum simultaneous client connections; more wait in the listen queue (0 = unlimited)" default:"0"`
+	MaxHeaderBytes int `help:"Largest request header block accepted, in bytes; larger requests get 431" default:"65536"`
+	ShutdownGracePeriod time.Duration `help:"How long shutdown waits for in-flight requests and streams before cancelling them" default:"30s"`
+	TLSCert string `help:"Serve HTTPS with this PEM certificate file; needs --tls-key" type:"path" placeholder:"FILE"`
+	TLSKey string `help:"Private key file (PEM) of --tls-cert" type:"path" placeholder:"FILE"`
+	AutoTLS bool `help:"Serve HTTPS with a self-signed certificate generated at startup, for local use"`
+	PlainHTTP string `help:"Answer to plain HTTP requests on the TLS port (reject, redirect)" enum:"reject,redirect" default:"reject"`
+	WarmupUpstream bool `help:"Open the connection to Anthropic at startup with a cheap model list request, so the first request is faster"`
+	ReadyzUpstream bool `help:"Make the /readyz readiness probe also check that Anthropic is reachable"`
+	UpstreamProxy string `help:"HTTP(S) proxy for requests to Anthropic, overriding HTTPS_PROXY/NO_PROXY" placeholder:"URL"`
+	AccessToken string `help:"Use this pre-issued OAuth access token instead of the stored login; it is never refreshed" env:"CLAUDE_GATE_ACCESS_TOKEN"`
+	AuthToken string `help:"Require clients to send this local API key as a bearer token" env:"CLAUDE_GATE_PROXY_AUTH_TOKEN"`
+	APIKeys []string `help:"Further local API keys clients may send instead of --auth-token (comma-separated)" env:"CLAUDE_GATE_API_KEYS" placeholder:"KEY,..."`
+	AdminKey  string `help:"Enable operator endpoints such as /streams, protected by this key" env:"CLAUDE_GATE_ADMIN_KEY"`
 	LogLevel  string `help:"Logging level (DEBUG, INFO, WARNING, ERROR)" default:"INFO"`
+	LogFormat string `help:"Log format on stderr; json logs startup as a single structured event (text, json)" enum:"text,json" default:"text"`
+	AccessLog string `help:"Write an access log line per request to stdout in Common or Combined Log Format, or as JSON (none, common, combined, json)" enum:"none,common,combined,json" default:"none"`
+	AccessLogLevel string `help:"Lowest level of JSON access log lines; 4xx log at WARNING, 5xx at ERROR (DEBUG, INFO, WARNING, ERROR)" default:"INFO"`
+	AccessLogBodySize bool `help:"Add the request body size to JSON access log lines (bodies are never logged)"`
+	AuditLog string `help:"Record authentication events such as token refreshes and rejected keys as JSON lines (stdout, stderr or a file path)" env:"CLAUDE_GATE_AUDIT_LOG"`
+	TagKeys []string `help:"Request tag keys, from OpenAI metadata or the X-Claude-Gate-Tags header, to add to logs and metrics" placeholder:"KEY,..."`
+	DisableMetrics bool `help:"Remove the Prometheus /metrics endpoint"`
+	DebugHeaders bool `help:"Let clients request transform summary response headers with X-Claude-Gate-Debug: transform"`
+	Debug bool `help:"Enable debugging endpoints such as /v1/debug/translate (not for production)"`
+	ResponseWarnings bool `help:"List request adjustments (dropped params, clamped values, substituted models) in OpenAI responses under x_claude_gate_warnings"`
 	SkipAuthCheck bool `help:"Skip OAuth authentication check"`
+	ValidateRequests bool `help:"Validate chat completion requests against the OpenAI schema before translating them"`
+	MaxMessages int `help:"Reject requests with more messages than this (0 = unlimited)" default:"0"`
+	ModelTimeouts []string `help:"Per-model request timeouts, matched by model prefix, overriding the global timeout" placeholder:"MODEL=DURATION,..."`
+	StreamMaxDuration time.Duration `help:"Maximum duration of a stream, overriding per-model and global timeouts (0 = not set)" default:"0"`
+	StreamIdleTimeout time.Duration `help:"End a stream when Anthropic sends nothing for this long (0 = no limit)" default:"0"`
+	PartialOnTimeout bool `help:"Stream non-streaming chat completions internally so one that times out returns its output so far with finish_reason length"`
+	TokenBudgets []string `help:"Token budgets per client API key, tracked in memory and reset on restart" placeholder:"KEY=TOKENS[/PERIOD],..." env:"CLAUDE_GATE_TOKEN_BUDGETS"`
+	TokenBudgetPeriod time.Duration `help:"Budget period for --token-budgets entries without one" default:"720h"`
+	MaxStreamsPerClient int `help:"Maximum concurrent streams per client, by API key or IP (0 = unlimited)" default:"0"`
+	EnableRateLimit bool `help:"Limit the requests of each client, by API key or IP; over the limit they get 429"`
+	RateLimitPerMinute int `help:"Requests each client may send per minute with --enable-rate-limit" default:"60"`
+	RateLimitBurst int `help:"Requests each client may send at once with --enable-rate-limit" default:"10"`
+	CoalesceStreams bool `help:"Share one upstream call between identical in-flight streaming requests at temperature 0"`
+	Sessions bool `help:"Keep conversation turns in memory for clients sending only new messages with an X-Claude-Gate-Session header; lost on restart"`
+	SessionTTL time.Duration `help:"How long an idle session is kept with --sessions" default:"30m"`
+	SessionMaxTurns int `help:"Turns kept per session with --sessions; older ones are dropped" default:"20"`
+	UpstreamRps float64 `name:"upstream-rps" help:"Maximum requests per second sent to Anthropic across all clients (0 = unlimited)" default:"0"`
+	UpstreamQueueTimeout time.Duration `help:"How long a request may queue behind --upstream-rps before failing with 429" default:"30s"`
+	RetryAfterMaxWait time.Duration `help:"Longest Retry-After to wait out and retry once when a stream is rate limited before it starts (0 = never retry)" default:"5s"`
+	UpstreamRetries int `help:"Retries of upstream requests failing transiently; completions only retry connection errors (0 = none)" default:"2"`
+	UpstreamRetryDelay time.Duration `help:"First upstream retry backoff, doubled per attempt with jitter" default:"500ms"`
+	AllowedOrigins []string `help:"Origins given CORS headers: exact origins, * for any, or wildcard subdomains such as https://*.example.com" default:"*" placeholder:"ORIGIN,..."`
+	CORSCredentials bool `name:"cors-credentials" help:"Allow credentialed CORS requests from the allowed origins (ignored for *)"`
+	FinishReasonPostprocess string `help:"Post-process responses truncated by the token limit (none, trim-to-sentence, append-notice)" enum:"none,trim-to-sentence,append-notice" default:"none"`
+	EmptyResponse string `help:"Answer non-streaming chat completions without content with the empty string, a single space or a 502 error (pass, space, error)" enum:"pass,space,error" default:"pass"`
+	SystemMerge string `help:"How multiple OpenAI system messages are merged (blocks, newline, space)" enum:"blocks,newline,space" default:"blocks"`
+	Locale string `help:"Ask for every response in this language, e.g. fr or Japanese, unless the request sets X-Claude-Gate-Locale" placeholder:"LOCALE"`
+	TrimWhitespace bool `help:"Trim leading and trailing whitespace from OpenAI response content, including streams"`
+	CacheTools bool `help:"Mark the tool definitions of OpenAI requests cacheable so a reused tool set is billed at the prompt cache rate"`
+	RepairToolArgs bool `help:"Complete streamed tool call arguments that end as truncated JSON instead of reporting an error"`
+	ContentFilterFinishReason bool `help:"Report Anthropic refusals with finish_reason content_filter instead of stop"`
+	FetchImages bool `help:"Download remote image URLs in OpenAI requests and send them inline; without it only data URLs are accepted"`
+	ModelCapabilities bool `help:"Include context_window and max_output_tokens in /v1/models (not part of the OpenAI schema)"`
+	ModelsCacheTtl time.Duration `name:"models-cache-ttl" help:"Cache the live Anthropic model list for this long; the static list is served if a fetch fails (0 = static list only)" default:"1h"`
+	ModelsTimeout time.Duration `help:"Give up on a live Anthropic model list fetch after this long" default:"30s"`
+	ModelsCacheFile string `help:"Persist the model cache to this file so restarts serve it immediately" type:"path"`
+	ModelsEmptyNote bool `help:"Explain an empty /v1/models list in an x_note field (not part of the OpenAI schema)"`
+	ModelsAllowlist []string `help:"Only list these model IDs at /v1/models (default all)" placeholder:"MODEL,..."`
+	ModelPrices []string `help:"Override prices served at /v1/models/pricing, in USD per million tokens, matched by model prefix" placeholder:"MODEL=INPUT/OUTPUT[/CACHE_WRITE/CACHE_READ],..."`
+	Passthrough bool `help:"Forward /v1/ paths the proxy does not handle itself to Anthropic"`
+	PassthroughMethods []string `help:"HTTP methods forwarded by --passthrough (default GET,HEAD)" placeholder:"METHOD,..."`
+	Mock bool `help:"Answer requests with canned, deterministic responses instead of calling Anthropic (no authentication needed)"`
+	MockResponse string `help:"Reply text returned by --mock (default: echo the last user message)" placeholder:"TEXT"`
+	AnthropicVersion string `help:"Default anthropic-version header sent upstream" placeholder:"VERSION"`
+	ModelAnthropicVersions []string `help:"Per-model anthropic-version overrides, matched by model prefix" placeholder:"MODEL=VERSION,..."`
+	Betas []string `help:"anthropic-beta values sent with every upstream request besides the OAuth beta" placeholder:"BETA,..."`
+	AllowedBetas []string `help:"Beta features the proxy may enable automatically ('none' to disable all)" placeholder:"BETA,..."`
+	RejectDisallowedBetas bool `help:"Reject requests that need a beta feature outside --allowed-betas instead of sending them without it"`
+	AllowBetaHeader bool `help:"Let clients add anthropic-beta values per request with their anthropic-beta or X-Claude-Gate-Beta header (trusted clients only)"`
+	AutoModelMediumThreshold int `help:"Estimated input tokens at which claude-auto moves from Haiku to Sonnet" default:"2000"`
+	AutoModelLargeThreshold int `help:"Estimated input tokens at which claude-auto moves from Sonnet to Opus" default:"20000"`
+	Account string `help:"Stored account to serve, as named at 'auth login --account' (default: the default account)" placeholder:"ALIAS"`
+	AccountBaseURLs []string `help:"Anthropic base URL per account, replacing the global one when that account is served" placeholder:"ACCOUNT=URL,..."`
+	AccountAnthropicVersions []string `help:"Default anthropic-version per account; per-model overrides still apply" placeholder:"ACCOUNT=VERSION,..."`
+	AccountBetas []string `help:"Beta allowlist per account, replacing --allowed-betas ('none' to disable all)" placeholder:"ACCOUNT=BETA+BETA,..."`
 }
 
 type AuthCmd struct {
 	Login   LoginCmd         `cmd:"" help:"Authenticate with Claude Pro/Max using OAuth"`
 	Logout  LogoutCmd        `cmd:"" help:"Clear stored authentication credentials"`
 	Status  StatusCmd        `cmd:"" help:"Check authentication status"`
+	Accounts AuthAccountsCmd `cmd:"" help:"List stored accounts and show which one is active"`
 	Storage AuthStorageCmd   `cmd:"" help:"Manage token storage backends"`
 }
 
-type LoginCmd struct{}
-type LogoutCmd struct{}
-type StatusCmd struct{}
+type LoginCmd struct {
+	Account string `help:"Account to log in to, e.g. personal or work (default: the default account)" placeholder:"ALIAS"`
+	NoBrowser bool `help:"Paste the authorization code into the terminal instead of receiving it on a local callback server"`
+	Timeout time.Duration `help:"How long to wait for the browser to complete authorization" default:"5m"`
+}
+type LogoutCmd struct {
+	Account string `help:"Account to log out of (default: the default account)" placeholder:"ALIAS"`
+	All bool `help:"Log out of every stored account"`
+}
+type StatusCmd struct {
+	Account string `help:"Account to show (default: the default account)" placeholder:"ALIAS"`
+	JSON bool `name:"json" help:"Print the status as JSON for scripts"`
+}
+
+// authConfig loads the configuration of an auth command; a non-empty account flag
+// overrides CLAUDE_GATE_ACCOUNT
+func authConfig(account string) (*config.Config, error) {
+	cfg := config.DefaultConfig()
+	cfg.LoadFromEnv()
+	if account != "" {
+		cfg.Account = account
+	}
+	if err := auth.ValidateAccount(cfg.Account); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// accountName is the alias of an account, naming the default one
+func accountName(account string) string {
+	if account == "" {
+		return auth.DefaultAccount
+	}
+	return account
+}
 
 type TestCmd struct {
 	BaseURL string `help:"Proxy server URL" default:"http://localhost:5789"`
@@ -75,18 +491,143 @@ type TestCmd struct {
 
 type VersionCmd struct{}
 
-func (s *StartCmd) Run() error {
+// flagSettings maps each start flag to a function copying its value into cfg. Flag
+// defaults are applied before the config file and environment, and flags given on
+// the command line after them, so they take precedence.
+func (s *StartCmd) flagSettings(cfg *config.Config) map[string]func() {
+	return map[string]func(){
+		"host": func() { cfg.Host = s.Host },
+		"port": func() { cfg.Port = s.Port },
+		"socket": func() { cfg.Socket = s.Socket },
+		"socket-mode": func() { cfg.SocketMode = s.SocketMode },
+		"max-connections": func() { cfg.MaxConnections = s.MaxConnections },
+		"max-header-bytes": func() { cfg.MaxHeaderBytes = s.MaxHeaderBytes },
+		"shutdown-grace-period": func() { cfg.ShutdownGracePeriod = s.ShutdownGracePeriod },
+		"tls-cert": func() { cfg.TLSCertFile = s.TLSCert },
+		"tls-key": func() { cfg.TLSKeyFile = s.TLSKey },
+		"auto-tls": func() { cfg.AutoTLS = s.AutoTLS },
+		"plain-http": func() { cfg.PlainHTTP = s.PlainHTTP },
+		"warmup-upstream": func() { cfg.WarmupUpstream = s.WarmupUpstream },
+		"readyz-upstream": func() { cfg.ReadyzUpstream = s.ReadyzUpstream },
+		"upstream-proxy": func() { cfg.UpstreamProxy = s.UpstreamProxy },
+		"access-token": func() { cfg.AccessToken = s.AccessToken },
+		"auth-token": func() { cfg.ProxyAuthToken = s.AuthToken },
+		"api-keys": func() { cfg.APIKeys = s.APIKeys },
+		"admin-key": func() { cfg.AdminKey = s.AdminKey },
+		"log-level": func() { cfg.LogLevel = s.LogLevel },
+		"log-format": func() { cfg.LogFormat = s.LogFormat },
+		"access-log": func() { cfg.AccessLog = s.AccessLog },
+		"access-log-level": func() { cfg.AccessLogLevel = s.AccessLogLevel },
+		"access-log-body-size": func() { cfg.AccessLogBodySize = s.AccessLogBodySize },
+		"audit-log": func() { cfg.AuditLog = s.AuditLog },
+		"tag-keys": func() { cfg.TagKeys = s.TagKeys },
+		"disable-metrics": func() { cfg.DisableMetrics = s.DisableMetrics },
+		"debug-headers": func() { cfg.DebugHeaders = s.DebugHeaders },
+		"debug": func() { cfg.Debug = s.Debug },
+		"response-warnings": func() { cfg.ResponseWarnings = s.ResponseWarnings },
+		"validate-requests": func() { cfg.ValidateRequests = s.ValidateRequests },
+		"max-messages": func() { cfg.MaxMessages = s.MaxMessages },
+		"model-timeouts": func() { cfg.ModelTimeouts = s.ModelTimeouts },
+		"stream-max-duration": func() { cfg.StreamMaxDuration = s.StreamMaxDuration },
+		"stream-idle-timeout": func() { cfg.StreamIdleTimeout = s.StreamIdleTimeout },
+		"partial-on-timeout": func() { cfg.PartialOnTimeout = s.PartialOnTimeout },
+		"token-budgets": func() { cfg.TokenBudgets = s.TokenBudgets },
+		"token-budget-period": func() { cfg.TokenBudgetPeriod = s.TokenBudgetPeriod },
+		"max-streams-per-client": func() { cfg.MaxStreamsPerClient = s.MaxStreamsPerClient },
+		"enable-rate-limit": func() { cfg.EnableRateLimit = s.EnableRateLimit },
+		"rate-limit-per-minute": func() { cfg.RateLimitPerMinute = s.RateLimitPerMinute },
+		"rate-limit-burst": func() { cfg.RateLimitBurst = s.RateLimitBurst },
+		"coalesce-streams": func() { cfg.CoalesceStreams = s.CoalesceStreams },
+		"sessions": func() { cfg.Sessions = s.Sessions },
+		"session-ttl": func() { cfg.SessionTTL = s.SessionTTL },
+		"session-max-turns": func() { cfg.SessionMaxTurns = s.SessionMaxTurns },
+		"upstream-rps": func() { cfg.UpstreamRPS = s.UpstreamRps },
+		"upstream-queue-timeout": func() { cfg.UpstreamQueueTimeout = s.UpstreamQueueTimeout },
+		"retry-after-max-wait": func() { cfg.RetryAfterMaxWait = s.RetryAfterMaxWait },
+		"upstream-retries": func() { cfg.UpstreamRetries = s.UpstreamRetries },
+		"upstream-retry-delay": func() { cfg.UpstreamRetryDelay = s.UpstreamRetryDelay },
+		"allowed-origins": func() { cfg.CORSAllowOrigins = s.AllowedOrigins },
+		"cors-credentials": func() { cfg.CORSAllowCredentials = s.CORSCredentials },
+		"finish-reason-postprocess": func() { cfg.FinishReasonPostProcess = s.FinishReasonPostprocess },
+		"empty-response": func() { cfg.EmptyResponse = s.EmptyResponse },
+		"system-merge": func() { cfg.SystemMerge = s.SystemMerge },
+		"locale": func() { cfg.Locale = s.Locale },
+		"trim-whitespace": func() { cfg.TrimWhitespace = s.TrimWhitespace },
+		"cache-tools": func() { cfg.CacheTools = s.CacheTools },
+		"repair-tool-args": func() { cfg.RepairToolArgs = s.RepairToolArgs },
+		"content-filter-finish-reason": func() { cfg.ContentFilterFinishReason = s.ContentFilterFinishReason },
+		"fetch-images": func() { cfg.FetchImages = s.FetchImages },
+		"model-capabilities": func() { cfg.ModelsIncludeCapabilities = s.ModelCapabilities },
+		"models-cache-ttl": func() { cfg.ModelsCacheTTL = s.ModelsCacheTtl },
+		"models-timeout": func() { cfg.ModelsTimeout = s.ModelsTimeout },
+		"models-cache-file": func() { cfg.ModelsCacheFile = s.ModelsCacheFile },
+		"models-empty-note": func() { cfg.ModelsEmptyNote = s.ModelsEmptyNote },
+		"models-allowlist": func() { cfg.ModelsAllowlist = s.ModelsAllowlist },
+		"model-prices": func() { cfg.ModelPrices = s.ModelPrices },
+		"passthrough": func() { cfg.Passthrough = s.Passthrough },
+		"passthrough-methods": func() { cfg.PassthroughMethods = s.PassthroughMethods },
+		"mock": func() { cfg.Mock = s.Mock },
+		"mock-response": func() { cfg.MockResponse = s.MockResponse },
+		"anthropic-version": func() { cfg.AnthropicVersion = s.AnthropicVersion },
+		"model-anthropic-versions": func() { cfg.ModelAnthropicVersions = s.ModelAnthropicVersions },
+		"betas": func() { cfg.Betas = s.Betas },
+		"allowed-betas": func() { cfg.AllowedBetas = s.AllowedBetas },
+		"reject-disallowed-betas": func() { cfg.RejectDisallowedBetas = s.RejectDisallowedBetas },
+		"allow-beta-header": func() { cfg.AllowBetaHeader = s.AllowBetaHeader },
+		"auto-model-medium-threshold": func() { cfg.AutoModelMediumThreshold = s.AutoModelMediumThreshold },
+		"auto-model-large-threshold": func() { cfg.AutoModelLargeThreshold = s.AutoModelLargeThreshold },
+		"account": func() { cfg.Account = s.Account },
+		"account-base-ur-ls": func() { cfg.AccountBaseURLs = s.AccountBaseURLs },
+		"account-anthropic-versions": func() { cfg.AccountAnthropicVersions = s.AccountAnthropicVersions },
+		"account-betas": func() { cfg.AccountBetas = s.AccountBetas },
+	}
+}
+
+// explicitFlags returns the names of the flags given on the command line
+func explicitFlags(ctx *kong.Context) map[string]bool {
+	flags := make(map[string]bool)
+	for _, path := range ctx.Path {
+		if path.Flag != nil {
+			flags[path.Flag.Name] = true
+		}
+	}
+	return flags
+}
+
+// buildConfig creates the effective configuration: defaults, then the config file,
+// the environment and the flags given on the command line, each overriding the last
+func (s *StartCmd) buildConfig() (*config.Config, error) {
 	cfg := config.DefaultConfig()
-	cfg.Host = s.Host
-	cfg.Port = s.Port
-	cfg.ProxyAuthToken = s.AuthToken
-	cfg.LogLevel = s.LogLevel
+	settings := s.flagSettings(cfg)
+	for _, apply := range settings {
+		apply()
+	}
+	if s.Config != "" {
+		if err := cfg.LoadFromFile(s.Config); err != nil {
+			return nil, err
+		}
+	}
 	cfg.LoadFromEnv()
+	for name := range s.explicit {
+		if apply, ok := settings[name]; ok {
+			apply()
+		}
+	}
+	return cfg, nil
+}
+
+func (s *StartCmd) Run(kctx *kong.Context) error {
+	s.explicit = explicitFlags(kctx)
+	cfg, err := s.buildConfig()
+	if err != nil {
+		return err
+	}
 	
 	out := ui.NewOutput()
 	
-	// Check authentication unless skipped
-	if !s.SkipAuthCheck {
+	// Check authentication unless skipped; mock mode and a pre-issued access token
+	// never use the stored login
+	if !s.SkipAuthCheck && !cfg.Mock && cfg.AccessToken == "" {
 		// Create storage using factory
 		factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
 		
@@ -95,37 +636,21 @@ func (s *StartCmd) Run() error {
 			return fmt.Errorf("failed to create storage: %w", err)
 		}
 		
-		token, err := storage.Get("anthropic")
+		token, err := storage.Get(auth.AccountKey(cfg.Account))
 		if err != nil || token == nil || token.Type != "oauth" {
 			out.Error("No OAuth authentication found!")
-			out.Info("Please run 'claude-gate auth login' first to set up OAuth.")
+			out.Info("Please run '%s' first to set up OAuth.", loginCommand(cfg.Account))
 			return fmt.Errorf("authentication required")
 		}
 		out.Success("OAuth authentication configured and ready")
 	}
 	
-	// Print startup banner
-	out.Title("🚀 Claude OAuth Proxy")
-	
-	headers := []string{"Configuration", "Value"}
-	rows := [][]string{
-		{"Server URL", fmt.Sprintf("http://%s", cfg.GetBindAddress())},
-		{"Anthropic API", cfg.AnthropicBaseURL},
-		{"Proxy Auth", func() string {
-			if cfg.ProxyAuthToken != "" {
-				return "Enabled"
-			}
-			return "Disabled"
-		}()},
-		{"OpenAI Compatible", fmt.Sprintf("http://%s/v1", cfg.GetBindAddress())},
-	}
-	out.Table(headers, rows)
+	// Create logger; its level can change on a reload
+	level := new(slog.LevelVar)
+	level.Set(logger.ParseLevel(cfg.LogLevel).Slog())
+	log := logger.NewWithLevelVar(level, cfg.LogFormat)
 	
-	if cfg.ProxyAuthToken == "" {
-		out.Warning("Proxy authentication disabled - anyone can use this proxy")
-	}
-	
-	out.Info("\nPress CTRL+C to stop the server")
+	announceStartup(cfg, out, log)
 	
 	// Create proxy server with storage
 	factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
@@ -135,37 +660,66 @@ func (s *StartCmd) Run() error {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
 	
-	tokenProvider := auth.NewOAuthTokenProvider(storage)
-	transformer := proxy.NewRequestTransformer()
+	// Authentication events go to the audit log, separate from request logs
+	auditLog, auditCloser, err := audit.Open(cfg.AuditLog)
+	if err != nil {
+		return err
+	}
+	defer auditCloser.Close()
 	
-	// Create logger
-	log := logger.New(logger.ParseLevel(cfg.LogLevel))
+	tokenProvider := newTokenProvider(cfg, storage, auditLog)
 	
-	proxyConfig := &proxy.ProxyConfig{
-		UpstreamURL:   cfg.AnthropicBaseURL,
-		TokenProvider: tokenProvider,
-		Transformer:   transformer,
-		Timeout:       cfg.RequestTimeout,
-		Logger:        log,
+	proxyConfig, err := createProxyConfig(cfg, tokenProvider, log)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
+	proxyConfig.Audit = auditLog
 	
 	server := proxy.NewProxyServer(proxyConfig, cfg.GetBindAddress(), storage)
 	
-	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	// Serve until SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	
+	serveErr := make(chan error, 1)
 	go func() {
-		<-sigChan
-		out.Info("\n\nShutting down proxy server...")
-		if err := server.Stop(30 * time.Second); err != nil {
-			out.Error("Error during shutdown: %v", err)
-		}
+		serveErr <- server.Start()
 	}()
 	
-	// Start server
-	if err := server.Start(); err != nil && err != http.ErrServerClosed {
-		return fmt.Errorf("server error: %w", err)
+	// SIGHUP re-reads the configuration
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	
+serving:
+	for {
+		select {
+		case err := <-serveErr:
+			if err != nil && err != http.ErrServerClosed {
+				return fmt.Errorf("server error: %w", err)
+			}
+			return nil
+		case <-hup:
+			cfg = s.reload(cfg, server, level, log)
+		case <-ctx.Done():
+			break serving
+		}
+	}
+	// A second signal kills the process without waiting for the drain
+	stop()
+	
+	// Drain in-flight requests before exiting; streams still running at the end of
+	// the grace period are cancelled
+	out.Info("\n\nShutting down proxy server...")
+	result, err := server.Shutdown(cfg.ShutdownGracePeriod)
+	log.Info("shutdown complete",
+		"grace_period", cfg.ShutdownGracePeriod,
+		"in_flight", result.InFlight,
+		"drained", result.Drained,
+		"cancelled", result.Cancelled,
+	)
+	if err != nil {
+		out.Error("Error during shutdown: %v", err)
 	}
 	
 	out.Success("Proxy server stopped")
@@ -176,14 +730,94 @@ func (d *DashboardCmd) Run() error {
 	cfg := config.DefaultConfig()
 	cfg.Host = d.Host
 	cfg.Port = d.Port
+	cfg.Socket = d.Socket
+	cfg.SocketMode = d.SocketMode
+	cfg.MaxConnections = d.MaxConnections
+	cfg.MaxHeaderBytes = d.MaxHeaderBytes
+	cfg.ShutdownGracePeriod = d.ShutdownGracePeriod
+	cfg.TLSCertFile = d.TLSCert
+	cfg.TLSKeyFile = d.TLSKey
+	cfg.AutoTLS = d.AutoTLS
+	cfg.PlainHTTP = d.PlainHTTP
+	cfg.WarmupUpstream = d.WarmupUpstream
+	cfg.ReadyzUpstream = d.ReadyzUpstream
+	cfg.UpstreamProxy = d.UpstreamProxy
+	cfg.AccessToken = d.AccessToken
 	cfg.ProxyAuthToken = d.AuthToken
+	cfg.APIKeys = d.APIKeys
+	cfg.AdminKey = d.AdminKey
 	cfg.LogLevel = d.LogLevel
+	cfg.LogFormat = d.LogFormat
+	cfg.AccessLog = d.AccessLog
+	cfg.AccessLogLevel = d.AccessLogLevel
+	cfg.AccessLogBodySize = d.AccessLogBodySize
+	cfg.AuditLog = d.AuditLog
+	cfg.TagKeys = d.TagKeys
+	cfg.DisableMetrics = d.DisableMetrics
+	cfg.DebugHeaders = d.DebugHeaders
+	cfg.Debug = d.Debug
+	cfg.ResponseWarnings = d.ResponseWarnings
+	cfg.ValidateRequests = d.ValidateRequests
+	cfg.MaxMessages = d.MaxMessages
+	cfg.ModelTimeouts = d.ModelTimeouts
+	cfg.StreamMaxDuration = d.StreamMaxDuration
+	cfg.StreamIdleTimeout = d.StreamIdleTimeout
+	cfg.PartialOnTimeout = d.PartialOnTimeout
+	cfg.TokenBudgets = d.TokenBudgets
+	cfg.TokenBudgetPeriod = d.TokenBudgetPeriod
+	cfg.MaxStreamsPerClient = d.MaxStreamsPerClient
+	cfg.EnableRateLimit = d.EnableRateLimit
+	cfg.RateLimitPerMinute = d.RateLimitPerMinute
+	cfg.RateLimitBurst = d.RateLimitBurst
+	cfg.CoalesceStreams = d.CoalesceStreams
+	cfg.Sessions = d.Sessions
+	cfg.SessionTTL = d.SessionTTL
+	cfg.SessionMaxTurns = d.SessionMaxTurns
+	cfg.UpstreamRPS = d.UpstreamRps
+	cfg.UpstreamQueueTimeout = d.UpstreamQueueTimeout
+	cfg.RetryAfterMaxWait = d.RetryAfterMaxWait
+	cfg.UpstreamRetries = d.UpstreamRetries
+	cfg.UpstreamRetryDelay = d.UpstreamRetryDelay
+	cfg.CORSAllowOrigins = d.AllowedOrigins
+	cfg.CORSAllowCredentials = d.CORSCredentials
+	cfg.FinishReasonPostProcess = d.FinishReasonPostprocess
+	cfg.EmptyResponse = d.EmptyResponse
+	cfg.SystemMerge = d.SystemMerge
+	cfg.Locale = d.Locale
+	cfg.TrimWhitespace = d.TrimWhitespace
+	cfg.CacheTools = d.CacheTools
+	cfg.RepairToolArgs = d.RepairToolArgs
+	cfg.ContentFilterFinishReason = d.ContentFilterFinishReason
+	cfg.FetchImages = d.FetchImages
+	cfg.ModelsIncludeCapabilities = d.ModelCapabilities
+	cfg.ModelsCacheTTL = d.ModelsCacheTtl
+	cfg.ModelsTimeout = d.ModelsTimeout
+	cfg.ModelsCacheFile = d.ModelsCacheFile
+	cfg.ModelsEmptyNote = d.ModelsEmptyNote
+	cfg.ModelsAllowlist = d.ModelsAllowlist
+	cfg.ModelPrices = d.ModelPrices
+	cfg.Passthrough = d.Passthrough
+	cfg.PassthroughMethods = d.PassthroughMethods
+	cfg.Mock = d.Mock
+	cfg.MockResponse = d.MockResponse
+	cfg.AnthropicVersion = d.AnthropicVersion
+	cfg.ModelAnthropicVersions = d.ModelAnthropicVersions
+	cfg.Betas = d.Betas
+	cfg.AllowedBetas = d.AllowedBetas
+	cfg.RejectDisallowedBetas = d.RejectDisallowedBetas
+	cfg.AllowBetaHeader = d.AllowBetaHeader
+	cfg.AutoModelMediumThreshold = d.AutoModelMediumThreshold
+	cfg.AutoModelLargeThreshold = d.AutoModelLargeThreshold
+	cfg.Account = d.Account
+	cfg.AccountBaseURLs = d.AccountBaseURLs
+	cfg.AccountAnthropicVersions = d.AccountAnthropicVersions
+	cfg.AccountBetas = d.AccountBetas
 	cfg.LoadFromEnv()
 	
 	out := ui.NewOutput()
 	
 	// Check authentication unless skipped
-	if !d.SkipAuthCheck {
+	if !d.SkipAuthCheck && !cfg.Mock && cfg.AccessToken == "" {
 		// Create storage using factory
 		factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
 		
@@ -192,10 +826,10 @@ func (d *DashboardCmd) Run() error {
 			return fmt.Errorf("failed to create storage: %w", err)
 		}
 		
-		token, err := storage.Get("anthropic")
+		token, err := storage.Get(auth.AccountKey(cfg.Account))
 		if err != nil || token == nil || token.Type != "oauth" {
 			out.Error("No OAuth authentication found!")
-			out.Info("Please run 'claude-gate auth login' first to set up OAuth.")
+			out.Info("Please run '%s' first to set up OAuth.", loginCommand(cfg.Account))
 			return fmt.Errorf("authentication required")
 		}
 	}
@@ -208,19 +842,23 @@ func (d *DashboardCmd) Run() error {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
 	
-	tokenProvider := auth.NewOAuthTokenProvider(storage)
-	transformer := proxy.NewRequestTransformer()
+	// Authentication events go to the audit log, separate from request logs
+	auditLog, auditCloser, err := audit.Open(cfg.AuditLog)
+	if err != nil {
+		return err
+	}
+	defer auditCloser.Close()
+	
+	tokenProvider := newTokenProvider(cfg, storage, auditLog)
 	
 	// Create logger
-	log := logger.New(logger.ParseLevel(cfg.LogLevel))
+	log := logger.NewWithFormat(logger.ParseLevel(cfg.LogLevel), cfg.LogFormat)
 	
-	proxyConfig := &proxy.ProxyConfig{
-		UpstreamURL:   cfg.AnthropicBaseURL,
-		TokenProvider: tokenProvider,
-		Transformer:   transformer,
-		Timeout:       cfg.RequestTimeout,
-		Logger:        log,
+	proxyConfig, err := createProxyConfig(cfg, tokenProvider, log)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
 	}
+	proxyConfig.Audit = auditLog
 	
 	server := proxy.NewEnhancedProxyServer(proxyConfig, cfg.GetBindAddress(), storage)
 	
@@ -264,7 +902,7 @@ func (d *DashboardCmd) Run() error {
 	
 	// Shutdown server
 	out.Info("\nShutting down proxy server...")
-	if err := server.Stop(30 * time.Second); err != nil {
+	if err := server.Stop(cfg.ShutdownGracePeriod); err != nil {
 		out.Error("Error during shutdown: %v", err)
 	}
 	
@@ -273,8 +911,11 @@ func (d *DashboardCmd) Run() error {
 }
 
 func (l *LoginCmd) Run() error {
-	cfg := config.DefaultConfig()
-	cfg.LoadFromEnv()
+	cfg, err := authConfig(l.Account)
+	if err != nil {
+		return err
+	}
+	accountKey := auth.AccountKey(cfg.Account)
 	
 	// Create storage using factory
 	factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
@@ -284,24 +925,42 @@ func (l *LoginCmd) Run() error {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
 	
+	auditLog, auditCloser, err := audit.Open(cfg.AuditLog)
+	if err != nil {
+		return err
+	}
+	defer auditCloser.Close()
+	
 	client := auth.NewOAuthClient()
 	out := ui.NewOutput()
 	
 	// Check if already authenticated
-	existing, _ := storage.Get("anthropic")
+	existing, _ := storage.Get(accountKey)
 	if existing != nil && existing.Type == "oauth" {
-		out.Warning("Already authenticated!")
+		out.Warning("Account %s is already authenticated!", accountName(cfg.Account))
 		if !components.Confirm("Do you want to re-authenticate?") {
 			return nil
 		}
 		err := components.RunSpinner("Removing existing authentication...", func() error {
-			return storage.Remove("anthropic")
+			return storage.Remove(accountKey)
 		})
 		if err != nil {
 			return err
 		}
 	}
 	
+	// Unless the code is to be pasted, the browser hands it to a local callback server
+	var callback *auth.CallbackServer
+	if !l.NoBrowser {
+		callback, err = auth.NewCallbackServer()
+		if err != nil {
+			out.Warning("Could not start the local callback server (%v); falling back to pasting the code", err)
+		} else {
+			defer callback.Close()
+			client.RedirectURI = callback.RedirectURI()
+		}
+	}
+	
 	// Get authorization URL
 	var authData *auth.AuthData
 	var authErr error
@@ -323,27 +982,39 @@ func (l *LoginCmd) Run() error {
 		return fmt.Errorf("failed to generate authorization URL: %w", err)
 	}
 	
-	// Run interactive OAuth flow
-	code, err := ui.RunOAuthFlow(authData.URL)
-	if err != nil {
-		return fmt.Errorf("authentication canceled: %w", err)
+	var code string
+	if callback != nil {
+		code, err = l.waitForCallback(out, callback, authData, cfg.Account)
+		if err != nil {
+			auditLog.Record(audit.LoginFailed, slog.String("account", accountName(cfg.Account)), slog.Any("error", err))
+			return err
+		}
+	} else {
+		// Run interactive OAuth flow
+		code, err = ui.RunOAuthFlow(authData.URL)
+		if err != nil {
+			return fmt.Errorf("authentication canceled: %w", err)
+		}
+		code = strings.TrimSpace(code)
 	}
-	code = strings.TrimSpace(code)
 	
 	// Exchange code for tokens
 	var token *auth.TokenInfo
-	err = components.RunSpinner("Exchanging code for tokens...", func() error {
+	err = components.RunSpinnerWithMessages("Exchanging code for tokens...", func(setMessage func(string)) error {
 		var err error
 		token, err = client.ExchangeCode(code, authData.Verifier)
 		if err != nil {
 			return err
 		}
 		// Save tokens
-		return storage.Set("anthropic", token)
+		setMessage("Saving tokens...")
+		return storage.Set(accountKey, token)
 	})
 	if err != nil {
+		auditLog.Record(audit.LoginFailed, slog.String("account", accountName(cfg.Account)), slog.Any("error", err))
 		return fmt.Errorf("authentication failed: %w", err)
 	}
+	auditLog.Record(audit.Login, slog.String("account", accountName(cfg.Account)), slog.Bool("reauthenticated", existing != nil && existing.Type == "oauth"))
 	
 	out.Success("\nAuthentication successful!")
 	out.Success("Your Claude Pro/Max account is now connected.")
@@ -352,38 +1023,40 @@ func (l *LoginCmd) Run() error {
 	return nil
 }
 
-func (l *LogoutCmd) Run() error {
-	cfg := config.DefaultConfig()
-	cfg.LoadFromEnv()
-	
-	// Create storage using factory
-	factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
-	
-	storage, err := factory.Create()
-	if err != nil {
-		return fmt.Errorf("failed to create storage: %w", err)
+// waitForCallback opens the browser on the authorization URL and waits for it to be
+// redirected to the callback server with the code
+func (l *LoginCmd) waitForCallback(out *ui.Output, callback *auth.CallbackServer, authData *auth.AuthData, account string) (string, error) {
+	out.Info("Opening your browser to authorize Claude Gate...")
+	if err := ui.OpenBrowser(authData.URL); err != nil {
+		out.Warning("Could not open a browser. Open this URL to authorize:")
+	} else {
+		out.Info("If it did not open, visit this URL:")
 	}
+	fmt.Printf("\n%s\n\n", authData.URL)
 	
-	out := ui.NewOutput()
+	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+	defer cancel()
 	
-	if !components.Confirm("Are you sure you want to logout?") {
-		return nil
-	}
-	
-	err = components.RunSpinner("Removing authentication...", func() error {
-		return storage.Remove("anthropic")
+	var code string
+	err := components.RunSpinner("Waiting for authorization in your browser...", func() error {
+		var err error
+		code, err = callback.Wait(ctx, authData.Verifier)
+		return err
 	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", fmt.Errorf("timed out after %s waiting for the browser; run '%s --no-browser' to paste the code instead", l.Timeout, loginCommand(account))
+	}
 	if err != nil {
-		return fmt.Errorf("failed to remove authentication: %w", err)
+		return "", fmt.Errorf("authentication failed: %w", err)
 	}
-	
-	out.Success("Logged out successfully")
-	return nil
+	return code, nil
 }
 
-func (s *StatusCmd) Run() error {
-	cfg := config.DefaultConfig()
-	cfg.LoadFromEnv()
+func (l *LogoutCmd) Run() error {
+	cfg, err := authConfig(l.Account)
+	if err != nil {
+		return err
+	}
 	
 	// Create storage using factory
 	factory := auth.NewStorageFactory(createStorageFactoryConfig(cfg))
@@ -393,49 +1066,72 @@ func (s *StatusCmd) Run() error {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
 	
+	auditLog, auditCloser, err := audit.Open(cfg.AuditLog)
+	if err != nil {
+		return err
+	}
+	defer auditCloser.Close()
+	
 	out := ui.NewOutput()
 	
-	out.Title("Claude Gate Status")
+	if l.All && l.Account != "" {
+		return fmt.Errorf("--all and --account cannot be combined")
+	}
 	
-	// Check authentication
-	token, err := storage.Get("anthropic")
-	if err != nil || token == nil {
-		out.Error("Authentication: Not configured")
-		out.Info("Run 'claude-gate auth login' to authenticate")
+	accounts := []string{accountName(cfg.Account)}
+	question := fmt.Sprintf("Are you sure you want to log out of account %s?", accounts[0])
+	if l.All {
+		accounts, err = auth.ListAccounts(storage)
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+		question = fmt.Sprintf("Are you sure you want to log out of all %d accounts (%s)?", len(accounts), strings.Join(accounts, ", "))
+	} else if token, _ := storage.Get(auth.AccountKey(cfg.Account)); token == nil {
+		accounts = nil
+	}
+	if len(accounts) == 0 {
+		out.Info("No stored accounts to log out of")
 		return nil
 	}
 	
-	if token.Type == "oauth" {
-		out.Success("OAuth Authentication: Configured")
-		expires := time.Unix(token.ExpiresAt, 0)
-		if token.IsExpired() {
-			out.Warning("Token is expired and will be refreshed on next use")
-		} else if token.NeedsRefresh() {
-			out.Warning("Token expires soon and will be refreshed on next use")
-		} else {
-			out.Info("Token expires: %s", expires.Format("2006-01-02 15:04:05"))
-		}
-	} else {
-		out.Warning("API Key Authentication: Configured")
-		out.Info("Consider using OAuth for free usage")
-	}
-	
-	// Show proxy configuration
-	out.Subtitle("\nProxy Configuration")
-	headers := []string{"Setting", "Value"}
-	rows := [][]string{
-		{"Default host", cfg.Host},
-		{"Default port", fmt.Sprintf("%d", cfg.Port)},
-		{"Auth required", func() string {
-			if cfg.ProxyAuthToken != "" {
-				return "Yes"
+	if !components.ConfirmWithDefault(question, false) {
+		return nil
+	}
+	
+	client := auth.NewOAuthClient()
+	var warnings []string
+	cleared := 0
+	err = components.RunSpinner("Removing authentication...", func() error {
+		for _, account := range accounts {
+			key := auth.AccountKey(account)
+			
+			// Revoke before deleting, but never let the provider keep the local tokens around
+			if token, _ := storage.Get(key); token != nil && token.Type == "oauth" && token.RefreshToken != "" {
+				if err := client.RevokeToken(token.RefreshToken); err != nil && !errors.Is(err, auth.ErrRevocationUnsupported) {
+					warnings = append(warnings, fmt.Sprintf("Could not revoke the token of account %s: %v", account, err))
+				}
+			}
+			
+			if err := storage.Remove(key); err != nil {
+				return fmt.Errorf("account %s: %w", account, err)
 			}
-			return "No"
-		}()},
-		{"Log level", cfg.LogLevel},
+			auditLog.Record(audit.Logout, slog.String("account", account))
+			cleared++
+		}
+		return nil
+	})
+	for _, warning := range warnings {
+		out.Warning("%s", warning)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove authentication: %w", err)
 	}
-	out.Table(headers, rows)
 	
+	if cleared == 1 {
+		out.Success("Logged out of account %s", accounts[0])
+	} else {
+		out.Success("Logged out of %d accounts", cleared)
+	}
 	return nil
 }
 
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/ml0-1337/claude-gate/internal/config"
+	"github.com/ml0-1337/claude-gate/internal/logger"
+	"github.com/ml0-1337/claude-gate/internal/proxy"
+)
+
+// reloadableSettings are the settings a SIGHUP applies to the running server; any
+// other change needs a restart
+var reloadableSettings = []string{
+	"CLAUDE_GATE_LOG_LEVEL",
+	"CLAUDE_GATE_RATE_LIMIT_PER_MINUTE",
+	"CLAUDE_GATE_RATE_LIMIT_BURST",
+	"CLAUDE_GATE_ALLOWED_ORIGINS",
+	"CLAUDE_GATE_CORS_CREDENTIALS",
+	"CLAUDE_GATE_MODELS_ALLOWLIST",
+}
+
+// reloadConfig merges a re-read configuration into the running one. The result takes
+// the reloadable settings from next and keeps everything else from current; the
+// settings that changed but need a restart are returned by name.
+func reloadConfig(current, next *config.Config) (*config.Config, []string) {
+	applied := *current
+	applied.LogLevel = next.LogLevel
+	if next.RateLimitPerMinute > 0 {
+		applied.RateLimitPerMinute = next.RateLimitPerMinute
+		applied.RateLimitBurst = next.RateLimitBurst
+	}
+	applied.CORSAllowOrigins = next.CORSAllowOrigins
+	applied.CORSAllowCredentials = next.CORSAllowCredentials
+	applied.ModelsAllowlist = next.ModelsAllowlist
+
+	var restart []string
+	for _, name := range config.ChangedSettings(&applied, next) {
+		if !slices.Contains(reloadableSettings, name) {
+			restart = append(restart, name)
+		}
+	}
+	return &applied, restart
+}
+
+// runtimeSettings returns the settings of cfg that Reload applies to a running server
+func runtimeSettings(cfg *config.Config) proxy.RuntimeSettings {
+	return proxy.RuntimeSettings{
+		AllowedModels:        cfg.ModelsAllowlist,
+		CORSAllowedOrigins:   cfg.CORSAllowOrigins,
+		CORSAllowCredentials: cfg.CORSAllowCredentials,
+		RateLimitPerMinute:   cfg.RateLimitPerMinute,
+		RateLimitBurst:       cfg.RateLimitBurst,
+	}
+}
+
+// reload re-reads the configuration of s and applies what changed to server and the
+// log level, logging the changes that need a restart. It returns the configuration
+// now in effect.
+func (s *StartCmd) reload(current *config.Config, server *proxy.ProxyServer, level *slog.LevelVar, log *slog.Logger) *config.Config {
+	next, err := s.buildConfig()
+	if err != nil {
+		log.Error("configuration reload failed; keeping the current settings", "error", err)
+		return current
+	}
+	if next.EnableRateLimit && next.RateLimitPerMinute <= 0 {
+		log.Warn("ignoring invalid rate limit on reload; requests per minute must be positive", "rate_limit_per_minute", next.RateLimitPerMinute)
+	}
+
+	applied, restart := reloadConfig(current, next)
+	server.Reload(runtimeSettings(applied))
+	level.Set(logger.ParseLevel(applied.LogLevel).Slog())
+
+	log.Info("configuration reloaded", "changed", config.ChangedSettings(current, applied))
+	if len(restart) > 0 {
+		log.Warn("changed settings need a restart to take effect", "settings", restart)
+	}
+	return applied
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/ml0-1337/claude-gate/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseStart parses a start command line as main does
+func parseStart(t *testing.T, args ...string) *StartCmd {
+	t.Helper()
+	var cli CLI
+	parser, err := kong.New(&cli, kong.Name("claude-gate"))
+	require.NoError(t, err)
+	ctx, err := parser.Parse(append([]string{"start"}, args...))
+	require.NoError(t, err)
+	cli.Start.explicit = explicitFlags(ctx)
+	return &cli.Start
+}
+
+func TestStartCmd_BuildConfig(t *testing.T) {
+	writeFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "claude-gate.env")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("should let flags given on the command line override the config file", func(t *testing.T) {
+		// Arrange
+		t.Setenv("CLAUDE_GATE_PORT", "")
+		t.Setenv("CLAUDE_GATE_LOG_LEVEL", "")
+		path := writeFile(t, "CLAUDE_GATE_PORT=9000\nCLAUDE_GATE_LOG_LEVEL=DEBUG\n")
+		start := parseStart(t, "--port", "8080", "--config", path)
+
+		// Act
+		cfg, err := start.buildConfig()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 8080, cfg.Port, "the explicit flag wins")
+		assert.Equal(t, "DEBUG", cfg.LogLevel, "the file overrides flag defaults")
+	})
+
+	t.Run("should let the environment override the config file but not flags", func(t *testing.T) {
+		// Arrange
+		t.Setenv("CLAUDE_GATE_PORT", "7000")
+		t.Setenv("CLAUDE_GATE_LOG_LEVEL", "ERROR")
+		path := writeFile(t, "CLAUDE_GATE_PORT=9000\nCLAUDE_GATE_LOG_LEVEL=DEBUG\n")
+		start := parseStart(t, "--log-level", "WARNING", "--config", path)
+
+		// Act
+		cfg, err := start.buildConfig()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 7000, cfg.Port)
+		assert.Equal(t, "WARNING", cfg.LogLevel)
+	})
+
+	t.Run("should map every setting flag", func(t *testing.T) {
+		// Arrange
+		var cli CLI
+		parser, err := kong.New(&cli, kong.Name("claude-gate"))
+		require.NoError(t, err)
+		settings := cli.Start.flagSettings(config.DefaultConfig())
+		var start *kong.Node
+		for _, child := range parser.Model.Children {
+			if child.Name == "start" {
+				start = child
+			}
+		}
+		require.NotNil(t, start)
+
+		// Act & Assert
+		for _, flag := range start.Flags {
+			switch flag.Name {
+			case "config", "skip-auth-check":
+				continue
+			}
+			assert.Contains(t, settings, flag.Name)
+		}
+	})
+}
+
+func TestReloadConfig(t *testing.T) {
+	t.Run("should apply reloadable settings and report the rest", func(t *testing.T) {
+		// Arrange
+		current := config.DefaultConfig()
+		next := config.DefaultConfig()
+		next.ModelsAllowlist = []string{"claude-sonnet-4"}
+		next.CORSAllowOrigins = []string{"https://app.example.com"}
+		next.RateLimitPerMinute = 120
+		next.LogLevel = "DEBUG"
+		next.Port = 9090
+		next.Sessions = true
+
+		// Act
+		applied, restart := reloadConfig(current, next)
+
+		// Assert
+		assert.Equal(t, []string{"claude-sonnet-4"}, applied.ModelsAllowlist)
+		assert.Equal(t, []string{"https://app.example.com"}, applied.CORSAllowOrigins)
+		assert.Equal(t, 120, applied.RateLimitPerMinute)
+		assert.Equal(t, "DEBUG", applied.LogLevel)
+		assert.Equal(t, current.Port, applied.Port, "the listen address needs a restart")
+		assert.False(t, applied.Sessions)
+		assert.Equal(t, []string{"CLAUDE_GATE_PORT", "CLAUDE_GATE_SESSIONS"}, restart)
+		assert.Equal(t, []string{"*"}, current.CORSAllowOrigins, "the running configuration is not modified")
+	})
+
+	t.Run("should keep the rate limit when the new one is invalid", func(t *testing.T) {
+		current := config.DefaultConfig()
+		next := config.DefaultConfig()
+		next.RateLimitPerMinute = 0
+
+		applied, restart := reloadConfig(current, next)
+
+		assert.Equal(t, current.RateLimitPerMinute, applied.RateLimitPerMinute)
+		assert.Empty(t, restart)
+	})
+}
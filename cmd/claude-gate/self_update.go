@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ml0-1337/claude-gate/internal/ui"
+	"github.com/ml0-1337/claude-gate/internal/ui/components"
+	"github.com/ml0-1337/claude-gate/internal/ui/utils"
+	"github.com/ml0-1337/claude-gate/internal/update"
+)
+
+// SelfUpdateCmd replaces the running binary with the latest GitHub release
+type SelfUpdateCmd struct {
+	Yes   bool `short:"y" help:"Replace the binary without asking for confirmation"`
+	Check bool `help:"Only check whether a newer version is available"`
+}
+
+func (c *SelfUpdateCmd) Run() error {
+	out := ui.NewOutput()
+	updater := update.NewUpdater()
+
+	var release *update.Release
+	err := components.RunSpinner("Checking for updates...", func() error {
+		var err error
+		release, err = updater.LatestRelease()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if !update.IsNewer(version, release.Version()) {
+		out.Success("claude-gate %s is up to date", version)
+		return nil
+	}
+
+	out.Info("A new version is available: %s (current: %s)", release.Version(), version)
+	if c.Check {
+		out.Info("Run 'claude-gate self-update' to install it")
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to locate current binary: %w", err)
+	}
+
+	if !c.Yes && !components.Confirm(fmt.Sprintf("Replace %s with version %s?", executable, release.Version())) {
+		out.Info("Update cancelled")
+		return nil
+	}
+
+	binary, err := downloadRelease(updater, release)
+	if err != nil {
+		return err
+	}
+
+	if err := update.ReplaceExecutable(executable, binary); err != nil {
+		return err
+	}
+
+	out.Success("Updated claude-gate to %s", release.Version())
+	return nil
+}
+
+// downloadRelease downloads and verifies a release, showing a progress bar when interactive
+func downloadRelease(updater *update.Updater, release *update.Release) ([]byte, error) {
+	if !utils.IsInteractive() {
+		ui.NewOutput().Info("Downloading %s...", release.TagName)
+		return updater.Download(release, nil)
+	}
+
+	title := fmt.Sprintf("Downloading %s", release.TagName)
+	tracker := components.NewProgressTracker(title, 1)
+	binary, err := updater.Download(release, func(downloaded, total int64) {
+		if total > 0 {
+			tracker.SetPercent(float64(downloaded)/float64(total), title)
+		}
+	})
+	tracker.Finish()
+
+	return binary, err
+}
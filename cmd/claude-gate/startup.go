@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/ml0-1337/claude-gate/internal/config"
+	"github.com/ml0-1337/claude-gate/internal/logger"
+	"github.com/ml0-1337/claude-gate/internal/proxy"
+	"github.com/ml0-1337/claude-gate/internal/ui"
+)
+
+// startupInfo is what the proxy reports when it starts, as a banner for humans or as
+// a structured startup event in JSON log mode. It holds no secrets: local keys are
+// only counted and URL credentials are redacted.
+type startupInfo struct {
+	Version       string
+	Listen        string
+	TLS           bool
+	Socket        bool
+	Upstream      string
+	UpstreamProxy string
+	Account       string
+	LocalAPIKeys  int
+	Features      []string
+}
+
+// newStartupInfo summarizes cfg for the startup banner and event
+func newStartupInfo(cfg *config.Config) startupInfo {
+	upstream := redactURL(upstreamURL(cfg))
+	if cfg.Mock {
+		upstream = "mock"
+	}
+	return startupInfo{
+		Version:       version,
+		Listen:        cfg.GetListenAddress(),
+		TLS:           cfg.TLSCertFile != "" || cfg.AutoTLS,
+		Socket:        cfg.Socket != "",
+		Upstream:      upstream,
+		UpstreamProxy: redactURL(cfg.UpstreamProxy),
+		Account:       accountName(cfg.Account),
+		LocalAPIKeys:  len(cfg.LocalAPIKeys()),
+		Features:      enabledFeatures(cfg),
+	}
+}
+
+// upstreamURL is the Anthropic base URL of the served account: its own when
+// configured, otherwise the global one
+func upstreamURL(cfg *config.Config) string {
+	upstreams, err := proxy.ParseAccountUpstreams(cfg.AccountBaseURLs, nil, nil)
+	if upstream := upstreams[accountName(cfg.Account)]; err == nil && upstream.BaseURL != "" {
+		return upstream.BaseURL
+	}
+	return cfg.AnthropicBaseURL
+}
+
+// enabledFeatures names the optional features cfg turns on, by their flag names
+func enabledFeatures(cfg *config.Config) []string {
+	toggles := []struct {
+		name    string
+		enabled bool
+	}{
+		{"mock", cfg.Mock},
+		{"tls", cfg.TLSCertFile != ""},
+		{"auto-tls", cfg.AutoTLS},
+		{"access-token", cfg.AccessToken != ""},
+		{"local-auth", len(cfg.LocalAPIKeys()) > 0},
+		{"admin-key", cfg.AdminKey != ""},
+		{"rate-limit", cfg.EnableRateLimit},
+		{"max-streams-per-client", cfg.MaxStreamsPerClient > 0},
+		{"coalesce-streams", cfg.CoalesceStreams},
+		{"token-budgets", len(cfg.TokenBudgets) > 0},
+		{"sessions", cfg.Sessions},
+		{"upstream-rps", cfg.UpstreamRPS > 0},
+		{"upstream-retries", cfg.UpstreamRetries > 0},
+		{"warmup-upstream", cfg.WarmupUpstream},
+		{"readyz-upstream", cfg.ReadyzUpstream},
+		{"validate-requests", cfg.ValidateRequests},
+		{"response-warnings", cfg.ResponseWarnings},
+		{"debug-headers", cfg.DebugHeaders},
+		{"debug", cfg.Debug},
+		{"trim-whitespace", cfg.TrimWhitespace},
+		{"cache-tools", cfg.CacheTools},
+		{"repair-tool-args", cfg.RepairToolArgs},
+		{"content-filter-finish-reason", cfg.ContentFilterFinishReason},
+		{"fetch-images", cfg.FetchImages},
+		{"passthrough", cfg.Passthrough},
+		{"allow-beta-header", cfg.AllowBetaHeader},
+		{"audit-log", cfg.AuditLog != ""},
+	}
+
+	features := []string{}
+	for _, toggle := range toggles {
+		if toggle.enabled {
+			features = append(features, toggle.name)
+		}
+	}
+	return features
+}
+
+// redactURL hides the password of a URL, such as an upstream proxy's
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		u.User = url.User("xxxxx")
+	}
+	return u.Redacted()
+}
+
+// serverURL is the base URL clients reach the proxy at. Over a Unix socket the host
+// is arbitrary, so it is given as localhost.
+func (s startupInfo) serverURL() string {
+	host := s.Listen
+	if s.Socket {
+		host = "localhost"
+	}
+	if s.TLS {
+		return "https://" + host
+	}
+	return "http://" + host
+}
+
+// attrs returns the fields of the startup event
+func (s startupInfo) attrs() []any {
+	attrs := []any{
+		slog.String("version", s.Version),
+		slog.String("listen", s.Listen),
+		slog.String("openai_base_url", s.serverURL()+"/v1"),
+		slog.String("upstream", s.Upstream),
+	}
+	if s.UpstreamProxy != "" {
+		attrs = append(attrs, slog.String("upstream_proxy", s.UpstreamProxy))
+	}
+	return append(attrs,
+		slog.String("account", s.Account),
+		slog.Int("local_api_keys", s.LocalAPIKeys),
+		slog.Any("features", s.Features),
+	)
+}
+
+// log writes the structured startup event
+func (s startupInfo) log(log *slog.Logger) {
+	log.Info("startup", s.attrs()...)
+}
+
+// printBanner writes the human-readable startup banner
+func (s startupInfo) printBanner(out *ui.Output) {
+	out.Title("🚀 Claude OAuth Proxy")
+
+	anthropic := s.Upstream
+	if anthropic == "mock" {
+		anthropic = "Mock (canned responses)"
+	}
+	proxyAuth := "Disabled"
+	if s.LocalAPIKeys > 0 {
+		proxyAuth = fmt.Sprintf("Enabled (%d local keys)", s.LocalAPIKeys)
+	}
+	features := "None"
+	if len(s.Features) > 0 {
+		features = strings.Join(s.Features, ", ")
+	}
+
+	rows := [][]string{
+		{"Version", s.Version},
+		{"Server URL", s.serverURL()},
+		{"Anthropic API", anthropic},
+	}
+	if s.Socket {
+		rows = slices.Insert(rows, 2, []string{"Socket", strings.TrimPrefix(s.Listen, "unix:")})
+	}
+	if s.UpstreamProxy != "" {
+		rows = append(rows, []string{"Upstream Proxy", s.UpstreamProxy})
+	}
+	rows = append(rows,
+		[]string{"Account", s.Account},
+		[]string{"Proxy Auth", proxyAuth},
+		[]string{"OpenAI Compatible", s.serverURL() + "/v1"},
+		[]string{"Features", features},
+	)
+	out.Table([]string{"Configuration", "Value"}, rows)
+
+	if s.LocalAPIKeys == 0 {
+		out.Warning("Proxy authentication disabled - anyone can use this proxy")
+	}
+}
+
+// announceStartup reports startup as the banner, or in JSON log mode as the single
+// structured startup event so log pipelines get no unstructured output
+func announceStartup(cfg *config.Config, out *ui.Output, log *slog.Logger) {
+	info := newStartupInfo(cfg)
+	if strings.EqualFold(cfg.LogFormat, logger.FormatJSON) {
+		info.log(log)
+		return
+	}
+	info.printBanner(out)
+	out.Info("\nPress CTRL+C to stop the server")
+}
@@ -0,0 +1,116 @@
+// Package audit records authentication events, such as token refreshes, logins and
+// rejected keys, to a dedicated sink for security review. It is separate from the
+// request logs and never records secrets: keys are reduced to fingerprints and
+// anything shaped like an Anthropic token is redacted.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Event names an audited authentication event
+type Event string
+
+const (
+	// TokenRefreshed is a successful OAuth access token refresh
+	TokenRefreshed Event = "token_refresh"
+	// TokenRefreshFailed is a failed OAuth access token refresh
+	TokenRefreshFailed Event = "token_refresh_failed"
+	// Login is a completed `auth login`
+	Login Event = "login"
+	// LoginFailed is an `auth login` whose code exchange failed
+	LoginFailed Event = "login_failed"
+	// Logout is a completed `auth logout`
+	Logout Event = "logout"
+	// LocalKeyRejected is a request to the proxy refused for a missing or wrong local key
+	LocalKeyRejected Event = "local_key_rejected"
+)
+
+// tokenPattern matches Anthropic API keys and OAuth tokens
+var tokenPattern = regexp.MustCompile(`sk-ant-[A-Za-z0-9_-]+`)
+
+// Logger writes one JSON line per audited event. A nil Logger records nothing, so
+// callers need not check whether auditing is enabled.
+type Logger struct {
+	handler slog.Handler
+	now     func() time.Time
+}
+
+// New returns a Logger writing to w
+func New(w io.Writer) *Logger {
+	return &Logger{
+		handler: slog.NewJSONHandler(w, &slog.HandlerOptions{ReplaceAttr: redactAttr}),
+		now:     time.Now,
+	}
+}
+
+// Open returns a Logger for sink: "stdout", "stderr" or a file path, appended to and
+// created with owner-only permissions. An empty sink disables auditing and returns a
+// nil Logger. The returned closer releases the file.
+func Open(sink string) (*Logger, io.Closer, error) {
+	switch sink {
+	case "":
+		return nil, io.NopCloser(nil), nil
+	case "stdout", "-":
+		return New(os.Stdout), io.NopCloser(nil), nil
+	case "stderr":
+		return New(os.Stderr), io.NopCloser(nil), nil
+	}
+	file, err := os.OpenFile(sink, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return New(file), file, nil
+}
+
+// Record writes event with its context attributes
+func (l *Logger) Record(event Event, attrs ...slog.Attr) {
+	if l == nil {
+		return
+	}
+	level := slog.LevelInfo
+	switch event {
+	case TokenRefreshFailed, LoginFailed, LocalKeyRejected:
+		level = slog.LevelWarn
+	}
+
+	record := slog.NewRecord(l.now(), level, "audit", 0)
+	record.AddAttrs(slog.String("event", string(event)))
+	record.AddAttrs(attrs...)
+	l.handler.Handle(context.Background(), record)
+}
+
+// Fingerprint identifies a secret in the audit log without revealing it: the first
+// 12 hex digits of its SHA-256. An empty secret has no fingerprint.
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:6])
+}
+
+// redactAttr scrubs tokens from string values, e.g. ones echoed in error messages
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(redact(err.Error()))
+		}
+	}
+	return a
+}
+
+func redact(value string) string {
+	return tokenPattern.ReplaceAllString(value, "sk-ant-[redacted]")
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeEvents parses the JSON lines written by a Logger
+func decodeEvents(t *testing.T, output string) []map[string]interface{} {
+	t.Helper()
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &event), line)
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestLogger_Record(t *testing.T) {
+	t.Run("should write one JSON line per event with its context", func(t *testing.T) {
+		// Arrange
+		var out bytes.Buffer
+		logger := New(&out)
+
+		// Act
+		logger.Record(Login, slog.String("provider", "anthropic"))
+		logger.Record(TokenRefreshFailed, slog.Any("error", errors.New("invalid_grant")))
+
+		// Assert
+		events := decodeEvents(t, out.String())
+		require.Len(t, events, 2)
+		assert.Equal(t, "login", events[0]["event"])
+		assert.Equal(t, "anthropic", events[0]["provider"])
+		assert.Equal(t, "INFO", events[0]["level"])
+		assert.Contains(t, events[0], "time")
+		assert.Equal(t, "token_refresh_failed", events[1]["event"])
+		assert.Equal(t, "WARN", events[1]["level"])
+		assert.Equal(t, "invalid_grant", events[1]["error"])
+	})
+
+	t.Run("should redact tokens in strings and errors", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := New(&out)
+
+		logger.Record(TokenRefreshFailed,
+			slog.String("detail", "refresh with sk-ant-ort01-abc_DEF-123 failed"),
+			slog.Any("error", errors.New("bad token sk-ant-oat01-secret")),
+		)
+
+		assert.NotContains(t, out.String(), "abc_DEF-123")
+		assert.NotContains(t, out.String(), "oat01-secret")
+		assert.Contains(t, out.String(), "sk-ant-[redacted]")
+	})
+
+	t.Run("should do nothing when disabled", func(t *testing.T) {
+		var logger *Logger
+
+		assert.NotPanics(t, func() { logger.Record(Logout) })
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Run("should identify a secret without revealing it", func(t *testing.T) {
+		fingerprint := Fingerprint("admin-secret")
+
+		assert.Len(t, fingerprint, 12)
+		assert.Equal(t, fingerprint, Fingerprint("admin-secret"))
+		assert.NotEqual(t, fingerprint, Fingerprint("admin-secreT"))
+		assert.NotContains(t, fingerprint, "secret")
+		assert.Empty(t, Fingerprint(""))
+	})
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("should append to a private file", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "audit.log")
+
+		// Act
+		for _, event := range []Event{Login, Logout} {
+			logger, closer, err := Open(path)
+			require.NoError(t, err)
+			logger.Record(event)
+			require.NoError(t, closer.Close())
+		}
+
+		// Assert
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		events := decodeEvents(t, string(data))
+		require.Len(t, events, 2)
+		assert.Equal(t, "login", events[0]["event"])
+		assert.Equal(t, "logout", events[1]["event"])
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("should be disabled without a sink", func(t *testing.T) {
+		logger, closer, err := Open("")
+
+		require.NoError(t, err)
+		assert.Nil(t, logger)
+		assert.NoError(t, closer.Close())
+	})
+
+	t.Run("should fail for an unwritable path", func(t *testing.T) {
+		_, _, err := Open(filepath.Join(t.TempDir(), "missing", "audit.log"))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultAccount is the alias of the account stored under the plain "anthropic"
+// key, where tokens lived before named accounts existed
+const DefaultAccount = "default"
+
+// accountProvider is the storage key prefix of Anthropic accounts
+const accountProvider = "anthropic"
+
+// accountAliasPattern keeps aliases usable as file, keyring and flag values
+var accountAliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidateAccount checks an account alias; empty means DefaultAccount
+func ValidateAccount(alias string) error {
+	if alias == "" || accountAliasPattern.MatchString(alias) {
+		return nil
+	}
+	return fmt.Errorf("invalid account %q: use up to 64 letters, digits, '-' or '_'", alias)
+}
+
+// AccountKey returns the storage key of an account's token. The default account
+// keeps the "anthropic" key, so existing logins keep working.
+func AccountKey(alias string) string {
+	if alias == "" || alias == DefaultAccount {
+		return accountProvider
+	}
+	return accountProvider + ":" + alias
+}
+
+// ListAccounts returns the aliases of the accounts stored in storage, sorted, with
+// other providers' entries left out
+func ListAccounts(storage StorageBackend) ([]string, error) {
+	providers, err := storage.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []string
+	for _, provider := range providers {
+		if provider == accountProvider {
+			accounts = append(accounts, DefaultAccount)
+		} else if alias, ok := strings.CutPrefix(provider, accountProvider+":"); ok && alias != "" {
+			accounts = append(accounts, alias)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts, nil
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountKey(t *testing.T) {
+	t.Run("should keep the legacy key for the default account", func(t *testing.T) {
+		assert.Equal(t, "anthropic", AccountKey(""))
+		assert.Equal(t, "anthropic", AccountKey(DefaultAccount))
+	})
+
+	t.Run("should namespace named accounts", func(t *testing.T) {
+		assert.Equal(t, "anthropic:work", AccountKey("work"))
+	})
+}
+
+func TestValidateAccount(t *testing.T) {
+	for _, alias := range []string{"", "work", "Personal_2", "team-a"} {
+		assert.NoError(t, ValidateAccount(alias), alias)
+	}
+	for _, alias := range []string{"work account", "a:b", "../x", string(make([]byte, 65))} {
+		assert.Error(t, ValidateAccount(alias), alias)
+	}
+}
+
+func TestListAccounts(t *testing.T) {
+	t.Run("should list account aliases and skip other providers", func(t *testing.T) {
+		// Arrange
+		storage := NewFileStorage(t.TempDir() + "/auth.json")
+		token := &TokenInfo{Type: "oauth", AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+		require.NoError(t, storage.Set(AccountKey("work"), token))
+		require.NoError(t, storage.Set(AccountKey(DefaultAccount), token))
+		require.NoError(t, storage.Set("openai", token))
+
+		// Act
+		accounts, err := ListAccounts(storage)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []string{DefaultAccount, "work"}, accounts)
+	})
+
+	t.Run("should be empty for an empty store", func(t *testing.T) {
+		accounts, err := ListAccounts(NewFileStorage(t.TempDir() + "/auth.json"))
+
+		require.NoError(t, err)
+		assert.Empty(t, accounts)
+	})
+}
+
+func TestOAuthTokenProvider_Accounts(t *testing.T) {
+	t.Run("should return the token of the selected account", func(t *testing.T) {
+		// Arrange
+		storage := NewFileStorage(t.TempDir() + "/auth.json")
+		expires := time.Now().Add(time.Hour).Unix()
+		require.NoError(t, storage.Set(AccountKey(DefaultAccount), &TokenInfo{Type: "oauth", AccessToken: "personal-token", ExpiresAt: expires}))
+		require.NoError(t, storage.Set(AccountKey("work"), &TokenInfo{Type: "oauth", AccessToken: "work-token", ExpiresAt: expires}))
+
+		// Act
+		personal, personalErr := NewOAuthTokenProvider(storage).GetAccessToken()
+		work, workErr := NewOAuthTokenProviderForAccount(storage, "work").GetAccessToken()
+
+		// Assert
+		require.NoError(t, personalErr)
+		require.NoError(t, workErr)
+		assert.Equal(t, "personal-token", personal)
+		assert.Equal(t, "work-token", work)
+	})
+
+	t.Run("should name the account missing a login", func(t *testing.T) {
+		provider := NewOAuthTokenProviderForAccount(NewFileStorage(t.TempDir()+"/auth.json"), "work")
+
+		_, err := provider.GetAccessToken()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `account "work"`)
+		assert.Equal(t, "work", provider.Account())
+	})
+
+	t.Run("should store a refreshed token under its account", func(t *testing.T) {
+		server, _ := newCountingRefreshServer(t)
+		storage := NewFileStorage(t.TempDir() + "/auth.json")
+		require.NoError(t, storage.Set(AccountKey("work"), &TokenInfo{Type: "oauth", AccessToken: "old", RefreshToken: "refresh", ExpiresAt: time.Now().Unix()}))
+		provider := NewOAuthTokenProviderForAccount(storage, "work")
+		provider.client.TokenURL = server.URL
+
+		token, err := provider.GetAccessToken()
+
+		require.NoError(t, err)
+		saved, err := storage.Get(AccountKey("work"))
+		require.NoError(t, err)
+		assert.Equal(t, token, saved.AccessToken)
+		defaultToken, err := storage.Get(AccountKey(DefaultAccount))
+		require.NoError(t, err)
+		assert.Nil(t, defaultToken)
+	})
+}
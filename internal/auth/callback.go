@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// CallbackPath is where the local callback server receives the OAuth redirect
+const CallbackPath = "/callback"
+
+// callbackPage is shown in the browser once the authorization code has been received
+const callbackPage = `<!DOCTYPE html>
+<html><head><title>Claude Gate</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 4em">
+<h2>%s</h2><p>%s</p>
+</body></html>`
+
+// CallbackServer is a temporary server on the loopback interface that captures the
+// authorization code the browser is redirected to at the end of the OAuth flow
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	state    string
+	result   chan callbackResult
+	once     sync.Once
+}
+
+// callbackResult is what the redirect carried: a code, or the error the
+// authorization server reported
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// NewCallbackServer reserves a free localhost port for a callback server. Nothing is
+// served until Wait, but a browser redirected earlier is held by the listener.
+func NewCallbackServer() (*CallbackServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+
+	s := &CallbackServer{
+		listener: listener,
+		result:   make(chan callbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(CallbackPath, s.handleCallback)
+	s.server = &http.Server{Handler: mux}
+
+	return s, nil
+}
+
+// RedirectURI is the redirect_uri to authorize with so the browser lands on this server
+func (s *CallbackServer) RedirectURI() string {
+	return fmt.Sprintf("http://localhost:%d%s", s.listener.Addr().(*net.TCPAddr).Port, CallbackPath)
+}
+
+// Wait serves the callback until a redirect with the given state arrives or ctx is
+// done, then shuts the server down. Redirects with another state are rejected. The
+// code is returned in the code#state form that ExchangeCode expects.
+func (s *CallbackServer) Wait(ctx context.Context, state string) (string, error) {
+	s.state = state
+	go s.server.Serve(s.listener)
+	defer s.Close()
+
+	select {
+	case result := <-s.result:
+		if result.err != nil {
+			return "", result.err
+		}
+		return result.code + "#" + s.state, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close stops the callback server and releases its port
+func (s *CallbackServer) Close() error {
+	s.listener.Close()
+	return s.server.Close()
+}
+
+func (s *CallbackServer) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("state") != s.state {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, callbackPage, "Authentication failed", "The login request did not match. Run claude-gate auth login again.")
+		return
+	}
+
+	var result callbackResult
+	switch {
+	case query.Get("error") != "":
+		result.err = fmt.Errorf("authorization denied: %s", query.Get("error"))
+		if description := query.Get("error_description"); description != "" {
+			result.err = fmt.Errorf("authorization denied: %s: %s", query.Get("error"), description)
+		}
+	case query.Get("code") == "":
+		result.err = errors.New("authorization redirect carried no code")
+	default:
+		result.code = query.Get("code")
+	}
+
+	if result.err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, callbackPage, "Authentication failed", "You can close this window and return to the terminal.")
+	} else {
+		fmt.Fprintf(w, callbackPage, "Authentication complete", "You can close this window and return to the terminal.")
+	}
+
+	// Only the first redirect counts; a reload of the page is ignored
+	s.once.Do(func() {
+		s.result <- result
+	})
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackServer(t *testing.T) {
+	type waitResult struct {
+		code string
+		err  error
+	}
+	wait := func(ctx context.Context, s *CallbackServer) <-chan waitResult {
+		done := make(chan waitResult, 1)
+		go func() {
+			code, err := s.Wait(ctx, "state-1")
+			done <- waitResult{code, err}
+		}()
+		return done
+	}
+	redirect := func(t *testing.T, s *CallbackServer, query url.Values) int {
+		resp, err := http.Get(s.RedirectURI() + "?" + query.Encode())
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("should capture the code from the redirect", func(t *testing.T) {
+		// Arrange
+		s, err := NewCallbackServer()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(s.RedirectURI(), "http://localhost:"))
+		assert.True(t, strings.HasSuffix(s.RedirectURI(), CallbackPath))
+
+		// Act
+		done := wait(context.Background(), s)
+		status := redirect(t, s, url.Values{"code": {"abc"}, "state": {"state-1"}})
+		result := <-done
+
+		// Assert
+		assert.Equal(t, http.StatusOK, status)
+		require.NoError(t, result.err)
+		assert.Equal(t, "abc#state-1", result.code)
+	})
+
+	t.Run("should hold a redirect that arrives before waiting", func(t *testing.T) {
+		s, err := NewCallbackServer()
+		require.NoError(t, err)
+
+		statuses := make(chan int, 1)
+		go func() { statuses <- redirect(t, s, url.Values{"code": {"abc"}, "state": {"state-1"}}) }()
+		time.Sleep(50 * time.Millisecond)
+		code, err := s.Wait(context.Background(), "state-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "abc#state-1", code)
+		assert.Equal(t, http.StatusOK, <-statuses)
+	})
+
+	t.Run("should reject a redirect with another state", func(t *testing.T) {
+		// Arrange
+		s, err := NewCallbackServer()
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		// Act
+		done := wait(ctx, s)
+		status := redirect(t, s, url.Values{"code": {"abc"}, "state": {"forged"}})
+		result := <-done
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.ErrorIs(t, result.err, context.DeadlineExceeded)
+	})
+
+	t.Run("should report an authorization error", func(t *testing.T) {
+		s, err := NewCallbackServer()
+		require.NoError(t, err)
+
+		done := wait(context.Background(), s)
+		status := redirect(t, s, url.Values{"error": {"access_denied"}, "state": {"state-1"}})
+		result := <-done
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.EqualError(t, result.err, "authorization denied: access_denied")
+	})
+
+	t.Run("should stop listening once done", func(t *testing.T) {
+		s, err := NewCallbackServer()
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = s.Wait(ctx, "state-1")
+
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = http.Get(s.RedirectURI())
+		assert.Error(t, err)
+	})
+}
@@ -4,28 +4,75 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+	
+	"github.com/ml0-1337/claude-gate/internal/audit"
+	"github.com/ml0-1337/claude-gate/internal/metrics"
 )
 
 // OAuthTokenProvider implements TokenProvider interface for the proxy
 type OAuthTokenProvider struct {
 	client      *OAuthClient
 	storage     StorageBackend
+	account     string
 	cachedToken *TokenInfo
 	cacheMutex  sync.RWMutex
+	
+	// inflight is the forced refresh in progress, shared by concurrent ForceRefresh calls
+	refreshMutex sync.Mutex
+	inflight     *refreshCall
+	
+	// audit records token refreshes (nil = not audited)
+	audit *audit.Logger
+}
+
+// refreshCall is one forced refresh and its result
+type refreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
 }
 
-// NewOAuthTokenProvider creates a new OAuth token provider
+// NewOAuthTokenProvider creates a new OAuth token provider for the default account
 func NewOAuthTokenProvider(storage StorageBackend) *OAuthTokenProvider {
+	return NewOAuthTokenProviderForAccount(storage, DefaultAccount)
+}
+
+// NewOAuthTokenProviderForAccount creates an OAuth token provider serving the token
+// of the named account (see AccountKey)
+func NewOAuthTokenProviderForAccount(storage StorageBackend, account string) *OAuthTokenProvider {
+	if account == "" {
+		account = DefaultAccount
+	}
 	return &OAuthTokenProvider{
 		client:  NewOAuthClient(),
 		storage: storage,
+		account: account,
 	}
 }
 
+// errNoToken reports that the account has never been logged in
+func (p *OAuthTokenProvider) errNoToken() error {
+	if p.account != DefaultAccount {
+		return fmt.Errorf("no OAuth token found for account %q - please authenticate first", p.account)
+	}
+	return fmt.Errorf("no OAuth token found - please authenticate first")
+}
+
+// Account returns the alias of the account whose token the provider serves
+func (p *OAuthTokenProvider) Account() string {
+	return p.account
+}
+
+// SetAuditLogger records every token refresh, and its outcome, to logger
+func (p *OAuthTokenProvider) SetAuditLogger(logger *audit.Logger) {
+	p.audit = logger
+}
+
 // GetAccessToken returns a valid access token, refreshing if necessary
 func (p *OAuthTokenProvider) GetAccessToken() (string, error) {
 	// First, check if we have a valid cached token
@@ -47,31 +94,18 @@ func (p *OAuthTokenProvider) GetAccessToken() (string, error) {
 	}
 	
 	// Fetch token from storage
-	token, err := p.storage.Get("anthropic")
+	token, err := p.storage.Get(AccountKey(p.account))
 	if err != nil {
 		return "", fmt.Errorf("failed to get token from storage: %w", err)
 	}
 	
 	if token == nil || token.Type != "oauth" {
-		return "", fmt.Errorf("no OAuth token found - please authenticate first")
+		return "", p.errNoToken()
 	}
 	
-	// Check if token needs refresh
+	// Refresh ahead of expiry so requests never carry a token about to lapse
 	if token.NeedsRefresh() {
-		// Refresh the token
-		newToken, err := p.client.RefreshToken(token.RefreshToken)
-		if err != nil {
-			return "", fmt.Errorf("failed to refresh token: %w", err)
-		}
-		
-		// Update storage
-		if err := p.storage.Set("anthropic", newToken); err != nil {
-			return "", fmt.Errorf("failed to save refreshed token: %w", err)
-		}
-		
-		// Update cache
-		p.cachedToken = newToken
-		return newToken.AccessToken, nil
+		return p.refresh(token)
 	}
 	
 	// Update cache with the token from storage
@@ -79,6 +113,70 @@ func (p *OAuthTokenProvider) GetAccessToken() (string, error) {
 	return token.AccessToken, nil
 }
 
+// ForceRefresh exchanges the refresh token for a new access token even though the
+// current one has not expired, e.g. after the API rejected it. Callers forcing a
+// refresh while one is in flight wait for it and share its result.
+func (p *OAuthTokenProvider) ForceRefresh() (string, error) {
+	p.refreshMutex.Lock()
+	if call := p.inflight; call != nil {
+		p.refreshMutex.Unlock()
+		<-call.done
+		return call.token, call.err
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	p.inflight = call
+	p.refreshMutex.Unlock()
+	
+	call.token, call.err = p.forceRefresh()
+	
+	p.refreshMutex.Lock()
+	p.inflight = nil
+	p.refreshMutex.Unlock()
+	close(call.done)
+	return call.token, call.err
+}
+
+// forceRefresh refreshes the stored token unconditionally
+func (p *OAuthTokenProvider) forceRefresh() (string, error) {
+	p.cacheMutex.Lock()
+	defer p.cacheMutex.Unlock()
+	
+	token, err := p.storage.Get(AccountKey(p.account))
+	if err != nil {
+		return "", fmt.Errorf("failed to get token from storage: %w", err)
+	}
+	
+	if token == nil || token.Type != "oauth" {
+		return "", p.errNoToken()
+	}
+	
+	return p.refresh(token)
+}
+
+// refresh exchanges token's refresh token, then stores and caches the result. The
+// caller must hold the write lock, which keeps refreshes from running concurrently.
+func (p *OAuthTokenProvider) refresh(token *TokenInfo) (string, error) {
+	newToken, err := p.client.RefreshToken(token.RefreshToken)
+	if err != nil {
+		p.audit.Record(audit.TokenRefreshFailed, slog.String("account", p.account), slog.Any("error", err))
+		metrics.TokenRefreshes.WithLabelValues("failure").Inc()
+		return "", fmt.Errorf("failed to refresh token: %w", err)
+	}
+	
+	// Update storage
+	if err := p.storage.Set(AccountKey(p.account), newToken); err != nil {
+		p.audit.Record(audit.TokenRefreshFailed, slog.String("account", p.account), slog.Any("error", err))
+		metrics.TokenRefreshes.WithLabelValues("failure").Inc()
+		return "", fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	p.audit.Record(audit.TokenRefreshed, slog.String("account", p.account), slog.Time("expires_at", time.Unix(newToken.ExpiresAt, 0)))
+	metrics.TokenRefreshes.WithLabelValues("success").Inc()
+	
+	// Update cache
+	p.cachedToken = newToken
+	return newToken.AccessToken, nil
+}
+
 // ExchangeCode exchanges an authorization code for tokens
 func (c *OAuthClient) ExchangeCode(code, verifier string) (*TokenInfo, error) {
 	// Parse code and state
@@ -113,6 +211,41 @@ func (c *OAuthClient) RefreshToken(refreshToken string) (*TokenInfo, error) {
 	return c.makeTokenRequest(reqBody)
 }
 
+// RevokeToken asks the authorization server to revoke a refresh token, which also
+// ends the access tokens issued from it
+func (c *OAuthClient) RevokeToken(refreshToken string) error {
+	if c.RevokeURL == "" {
+		return ErrRevocationUnsupported
+	}
+	
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"token":           refreshToken,
+		"token_type_hint": "refresh_token",
+		"client_id":       c.ClientID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	
+	req, err := http.NewRequestWithContext(context.Background(), "POST", c.RevokeURL, strings.NewReader(string(jsonBody)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make revocation request: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revocation request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // makeTokenRequest makes a token request to the OAuth server
 func (c *OAuthClient) makeTokenRequest(body map[string]interface{}) (*TokenInfo, error) {
 	jsonBody, err := json.Marshal(body)
@@ -1,12 +1,18 @@
 package auth
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ml0-1337/claude-gate/internal/audit"
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -349,4 +355,199 @@ type mockStorageCounter struct {
 func (m *mockStorageCounter) Get(provider string) (*TokenInfo, error) {
 	m.getCalls++
 	return m.StorageBackend.Get(provider)
-}
\ No newline at end of file
+}
+// newCountingRefreshServer answers refresh requests after a short delay, counting them
+func newCountingRefreshServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "refreshed-token-" + string(rune('0'+n)),
+			"refresh_token": "new-refresh-token",
+			"expires_in":    3600,
+			"token_type":    "Bearer",
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// newProviderWithToken stores an OAuth token expiring at expiresAt and returns a
+// provider refreshing against tokenURL
+func newProviderWithToken(t *testing.T, expiresAt time.Time, tokenURL string) (*OAuthTokenProvider, StorageBackend) {
+	t.Helper()
+	storage := NewFileStorage(t.TempDir() + "/auth.json")
+	require.NoError(t, storage.Set("anthropic", &TokenInfo{
+		Type:         "oauth",
+		AccessToken:  "current-token",
+		RefreshToken: "refresh-token",
+		ExpiresAt:    expiresAt.Unix(),
+	}))
+	provider := NewOAuthTokenProvider(storage)
+	provider.client.TokenURL = tokenURL
+	return provider, storage
+}
+
+func TestOAuthTokenProvider_Refresh(t *testing.T) {
+	t.Run("should refresh only once for concurrent callers of an expiring token", func(t *testing.T) {
+		// Arrange
+		server, calls := newCountingRefreshServer(t)
+		provider, _ := newProviderWithToken(t, time.Now().Add(30*time.Second), server.URL)
+
+		// Act
+		var wg sync.WaitGroup
+		tokens := make([]string, 20)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				token, err := provider.GetAccessToken()
+				assert.NoError(t, err)
+				tokens[i] = token
+			}(i)
+		}
+		wg.Wait()
+
+		// Assert
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		for _, token := range tokens {
+			assert.Equal(t, "refreshed-token-1", token)
+		}
+	})
+
+	t.Run("should force a refresh of a valid token", func(t *testing.T) {
+		server, calls := newCountingRefreshServer(t)
+		provider, storage := newProviderWithToken(t, time.Now().Add(time.Hour), server.URL)
+
+		before, err := provider.GetAccessToken()
+		require.NoError(t, err)
+		after, err := provider.ForceRefresh()
+		require.NoError(t, err)
+
+		assert.Equal(t, "current-token", before)
+		assert.Equal(t, "refreshed-token-1", after)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		cached, err := provider.GetAccessToken()
+		require.NoError(t, err)
+		assert.Equal(t, "refreshed-token-1", cached)
+		saved, err := storage.Get("anthropic")
+		require.NoError(t, err)
+		assert.Equal(t, "refreshed-token-1", saved.AccessToken)
+	})
+
+	t.Run("should share one exchange between concurrent forced refreshes", func(t *testing.T) {
+		server, calls := newCountingRefreshServer(t)
+		provider, _ := newProviderWithToken(t, time.Now().Add(time.Hour), server.URL)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := provider.ForceRefresh()
+				assert.NoError(t, err)
+				assert.Equal(t, "refreshed-token-1", token)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+
+	t.Run("should count refreshes in the token refresh metric", func(t *testing.T) {
+		server, _ := newCountingRefreshServer(t)
+		provider, _ := newProviderWithToken(t, time.Now().Add(time.Hour), server.URL)
+		before := testutil.ToFloat64(metrics.TokenRefreshes.WithLabelValues("success"))
+
+		_, err := provider.ForceRefresh()
+
+		require.NoError(t, err)
+		assert.Equal(t, before+1, testutil.ToFloat64(metrics.TokenRefreshes.WithLabelValues("success")))
+	})
+
+	t.Run("should report a missing token on forced refresh", func(t *testing.T) {
+		provider := NewOAuthTokenProvider(NewFileStorage(t.TempDir() + "/auth.json"))
+
+		_, err := provider.ForceRefresh()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no OAuth token found")
+	})
+
+	t.Run("should audit refreshes without recording tokens", func(t *testing.T) {
+		// Arrange
+		server, _ := newCountingRefreshServer(t)
+		provider, _ := newProviderWithToken(t, time.Now().Add(time.Hour), server.URL)
+		var out bytes.Buffer
+		provider.SetAuditLogger(audit.New(&out))
+
+		// Act
+		_, err := provider.ForceRefresh()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), `"event":"token_refresh"`)
+		assert.Contains(t, out.String(), `"expires_at"`)
+		assert.NotContains(t, out.String(), "refreshed-token")
+		assert.NotContains(t, out.String(), "refresh-token")
+	})
+
+	t.Run("should audit failed refreshes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		}))
+		defer server.Close()
+		provider, _ := newProviderWithToken(t, time.Now().Add(time.Hour), server.URL)
+		var out bytes.Buffer
+		provider.SetAuditLogger(audit.New(&out))
+
+		_, err := provider.ForceRefresh()
+
+		require.Error(t, err)
+		assert.Contains(t, out.String(), `"event":"token_refresh_failed"`)
+		assert.NotContains(t, out.String(), "current-token")
+	})
+}
+
+func TestRevokeToken(t *testing.T) {
+	t.Run("should post the refresh token to the revocation endpoint", func(t *testing.T) {
+		// Arrange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "refresh-token", body["token"])
+			assert.Equal(t, "refresh_token", body["token_type_hint"])
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+		client := NewOAuthClient()
+		client.RevokeURL = server.URL
+
+		// Act
+		err := client.RevokeToken("refresh-token")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("should report a rejected revocation", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		client := NewOAuthClient()
+		client.RevokeURL = server.URL
+
+		err := client.RevokeToken("refresh-token")
+
+		assert.EqualError(t, err, "revocation request failed with status 503")
+	})
+
+	t.Run("should be unsupported without a revocation endpoint", func(t *testing.T) {
+		assert.ErrorIs(t, NewOAuthClient().RevokeToken("refresh-token"), ErrRevocationUnsupported)
+	})
+}
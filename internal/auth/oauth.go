@@ -4,11 +4,20 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
 )
 
+// ManualRedirectURI is the console page that shows the authorization code for the
+// user to paste into the terminal
+const ManualRedirectURI = "https://console.anthropic.com/oauth/code/callback"
+
+// ErrRevocationUnsupported is returned by RevokeToken when no revocation endpoint is
+// configured
+var ErrRevocationUnsupported = errors.New("token revocation is not supported")
+
 // OAuthClient handles OAuth authentication with Anthropic
 type OAuthClient struct {
 	ClientID     string
@@ -16,6 +25,7 @@ type OAuthClient struct {
 	TokenURL     string
 	RedirectURI  string
 	Scopes       string
+	RevokeURL    string // RFC 7009 revocation endpoint; empty when the provider has none
 }
 
 // AuthData contains authorization URL and PKCE verifier
@@ -33,8 +43,9 @@ func NewOAuthClient() *OAuthClient {
 		ClientID:     "9d1c250a-e61b-44d9-88ed-5944d1962f5e",
 		AuthorizeURL: "https://claude.ai/oauth/authorize",
 		TokenURL:     "https://console.anthropic.com/v1/oauth/token",
-		RedirectURI:  "https://console.anthropic.com/oauth/code/callback",
+		RedirectURI:  ManualRedirectURI,
 		Scopes:       "org:create_api_key user:profile user:inference",
+		// Anthropic does not publish a revocation endpoint, so RevokeURL stays empty
 	}
 }
 
@@ -64,7 +75,6 @@ func (c *OAuthClient) GetAuthorizationURL() (*AuthData, error) {
 	}
 	
 	params := url.Values{
-		"code":                  {"true"},
 		"client_id":             {c.ClientID},
 		"response_type":         {"code"},
 		"redirect_uri":          {c.RedirectURI},
@@ -73,6 +83,10 @@ func (c *OAuthClient) GetAuthorizationURL() (*AuthData, error) {
 		"code_challenge_method": {"S256"},
 		"state":                 {verifier}, // Using verifier as state (following Python impl)
 	}
+	if c.RedirectURI == ManualRedirectURI {
+		// Ask the console to display the code instead of redirecting with it
+		params.Set("code", "true")
+	}
 	
 	authURL := fmt.Sprintf("%s?%s", c.AuthorizeURL, params.Encode())
 	
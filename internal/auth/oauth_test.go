@@ -72,8 +72,20 @@ func TestOAuthClient(t *testing.T) {
 		assert.Contains(t, authData.URL, "code_challenge=")
 		assert.Contains(t, authData.URL, "code_challenge_method=S256")
 		assert.Contains(t, authData.URL, "state=")
+		assert.Contains(t, authData.URL, "code=true")
 		assert.NotEmpty(t, authData.Verifier)
 	})
+	
+	t.Run("asks for a redirect instead of a displayed code with a local redirect URI", func(t *testing.T) {
+		local := NewOAuthClient()
+		local.RedirectURI = "http://localhost:54545/callback"
+		
+		authData, err := local.GetAuthorizationURL()
+		require.NoError(t, err)
+		
+		assert.Contains(t, authData.URL, "redirect_uri=http%3A%2F%2Flocalhost%3A54545%2Fcallback")
+		assert.NotContains(t, authData.URL, "code=true")
+	})
 }
 
 func TestTokenExchange(t *testing.T) {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,29 +13,145 @@ type Config struct {
 	// Server settings
 	Host string
 	Port int
+	Socket     string // Listen on this Unix domain socket instead of Host and Port
+	SocketMode string // Octal permissions of the socket file
+	MaxConnections int // Simultaneous client connections accepted (0 = unlimited)
+	MaxHeaderBytes int // Largest request header block accepted; larger ones get 431
+	ShutdownGracePeriod time.Duration // How long shutdown waits for in-flight requests before cancelling them
+	TLSCertFile string // Serve HTTPS with this certificate (PEM), together with TLSKeyFile
+	TLSKeyFile  string
+	AutoTLS     bool   // Serve HTTPS with a self-signed certificate generated at startup
+	PlainHTTP   string // Answer to plain HTTP on the TLS port: reject or redirect
 	
 	// Anthropic API settings
 	AnthropicBaseURL string
+	AccessToken      string // Pre-issued OAuth access token used instead of the stored login
+	UpstreamProxy    string // HTTP(S) proxy for requests to Anthropic (empty = HTTPS_PROXY/NO_PROXY)
+	WarmupUpstream   bool   // Open the upstream connection at startup
+	ReadyzUpstream   bool   // Ping the upstream in the /readyz readiness probe
 	
 	// Proxy authentication
 	ProxyAuthToken string
+	APIKeys        []string // Further local API keys accepted besides ProxyAuthToken
+	AdminKey       string   // Protects operator endpoints such as /streams
 	
 	// Request settings
-	RequestTimeout time.Duration
-	MaxRequestSize int
+	RequestTimeout   time.Duration
+	ModelTimeouts     []string      // MODEL=DURATION overrides of RequestTimeout, matched by model prefix
+	StreamMaxDuration time.Duration // Caps streams in place of the request timeouts (0 = not set)
+	StreamIdleTimeout time.Duration // Ends streams that send nothing for this long (0 = no limit)
+	PartialOnTimeout  bool          // Return the output so far when a non-streaming request times out
+	MaxRequestSize   int
+	ValidateRequests bool // Check chat completion requests against the OpenAI schema
+	MaxMessages      int  // Reject requests with more messages (0 = unlimited)
+	
+	// Per-key token budgets, kept in memory only
+	TokenBudgets      []string      // KEY=TOKENS[/PERIOD] budgets by client API key
+	TokenBudgetPeriod time.Duration // Budget period when a budget does not name one
 	
 	// Logging
 	LogLevel     string
+	LogFormat    string // Application log format on stderr ("text", "json")
 	LogRequests  bool
+	DebugHeaders bool // Honor X-Claude-Gate-Debug and return transform summary headers
+	Debug        bool // Mount debugging endpoints such as /v1/debug/translate
+	AccessLog    string // Access log lines on stdout ("none", "common", "combined", "json")
+	AccessLogLevel    string // Lowest level of JSON access log lines (4xx log at WARNING, 5xx at ERROR)
+	AccessLogBodySize bool   // Add the request body size to JSON access log lines
+	AuditLog     string // Authentication event sink: "stdout", "stderr" or a file path (empty = off)
+	TagKeys      []string // Request tag keys added to logs and metrics (empty = tags ignored)
+	DisableMetrics bool   // Remove the Prometheus /metrics endpoint
+	
+	// List request adjustments in OpenAI responses under x_claude_gate_warnings
+	ResponseWarnings bool
+	
+	// Response post-processing for truncated responses ("none", "trim-to-sentence", "append-notice")
+	FinishReasonPostProcess string
+	
+	// Non-streaming chat completions without content ("pass", "space", "error")
+	EmptyResponse string
+	
+	// How multiple OpenAI system messages are merged ("blocks", "newline", "space")
+	SystemMerge string
+	
+	// Language responses are written in, unless a request sets X-Claude-Gate-Locale (empty = any)
+	Locale string
+	
+	// Trim leading/trailing whitespace from OpenAI response content
+	TrimWhitespace bool
+	
+	// Mark translated OpenAI tool definitions cacheable (prompt caching)
+	CacheTools bool
+	
+	// Complete truncated tool call arguments at the end of a stream instead of
+	// reporting them with an error chunk
+	RepairToolArgs bool
+	
+	// Report Anthropic refusals with finish_reason "content_filter" instead of "stop"
+	ContentFilterFinishReason bool
+	
+	// Download remote image URLs of OpenAI requests and send them inline; off rejects them
+	FetchImages bool
 	
 	// Rate limiting
 	EnableRateLimit     bool
-	RateLimitPerMinute  int
+	RateLimitPerMinute  int  // Requests per client per minute, by API key or IP
+	RateLimitBurst      int  // Requests a client may send at once
+	MaxStreamsPerClient int // Concurrent streams per client (0 = unlimited)
+	CoalesceStreams     bool // Share one upstream call between identical temperature-0 streams
+	
+	// In-memory conversation sessions, keyed by the X-Claude-Gate-Session header
+	Sessions        bool
+	SessionTTL      time.Duration // How long an idle session is kept
+	SessionMaxTurns int           // Turns kept per session
+	
+	// Global upstream throttle
+	UpstreamRPS          float64       // Requests per second sent to Anthropic (0 = unlimited)
+	UpstreamQueueTimeout time.Duration // How long a request may queue behind the throttle
+	RetryAfterMaxWait    time.Duration // Longest pre-stream 429 Retry-After waited out and retried once
+	UpstreamRetries      int           // Retries of transiently failed upstream requests (0 = none)
+	UpstreamRetryDelay   time.Duration // First retry backoff, doubled per attempt
 	
 	// CORS settings
-	CORSAllowOrigins []string
+	CORSAllowOrigins     []string // Origins given CORS headers: exact, "*" or "https://*.example.com"
+	CORSAllowCredentials bool     // Allow credentialed requests from listed origins (never for "*")
+	
+	// Models endpoint settings
+	ModelsIncludeCapabilities bool          // Add context_window/max_output_tokens to /v1/models
+	ModelsCacheTTL            time.Duration // Serve the live model list cached this long (0 = static list)
+	ModelsTimeout             time.Duration // Bound on each live model list fetch
+	ModelsCacheFile           string        // Persist the model cache across restarts (empty = memory only)
+	ModelsEmptyNote           bool          // Explain an empty model list in an x_note field
+	ModelsAllowlist           []string      // Model IDs listed by /v1/models (nil = all)
+	ModelPrices               []string      // MODEL=INPUT/OUTPUT[/CACHE_WRITE/CACHE_READ] price overrides, USD per MTok
+	
+	// claude-auto routing thresholds, in estimated input tokens
+	AutoModelMediumThreshold int // Smallest prompt routed to the medium model
+	AutoModelLargeThreshold  int // Smallest prompt routed to the large model
+	
+	// Generic passthrough of unknown /v1/ paths
+	Passthrough        bool     // Forward /v1/ paths the proxy does not handle itself
+	PassthroughMethods []string // Methods forwarded by passthrough (nil = GET, HEAD)
+	
+	// Mock mode for tests and demos: canned responses, no credentials or network
+	Mock         bool   // Answer requests locally instead of calling Anthropic
+	MockResponse string // Fixed reply text (empty = echo the last user message)
+	
+	// anthropic-version header
+	AnthropicVersion       string   // Default version (empty = built-in default)
+	ModelAnthropicVersions []string // MODEL=VERSION overrides, matched by model prefix
+	
+	// Beta features
+	Betas                 []string // Betas sent with every upstream request besides the OAuth beta
+	AllowedBetas          []string // Betas the proxy may auto-enable (nil = built-in defaults)
+	RejectDisallowedBetas bool     // Reject requests needing other betas instead of dropping them
+	AllowBetaHeader       bool     // Honor per-request client anthropic-beta and X-Claude-Gate-Beta headers
 	
 	// Storage settings
+	Account           string  // Stored account alias to use (empty = default account)
+	AccountBaseURLs          []string // ACCOUNT=URL upstreams replacing AnthropicBaseURL for those accounts
+	AccountAnthropicVersions []string // ACCOUNT=VERSION default anthropic-versions for those accounts
+	AccountBetas             []string // ACCOUNT=BETA+BETA allowlists replacing AllowedBetas for those accounts
 	AuthStoragePath   string
 	AuthStorageType   string  // "auto", "keyring", or "file"
 	KeyringService    string  // Service name for keyring
@@ -53,14 +170,39 @@ func DefaultConfig() *Config {
 	return &Config{
 		Host:                "127.0.0.1",
 		Port:                5789,
+		MaxHeaderBytes:      64 << 10,
+		ShutdownGracePeriod: 30 * time.Second,
+		SocketMode:          "0660",
+		PlainHTTP:           "reject",
 		AnthropicBaseURL:    "https://api.anthropic.com",
 		RequestTimeout:      600 * time.Second,
 		MaxRequestSize:      10 * 1024 * 1024, // 10MB
+		TokenBudgetPeriod:   30 * 24 * time.Hour,
 		LogLevel:            "INFO",
+		LogFormat:           "text",
 		LogRequests:         true,
+		AccessLog:           "none",
+		AccessLogLevel:      "INFO",
+		FinishReasonPostProcess: "none",
+		EmptyResponse:       "pass",
+		SystemMerge:         "blocks",
 		EnableRateLimit:     false,
 		RateLimitPerMinute:  60,
+		RateLimitBurst:      10,
+		MaxStreamsPerClient: 0,
+		SessionTTL:          30 * time.Minute,
+		SessionMaxTurns:     20,
+		UpstreamRPS:          0,
+		UpstreamQueueTimeout: 30 * time.Second,
+		RetryAfterMaxWait:    5 * time.Second,
+		UpstreamRetries:      2,
+		UpstreamRetryDelay:   500 * time.Millisecond,
 		CORSAllowOrigins:    []string{"*"},
+		ModelsIncludeCapabilities: false, // Strict OpenAI model objects by default
+		ModelsCacheTTL:      time.Hour,   // Live model list, static list only if the fetch fails
+		ModelsTimeout:       30 * time.Second,
+		AutoModelMediumThreshold: 2000,
+		AutoModelLargeThreshold:  20000,
 		AuthStoragePath:     filepath.Join(homeDir, ".claude-gate", "auth.json"),
 		AuthStorageType:     "auto",
 		KeyringService:      "claude-gate",
@@ -73,83 +215,414 @@ func DefaultConfig() *Config {
 
 // LoadFromEnv loads configuration from environment variables
 func (c *Config) LoadFromEnv() {
+	c.load(os.Getenv)
+}
+
+// load reads the CLAUDE_GATE_* settings through getenv, skipping unset ones
+func (c *Config) load(getenv func(string) string) {
 	// Server settings
-	if host := os.Getenv("CLAUDE_GATE_HOST"); host != "" {
+	if host := getenv("CLAUDE_GATE_HOST"); host != "" {
 		c.Host = host
 	}
-	if port := os.Getenv("CLAUDE_GATE_PORT"); port != "" {
+	if port := getenv("CLAUDE_GATE_PORT"); port != "" {
 		if p, err := strconv.Atoi(port); err == nil {
 			c.Port = p
 		}
 	}
+	if socket := getenv("CLAUDE_GATE_SOCKET"); socket != "" {
+		c.Socket = socket
+	}
+	if mode := getenv("CLAUDE_GATE_SOCKET_MODE"); mode != "" {
+		c.SocketMode = mode
+	}
+	if conns := getenv("CLAUDE_GATE_MAX_CONNECTIONS"); conns != "" {
+		if n, err := strconv.Atoi(conns); err == nil {
+			c.MaxConnections = n
+		}
+	}
+	if size := getenv("CLAUDE_GATE_MAX_HEADER_BYTES"); size != "" {
+		if n, err := strconv.Atoi(size); err == nil {
+			c.MaxHeaderBytes = n
+		}
+	}
+	if grace := getenv("CLAUDE_GATE_SHUTDOWN_GRACE_PERIOD"); grace != "" {
+		if d, err := time.ParseDuration(grace); err == nil {
+			c.ShutdownGracePeriod = d
+		}
+	}
+	if cert := getenv("CLAUDE_GATE_TLS_CERT"); cert != "" {
+		c.TLSCertFile = cert
+	}
+	if key := getenv("CLAUDE_GATE_TLS_KEY"); key != "" {
+		c.TLSKeyFile = key
+	}
+	if autoTLS := getenv("CLAUDE_GATE_AUTO_TLS"); autoTLS != "" {
+		c.AutoTLS = autoTLS == "true" || autoTLS == "1"
+	}
+	if plain := getenv("CLAUDE_GATE_PLAIN_HTTP"); plain != "" {
+		c.PlainHTTP = plain
+	}
 	
 	// Anthropic API
-	if url := os.Getenv("CLAUDE_GATE_ANTHROPIC_BASE_URL"); url != "" {
+	if url := getenv("CLAUDE_GATE_ANTHROPIC_BASE_URL"); url != "" {
 		c.AnthropicBaseURL = url
 	}
+	if token := getenv("CLAUDE_GATE_ACCESS_TOKEN"); token != "" {
+		c.AccessToken = token
+	}
+	if proxyURL := getenv("CLAUDE_GATE_UPSTREAM_PROXY"); proxyURL != "" {
+		c.UpstreamProxy = proxyURL
+	}
+	if warmup := getenv("CLAUDE_GATE_WARMUP_UPSTREAM"); warmup != "" {
+		c.WarmupUpstream = warmup == "true" || warmup == "1"
+	}
+	if readyz := getenv("CLAUDE_GATE_READYZ_UPSTREAM"); readyz != "" {
+		c.ReadyzUpstream = readyz == "true" || readyz == "1"
+	}
 	
 	// Proxy auth
-	if token := os.Getenv("CLAUDE_GATE_PROXY_AUTH_TOKEN"); token != "" {
+	if token := getenv("CLAUDE_GATE_PROXY_AUTH_TOKEN"); token != "" {
 		c.ProxyAuthToken = token
 	}
+	if keys := getenv("CLAUDE_GATE_API_KEYS"); keys != "" {
+		c.APIKeys = splitList(keys)
+	}
+	if key := getenv("CLAUDE_GATE_ADMIN_KEY"); key != "" {
+		c.AdminKey = key
+	}
 	
 	// Request settings
-	if timeout := os.Getenv("CLAUDE_GATE_REQUEST_TIMEOUT"); timeout != "" {
+	if timeout := getenv("CLAUDE_GATE_REQUEST_TIMEOUT"); timeout != "" {
 		if d, err := time.ParseDuration(timeout); err == nil {
 			c.RequestTimeout = d
 		}
 	}
-	if size := os.Getenv("CLAUDE_GATE_MAX_REQUEST_SIZE"); size != "" {
+	if timeouts := getenv("CLAUDE_GATE_MODEL_TIMEOUTS"); timeouts != "" {
+		c.ModelTimeouts = splitList(timeouts)
+	}
+	if duration := getenv("CLAUDE_GATE_STREAM_MAX_DURATION"); duration != "" {
+		if d, err := time.ParseDuration(duration); err == nil {
+			c.StreamMaxDuration = d
+		}
+	}
+	if idle := getenv("CLAUDE_GATE_STREAM_IDLE_TIMEOUT"); idle != "" {
+		if d, err := time.ParseDuration(idle); err == nil {
+			c.StreamIdleTimeout = d
+		}
+	}
+	if partial := getenv("CLAUDE_GATE_PARTIAL_ON_TIMEOUT"); partial != "" {
+		c.PartialOnTimeout = partial == "true" || partial == "1"
+	}
+	if size := getenv("CLAUDE_GATE_MAX_REQUEST_SIZE"); size != "" {
 		if s, err := strconv.Atoi(size); err == nil {
 			c.MaxRequestSize = s
 		}
 	}
 	
+	if validate := getenv("CLAUDE_GATE_VALIDATE_REQUESTS"); validate != "" {
+		c.ValidateRequests = validate == "true" || validate == "1"
+	}
+	if max := getenv("CLAUDE_GATE_MAX_MESSAGES"); max != "" {
+		if n, err := strconv.Atoi(max); err == nil {
+			c.MaxMessages = n
+		}
+	}
+	if budgets := getenv("CLAUDE_GATE_TOKEN_BUDGETS"); budgets != "" {
+		c.TokenBudgets = splitList(budgets)
+	}
+	if period := getenv("CLAUDE_GATE_TOKEN_BUDGET_PERIOD"); period != "" {
+		if d, err := time.ParseDuration(period); err == nil {
+			c.TokenBudgetPeriod = d
+		}
+	}
+	
 	// Logging
-	if level := os.Getenv("CLAUDE_GATE_LOG_LEVEL"); level != "" {
+	if level := getenv("CLAUDE_GATE_LOG_LEVEL"); level != "" {
 		c.LogLevel = level
 	}
-	if logReq := os.Getenv("CLAUDE_GATE_LOG_REQUESTS"); logReq != "" {
+	if format := getenv("CLAUDE_GATE_LOG_FORMAT"); format != "" {
+		c.LogFormat = format
+	}
+	if logReq := getenv("CLAUDE_GATE_LOG_REQUESTS"); logReq != "" {
 		c.LogRequests = logReq == "true" || logReq == "1"
 	}
+	if accessLog := getenv("CLAUDE_GATE_ACCESS_LOG"); accessLog != "" {
+		c.AccessLog = accessLog
+	}
+	if level := getenv("CLAUDE_GATE_ACCESS_LOG_LEVEL"); level != "" {
+		c.AccessLogLevel = level
+	}
+	if bodySize := getenv("CLAUDE_GATE_ACCESS_LOG_BODY_SIZE"); bodySize != "" {
+		c.AccessLogBodySize = bodySize == "true" || bodySize == "1"
+	}
+	if auditLog := getenv("CLAUDE_GATE_AUDIT_LOG"); auditLog != "" {
+		c.AuditLog = auditLog
+	}
+	if keys := getenv("CLAUDE_GATE_TAG_KEYS"); keys != "" {
+		c.TagKeys = splitList(keys)
+	}
+	if disable := getenv("CLAUDE_GATE_DISABLE_METRICS"); disable != "" {
+		c.DisableMetrics = disable == "true" || disable == "1"
+	}
+	
+	if debug := getenv("CLAUDE_GATE_DEBUG_HEADERS"); debug != "" {
+		c.DebugHeaders = debug == "true" || debug == "1"
+	}
+	if debug := getenv("CLAUDE_GATE_DEBUG"); debug != "" {
+		c.Debug = debug == "true" || debug == "1"
+	}
+	if warnings := getenv("CLAUDE_GATE_RESPONSE_WARNINGS"); warnings != "" {
+		c.ResponseWarnings = warnings == "true" || warnings == "1"
+	}
+	
+	// Response post-processing
+	if mode := getenv("CLAUDE_GATE_FINISH_REASON_POSTPROCESS"); mode != "" {
+		c.FinishReasonPostProcess = mode
+	}
+	if mode := getenv("CLAUDE_GATE_EMPTY_RESPONSE"); mode != "" {
+		c.EmptyResponse = mode
+	}
+	
+	if trim := getenv("CLAUDE_GATE_TRIM_WHITESPACE"); trim != "" {
+		c.TrimWhitespace = trim == "true" || trim == "1"
+	}
+	if cache := getenv("CLAUDE_GATE_CACHE_TOOLS"); cache != "" {
+		c.CacheTools = cache == "true" || cache == "1"
+	}
+	if repair := getenv("CLAUDE_GATE_REPAIR_TOOL_ARGS"); repair != "" {
+		c.RepairToolArgs = repair == "true" || repair == "1"
+	}
+	if filter := getenv("CLAUDE_GATE_CONTENT_FILTER_FINISH_REASON"); filter != "" {
+		c.ContentFilterFinishReason = filter == "true" || filter == "1"
+	}
+	if fetch := getenv("CLAUDE_GATE_FETCH_IMAGES"); fetch != "" {
+		c.FetchImages = fetch == "true" || fetch == "1"
+	}
+	
+	// System message merging
+	if merge := getenv("CLAUDE_GATE_SYSTEM_MERGE"); merge != "" {
+		c.SystemMerge = merge
+	}
+	if locale := getenv("CLAUDE_GATE_LOCALE"); locale != "" {
+		c.Locale = locale
+	}
 	
 	// Rate limiting
-	if enable := os.Getenv("CLAUDE_GATE_ENABLE_RATE_LIMIT"); enable != "" {
+	if enable := getenv("CLAUDE_GATE_ENABLE_RATE_LIMIT"); enable != "" {
 		c.EnableRateLimit = enable == "true" || enable == "1"
 	}
-	if limit := os.Getenv("CLAUDE_GATE_RATE_LIMIT_PER_MINUTE"); limit != "" {
+	if limit := getenv("CLAUDE_GATE_RATE_LIMIT_PER_MINUTE"); limit != "" {
 		if l, err := strconv.Atoi(limit); err == nil {
 			c.RateLimitPerMinute = l
 		}
 	}
+	if burst := getenv("CLAUDE_GATE_RATE_LIMIT_BURST"); burst != "" {
+		if b, err := strconv.Atoi(burst); err == nil {
+			c.RateLimitBurst = b
+		}
+	}
+	if streams := getenv("CLAUDE_GATE_MAX_STREAMS_PER_CLIENT"); streams != "" {
+		if n, err := strconv.Atoi(streams); err == nil {
+			c.MaxStreamsPerClient = n
+		}
+	}
+	if coalesce := getenv("CLAUDE_GATE_COALESCE_STREAMS"); coalesce != "" {
+		c.CoalesceStreams = coalesce == "true" || coalesce == "1"
+	}
+	if sessions := getenv("CLAUDE_GATE_SESSIONS"); sessions != "" {
+		c.Sessions = sessions == "true" || sessions == "1"
+	}
+	if ttl := getenv("CLAUDE_GATE_SESSION_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			c.SessionTTL = d
+		}
+	}
+	if turns := getenv("CLAUDE_GATE_SESSION_MAX_TURNS"); turns != "" {
+		if n, err := strconv.Atoi(turns); err == nil {
+			c.SessionMaxTurns = n
+		}
+	}
+	
+	if rps := getenv("CLAUDE_GATE_UPSTREAM_RPS"); rps != "" {
+		if r, err := strconv.ParseFloat(rps, 64); err == nil {
+			c.UpstreamRPS = r
+		}
+	}
+	if timeout := getenv("CLAUDE_GATE_UPSTREAM_QUEUE_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil {
+			c.UpstreamQueueTimeout = d
+		}
+	}
+	if wait := getenv("CLAUDE_GATE_RETRY_AFTER_MAX_WAIT"); wait != "" {
+		if d, err := time.ParseDuration(wait); err == nil {
+			c.RetryAfterMaxWait = d
+		}
+	}
+	if retries := getenv("CLAUDE_GATE_UPSTREAM_RETRIES"); retries != "" {
+		if n, err := strconv.Atoi(retries); err == nil {
+			c.UpstreamRetries = n
+		}
+	}
+	if delay := getenv("CLAUDE_GATE_UPSTREAM_RETRY_DELAY"); delay != "" {
+		if d, err := time.ParseDuration(delay); err == nil {
+			c.UpstreamRetryDelay = d
+		}
+	}
+	
+	// CORS settings
+	if origins := getenv("CLAUDE_GATE_ALLOWED_ORIGINS"); origins != "" {
+		c.CORSAllowOrigins = splitList(origins)
+	}
+	if credentials := getenv("CLAUDE_GATE_CORS_CREDENTIALS"); credentials != "" {
+		c.CORSAllowCredentials = credentials == "true" || credentials == "1"
+	}
+	
+	// Models endpoint settings
+	if include := getenv("CLAUDE_GATE_MODELS_INCLUDE_CAPABILITIES"); include != "" {
+		c.ModelsIncludeCapabilities = include == "true" || include == "1"
+	}
+	if ttl := getenv("CLAUDE_GATE_MODELS_CACHE_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil {
+			c.ModelsCacheTTL = d
+		}
+	}
+	if timeout := getenv("CLAUDE_GATE_MODELS_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil {
+			c.ModelsTimeout = d
+		}
+	}
+	if file := getenv("CLAUDE_GATE_MODELS_CACHE_FILE"); file != "" {
+		c.ModelsCacheFile = file
+	}
+	if note := getenv("CLAUDE_GATE_MODELS_EMPTY_NOTE"); note != "" {
+		c.ModelsEmptyNote = note == "true" || note == "1"
+	}
+	if allowlist := getenv("CLAUDE_GATE_MODELS_ALLOWLIST"); allowlist != "" {
+		c.ModelsAllowlist = splitList(allowlist)
+	}
+	if prices := getenv("CLAUDE_GATE_MODEL_PRICES"); prices != "" {
+		c.ModelPrices = splitList(prices)
+	}
+	
+	// claude-auto routing
+	if medium := getenv("CLAUDE_GATE_AUTO_MODEL_MEDIUM_THRESHOLD"); medium != "" {
+		if n, err := strconv.Atoi(medium); err == nil {
+			c.AutoModelMediumThreshold = n
+		}
+	}
+	if large := getenv("CLAUDE_GATE_AUTO_MODEL_LARGE_THRESHOLD"); large != "" {
+		if n, err := strconv.Atoi(large); err == nil {
+			c.AutoModelLargeThreshold = n
+		}
+	}
+	
+	// Generic passthrough
+	if passthrough := getenv("CLAUDE_GATE_PASSTHROUGH"); passthrough != "" {
+		c.Passthrough = passthrough == "true" || passthrough == "1"
+	}
+	if methods := getenv("CLAUDE_GATE_PASSTHROUGH_METHODS"); methods != "" {
+		c.PassthroughMethods = splitList(methods)
+	}
+	
+	// Mock mode
+	if mock := getenv("CLAUDE_GATE_MOCK"); mock != "" {
+		c.Mock = mock == "true" || mock == "1"
+	}
+	if response := getenv("CLAUDE_GATE_MOCK_RESPONSE"); response != "" {
+		c.MockResponse = response
+	}
+	
+	// anthropic-version header
+	if version := getenv("CLAUDE_GATE_ANTHROPIC_VERSION"); version != "" {
+		c.AnthropicVersion = version
+	}
+	if versions := getenv("CLAUDE_GATE_MODEL_ANTHROPIC_VERSIONS"); versions != "" {
+		c.ModelAnthropicVersions = splitList(versions)
+	}
+	
+	// Beta features
+	if betas := getenv("CLAUDE_GATE_BETAS"); betas != "" {
+		c.Betas = splitList(betas)
+	}
+	if betas := getenv("CLAUDE_GATE_ALLOWED_BETAS"); betas != "" {
+		c.AllowedBetas = splitList(betas)
+	}
+	if reject := getenv("CLAUDE_GATE_REJECT_DISALLOWED_BETAS"); reject != "" {
+		c.RejectDisallowedBetas = reject == "true" || reject == "1"
+	}
+	if allow := getenv("CLAUDE_GATE_ALLOW_BETA_HEADER"); allow != "" {
+		c.AllowBetaHeader = allow == "true" || allow == "1"
+	}
 	
 	// Storage settings
-	if path := os.Getenv("CLAUDE_GATE_AUTH_STORAGE_PATH"); path != "" {
+	if account := getenv("CLAUDE_GATE_ACCOUNT"); account != "" {
+		c.Account = account
+	}
+	if urls := getenv("CLAUDE_GATE_ACCOUNT_BASE_URLS"); urls != "" {
+		c.AccountBaseURLs = splitList(urls)
+	}
+	if versions := getenv("CLAUDE_GATE_ACCOUNT_ANTHROPIC_VERSIONS"); versions != "" {
+		c.AccountAnthropicVersions = splitList(versions)
+	}
+	if betas := getenv("CLAUDE_GATE_ACCOUNT_BETAS"); betas != "" {
+		c.AccountBetas = splitList(betas)
+	}
+	if path := getenv("CLAUDE_GATE_AUTH_STORAGE_PATH"); path != "" {
 		c.AuthStoragePath = path
 	}
-	if storageType := os.Getenv("CLAUDE_GATE_AUTH_STORAGE_TYPE"); storageType != "" {
+	if storageType := getenv("CLAUDE_GATE_AUTH_STORAGE_TYPE"); storageType != "" {
 		c.AuthStorageType = storageType
 	}
-	if service := os.Getenv("CLAUDE_GATE_KEYRING_SERVICE"); service != "" {
+	if service := getenv("CLAUDE_GATE_KEYRING_SERVICE"); service != "" {
 		c.KeyringService = service
 	}
-	if autoMigrate := os.Getenv("CLAUDE_GATE_AUTO_MIGRATE_TOKENS"); autoMigrate != "" {
+	if autoMigrate := getenv("CLAUDE_GATE_AUTO_MIGRATE_TOKENS"); autoMigrate != "" {
 		c.AutoMigrateTokens = autoMigrate == "true" || autoMigrate == "1"
 	}
 	
 	// macOS Keychain settings
-	if trustApp := os.Getenv("CLAUDE_GATE_KEYCHAIN_TRUST_APP"); trustApp != "" {
+	if trustApp := getenv("CLAUDE_GATE_KEYCHAIN_TRUST_APP"); trustApp != "" {
 		c.KeychainTrustApp = trustApp == "true" || trustApp == "1"
 	}
-	if accessible := os.Getenv("CLAUDE_GATE_KEYCHAIN_ACCESSIBLE_WHEN_UNLOCKED"); accessible != "" {
+	if accessible := getenv("CLAUDE_GATE_KEYCHAIN_ACCESSIBLE_WHEN_UNLOCKED"); accessible != "" {
 		c.KeychainAccessibleWhenUnlocked = accessible == "true" || accessible == "1"
 	}
-	if sync := os.Getenv("CLAUDE_GATE_KEYCHAIN_SYNCHRONIZABLE"); sync != "" {
+	if sync := getenv("CLAUDE_GATE_KEYCHAIN_SYNCHRONIZABLE"); sync != "" {
 		c.KeychainSynchronizable = sync == "true" || sync == "1"
 	}
 }
 
+// splitList splits a comma-separated environment value, dropping empty items
+// LocalAPIKeys returns every local API key clients may send: the proxy auth token
+// and the further API keys. None means the proxy is open to anyone reaching it.
+func (c *Config) LocalAPIKeys() []string {
+	var keys []string
+	if c.ProxyAuthToken != "" {
+		keys = append(keys, c.ProxyAuthToken)
+	}
+	return append(keys, c.APIKeys...)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // GetBindAddress returns the server bind address
 func (c *Config) GetBindAddress() string {
 	return c.Host + ":" + strconv.Itoa(c.Port)
+}
+
+// GetListenAddress describes where the server listens: its Unix socket as
+// unix:PATH, or else its bind address
+func (c *Config) GetListenAddress() string {
+	if c.Socket != "" {
+		return "unix:" + c.Socket
+	}
+	return c.GetBindAddress()
 }
\ No newline at end of file
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// setting describes one exportable configuration value
+type setting struct {
+	env    string // Environment variable read by LoadFromEnv
+	flag   string // Matching `claude-gate start` flag, if any
+	secret bool   // Never exported in clear text
+	value  func(c *Config) string
+}
+
+// settings lists every value LoadFromEnv reads, in the order it reads them
+var settings = []setting{
+	{env: "CLAUDE_GATE_HOST", flag: "host", value: func(c *Config) string { return c.Host }},
+	{env: "CLAUDE_GATE_PORT", flag: "port", value: func(c *Config) string { return strconv.Itoa(c.Port) }},
+	{env: "CLAUDE_GATE_SOCKET", flag: "socket", value: func(c *Config) string { return c.Socket }},
+	{env: "CLAUDE_GATE_SOCKET_MODE", flag: "socket-mode", value: func(c *Config) string { return c.SocketMode }},
+	{env: "CLAUDE_GATE_MAX_CONNECTIONS", flag: "max-connections", value: func(c *Config) string { return strconv.Itoa(c.MaxConnections) }},
+	{env: "CLAUDE_GATE_MAX_HEADER_BYTES", flag: "max-header-bytes", value: func(c *Config) string { return strconv.Itoa(c.MaxHeaderBytes) }},
+	{env: "CLAUDE_GATE_SHUTDOWN_GRACE_PERIOD", flag: "shutdown-grace-period", value: func(c *Config) string { return c.ShutdownGracePeriod.String() }},
+	{env: "CLAUDE_GATE_TLS_CERT", flag: "tls-cert", value: func(c *Config) string { return c.TLSCertFile }},
+	{env: "CLAUDE_GATE_TLS_KEY", flag: "tls-key", value: func(c *Config) string { return c.TLSKeyFile }},
+	{env: "CLAUDE_GATE_AUTO_TLS", flag: "auto-tls", value: func(c *Config) string { return strconv.FormatBool(c.AutoTLS) }},
+	{env: "CLAUDE_GATE_PLAIN_HTTP", flag: "plain-http", value: func(c *Config) string { return c.PlainHTTP }},
+	{env: "CLAUDE_GATE_ANTHROPIC_BASE_URL", value: func(c *Config) string { return c.AnthropicBaseURL }},
+	{env: "CLAUDE_GATE_ACCESS_TOKEN", flag: "access-token", secret: true, value: func(c *Config) string { return c.AccessToken }},
+	{env: "CLAUDE_GATE_UPSTREAM_PROXY", flag: "upstream-proxy", secret: true, value: func(c *Config) string { return c.UpstreamProxy }},
+	{env: "CLAUDE_GATE_WARMUP_UPSTREAM", flag: "warmup-upstream", value: func(c *Config) string { return strconv.FormatBool(c.WarmupUpstream) }},
+	{env: "CLAUDE_GATE_READYZ_UPSTREAM", flag: "readyz-upstream", value: func(c *Config) string { return strconv.FormatBool(c.ReadyzUpstream) }},
+	{env: "CLAUDE_GATE_PROXY_AUTH_TOKEN", flag: "auth-token", secret: true, value: func(c *Config) string { return c.ProxyAuthToken }},
+	{env: "CLAUDE_GATE_API_KEYS", flag: "api-keys", secret: true, value: func(c *Config) string { return strings.Join(c.APIKeys, ",") }},
+	{env: "CLAUDE_GATE_ADMIN_KEY", flag: "admin-key", secret: true, value: func(c *Config) string { return c.AdminKey }},
+	{env: "CLAUDE_GATE_REQUEST_TIMEOUT", value: func(c *Config) string { return c.RequestTimeout.String() }},
+	{env: "CLAUDE_GATE_MODEL_TIMEOUTS", flag: "model-timeouts", value: func(c *Config) string { return strings.Join(c.ModelTimeouts, ",") }},
+	{env: "CLAUDE_GATE_STREAM_MAX_DURATION", flag: "stream-max-duration", value: func(c *Config) string { return c.StreamMaxDuration.String() }},
+	{env: "CLAUDE_GATE_STREAM_IDLE_TIMEOUT", flag: "stream-idle-timeout", value: func(c *Config) string { return c.StreamIdleTimeout.String() }},
+	{env: "CLAUDE_GATE_PARTIAL_ON_TIMEOUT", flag: "partial-on-timeout", value: func(c *Config) string { return strconv.FormatBool(c.PartialOnTimeout) }},
+	{env: "CLAUDE_GATE_MAX_REQUEST_SIZE", value: func(c *Config) string { return strconv.Itoa(c.MaxRequestSize) }},
+	{env: "CLAUDE_GATE_VALIDATE_REQUESTS", flag: "validate-requests", value: func(c *Config) string { return strconv.FormatBool(c.ValidateRequests) }},
+	{env: "CLAUDE_GATE_MAX_MESSAGES", flag: "max-messages", value: func(c *Config) string { return strconv.Itoa(c.MaxMessages) }},
+	{env: "CLAUDE_GATE_TOKEN_BUDGETS", flag: "token-budgets", secret: true, value: func(c *Config) string { return strings.Join(c.TokenBudgets, ",") }},
+	{env: "CLAUDE_GATE_TOKEN_BUDGET_PERIOD", flag: "token-budget-period", value: func(c *Config) string { return c.TokenBudgetPeriod.String() }},
+	{env: "CLAUDE_GATE_LOG_LEVEL", flag: "log-level", value: func(c *Config) string { return c.LogLevel }},
+	{env: "CLAUDE_GATE_LOG_FORMAT", flag: "log-format", value: func(c *Config) string { return c.LogFormat }},
+	{env: "CLAUDE_GATE_LOG_REQUESTS", value: func(c *Config) string { return strconv.FormatBool(c.LogRequests) }},
+	{env: "CLAUDE_GATE_ACCESS_LOG", flag: "access-log", value: func(c *Config) string { return c.AccessLog }},
+	{env: "CLAUDE_GATE_ACCESS_LOG_LEVEL", flag: "access-log-level", value: func(c *Config) string { return c.AccessLogLevel }},
+	{env: "CLAUDE_GATE_ACCESS_LOG_BODY_SIZE", flag: "access-log-body-size", value: func(c *Config) string { return strconv.FormatBool(c.AccessLogBodySize) }},
+	{env: "CLAUDE_GATE_AUDIT_LOG", flag: "audit-log", value: func(c *Config) string { return c.AuditLog }},
+	{env: "CLAUDE_GATE_TAG_KEYS", flag: "tag-keys", value: func(c *Config) string { return strings.Join(c.TagKeys, ",") }},
+	{env: "CLAUDE_GATE_DISABLE_METRICS", flag: "disable-metrics", value: func(c *Config) string { return strconv.FormatBool(c.DisableMetrics) }},
+	{env: "CLAUDE_GATE_DEBUG_HEADERS", flag: "debug-headers", value: func(c *Config) string { return strconv.FormatBool(c.DebugHeaders) }},
+	{env: "CLAUDE_GATE_DEBUG", flag: "debug", value: func(c *Config) string { return strconv.FormatBool(c.Debug) }},
+	{env: "CLAUDE_GATE_RESPONSE_WARNINGS", flag: "response-warnings", value: func(c *Config) string { return strconv.FormatBool(c.ResponseWarnings) }},
+	{env: "CLAUDE_GATE_FINISH_REASON_POSTPROCESS", flag: "finish-reason-postprocess", value: func(c *Config) string { return c.FinishReasonPostProcess }},
+	{env: "CLAUDE_GATE_EMPTY_RESPONSE", flag: "empty-response", value: func(c *Config) string { return c.EmptyResponse }},
+	{env: "CLAUDE_GATE_TRIM_WHITESPACE", flag: "trim-whitespace", value: func(c *Config) string { return strconv.FormatBool(c.TrimWhitespace) }},
+	{env: "CLAUDE_GATE_CACHE_TOOLS", flag: "cache-tools", value: func(c *Config) string { return strconv.FormatBool(c.CacheTools) }},
+	{env: "CLAUDE_GATE_REPAIR_TOOL_ARGS", flag: "repair-tool-args", value: func(c *Config) string { return strconv.FormatBool(c.RepairToolArgs) }},
+	{env: "CLAUDE_GATE_CONTENT_FILTER_FINISH_REASON", flag: "content-filter-finish-reason", value: func(c *Config) string { return strconv.FormatBool(c.ContentFilterFinishReason) }},
+	{env: "CLAUDE_GATE_FETCH_IMAGES", flag: "fetch-images", value: func(c *Config) string { return strconv.FormatBool(c.FetchImages) }},
+	{env: "CLAUDE_GATE_SYSTEM_MERGE", flag: "system-merge", value: func(c *Config) string { return c.SystemMerge }},
+	{env: "CLAUDE_GATE_LOCALE", flag: "locale", value: func(c *Config) string { return c.Locale }},
+	{env: "CLAUDE_GATE_ENABLE_RATE_LIMIT", flag: "enable-rate-limit", value: func(c *Config) string { return strconv.FormatBool(c.EnableRateLimit) }},
+	{env: "CLAUDE_GATE_RATE_LIMIT_PER_MINUTE", flag: "rate-limit-per-minute", value: func(c *Config) string { return strconv.Itoa(c.RateLimitPerMinute) }},
+	{env: "CLAUDE_GATE_RATE_LIMIT_BURST", flag: "rate-limit-burst", value: func(c *Config) string { return strconv.Itoa(c.RateLimitBurst) }},
+	{env: "CLAUDE_GATE_MAX_STREAMS_PER_CLIENT", flag: "max-streams-per-client", value: func(c *Config) string { return strconv.Itoa(c.MaxStreamsPerClient) }},
+	{env: "CLAUDE_GATE_COALESCE_STREAMS", flag: "coalesce-streams", value: func(c *Config) string { return strconv.FormatBool(c.CoalesceStreams) }},
+	{env: "CLAUDE_GATE_SESSIONS", flag: "sessions", value: func(c *Config) string { return strconv.FormatBool(c.Sessions) }},
+	{env: "CLAUDE_GATE_SESSION_TTL", flag: "session-ttl", value: func(c *Config) string { return c.SessionTTL.String() }},
+	{env: "CLAUDE_GATE_SESSION_MAX_TURNS", flag: "session-max-turns", value: func(c *Config) string { return strconv.Itoa(c.SessionMaxTurns) }},
+	{env: "CLAUDE_GATE_UPSTREAM_RPS", flag: "upstream-rps", value: func(c *Config) string { return strconv.FormatFloat(c.UpstreamRPS, 'g', -1, 64) }},
+	{env: "CLAUDE_GATE_UPSTREAM_QUEUE_TIMEOUT", flag: "upstream-queue-timeout", value: func(c *Config) string { return c.UpstreamQueueTimeout.String() }},
+	{env: "CLAUDE_GATE_RETRY_AFTER_MAX_WAIT", flag: "retry-after-max-wait", value: func(c *Config) string { return c.RetryAfterMaxWait.String() }},
+	{env: "CLAUDE_GATE_UPSTREAM_RETRIES", flag: "upstream-retries", value: func(c *Config) string { return strconv.Itoa(c.UpstreamRetries) }},
+	{env: "CLAUDE_GATE_UPSTREAM_RETRY_DELAY", flag: "upstream-retry-delay", value: func(c *Config) string { return c.UpstreamRetryDelay.String() }},
+	{env: "CLAUDE_GATE_ALLOWED_ORIGINS", flag: "allowed-origins", value: func(c *Config) string { return strings.Join(c.CORSAllowOrigins, ",") }},
+	{env: "CLAUDE_GATE_CORS_CREDENTIALS", flag: "cors-credentials", value: func(c *Config) string { return strconv.FormatBool(c.CORSAllowCredentials) }},
+	{env: "CLAUDE_GATE_MODELS_INCLUDE_CAPABILITIES", flag: "model-capabilities", value: func(c *Config) string { return strconv.FormatBool(c.ModelsIncludeCapabilities) }},
+	{env: "CLAUDE_GATE_MODELS_CACHE_TTL", flag: "models-cache-ttl", value: func(c *Config) string { return c.ModelsCacheTTL.String() }},
+	{env: "CLAUDE_GATE_MODELS_TIMEOUT", flag: "models-timeout", value: func(c *Config) string { return c.ModelsTimeout.String() }},
+	{env: "CLAUDE_GATE_MODELS_CACHE_FILE", flag: "models-cache-file", value: func(c *Config) string { return c.ModelsCacheFile }},
+	{env: "CLAUDE_GATE_MODELS_EMPTY_NOTE", flag: "models-empty-note", value: func(c *Config) string { return strconv.FormatBool(c.ModelsEmptyNote) }},
+	{env: "CLAUDE_GATE_MODELS_ALLOWLIST", flag: "models-allowlist", value: func(c *Config) string { return strings.Join(c.ModelsAllowlist, ",") }},
+	{env: "CLAUDE_GATE_MODEL_PRICES", flag: "model-prices", value: func(c *Config) string { return strings.Join(c.ModelPrices, ",") }},
+	{env: "CLAUDE_GATE_AUTO_MODEL_MEDIUM_THRESHOLD", flag: "auto-model-medium-threshold", value: func(c *Config) string { return strconv.Itoa(c.AutoModelMediumThreshold) }},
+	{env: "CLAUDE_GATE_AUTO_MODEL_LARGE_THRESHOLD", flag: "auto-model-large-threshold", value: func(c *Config) string { return strconv.Itoa(c.AutoModelLargeThreshold) }},
+	{env: "CLAUDE_GATE_PASSTHROUGH", flag: "passthrough", value: func(c *Config) string { return strconv.FormatBool(c.Passthrough) }},
+	{env: "CLAUDE_GATE_PASSTHROUGH_METHODS", flag: "passthrough-methods", value: func(c *Config) string { return strings.Join(c.PassthroughMethods, ",") }},
+	{env: "CLAUDE_GATE_MOCK", flag: "mock", value: func(c *Config) string { return strconv.FormatBool(c.Mock) }},
+	{env: "CLAUDE_GATE_MOCK_RESPONSE", flag: "mock-response", value: func(c *Config) string { return c.MockResponse }},
+	{env: "CLAUDE_GATE_ANTHROPIC_VERSION", flag: "anthropic-version", value: func(c *Config) string { return c.AnthropicVersion }},
+	{env: "CLAUDE_GATE_MODEL_ANTHROPIC_VERSIONS", flag: "model-anthropic-versions", value: func(c *Config) string { return strings.Join(c.ModelAnthropicVersions, ",") }},
+	{env: "CLAUDE_GATE_BETAS", flag: "betas", value: func(c *Config) string { return strings.Join(c.Betas, ",") }},
+	{env: "CLAUDE_GATE_ALLOWED_BETAS", flag: "allowed-betas", value: func(c *Config) string { return strings.Join(c.AllowedBetas, ",") }},
+	{env: "CLAUDE_GATE_REJECT_DISALLOWED_BETAS", flag: "reject-disallowed-betas", value: func(c *Config) string { return strconv.FormatBool(c.RejectDisallowedBetas) }},
+	{env: "CLAUDE_GATE_ALLOW_BETA_HEADER", flag: "allow-beta-header", value: func(c *Config) string { return strconv.FormatBool(c.AllowBetaHeader) }},
+	{env: "CLAUDE_GATE_ACCOUNT", flag: "account", value: func(c *Config) string { return c.Account }},
+	{env: "CLAUDE_GATE_ACCOUNT_BASE_URLS", flag: "account-base-urls", value: func(c *Config) string { return strings.Join(c.AccountBaseURLs, ",") }},
+	{env: "CLAUDE_GATE_ACCOUNT_ANTHROPIC_VERSIONS", flag: "account-anthropic-versions", value: func(c *Config) string { return strings.Join(c.AccountAnthropicVersions, ",") }},
+	{env: "CLAUDE_GATE_ACCOUNT_BETAS", flag: "account-betas", value: func(c *Config) string { return strings.Join(c.AccountBetas, ",") }},
+	{env: "CLAUDE_GATE_AUTH_STORAGE_PATH", value: func(c *Config) string { return c.AuthStoragePath }},
+	{env: "CLAUDE_GATE_AUTH_STORAGE_TYPE", value: func(c *Config) string { return c.AuthStorageType }},
+	{env: "CLAUDE_GATE_KEYRING_SERVICE", value: func(c *Config) string { return c.KeyringService }},
+	{env: "CLAUDE_GATE_AUTO_MIGRATE_TOKENS", value: func(c *Config) string { return strconv.FormatBool(c.AutoMigrateTokens) }},
+	{env: "CLAUDE_GATE_KEYCHAIN_TRUST_APP", value: func(c *Config) string { return strconv.FormatBool(c.KeychainTrustApp) }},
+	{env: "CLAUDE_GATE_KEYCHAIN_ACCESSIBLE_WHEN_UNLOCKED", value: func(c *Config) string { return strconv.FormatBool(c.KeychainAccessibleWhenUnlocked) }},
+	{env: "CLAUDE_GATE_KEYCHAIN_SYNCHRONIZABLE", value: func(c *Config) string { return strconv.FormatBool(c.KeychainSynchronizable) }},
+}
+
+// ChangedSettings returns the environment names of the settings whose values differ
+// between a and b, in the order of settings. Secrets are compared but never returned
+// with their values.
+func ChangedSettings(a, b *Config) []string {
+	var changed []string
+	for _, s := range settings {
+		if s.value(a) != s.value(b) {
+			changed = append(changed, s.env)
+		}
+	}
+	return changed
+}
+
+// ExportEnv returns the configuration as shell environment assignments that
+// LoadFromEnv reads back. Secrets that are set appear only as comments.
+func (c *Config) ExportEnv() []string {
+	var lines []string
+	for _, s := range settings {
+		value := s.value(c)
+		if s.secret {
+			if value != "" {
+				lines = append(lines, fmt.Sprintf("# %s is set (redacted)", s.env))
+			}
+			continue
+		}
+		lines = append(lines, s.env+"="+shellQuote(value))
+	}
+	return lines
+}
+
+// ExportFlags returns the configuration as a `claude-gate start` command. Settings
+// without a flag are passed as environment assignments in front of the command.
+// Secrets that are set appear only as comments.
+func (c *Config) ExportFlags() []string {
+	var comments, envs, flags []string
+	for _, s := range settings {
+		value := s.value(c)
+		switch {
+		case s.secret:
+			if value != "" {
+				comments = append(comments, fmt.Sprintf("# --%s is set (redacted)", s.flag))
+			}
+		case s.flag == "":
+			envs = append(envs, s.env+"="+shellQuote(value))
+		case value == "true":
+			flags = append(flags, "--"+s.flag)
+		case value == "false" || value == "":
+			// Boolean flags default to off and empty lists to unset
+		default:
+			flags = append(flags, "--"+s.flag+"="+shellQuote(value))
+		}
+	}
+
+	lines := comments
+	for _, env := range envs {
+		lines = append(lines, env+" \\")
+	}
+	command := "claude-gate start"
+	if len(flags) > 0 {
+		command += " \\"
+	}
+	lines = append(lines, command)
+	for i, flag := range flags {
+		if i < len(flags)-1 {
+			flag += " \\"
+		}
+		lines = append(lines, "  "+flag)
+	}
+	return lines
+}
+
+// shellQuote quotes a value for POSIX shells when it contains special characters
+func shellQuote(value string) string {
+	if value == "" {
+		return "''"
+	}
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:,=@+%", r)) {
+			return "'" + strings.ReplaceAll(value, "'", `'"'"'`) + "'"
+		}
+	}
+	return value
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadFromFile loads configuration from a file of CLAUDE_GATE_* assignments, one per
+// line, in the format written by ExportEnv. Blank lines, # comments and a leading
+// "export" are allowed; values may be quoted as in a POSIX shell. Unknown settings
+// and malformed lines are errors, and nothing is applied then.
+func (c *Config) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.env] = true
+	}
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected NAME=VALUE", path, n)
+		}
+		if !known[key] {
+			return fmt.Errorf("%s:%d: unknown setting %s", path, n, key)
+		}
+		value, err := shellUnquote(raw)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s: %w", path, n, key, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	c.load(func(key string) string { return values[key] })
+	return nil
+}
+
+// shellUnquote undoes shellQuote and the other single and double quoting a shell
+// accepts in a plain word
+func shellUnquote(raw string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch quote := raw[i]; quote {
+		case '\'', '"':
+			end := strings.IndexByte(raw[i+1:], quote)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated %c quote", quote)
+			}
+			b.WriteString(raw[i+1 : i+1+end])
+			i += end + 1
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+	return b.String(), nil
+}
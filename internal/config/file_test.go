@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "claude-gate.env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	t.Run("should read back what ExportEnv writes, secrets aside", func(t *testing.T) {
+		// Arrange
+		want := customConfig()
+		path := writeConfigFile(t, strings.Join(want.ExportEnv(), "\n")+"\n")
+
+		// Act
+		cfg := DefaultConfig()
+		err := cfg.LoadFromFile(path)
+
+		// Assert
+		require.NoError(t, err)
+		var secrets []string
+		for _, s := range settings {
+			if s.secret {
+				secrets = append(secrets, s.env)
+			}
+		}
+		assert.Equal(t, secrets, ChangedSettings(want, cfg))
+	})
+
+	t.Run("should accept comments, export and quoting", func(t *testing.T) {
+		path := writeConfigFile(t, `
+# Proxy for the web app
+export CLAUDE_GATE_PORT=8080
+CLAUDE_GATE_ALLOWED_ORIGINS='https://app.example.com,https://*.example.com'
+CLAUDE_GATE_MOCK_RESPONSE="it's fine"
+`)
+
+		cfg := DefaultConfig()
+		require.NoError(t, cfg.LoadFromFile(path))
+
+		assert.Equal(t, 8080, cfg.Port)
+		assert.Equal(t, []string{"https://app.example.com", "https://*.example.com"}, cfg.CORSAllowOrigins)
+		assert.Equal(t, "it's fine", cfg.MockResponse)
+	})
+
+	t.Run("should reject unknown settings and malformed lines without applying any", func(t *testing.T) {
+		for content, message := range map[string]string{
+			"CLAUDE_GATE_PORT=8080\nCLAUDE_GATE_PROT=9090\n": "unknown setting CLAUDE_GATE_PROT",
+			"CLAUDE_GATE_PORT=8080\nCLAUDE_GATE_HOST\n":      "expected NAME=VALUE",
+			"CLAUDE_GATE_HOST='0.0.0.0\n":                    "unterminated ' quote",
+		} {
+			cfg := DefaultConfig()
+
+			err := cfg.LoadFromFile(writeConfigFile(t, content))
+
+			assert.ErrorContains(t, err, message)
+			assert.Equal(t, DefaultConfig().Port, cfg.Port)
+		}
+	})
+
+	t.Run("should fail on a missing file", func(t *testing.T) {
+		err := DefaultConfig().LoadFromFile(filepath.Join(t.TempDir(), "missing.env"))
+
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+}
+
+func TestChangedSettings(t *testing.T) {
+	t.Run("should list the settings that differ", func(t *testing.T) {
+		// Arrange
+		a := DefaultConfig()
+		b := DefaultConfig()
+		b.Port = 9090
+		b.ModelsAllowlist = []string{"claude-sonnet-4"}
+		b.AdminKey = "admin-secret"
+
+		// Act
+		changed := ChangedSettings(a, b)
+
+		// Assert
+		assert.Equal(t, []string{"CLAUDE_GATE_PORT", "CLAUDE_GATE_ADMIN_KEY", "CLAUDE_GATE_MODELS_ALLOWLIST"}, changed)
+		assert.Empty(t, ChangedSettings(a, DefaultConfig()))
+	})
+}
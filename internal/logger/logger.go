@@ -22,25 +22,46 @@ const (
 	ERROR   LogLevel = "ERROR"
 )
 
-// New creates a new structured logger with the specified level
-func New(level LogLevel) *slog.Logger {
-	var slogLevel slog.Level
-	
+// Slog returns the slog level for the logging level
+func (level LogLevel) Slog() slog.Level {
 	switch level {
 	case DEBUG:
-		slogLevel = slog.LevelDebug
-	case INFO:
-		slogLevel = slog.LevelInfo
+		return slog.LevelDebug
 	case WARNING:
-		slogLevel = slog.LevelWarn
+		return slog.LevelWarn
 	case ERROR:
-		slogLevel = slog.LevelError
+		return slog.LevelError
 	default:
-		slogLevel = slog.LevelInfo
+		return slog.LevelInfo
 	}
-	
+}
+
+// New creates a new structured logger with the specified level
+func New(level LogLevel) *slog.Logger {
+	return NewWithFormat(level, FormatText)
+}
+
+// Log formats accepted by NewWithFormat
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewWithFormat creates a new structured logger writing text or JSON lines; unknown
+// formats write text
+func NewWithFormat(level LogLevel, format string) *slog.Logger {
+	return newLogger(level.Slog(), format)
+}
+
+// NewWithLevelVar creates a logger like NewWithFormat whose level follows level, so
+// it can change while the logger is in use
+func NewWithLevelVar(level *slog.LevelVar, format string) *slog.Logger {
+	return newLogger(level, format)
+}
+
+func newLogger(level slog.Leveler, format string) *slog.Logger {
 	opts := &slog.HandlerOptions{
-		Level: slogLevel,
+		Level: level,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			// Customize time format
 			if a.Key == slog.TimeKey {
@@ -53,7 +74,10 @@ func New(level LogLevel) *slog.Logger {
 		},
 	}
 	
-	handler := slog.NewTextHandler(os.Stderr, opts)
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if strings.EqualFold(format, FormatJSON) {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	}
 	return slog.New(handler)
 }
 
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "claude_gate"
+
+// UnsupportedParams counts OpenAI request parameters dropped during translation
+var UnsupportedParams = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "unsupported_params_total",
+	Help:      "OpenAI request parameters without an Anthropic equivalent that were dropped during translation.",
+}, []string{"param"})
+
+// TaggedRequests counts requests by allowlisted client tag
+var TaggedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "tagged_requests_total",
+	Help:      "Requests carrying an allowlisted tag, by tag key and value.",
+}, []string{"key", "value"})
+
+// RequestsTotal counts requests served by the proxy, by normalized path and status
+var RequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "requests_total",
+	Help:      "Requests served, by route and HTTP status code.",
+}, []string{"path", "status"})
+
+// UpstreamDuration observes the time until Anthropic's response headers arrive
+var UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "upstream_request_duration_seconds",
+	Help:      "Latency of upstream requests to Anthropic until the response headers arrive, by endpoint.",
+	Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+}, []string{"endpoint"})
+
+// UpstreamErrors counts failed upstream requests by error type
+var UpstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "upstream_errors_total",
+	Help:      "Upstream requests that failed, by Anthropic error type or transport failure.",
+}, []string{"type"})
+
+// TokenRefreshes counts OAuth token refreshes by result
+var TokenRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "token_refreshes_total",
+	Help:      "OAuth access token refreshes, by result (success or failure).",
+}, []string{"result"})
+
+// ActiveStreams is the number of streaming responses currently being proxied
+var ActiveStreams = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "active_streams",
+	Help:      "Streaming responses currently being proxied.",
+})
+
+// Handler returns the HTTP handler exposing all metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
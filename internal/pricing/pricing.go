@@ -0,0 +1,129 @@
+// Package pricing holds the per-model token prices shared by the pricing endpoint
+// and cost estimates.
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Price is the USD price per million tokens of each token kind
+type Price struct {
+	InputPerMTok      float64 `json:"input_per_mtok"`
+	OutputPerMTok     float64 `json:"output_per_mtok"`
+	CacheWritePerMTok float64 `json:"cache_write_per_mtok"`
+	CacheReadPerMTok  float64 `json:"cache_read_per_mtok"`
+}
+
+// Cost returns the USD cost of the given input and output token counts
+func (p Price) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.InputPerMTok + float64(outputTokens)*p.OutputPerMTok) / 1_000_000
+}
+
+// withCachePrices fills in cache prices from the input price at Anthropic's standard
+// multipliers: writes cost 1.25x and reads 0.1x
+func withCachePrices(input, output float64) Price {
+	return Price{
+		InputPerMTok:      input,
+		OutputPerMTok:     output,
+		CacheWritePerMTok: input * 1.25,
+		CacheReadPerMTok:  input * 0.1,
+	}
+}
+
+// Entry is the price of every model whose name starts with Model
+type Entry struct {
+	Model string `json:"model"`
+	Price
+}
+
+// builtin lists list prices by model family
+var builtin = map[string]Price{
+	"claude-opus-4":     withCachePrices(15, 75),
+	"claude-sonnet-4":   withCachePrices(3, 15),
+	"claude-3-7-sonnet": withCachePrices(3, 15),
+	"claude-3-5-sonnet": withCachePrices(3, 15),
+	"claude-3-5-haiku":  withCachePrices(0.8, 4),
+	"claude-3-opus":     withCachePrices(15, 75),
+	"claude-3-sonnet":   withCachePrices(3, 15),
+	"claude-3-haiku":    withCachePrices(0.25, 1.25),
+}
+
+// Table maps model name prefixes to prices
+type Table struct {
+	prices map[string]Price
+}
+
+// Default returns the built-in price table
+func Default() *Table {
+	return (&Table{prices: builtin}).WithOverrides(nil)
+}
+
+// WithOverrides returns a copy of the table with the given prices added or replaced
+func (t *Table) WithOverrides(overrides map[string]Price) *Table {
+	prices := make(map[string]Price, len(t.prices)+len(overrides))
+	for model, price := range t.prices {
+		prices[model] = price
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+	return &Table{prices: prices}
+}
+
+// Lookup returns the price of a model, matched by the longest model prefix
+func (t *Table) Lookup(model string) (Price, bool) {
+	var price Price
+	matched := 0
+	for prefix, p := range t.prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > matched {
+			price, matched = p, len(prefix)
+		}
+	}
+	return price, matched > 0
+}
+
+// Entries returns every price, sorted by model
+func (t *Table) Entries() []Entry {
+	entries := make([]Entry, 0, len(t.prices))
+	for model, price := range t.prices {
+		entries = append(entries, Entry{Model: model, Price: price})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Model < entries[j].Model })
+	return entries
+}
+
+// ParseOverrides parses MODEL=INPUT/OUTPUT[/CACHE_WRITE/CACHE_READ] prices in USD per
+// million tokens. Cache prices default to the standard multipliers of the input price.
+func ParseOverrides(pairs []string) (map[string]Price, error) {
+	overrides := make(map[string]Price, len(pairs))
+	for _, pair := range pairs {
+		model, value, ok := strings.Cut(pair, "=")
+		model = strings.TrimSpace(model)
+		if !ok || model == "" {
+			return nil, fmt.Errorf("invalid model price %q, expected MODEL=INPUT/OUTPUT[/CACHE_WRITE/CACHE_READ]", pair)
+		}
+
+		parts := strings.Split(value, "/")
+		if len(parts) != 2 && len(parts) != 4 {
+			return nil, fmt.Errorf("invalid model price %q, expected MODEL=INPUT/OUTPUT[/CACHE_WRITE/CACHE_READ]", pair)
+		}
+		amounts := make([]float64, len(parts))
+		for i, part := range parts {
+			amount, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil || amount < 0 {
+				return nil, fmt.Errorf("invalid model price %q, expected non-negative USD amounts", pair)
+			}
+			amounts[i] = amount
+		}
+
+		price := withCachePrices(amounts[0], amounts[1])
+		if len(amounts) == 4 {
+			price.CacheWritePerMTok, price.CacheReadPerMTok = amounts[2], amounts[3]
+		}
+		overrides[model] = price
+	}
+	return overrides, nil
+}
@@ -0,0 +1,89 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable_Lookup(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected Price
+	}{
+		{"claude-opus-4-20250514", Price{InputPerMTok: 15, OutputPerMTok: 75, CacheWritePerMTok: 18.75, CacheReadPerMTok: 1.5}},
+		{"claude-sonnet-4-20250514", Price{InputPerMTok: 3, OutputPerMTok: 15, CacheWritePerMTok: 3.75, CacheReadPerMTok: 0.3}},
+		{"claude-3-5-sonnet-20241022", Price{InputPerMTok: 3, OutputPerMTok: 15, CacheWritePerMTok: 3.75, CacheReadPerMTok: 0.3}},
+		{"claude-3-5-haiku-20241022", Price{InputPerMTok: 0.8, OutputPerMTok: 4, CacheWritePerMTok: 1, CacheReadPerMTok: 0.08}},
+		{"claude-3-haiku-20240307", Price{InputPerMTok: 0.25, OutputPerMTok: 1.25, CacheWritePerMTok: 0.3125, CacheReadPerMTok: 0.025}},
+	}
+
+	for _, tt := range tests {
+		t.Run("should price "+tt.model, func(t *testing.T) {
+			price, ok := Default().Lookup(tt.model)
+
+			require.True(t, ok)
+			assert.InDelta(t, tt.expected.InputPerMTok, price.InputPerMTok, 1e-9)
+			assert.InDelta(t, tt.expected.OutputPerMTok, price.OutputPerMTok, 1e-9)
+			assert.InDelta(t, tt.expected.CacheWritePerMTok, price.CacheWritePerMTok, 1e-9)
+			assert.InDelta(t, tt.expected.CacheReadPerMTok, price.CacheReadPerMTok, 1e-9)
+		})
+	}
+
+	t.Run("should not price unknown models", func(t *testing.T) {
+		_, ok := Default().Lookup("gpt-4o")
+
+		assert.False(t, ok)
+	})
+}
+
+func TestTable_WithOverrides(t *testing.T) {
+	t.Run("should prefer the longest matching override without changing the default table", func(t *testing.T) {
+		// Arrange
+		overrides := map[string]Price{"claude-opus-4-1": {InputPerMTok: 20, OutputPerMTok: 100}}
+
+		// Act
+		table := Default().WithOverrides(overrides)
+
+		// Assert
+		price, _ := table.Lookup("claude-opus-4-1-20250805")
+		assert.Equal(t, 20.0, price.InputPerMTok)
+		price, _ = table.Lookup("claude-opus-4-20250514")
+		assert.Equal(t, 15.0, price.InputPerMTok)
+		price, _ = Default().Lookup("claude-opus-4-1-20250805")
+		assert.Equal(t, 15.0, price.InputPerMTok)
+	})
+}
+
+func TestTable_Entries(t *testing.T) {
+	entries := Default().Entries()
+
+	require.Len(t, entries, len(builtin))
+	for i := 1; i < len(entries); i++ {
+		assert.Less(t, entries[i-1].Model, entries[i].Model)
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	t.Run("should parse prices with and without cache prices", func(t *testing.T) {
+		overrides, err := ParseOverrides([]string{"claude-x=2/10", "claude-y=1/5/1.5/0.2"})
+
+		require.NoError(t, err)
+		assert.Equal(t, Price{InputPerMTok: 2, OutputPerMTok: 10, CacheWritePerMTok: 2.5, CacheReadPerMTok: 0.2}, overrides["claude-x"])
+		assert.Equal(t, Price{InputPerMTok: 1, OutputPerMTok: 5, CacheWritePerMTok: 1.5, CacheReadPerMTok: 0.2}, overrides["claude-y"])
+	})
+
+	t.Run("should reject malformed prices", func(t *testing.T) {
+		for _, pair := range []string{"claude-x", "=1/2", "claude-x=1", "claude-x=1/2/3", "claude-x=a/b", "claude-x=-1/2"} {
+			_, err := ParseOverrides([]string{pair})
+			assert.Error(t, err, pair)
+		}
+	})
+}
+
+func TestPrice_Cost(t *testing.T) {
+	price := Price{InputPerMTok: 3, OutputPerMTok: 15}
+
+	assert.InDelta(t, 0.018, price.Cost(1000, 1000), 1e-9)
+}
@@ -0,0 +1,218 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/requestid"
+)
+
+// AccessLogFormat selects the Apache/Nginx-style or JSON access log written for each request
+type AccessLogFormat string
+
+const (
+	// AccessLogNone writes no access log; the structured logger still logs requests
+	AccessLogNone AccessLogFormat = "none"
+	// AccessLogCommon writes Common Log Format lines
+	AccessLogCommon AccessLogFormat = "common"
+	// AccessLogCombined writes Combined Log Format lines, CLF plus referer and user agent
+	AccessLogCombined AccessLogFormat = "combined"
+	// AccessLogJSON writes one slog JSON object per request for log aggregators
+	AccessLogJSON AccessLogFormat = "json"
+)
+
+// clfTimeFormat is the timestamp layout of Common Log Format
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// ParseAccessLogFormat validates an access log format name; empty means none
+func ParseAccessLogFormat(format string) (AccessLogFormat, error) {
+	switch f := AccessLogFormat(strings.ToLower(strings.TrimSpace(format))); f {
+	case "", AccessLogNone:
+		return AccessLogNone, nil
+	case AccessLogCommon, AccessLogCombined, AccessLogJSON:
+		return f, nil
+	default:
+		return AccessLogNone, fmt.Errorf("unknown access log format %q (want none, common, combined or json)", format)
+	}
+}
+
+// accessLogOptions tunes the JSON access log
+type accessLogOptions struct {
+	// level is the lowest level logged; requests log at INFO, 4xx at WARN, 5xx at ERROR
+	level slog.Level
+	// bodySize adds the request body size; bodies themselves are never logged
+	bodySize bool
+}
+
+// accessLogger writes one access log line per request to out
+type accessLogger struct {
+	format  AccessLogFormat
+	options accessLogOptions
+	now     func() time.Time
+
+	mu  sync.Mutex
+	out io.Writer
+
+	jsonOnce sync.Once
+	json     slog.Handler
+}
+
+// accessLogMiddleware logs every request served by next in the given format
+func accessLogMiddleware(next http.Handler, format AccessLogFormat, out io.Writer, options accessLogOptions) http.Handler {
+	return (&accessLogger{format: format, options: options, out: out, now: time.Now}).middleware(next)
+}
+
+func (l *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.now()
+		var body *countingReader
+		if l.format == AccessLogJSON && l.options.bodySize && r.Body != nil {
+			body = &countingReader{ReadCloser: r.Body}
+			r.Body = body
+		}
+		recorder := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if l.format == AccessLogJSON {
+			l.logJSON(r, recorder, start, body)
+			return
+		}
+		l.log(r, start, recorder.status, recorder.written)
+	})
+}
+
+// logJSON writes the JSON object for a finished request, with the request ID the
+// proxy handler logs under so log lines can be joined
+func (l *accessLogger) logJSON(r *http.Request, recorder *accessLogWriter, start time.Time, body *countingReader) {
+	l.jsonOnce.Do(func() {
+		l.json = slog.NewJSONHandler(l.out, &slog.HandlerOptions{Level: l.options.level})
+	})
+
+	level := slog.LevelInfo
+	switch {
+	case recorder.status >= 500:
+		level = slog.LevelError
+	case recorder.status >= 400:
+		level = slog.LevelWarn
+	}
+	ctx := context.Background()
+	if !l.json.Enabled(ctx, level) {
+		return
+	}
+
+	record := slog.NewRecord(start, level, "request", 0)
+	record.AddAttrs(
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", recorder.status),
+		slog.Int64("latency_ms", l.now().Sub(start).Milliseconds()),
+		slog.String("request_id", requestID(r, recorder)),
+		slog.Int64("bytes_out", recorder.written),
+	)
+	if body != nil {
+		record.AddAttrs(slog.Int64("bytes_in", body.read))
+	}
+	l.json.Handle(ctx, record)
+}
+
+// requestID is the ID from requestid.Middleware, or else the one a handler put on
+// the response
+func requestID(r *http.Request, w http.ResponseWriter) string {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		return id
+	}
+	return w.Header().Get(requestid.Header)
+}
+
+// log writes the line for a finished request
+func (l *accessLogger) log(r *http.Request, start time.Time, status int, written int64) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if host == "" {
+		host = "-"
+	}
+	size := "-"
+	if written > 0 {
+		size = strconv.FormatInt(written, 10)
+	}
+
+	// ident and authuser are always "-": API keys must never reach the log
+	line := fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %s`,
+		host,
+		start.Format(clfTimeFormat),
+		r.Method, clfEscape(r.URL.RequestURI()), r.Proto,
+		status,
+		size,
+	)
+	if l.format == AccessLogCombined {
+		line += fmt.Sprintf(` "%s" "%s"`, clfField(r.Referer()), clfField(r.UserAgent()))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, line+"\n")
+}
+
+// clfField quotes an optional header value, "-" when it is missing
+func clfField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return clfEscape(value)
+}
+
+// clfEscape keeps client-controlled values from breaking the line format
+func clfEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+// accessLogWriter records the status and body size of a response
+type accessLogWriter struct {
+	http.ResponseWriter
+	status      int
+	written     int64
+	wroteHeader bool
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush keeps streaming responses flowing through the middleware
+func (w *accessLogWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
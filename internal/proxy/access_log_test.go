@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	fixed := time.Date(2024, time.October, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+	serve := func(format AccessLogFormat, handler http.HandlerFunc, req *http.Request) string {
+		var out bytes.Buffer
+		logger := &accessLogger{format: format, out: &out, now: func() time.Time { return fixed }}
+		logger.middleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+		return out.String()
+	}
+	hello := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}
+
+	t.Run("should write Common Log Format lines", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest("GET", "/v1/models?limit=2", nil)
+		req.RemoteAddr = "192.0.2.10:53211"
+		req.Header.Set("Authorization", "Bearer sk-secret")
+
+		// Act
+		line := serve(AccessLogCommon, hello, req)
+
+		// Assert
+		assert.Equal(t, `192.0.2.10 - - [10/Oct/2024:13:55:36 -0700] "GET /v1/models?limit=2 HTTP/1.1" 200 5`+"\n", line)
+		assert.NotContains(t, line, "sk-secret")
+	})
+
+	t.Run("should add referer and user agent in Combined Log Format", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader("{}"))
+		req.RemoteAddr = "[2001:db8::1]:443"
+		req.Header.Set("Referer", "https://app.example.com/")
+		req.Header.Set("User-Agent", `client "quoted"/1.0`)
+
+		line := serve(AccessLogCombined, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}, req)
+
+		assert.Equal(t, `2001:db8::1 - - [10/Oct/2024:13:55:36 -0700] "POST /v1/chat/completions HTTP/1.1" 429 - "https://app.example.com/" "client \"quoted\"/1.0"`+"\n", line)
+	})
+
+	t.Run("should log dashes for missing combined fields", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/health", nil)
+
+		line := serve(AccessLogCombined, hello, req)
+
+		assert.True(t, strings.HasSuffix(line, ` 200 5 "-" "-"`+"\n"), line)
+	})
+
+	t.Run("should keep streams flushable", func(t *testing.T) {
+		var flushable bool
+		serve(AccessLogCommon, func(w http.ResponseWriter, r *http.Request) {
+			_, flushable = w.(http.Flusher)
+		}, httptest.NewRequest("GET", "/", nil))
+
+		assert.True(t, flushable)
+	})
+}
+
+func TestAccessLogMiddleware_JSON(t *testing.T) {
+	serve := func(options accessLogOptions, handler http.HandlerFunc, req *http.Request) string {
+		var out bytes.Buffer
+		logger := &accessLogger{format: AccessLogJSON, options: options, out: &out, now: time.Now}
+		logger.middleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+		return out.String()
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		io.Copy(w, r.Body)
+	}
+
+	t.Run("should write one JSON object per request without the body", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest("POST", "/v1/chat/completions?x=1", strings.NewReader(`{"prompt":"secret plans"}`))
+
+		// Act
+		line := serve(accessLogOptions{}, echo, req)
+
+		// Assert
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		assert.Equal(t, "INFO", entry["level"])
+		assert.Equal(t, "request", entry["msg"])
+		assert.Equal(t, "POST", entry["method"])
+		assert.Equal(t, "/v1/chat/completions", entry["path"])
+		assert.Equal(t, float64(200), entry["status"])
+		assert.Equal(t, "req-123", entry["request_id"])
+		assert.Equal(t, float64(25), entry["bytes_out"])
+		assert.Contains(t, entry, "latency_ms")
+		assert.Contains(t, entry, "time")
+		assert.NotContains(t, entry, "bytes_in")
+		assert.NotContains(t, line, "secret plans")
+		assert.Equal(t, 1, strings.Count(line, "\n"), "one line per request")
+	})
+
+	t.Run("should add the request body size when enabled", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"prompt":"secret plans"}`))
+
+		line := serve(accessLogOptions{bodySize: true}, echo, req)
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		assert.Equal(t, float64(25), entry["bytes_in"])
+		assert.NotContains(t, line, "secret plans")
+	})
+
+	t.Run("should log by status at or above the configured level", func(t *testing.T) {
+		status := func(code int) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(code) }
+		}
+		options := accessLogOptions{level: slog.LevelWarn}
+
+		assert.Empty(t, serve(options, status(http.StatusOK), httptest.NewRequest("GET", "/health", nil)))
+		assert.Contains(t, serve(options, status(http.StatusNotFound), httptest.NewRequest("GET", "/nope", nil)), `"level":"WARN"`)
+		assert.Contains(t, serve(options, status(http.StatusBadGateway), httptest.NewRequest("GET", "/v1/models", nil)), `"level":"ERROR"`)
+	})
+}
+
+func TestCreateMux_AccessLog(t *testing.T) {
+	t.Run("should log requests to every route when enabled", func(t *testing.T) {
+		var out bytes.Buffer
+		mux := CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), &ProxyConfig{
+			TokenProvider:   &mockTokenProvider{token: "test-token"},
+			AccessLogFormat: AccessLogCommon,
+			AccessLog:       &out,
+		})
+
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", PricingPath, nil))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("OPTIONS", "/v1/chat/completions", nil))
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"GET /v1/models/pricing HTTP/1.1" 200 `)
+		assert.Contains(t, lines[1], `"OPTIONS /v1/chat/completions HTTP/1.1" 204 -`)
+	})
+}
+
+func TestParseAccessLogFormat(t *testing.T) {
+	for input, want := range map[string]AccessLogFormat{"": AccessLogNone, "none": AccessLogNone, "Common": AccessLogCommon, "combined": AccessLogCombined, "JSON": AccessLogJSON} {
+		format, err := ParseAccessLogFormat(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, format, input)
+	}
+
+	_, err := ParseAccessLogFormat("xml")
+	assert.Error(t, err)
+}
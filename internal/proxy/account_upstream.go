@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ml0-1337/claude-gate/internal/auth"
+)
+
+// AccountUpstream holds the upstream settings of one stored account, for accounts
+// served by another endpoint than the default, such as a regional one. Empty fields
+// keep the global settings.
+type AccountUpstream struct {
+	BaseURL          string
+	AnthropicVersion string   // Replaces the default anthropic-version; per-model overrides still apply
+	AllowedBetas     []string // Replaces the beta allowlist
+}
+
+// ParseAccountUpstreams builds account upstream settings from ACCOUNT=URL base URLs,
+// ACCOUNT=VERSION anthropic-versions and ACCOUNT=BETA+BETA beta allowlists, where
+// ACCOUNT=none allows no betas
+func ParseAccountUpstreams(baseURLs, versions, betas []string) (map[string]AccountUpstream, error) {
+	upstreams := make(map[string]AccountUpstream)
+	parse := func(kind string, specs []string, apply func(*AccountUpstream, string)) error {
+		for _, spec := range specs {
+			account, value, ok := strings.Cut(spec, "=")
+			account, value = strings.TrimSpace(account), strings.TrimSpace(value)
+			if !ok || account == "" || value == "" {
+				return fmt.Errorf("invalid account %s %q, expected ACCOUNT=%s", kind, spec, strings.ToUpper(kind))
+			}
+			if err := auth.ValidateAccount(account); err != nil {
+				return err
+			}
+			upstream := upstreams[account]
+			apply(&upstream, value)
+			upstreams[account] = upstream
+		}
+		return nil
+	}
+
+	if err := parse("url", baseURLs, func(u *AccountUpstream, value string) {
+		u.BaseURL = strings.TrimRight(value, "/")
+	}); err != nil {
+		return nil, err
+	}
+	if err := parse("version", versions, func(u *AccountUpstream, value string) {
+		u.AnthropicVersion = value
+	}); err != nil {
+		return nil, err
+	}
+	if err := parse("betas", betas, func(u *AccountUpstream, value string) {
+		u.AllowedBetas = []string{}
+		if strings.EqualFold(value, "none") {
+			return
+		}
+		for _, beta := range strings.Split(value, "+") {
+			if beta = strings.TrimSpace(beta); beta != "" {
+				u.AllowedBetas = append(u.AllowedBetas, beta)
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return upstreams, nil
+}
+
+// upstream returns the upstream settings of the served account, with the base URL
+// falling back to UpstreamURL
+func (c *ProxyConfig) upstream() AccountUpstream {
+	account := c.Account
+	if account == "" {
+		account = auth.DefaultAccount
+	}
+	upstream := c.AccountUpstreams[account]
+	if upstream.BaseURL == "" {
+		upstream.BaseURL = c.UpstreamURL
+	}
+	return upstream
+}
+
+// anthropicVersion returns the anthropic-version header for a resolved model of the
+// served account
+func (h *ProxyHandler) anthropicVersion(model string) string {
+	return h.config.Transformer.versionFor(model, h.config.upstream().AnthropicVersion)
+}
+
+// resolveBetas resolves the betas of a request with the served account's allowlist
+func (h *ProxyHandler) resolveBetas(body []byte) (allowed []string, disallowed []string, err error) {
+	return h.config.Transformer.resolveBetas(body, h.config.upstream().AllowedBetas)
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAccountUpstreams(t *testing.T) {
+	t.Run("should combine the settings of each account", func(t *testing.T) {
+		// Act
+		upstreams, err := ParseAccountUpstreams(
+			[]string{"eu=https://eu.anthropic.example/", "default=https://api.anthropic.com"},
+			[]string{"eu=2025-01-01"},
+			[]string{"eu=prompt-caching-2024-07-31+output-128k-2025-02-19", "default=none"},
+		)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, map[string]AccountUpstream{
+			"eu": {
+				BaseURL:          "https://eu.anthropic.example",
+				AnthropicVersion: "2025-01-01",
+				AllowedBetas:     []string{BetaPromptCaching, BetaOutput128k},
+			},
+			"default": {BaseURL: "https://api.anthropic.com", AllowedBetas: []string{}},
+		}, upstreams)
+	})
+
+	t.Run("should reject malformed specs and invalid accounts", func(t *testing.T) {
+		for _, spec := range []string{"eu", "=https://eu.anthropic.example", "eu=", "bad account=https://x"} {
+			_, err := ParseAccountUpstreams([]string{spec}, nil, nil)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestProxyHandler_AccountUpstreams(t *testing.T) {
+	type call struct {
+		upstream string
+		path     string
+		version  string
+		betas    string
+	}
+	var mu sync.Mutex
+	var calls []call
+	newUpstream := func(name string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			calls = append(calls, call{name, r.URL.Path, r.Header.Get("anthropic-version"), r.Header.Get("anthropic-beta")})
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/v1/models" {
+				w.Write([]byte(`{"data":[{"type":"model","id":"claude-` + name + `"}],"has_more":false}`))
+				return
+			}
+			w.Write([]byte(`{"id":"msg_1","type":"message","content":[]}`))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	global := newUpstream("global")
+	regional := newUpstream("regional")
+
+	upstreams := map[string]AccountUpstream{
+		"eu": {BaseURL: regional.URL, AnthropicVersion: "2025-01-01", AllowedBetas: []string{}},
+	}
+	serve := func(t *testing.T, account string) {
+		t.Helper()
+		config := &ProxyConfig{
+			UpstreamURL:      global.URL,
+			TokenProvider:    &mockTokenProvider{token: "test-token"},
+			Transformer:      NewRequestTransformer(),
+			Account:          account,
+			AccountUpstreams: upstreams,
+			ModelsCacheTTL:   time.Hour,
+		}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+
+		body := `{"model":"claude-sonnet-4-20250514","max_tokens":10,"messages":[{"role":"user","content":[{"type":"text","text":"Hi","cache_control":{"type":"ephemeral"}}]}]}`
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		fetchModels(t, mux)
+	}
+
+	t.Run("should send each account to its own endpoint with its own settings", func(t *testing.T) {
+		// Act
+		serve(t, "work")
+		serve(t, "eu")
+
+		// Assert
+		require.Len(t, calls, 4)
+		assert.Equal(t, call{"global", "/v1/messages", DefaultAnthropicVersion, oauthBeta + "," + BetaPromptCaching}, calls[0])
+		assert.Equal(t, call{"global", "/v1/models", DefaultAnthropicVersion, oauthBeta}, calls[1])
+		assert.Equal(t, call{"regional", "/v1/messages", "2025-01-01", oauthBeta}, calls[2], "the empty allowlist enables no betas")
+		assert.Equal(t, call{"regional", "/v1/models", "2025-01-01", oauthBeta}, calls[3])
+	})
+}
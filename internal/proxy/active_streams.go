@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/audit"
+)
+
+// ActiveStream describes a streaming request currently being proxied
+type ActiveStream struct {
+	ID        string
+	Model     string
+	ClientIP  string
+	Path      string
+	StartedAt time.Time
+}
+
+// streamRegistry tracks in-flight streams so operators can inspect them. Streams
+// are keyed by a token of the registry's own: request IDs may come from the
+// client's X-Request-Id and are not unique.
+type streamRegistry struct {
+	mu      sync.RWMutex
+	next    uint64
+	streams map[uint64]ActiveStream
+}
+
+// newStreamRegistry creates an empty stream registry
+func newStreamRegistry() *streamRegistry {
+	return &streamRegistry{
+		streams: make(map[uint64]ActiveStream),
+	}
+}
+
+// Register records a stream as active until Unregister is called with the
+// returned token
+func (r *streamRegistry) Register(stream ActiveStream) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	r.streams[r.next] = stream
+	return r.next
+}
+
+// Unregister removes a finished stream
+func (r *streamRegistry) Unregister(token uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.streams, token)
+}
+
+// List returns the active streams, oldest first
+func (r *streamRegistry) List() []ActiveStream {
+	r.mu.RLock()
+	streams := make([]ActiveStream, 0, len(r.streams))
+	for _, stream := range r.streams {
+		streams = append(streams, stream)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].StartedAt.Before(streams[j].StartedAt)
+	})
+	return streams
+}
+
+// StreamsHandler lists the active streams of a proxy handler
+type StreamsHandler struct {
+	registry *streamRegistry
+}
+
+// NewStreamsHandler creates a handler listing the proxy handler's active streams
+func NewStreamsHandler(proxyHandler *ProxyHandler) *StreamsHandler {
+	return &StreamsHandler{
+		registry: proxyHandler.activeStreams,
+	}
+}
+
+func (h *StreamsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	data := []interface{}{}
+	for _, stream := range h.registry.List() {
+		data = append(data, map[string]interface{}{
+			"id":              stream.ID,
+			"model":           stream.Model,
+			"client_ip":       stream.ClientIP,
+			"path":            stream.Path,
+			"started_at":      stream.StartedAt.UTC().Format(time.RFC3339),
+			"elapsed_seconds": now.Sub(stream.StartedAt).Seconds(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+// requireAdminKey only lets requests carrying the admin key through, either as
+// a Bearer token or in the X-Admin-Key header. Rejections are audited.
+func requireAdminKey(adminKey string, auditLog *audit.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Admin-Key")
+		if key == "" {
+			key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			auditLog.Record(audit.LocalKeyRejected,
+				slog.String("key", "admin"),
+				slog.String("key_fingerprint", audit.Fingerprint(key)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]interface{}{
+					"type":    "authentication_error",
+					"message": "A valid admin key is required",
+				},
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listStreams fetches /streams from the mux with the given admin key
+func listStreams(t *testing.T, mux http.Handler, adminKey string) (int, []map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", "/streams", nil)
+	if adminKey != "" {
+		req.Header.Set("Authorization", "Bearer "+adminKey)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		return w.Code, nil
+	}
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response.Data
+}
+
+func TestStreamsEndpoint(t *testing.T) {
+	t.Run("should list in-flight streams until they finish", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-release
+		}))
+		defer upstream.Close()
+
+		config := &ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			AdminKey:      "admin-secret",
+		}
+		handler := NewProxyHandler(config)
+		mux := CreateMux(handler, http.NotFoundHandler(), config)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			body := `{"model":"claude-3-5-haiku-latest","max_tokens":10,"stream":true,"messages":[{"role":"user","content":"Hi"}]}`
+			req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body))
+			req.RemoteAddr = "203.0.113.7:5555"
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		// Act
+		var streams []map[string]interface{}
+		require.Eventually(t, func() bool {
+			_, streams = listStreams(t, mux, "admin-secret")
+			return len(streams) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// Assert
+		stream := streams[0]
+		assert.NotEmpty(t, stream["id"])
+		assert.Equal(t, "claude-3-5-haiku-20241022", stream["model"])
+		assert.Equal(t, "203.0.113.7", stream["client_ip"])
+		assert.Equal(t, "/v1/messages", stream["path"])
+		assert.GreaterOrEqual(t, stream["elapsed_seconds"], 0.0)
+
+		close(release)
+		<-done
+		_, streams = listStreams(t, mux, "admin-secret")
+		assert.Empty(t, streams)
+	})
+
+	t.Run("should track streams sharing a client request ID separately", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-release
+		}))
+		defer upstream.Close()
+
+		config := &ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			AdminKey:      "admin-secret",
+		}
+		handler := NewProxyHandler(config)
+		mux := CreateMux(handler, http.NotFoundHandler(), config)
+
+		finished := make(chan struct{}, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer func() { finished <- struct{}{} }()
+				body := `{"model":"claude-3-5-haiku-latest","max_tokens":10,"stream":true,"messages":[{"role":"user","content":"Hi"}]}`
+				req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body))
+				req.Header.Set("X-Request-Id", "shared-id")
+				mux.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+		}
+
+		var streams []map[string]interface{}
+		require.Eventually(t, func() bool {
+			_, streams = listStreams(t, mux, "admin-secret")
+			return len(streams) == 2
+		}, 2*time.Second, 10*time.Millisecond)
+
+		// Act
+		release <- struct{}{}
+		<-finished
+
+		// Assert
+		_, streams = listStreams(t, mux, "admin-secret")
+		require.Len(t, streams, 1)
+		assert.Equal(t, "shared-id", streams[0]["id"])
+
+		release <- struct{}{}
+		<-finished
+		_, streams = listStreams(t, mux, "admin-secret")
+		assert.Empty(t, streams)
+	})
+
+	t.Run("should require the admin key", func(t *testing.T) {
+		config := &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}, AdminKey: "admin-secret"}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+
+		missing, _ := listStreams(t, mux, "")
+		wrong, _ := listStreams(t, mux, "guess")
+		correct, _ := listStreams(t, mux, "admin-secret")
+
+		assert.Equal(t, http.StatusUnauthorized, missing)
+		assert.Equal(t, http.StatusUnauthorized, wrong)
+		assert.Equal(t, http.StatusOK, correct)
+	})
+
+	t.Run("should audit rejected admin keys by fingerprint", func(t *testing.T) {
+		var out bytes.Buffer
+		config := &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}, AdminKey: "admin-secret", Audit: audit.New(&out)}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+
+		listStreams(t, mux, "guess-secret")
+		listStreams(t, mux, "admin-secret")
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], `"event":"local_key_rejected"`)
+		assert.Contains(t, lines[0], `"path":"/streams"`)
+		assert.Contains(t, lines[0], audit.Fingerprint("guess-secret"))
+		assert.NotContains(t, lines[0], "guess-secret")
+		assert.NotContains(t, lines[0], "admin-secret")
+	})
+
+	t.Run("should not expose the endpoint without an admin key", func(t *testing.T) {
+		config := &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+
+		req := httptest.NewRequest("GET", "/streams", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		// The request falls through to the root handler
+		assert.NotContains(t, w.Body.String(), `"data"`)
+	})
+}
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultAnthropicVersion is the anthropic-version header sent when no override applies
+const DefaultAnthropicVersion = "2023-06-01"
+
+// SetAnthropicVersions sets the default anthropic-version and per-model overrides. An
+// override key matches a model exactly or as a prefix, the longest match winning, so
+// "claude-opus-4" covers every dated Opus 4 release.
+func (t *RequestTransformer) SetAnthropicVersions(defaultVersion string, perModel map[string]string) {
+	t.anthropicVersion = defaultVersion
+	t.modelVersions = perModel
+}
+
+// AnthropicVersion returns the anthropic-version header value for a resolved model
+func (t *RequestTransformer) AnthropicVersion(model string) string {
+	return t.versionFor(model, "")
+}
+
+// versionFor returns the anthropic-version for a resolved model, with
+// defaultVersion, when set, in place of the configured default
+func (t *RequestTransformer) versionFor(model, defaultVersion string) string {
+	version, matched := "", 0
+	for prefix, v := range t.modelVersions {
+		if strings.HasPrefix(model, prefix) && len(prefix) > matched {
+			version, matched = v, len(prefix)
+		}
+	}
+	if version != "" {
+		return version
+	}
+	if defaultVersion != "" {
+		return defaultVersion
+	}
+	if t.anthropicVersion != "" {
+		return t.anthropicVersion
+	}
+	return DefaultAnthropicVersion
+}
+
+// ParseModelVersions parses MODEL=VERSION pairs into per-model anthropic-version overrides
+func ParseModelVersions(pairs []string) (map[string]string, error) {
+	versions := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		model, version, ok := strings.Cut(pair, "=")
+		model, version = strings.TrimSpace(model), strings.TrimSpace(version)
+		if !ok || model == "" || version == "" {
+			return nil, fmt.Errorf("invalid model anthropic-version %q, expected MODEL=VERSION", pair)
+		}
+		versions[model] = version
+	}
+	return versions, nil
+}
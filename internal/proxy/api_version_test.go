@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTransformer_AnthropicVersion(t *testing.T) {
+	t.Run("should use the built-in default without configuration", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+
+		assert.Equal(t, DefaultAnthropicVersion, transformer.AnthropicVersion("claude-sonnet-4-20250514"))
+	})
+
+	t.Run("should select the version by model", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+		transformer.SetAnthropicVersions("2023-06-01", map[string]string{
+			"claude-opus-4":          "2025-05-01",
+			"claude-opus-4-20250514": "2025-06-01",
+			"claude-sonnet-4":        "2025-04-01",
+		})
+
+		// Act & Assert
+		assert.Equal(t, "2025-06-01", transformer.AnthropicVersion("claude-opus-4-20250514"), "exact match")
+		assert.Equal(t, "2025-05-01", transformer.AnthropicVersion("claude-opus-4-1-20250805"), "prefix match")
+		assert.Equal(t, "2025-04-01", transformer.AnthropicVersion("claude-sonnet-4-20250514"))
+		assert.Equal(t, "2023-06-01", transformer.AnthropicVersion("claude-3-5-haiku-20241022"), "global default")
+	})
+}
+
+func TestParseModelVersions(t *testing.T) {
+	t.Run("should parse model=version pairs", func(t *testing.T) {
+		versions, err := ParseModelVersions([]string{"claude-opus-4=2025-05-01", " claude-sonnet-4 = 2025-04-01 "})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"claude-opus-4": "2025-05-01", "claude-sonnet-4": "2025-04-01"}, versions)
+	})
+
+	t.Run("should reject malformed pairs", func(t *testing.T) {
+		for _, pair := range []string{"claude-opus-4", "=2025-05-01", "claude-opus-4="} {
+			_, err := ParseModelVersions([]string{pair})
+			assert.Error(t, err, pair)
+		}
+	})
+}
+
+func TestProxyHandler_AnthropicVersion(t *testing.T) {
+	// Arrange
+	var versions []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions = append(versions, r.Header.Get("anthropic-version"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","content":[]}`))
+	}))
+	defer upstream.Close()
+
+	transformer := NewRequestTransformer()
+	transformer.SetAnthropicVersions("", map[string]string{"claude-opus-4": "2025-05-01"})
+	handler := NewProxyHandler(&ProxyConfig{
+		UpstreamURL:   upstream.URL,
+		TokenProvider: &mockTokenProvider{token: "test-token"},
+		Transformer:   transformer,
+	})
+
+	// Act
+	for _, model := range []string{"claude-opus-4-20250514", "claude-3-5-sonnet-latest"} {
+		body := `{"model":"` + model + `","max_tokens":10,"messages":[{"role":"user","content":"Hi"}]}`
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+	}
+
+	// Assert
+	assert.Equal(t, []string{"2025-05-01", DefaultAnthropicVersion}, versions)
+}
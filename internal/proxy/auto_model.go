@@ -0,0 +1,103 @@
+package proxy
+
+import "fmt"
+
+// AutoModel is the virtual model name that picks a real model by prompt size
+const AutoModel = "claude-auto"
+
+// Default models and token thresholds for AutoModel routing
+const (
+	DefaultAutoSmallModel  = "claude-3-5-haiku-20241022"
+	DefaultAutoMediumModel = "claude-sonnet-4-20250514"
+	DefaultAutoLargeModel  = "claude-opus-4-20250514"
+
+	DefaultAutoMediumThreshold = 2000
+	DefaultAutoLargeThreshold  = 20000
+)
+
+// charsPerToken is the rough character-to-token ratio used for estimates
+const charsPerToken = 4
+
+// AutoModelRouter maps estimated input tokens to a model. Prompts below
+// MediumThreshold go to SmallModel, prompts below LargeThreshold go to
+// MediumModel, and anything larger goes to LargeModel.
+type AutoModelRouter struct {
+	SmallModel  string
+	MediumModel string
+	LargeModel  string
+
+	MediumThreshold int
+	LargeThreshold  int
+}
+
+// NewAutoModelRouter creates a router with the default models and thresholds
+func NewAutoModelRouter() *AutoModelRouter {
+	return &AutoModelRouter{
+		SmallModel:      DefaultAutoSmallModel,
+		MediumModel:     DefaultAutoMediumModel,
+		LargeModel:      DefaultAutoLargeModel,
+		MediumThreshold: DefaultAutoMediumThreshold,
+		LargeThreshold:  DefaultAutoLargeThreshold,
+	}
+}
+
+// SetThresholds sets the token counts at which routing moves up a model
+func (r *AutoModelRouter) SetThresholds(medium, large int) error {
+	if medium <= 0 || large <= 0 {
+		return fmt.Errorf("auto model thresholds must be positive, got %d and %d", medium, large)
+	}
+	if medium >= large {
+		return fmt.Errorf("auto model medium threshold (%d) must be below the large threshold (%d)", medium, large)
+	}
+	r.MediumThreshold = medium
+	r.LargeThreshold = large
+	return nil
+}
+
+// Route returns the model for a prompt of the given estimated size
+func (r *AutoModelRouter) Route(estimatedTokens int) string {
+	switch {
+	case estimatedTokens < r.MediumThreshold:
+		return r.SmallModel
+	case estimatedTokens < r.LargeThreshold:
+		return r.MediumModel
+	default:
+		return r.LargeModel
+	}
+}
+
+// EstimateInputTokens roughly estimates the input tokens of an Anthropic
+// request from the text in its system prompt, messages and tools
+func EstimateInputTokens(data map[string]interface{}) int {
+	chars := 0
+	for _, field := range []string{"system", "messages", "tools"} {
+		chars += countTextChars(data[field])
+	}
+	return (chars + charsPerToken - 1) / charsPerToken
+}
+
+// countTextChars sums the length of every string value in a decoded JSON value
+func countTextChars(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		return len(v)
+	case []interface{}:
+		total := 0
+		for _, item := range v {
+			total += countTextChars(item)
+		}
+		return total
+	case map[string]interface{}:
+		total := 0
+		for key, item := range v {
+			// Image data and block types say little about prompt length
+			if key == "type" || key == "source" || key == "cache_control" {
+				continue
+			}
+			total += countTextChars(item)
+		}
+		return total
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// autoRequest builds a claude-auto OpenAI request whose prompt is about the given number of tokens
+func autoRequest(tokens int, extra string) string {
+	prompt := strings.Repeat("x", tokens*charsPerToken)
+	return `{"model":"claude-auto",` + extra + `"messages":[{"role":"user","content":"` + prompt + `"}]}`
+}
+
+func TestAutoModelRouter_Route(t *testing.T) {
+	router := NewAutoModelRouter()
+
+	tests := []struct {
+		name   string
+		tokens int
+		want   string
+	}{
+		{"empty prompt", 0, DefaultAutoSmallModel},
+		{"just below medium threshold", DefaultAutoMediumThreshold - 1, DefaultAutoSmallModel},
+		{"at medium threshold", DefaultAutoMediumThreshold, DefaultAutoMediumModel},
+		{"just below large threshold", DefaultAutoLargeThreshold - 1, DefaultAutoMediumModel},
+		{"at large threshold", DefaultAutoLargeThreshold, DefaultAutoLargeModel},
+		{"far above large threshold", DefaultAutoLargeThreshold * 10, DefaultAutoLargeModel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, router.Route(tt.tokens))
+		})
+	}
+}
+
+func TestAutoModelRouter_SetThresholds(t *testing.T) {
+	t.Run("should route by custom thresholds", func(t *testing.T) {
+		router := NewAutoModelRouter()
+
+		require.NoError(t, router.SetThresholds(10, 100))
+
+		assert.Equal(t, DefaultAutoSmallModel, router.Route(9))
+		assert.Equal(t, DefaultAutoMediumModel, router.Route(10))
+		assert.Equal(t, DefaultAutoLargeModel, router.Route(100))
+	})
+
+	t.Run("should reject unordered or non-positive thresholds", func(t *testing.T) {
+		router := NewAutoModelRouter()
+
+		assert.Error(t, router.SetThresholds(100, 10))
+		assert.Error(t, router.SetThresholds(100, 100))
+		assert.Error(t, router.SetThresholds(0, 100))
+		assert.Equal(t, DefaultAutoMediumThreshold, router.MediumThreshold)
+	})
+}
+
+func TestEstimateInputTokens(t *testing.T) {
+	t.Run("should count text in system, messages and tools", func(t *testing.T) {
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"model": "claude-auto",
+			"system": "abcd",
+			"messages": [{"role":"user","content":[{"type":"text","text":"abcdefgh"}]}],
+			"tools": [{"name":"abcd"}]
+		}`), &data))
+
+		// "abcd" + "user" + "abcdefgh" + "abcd" = 20 characters
+		assert.Equal(t, 5, EstimateInputTokens(data))
+	})
+
+	t.Run("should ignore image data", func(t *testing.T) {
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"messages": [{"role":"user","content":[{"type":"image","source":{"type":"base64","data":"`+strings.Repeat("A", 4000)+`"}}]}]
+		}`), &data))
+
+		assert.Equal(t, 1, EstimateInputTokens(data))
+	})
+}
+
+func TestRequestTransformer_AutoModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens int
+		want   string
+	}{
+		{"short prompt goes to haiku", 100, DefaultAutoSmallModel},
+		{"medium prompt goes to sonnet", DefaultAutoMediumThreshold + 100, DefaultAutoMediumModel},
+		{"long prompt goes to opus", DefaultAutoLargeThreshold + 100, DefaultAutoLargeModel},
+	}
+
+	for _, tt := range tests {
+		t.Run("should route "+tt.name, func(t *testing.T) {
+			// Arrange
+			transformer := NewRequestTransformer()
+
+			// Act
+			result, err := transformer.TransformRequestBody([]byte(autoRequest(tt.tokens, "")), "/v1/chat/completions")
+
+			// Assert
+			require.NoError(t, err)
+			var data map[string]interface{}
+			require.NoError(t, json.Unmarshal(result, &data))
+			assert.Equal(t, tt.want, data["model"])
+		})
+	}
+
+	t.Run("should use a configured router", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+		router := NewAutoModelRouter()
+		require.NoError(t, router.SetThresholds(10, 50))
+		transformer.SetAutoModelRouter(router)
+
+		// Act
+		result, err := transformer.TransformRequestBody([]byte(autoRequest(100, "")), "/v1/chat/completions")
+
+		// Assert
+		require.NoError(t, err)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &data))
+		assert.Equal(t, DefaultAutoLargeModel, data["model"])
+	})
+
+	t.Run("should cap max_tokens to the routed model's limit", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+
+		// Act
+		result, err := transformer.TransformRequestBody([]byte(autoRequest(10, `"max_tokens":32000,`)), "/v1/chat/completions")
+
+		// Assert
+		require.NoError(t, err)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &data))
+		assert.Equal(t, DefaultAutoSmallModel, data["model"])
+		assert.Equal(t, float64(8192), data["max_tokens"])
+	})
+
+	t.Run("should leave other models alone", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		body := `{"model":"claude-3-opus-latest","max_tokens":100,"messages":[{"role":"user","content":"Hi"}]}`
+
+		result, err := transformer.TransformRequestBody([]byte(body), "/v1/messages")
+
+		require.NoError(t, err)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &data))
+		assert.Equal(t, "claude-3-opus-20240229", data["model"])
+	})
+}
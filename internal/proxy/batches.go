@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BatchesPath is the Anthropic Message Batches endpoint
+const BatchesPath = "/v1/messages/batches"
+
+// isBatchPath reports whether path belongs to the Message Batches API, including
+// retrieval, results, cancel and delete of a single batch
+func isBatchPath(path string) bool {
+	return path == BatchesPath || strings.HasPrefix(path, BatchesPath+"/")
+}
+
+// transformBatchRequest applies the messages transformations to the params of every
+// request in a batch create body, so each one carries the OAuth system prompt
+func (t *RequestTransformer) transformBatchRequest(body []byte) ([]byte, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body, nil // Let the upstream report malformed bodies
+	}
+
+	requests, ok := data["requests"].([]interface{})
+	if !ok {
+		return body, nil
+	}
+
+	for i, item := range requests {
+		request, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		params, ok := request["params"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		paramsJSON, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		transformed, err := t.transformRequestBody(paramsJSON, "/v1/messages", &TransformReport{})
+		if err != nil {
+			return nil, fmt.Errorf("batch request %d: %w", i, err)
+		}
+		request["params"] = json.RawMessage(transformed)
+	}
+
+	return json.Marshal(data)
+}
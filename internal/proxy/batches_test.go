@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyHandler_MessageBatches(t *testing.T) {
+	// upstreamRequest is what the fake upstream saw
+	type upstreamRequest struct {
+		method string
+		path   string
+		header http.Header
+		body   []byte
+	}
+
+	newBatchHandler := func(t *testing.T, respond func(w http.ResponseWriter)) (*ProxyHandler, *[]upstreamRequest) {
+		var received []upstreamRequest
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received = append(received, upstreamRequest{r.Method, r.URL.Path, r.Header.Clone(), body})
+			respond(w)
+		}))
+		t.Cleanup(upstream.Close)
+
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		}), &received
+	}
+
+	t.Run("should forward batch creation with auth and transformed params", func(t *testing.T) {
+		// Arrange
+		handler, received := newBatchHandler(t, func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msgbatch_1","type":"message_batch","processing_status":"in_progress"}`))
+		})
+		body := `{"requests":[
+			{"custom_id":"first","params":{"model":"claude-3-5-sonnet-latest","max_tokens":10,"messages":[{"role":"user","content":"Hi"}]}},
+			{"custom_id":"second","params":{"model":"claude-3-5-haiku-20241022","max_tokens":10,"system":"Be brief.","messages":[{"role":"user","content":"Hello"}]}}
+		]}`
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages/batches", strings.NewReader(body)))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "msgbatch_1")
+		require.Len(t, *received, 1)
+		upstreamReq := (*received)[0]
+		assert.Equal(t, "POST", upstreamReq.method)
+		assert.Equal(t, "/v1/messages/batches", upstreamReq.path)
+		assert.Equal(t, "Bearer test-token", upstreamReq.header.Get("Authorization"))
+		assert.Contains(t, upstreamReq.header.Get("anthropic-beta"), oauthBeta)
+
+		var forwarded struct {
+			Requests []struct {
+				CustomID string `json:"custom_id"`
+				Params   struct {
+					Model  string          `json:"model"`
+					System json.RawMessage `json:"system"`
+				} `json:"params"`
+			} `json:"requests"`
+		}
+		require.NoError(t, json.Unmarshal(upstreamReq.body, &forwarded))
+		require.Len(t, forwarded.Requests, 2)
+		assert.Equal(t, "first", forwarded.Requests[0].CustomID)
+		assert.Equal(t, "claude-3-5-sonnet-20241022", forwarded.Requests[0].Params.Model, "aliases are mapped")
+		assert.JSONEq(t, `"`+ClaudeCodePrompt+`"`, string(forwarded.Requests[0].Params.System))
+		assert.JSONEq(t, `[{"type":"text","text":"`+ClaudeCodePrompt+`"},{"type":"text","text":"Be brief."}]`,
+			string(forwarded.Requests[1].Params.System))
+	})
+
+	t.Run("should pass batch results through unchanged", func(t *testing.T) {
+		// Arrange
+		results := `{"custom_id":"first","result":{"type":"succeeded","message":{"id":"msg_1","content":[{"type":"text","text":"Hi"}]}}}` + "\n" +
+			`{"custom_id":"second","result":{"type":"errored","error":{"type":"invalid_request_error","message":"bad"}}}` + "\n"
+		handler, received := newBatchHandler(t, func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "application/x-jsonl")
+			w.Write([]byte(results))
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/messages/batches/msgbatch_1/results", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, results, w.Body.String())
+		assert.Equal(t, "application/x-jsonl", w.Header().Get("Content-Type"))
+		require.Len(t, *received, 1)
+		assert.Equal(t, "/v1/messages/batches/msgbatch_1/results", (*received)[0].path)
+		assert.Equal(t, "Bearer test-token", (*received)[0].header.Get("Authorization"))
+	})
+
+	t.Run("should forward batch retrieval without passthrough enabled", func(t *testing.T) {
+		handler, received := newBatchHandler(t, func(w http.ResponseWriter) {
+			w.Write([]byte(`{"id":"msgbatch_1","type":"message_batch"}`))
+		})
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/messages/batches/msgbatch_1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, *received, 1)
+	})
+}
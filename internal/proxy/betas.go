@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Beta features the proxy can enable automatically based on request content
+const (
+	BetaPromptCaching       = "prompt-caching-2024-07-31"
+	BetaInterleavedThinking = "interleaved-thinking-2025-05-14"
+	BetaComputerUse         = "computer-use-2025-01-24"
+	BetaOutput128k          = "output-128k-2025-02-19"
+)
+
+// oauthBeta is always sent; OAuth tokens are rejected without it
+const oauthBeta = "oauth-2025-04-20"
+
+// BetaOverrideHeader lets clients append anthropic-beta values per request when allowed
+const BetaOverrideHeader = "X-Claude-Gate-Beta"
+
+// DefaultAllowedBetas lists the betas auto-enabled when no allowlist is configured
+var DefaultAllowedBetas = []string{
+	BetaPromptCaching,
+	BetaInterleavedThinking,
+	BetaComputerUse,
+	BetaOutput128k,
+}
+
+// BetaNotAllowedError reports betas a request needs that the allowlist forbids
+type BetaNotAllowedError struct {
+	Betas []string
+}
+
+func (e *BetaNotAllowedError) Error() string {
+	return "request requires beta features not enabled on this proxy: " + strings.Join(e.Betas, ", ")
+}
+
+// SetAllowedBetas sets which betas may be auto-enabled and whether requests needing
+// other betas are rejected (otherwise they are sent without them)
+func (t *RequestTransformer) SetAllowedBetas(betas []string, rejectDisallowed bool) {
+	t.allowedBetas = make(map[string]bool, len(betas))
+	for _, beta := range betas {
+		t.allowedBetas[beta] = true
+	}
+	t.rejectDisallowedBetas = rejectDisallowed
+}
+
+// SetAllowBetaHeader toggles merging the client's anthropic-beta header and the
+// BetaOverrideHeader into the upstream betas. It is off by default because it lets any
+// client enable arbitrary betas on the proxy's account.
+func (t *RequestTransformer) SetAllowBetaHeader(allow bool) {
+	t.allowBetaHeader = allow
+}
+
+// ResolveBetas returns the allowed betas an Anthropic request body needs. Betas outside
+// the allowlist are returned separately, along with a BetaNotAllowedError in reject mode.
+func (t *RequestTransformer) ResolveBetas(body []byte) (allowed []string, disallowed []string, err error) {
+	return t.resolveBetas(body, nil)
+}
+
+// resolveBetas resolves betas as ResolveBetas, with allowlist, when set, in place of
+// the configured one
+func (t *RequestTransformer) resolveBetas(body []byte, allowlist []string) (allowed []string, disallowed []string, err error) {
+	allowedBetas := t.allowedBetas
+	if allowlist != nil {
+		allowedBetas = make(map[string]bool, len(allowlist))
+		for _, beta := range allowlist {
+			allowedBetas[beta] = true
+		}
+	}
+	if allowedBetas == nil {
+		allowedBetas = make(map[string]bool, len(DefaultAllowedBetas))
+		for _, beta := range DefaultAllowedBetas {
+			allowedBetas[beta] = true
+		}
+	}
+
+	for _, beta := range DetectBetas(body) {
+		if allowedBetas[beta] {
+			allowed = append(allowed, beta)
+		} else {
+			disallowed = append(disallowed, beta)
+		}
+	}
+
+	if len(disallowed) > 0 && t.rejectDisallowedBetas {
+		return allowed, disallowed, &BetaNotAllowedError{Betas: disallowed}
+	}
+	return allowed, disallowed, nil
+}
+
+// DetectBetas returns the beta features an Anthropic request body relies on, sorted
+func DetectBetas(body []byte) []string {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil
+	}
+
+	betas := make(map[string]bool)
+
+	if containsKey(request, "cache_control") {
+		betas[BetaPromptCaching] = true
+	}
+
+	tools, _ := request["tools"].([]interface{})
+	for _, tool := range tools {
+		if toolMap, ok := tool.(map[string]interface{}); ok {
+			if toolType, _ := toolMap["type"].(string); strings.HasPrefix(toolType, "computer_") {
+				betas[BetaComputerUse] = true
+			}
+		}
+	}
+
+	if thinking, ok := request["thinking"].(map[string]interface{}); ok && thinking["type"] == "enabled" && len(tools) > 0 {
+		betas[BetaInterleavedThinking] = true
+	}
+
+	model, _ := request["model"].(string)
+	if maxTokens, ok := request["max_tokens"].(float64); ok && maxTokens > 64000 && strings.HasPrefix(model, "claude-3-7-sonnet") {
+		betas[BetaOutput128k] = true
+	}
+
+	result := make([]string, 0, len(betas))
+	for beta := range betas {
+		result = append(result, beta)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// requestBetas returns the anthropic-beta values added on top of the OAuth beta
+func requestBetas(headers http.Header) []string {
+	var betas []string
+	for _, value := range strings.Split(headers.Get("anthropic-beta"), ",") {
+		if value = strings.TrimSpace(value); value != "" && value != oauthBeta {
+			betas = append(betas, value)
+		}
+	}
+	return betas
+}
+
+// addBetaHeader appends betas to the anthropic-beta header, skipping ones already present
+func addBetaHeader(headers http.Header, betas ...string) {
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range strings.Split(headers.Get("anthropic-beta"), ",") {
+		if value = strings.TrimSpace(value); value != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	for _, beta := range betas {
+		if beta = strings.TrimSpace(beta); beta != "" && !seen[beta] {
+			seen[beta] = true
+			values = append(values, beta)
+		}
+	}
+	if len(values) > 0 {
+		headers.Set("anthropic-beta", strings.Join(values, ","))
+	}
+}
+
+// containsKey reports whether key appears anywhere in a decoded JSON value
+func containsKey(value interface{}, key string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == key || containsKey(child, key) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if containsKey(child, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
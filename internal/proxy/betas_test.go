@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cachingRequest = `{"model":"claude-sonnet-4-20250514","max_tokens":100,"system":[{"type":"text","text":"Long prompt","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":"Hi"}]}`
+
+func TestDetectBetas(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"plain request", `{"model":"claude-sonnet-4-20250514","max_tokens":100,"messages":[{"role":"user","content":"Hi"}]}`, []string{}},
+		{"cache_control", cachingRequest, []string{BetaPromptCaching}},
+		{"thinking with tools", `{"thinking":{"type":"enabled","budget_tokens":1024},"tools":[{"name":"lookup"}]}`, []string{BetaInterleavedThinking}},
+		{"thinking without tools", `{"thinking":{"type":"enabled","budget_tokens":1024}}`, []string{}},
+		{"computer use tool", `{"tools":[{"type":"computer_20250124","name":"computer"}]}`, []string{BetaComputerUse}},
+		{"long output on 3.7 sonnet", `{"model":"claude-3-7-sonnet-20250219","max_tokens":100000}`, []string{BetaOutput128k}},
+		{"invalid JSON", `not json`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectBetas([]byte(tt.body)))
+		})
+	}
+}
+
+func TestRequestTransformer_ResolveBetas(t *testing.T) {
+	t.Run("should allow default betas when no allowlist is set", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+
+		allowed, disallowed, err := transformer.ResolveBetas([]byte(cachingRequest))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{BetaPromptCaching}, allowed)
+		assert.Empty(t, disallowed)
+	})
+
+	t.Run("should silently drop betas outside the allowlist", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetAllowedBetas([]string{BetaInterleavedThinking}, false)
+
+		allowed, disallowed, err := transformer.ResolveBetas([]byte(cachingRequest))
+
+		require.NoError(t, err)
+		assert.Empty(t, allowed)
+		assert.Equal(t, []string{BetaPromptCaching}, disallowed)
+	})
+
+	t.Run("should reject betas outside the allowlist in reject mode", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetAllowedBetas(nil, true)
+
+		_, _, err := transformer.ResolveBetas([]byte(cachingRequest))
+
+		var betaErr *BetaNotAllowedError
+		require.ErrorAs(t, err, &betaErr)
+		assert.Equal(t, []string{BetaPromptCaching}, betaErr.Betas)
+	})
+}
+
+func TestProxyHandler_Betas(t *testing.T) {
+	newHandler := func(upstreamURL string, transformer *RequestTransformer) *ProxyHandler {
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstreamURL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   transformer,
+		})
+	}
+
+	t.Run("should send allowed betas upstream", func(t *testing.T) {
+		var betaHeader string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betaHeader = r.Header.Get("anthropic-beta")
+			w.Write([]byte(`{}`))
+		}))
+		defer upstream.Close()
+
+		req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(cachingRequest))
+		w := httptest.NewRecorder()
+		newHandler(upstream.URL, NewRequestTransformer()).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "oauth-2025-04-20,"+BetaPromptCaching, betaHeader)
+	})
+
+	t.Run("should forward without disallowed betas by default", func(t *testing.T) {
+		var betaHeader string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betaHeader = r.Header.Get("anthropic-beta")
+			w.Write([]byte(`{}`))
+		}))
+		defer upstream.Close()
+
+		transformer := NewRequestTransformer()
+		transformer.SetAllowedBetas([]string{BetaComputerUse}, false)
+
+		req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(cachingRequest))
+		w := httptest.NewRecorder()
+		newHandler(upstream.URL, transformer).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "oauth-2025-04-20", betaHeader)
+	})
+
+	t.Run("should reject disallowed betas before calling upstream", func(t *testing.T) {
+		called := false
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer upstream.Close()
+
+		transformer := NewRequestTransformer()
+		transformer.SetAllowedBetas([]string{BetaComputerUse}, true)
+
+		req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(cachingRequest))
+		w := httptest.NewRecorder()
+		newHandler(upstream.URL, transformer).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), BetaPromptCaching)
+		assert.False(t, called)
+	})
+
+	t.Run("should merge configured betas without duplicates", func(t *testing.T) {
+		// Arrange
+		var betaHeader string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betaHeader = r.Header.Get("anthropic-beta")
+			w.Write([]byte(`{}`))
+		}))
+		defer upstream.Close()
+		transformer := NewRequestTransformer()
+		transformer.SetAllowBetaHeader(true)
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   transformer,
+			Betas:         []string{"files-api-2025-04-14", BetaPromptCaching},
+		})
+		req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(cachingRequest))
+		req.Header.Set("anthropic-beta", "mcp-client-2025-04-04,files-api-2025-04-14")
+
+		// Act
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		// Assert
+		assert.Equal(t, "oauth-2025-04-20,mcp-client-2025-04-04,files-api-2025-04-14,"+BetaPromptCaching, betaHeader)
+	})
+
+	t.Run("should send configured betas with the model list fetch", func(t *testing.T) {
+		var betaHeader string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betaHeader = r.Header.Get("anthropic-beta")
+			w.Write([]byte(`{"data":[]}`))
+		}))
+		defer upstream.Close()
+		models := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, upstream.URL, time.Minute)
+		models.SetBetas([]string{"files-api-2025-04-14"})
+
+		models.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/models", nil))
+
+		assert.Equal(t, "oauth-2025-04-20,files-api-2025-04-14", betaHeader)
+	})
+}
@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"net/http"
+)
+
+// ChatCompletionsPath is the OpenAI chat completions endpoint
+const ChatCompletionsPath = "/v1/chat/completions"
+
+// ChatCompletionsHandler serves OpenAI chat completions. Requests go through the
+// proxy's pipeline, which translates the OpenAI body into an Anthropic messages request
+// (system messages become the top-level system field; max_tokens, temperature, top_p
+// and stop are mapped), sends it upstream with the OAuth token and converts the reply
+// into a chat.completion object or chunk stream. CORS is handled by the mux middleware.
+type ChatCompletionsHandler struct {
+	proxy *ProxyHandler
+}
+
+// NewChatCompletionsHandler creates a chat completions handler on top of proxy
+func NewChatCompletionsHandler(proxy *ProxyHandler) *ChatCompletionsHandler {
+	return &ChatCompletionsHandler{proxy: proxy}
+}
+
+func (h *ChatCompletionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		h.proxy.writeError(w, http.StatusMethodNotAllowed, "invalid_request_error",
+			"Method "+r.Method+" is not allowed on "+ChatCompletionsPath+"; use POST")
+		return
+	}
+	h.proxy.ServeHTTP(w, r)
+}
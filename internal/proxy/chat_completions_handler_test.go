@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatCompletionsHandler(t *testing.T) {
+	newMux := func(t *testing.T, upstream http.HandlerFunc) http.Handler {
+		server := httptest.NewServer(upstream)
+		t.Cleanup(server.Close)
+		config := &ProxyConfig{
+			UpstreamURL:   server.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		}
+		return CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+	}
+
+	t.Run("should translate a chat completion to a messages request and back", func(t *testing.T) {
+		// Arrange
+		var upstreamPath, authorization string
+		var upstreamRequest map[string]interface{}
+		mux := newMux(t, func(w http.ResponseWriter, r *http.Request) {
+			upstreamPath = r.URL.Path
+			authorization = r.Header.Get("Authorization")
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &upstreamRequest)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Bonjour"}],"stop_reason":"end_turn","usage":{"input_tokens":12,"output_tokens":3}}`))
+		})
+		body := `{"model":"claude-3-5-sonnet-20241022","max_tokens":50,"temperature":0.3,"top_p":0.9,"stop":"END",
+			"messages":[{"role":"system","content":"Answer in French"},{"role":"user","content":"Hello"},{"role":"assistant","content":"Salut"},{"role":"user","content":"Again"}]}`
+		w := httptest.NewRecorder()
+
+		// Act
+		mux.ServeHTTP(w, httptest.NewRequest("POST", ChatCompletionsPath, strings.NewReader(body)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "/v1/messages", upstreamPath)
+		assert.Equal(t, "Bearer test-token", authorization)
+		assert.Equal(t, float64(50), upstreamRequest["max_tokens"])
+		assert.Equal(t, 0.3, upstreamRequest["temperature"])
+		assert.Equal(t, 0.9, upstreamRequest["top_p"])
+		assert.Equal(t, []interface{}{"END"}, upstreamRequest["stop_sequences"])
+
+		system, _ := json.Marshal(upstreamRequest["system"])
+		assert.Contains(t, string(system), "Answer in French")
+		messages := upstreamRequest["messages"].([]interface{})
+		require.Len(t, messages, 3)
+		for _, message := range messages {
+			assert.NotEqual(t, "system", message.(map[string]interface{})["role"])
+		}
+
+		var completion struct {
+			Object  string `json:"object"`
+			Choices []struct {
+				Message struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"message"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &completion))
+		assert.Equal(t, "chat.completion", completion.Object)
+		require.Len(t, completion.Choices, 1)
+		assert.Equal(t, "assistant", completion.Choices[0].Message.Role)
+		assert.Equal(t, "Bonjour", completion.Choices[0].Message.Content)
+		assert.Equal(t, "stop", completion.Choices[0].FinishReason)
+	})
+
+	t.Run("should reject methods other than POST", func(t *testing.T) {
+		called := false
+		mux := newMux(t, func(w http.ResponseWriter, r *http.Request) { called = true })
+		w := httptest.NewRecorder()
+
+		mux.ServeHTTP(w, httptest.NewRequest("GET", ChatCompletionsPath, nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+		assert.False(t, called)
+	})
+
+	t.Run("should answer CORS preflight", func(t *testing.T) {
+		mux := newMux(t, func(w http.ResponseWriter, r *http.Request) {})
+		req := httptest.NewRequest("OPTIONS", ChatCompletionsPath, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CompletionsPath is the legacy OpenAI text completions endpoint
+const CompletionsPath = "/v1/completions"
+
+// CompletionsHandler serves legacy OpenAI text completions for older tooling. The
+// prompt becomes a single user message of a chat completion, which goes through the
+// proxy's pipeline like any other; the reply is returned as a text_completion object,
+// or as legacy completion chunks when the request streams.
+type CompletionsHandler struct {
+	proxy *ProxyHandler
+}
+
+// NewCompletionsHandler creates a legacy completions handler on top of proxy
+func NewCompletionsHandler(proxy *ProxyHandler) *CompletionsHandler {
+	return &CompletionsHandler{proxy: proxy}
+}
+
+func (h *CompletionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		h.proxy.writeError(w, http.StatusMethodNotAllowed, "invalid_request_error",
+			"Method "+r.Method+" is not allowed on "+CompletionsPath+"; use POST")
+		return
+	}
+	h.proxy.ServeHTTP(w, r)
+}
+
+// completionToChatCompletion rewrites a legacy completion request as a chat completion
+// with the prompt as its only user message. Other parameters are kept, so the ones
+// without an Anthropic equivalent (echo, suffix, best_of) are dropped and reported
+// like any other unsupported chat completion parameter.
+func completionToChatCompletion(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	var prompt string
+	switch p := request["prompt"].(type) {
+	case string:
+		prompt = p
+	case []interface{}:
+		if len(p) != 1 {
+			return nil, fmt.Errorf("prompt must be a single string; batched prompts are not supported")
+		}
+		s, ok := p[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("prompt must be a string; token arrays are not supported")
+		}
+		prompt = s
+	case nil:
+		return nil, fmt.Errorf("prompt is required")
+	default:
+		return nil, fmt.Errorf("prompt must be a string")
+	}
+
+	delete(request, "prompt")
+	request["messages"] = []interface{}{
+		map[string]interface{}{"role": "user", "content": prompt},
+	}
+	return json.Marshal(request)
+}
+
+// chatCompletionToCompletion reshapes a chat.completion into a text_completion, with
+// the message content as the choice text. Error bodies are returned unchanged.
+func chatCompletionToCompletion(body []byte) ([]byte, error) {
+	var chat map[string]interface{}
+	if err := json.Unmarshal(body, &chat); err != nil {
+		return nil, err
+	}
+	if _, isError := chat["error"]; isError {
+		return body, nil
+	}
+	return json.Marshal(completionObject(chat, "text_completion", "message"))
+}
+
+// completionObject copies a chat completion object or chunk into its legacy form.
+// Choices carry the content of their message or delta field as text; other fields,
+// such as usage and warnings, are kept.
+func completionObject(chat map[string]interface{}, object, contentField string) map[string]interface{} {
+	completion := make(map[string]interface{}, len(chat))
+	for key, value := range chat {
+		completion[key] = value
+	}
+	if id, _ := chat["id"].(string); strings.HasPrefix(id, "chatcmpl-") {
+		completion["id"] = "cmpl-" + strings.TrimPrefix(id, "chatcmpl-")
+	}
+	completion["object"] = object
+
+	chatChoices, _ := chat["choices"].([]interface{})
+	choices := make([]interface{}, 0, len(chatChoices))
+	for _, c := range chatChoices {
+		choice, _ := c.(map[string]interface{})
+		message, _ := choice[contentField].(map[string]interface{})
+		text, _ := message["content"].(string)
+		choices = append(choices, map[string]interface{}{
+			"text":          text,
+			"index":         choice["index"],
+			"logprobs":      nil,
+			"finish_reason": choice["finish_reason"],
+		})
+	}
+	completion["choices"] = choices
+	return completion
+}
+
+// completionChunk converts the data of one chat completion chunk into a legacy
+// completion chunk. Chunks without text or a finish reason, such as the opening role
+// chunk, are skipped; data that is not a chunk, such as an error, passes unchanged.
+func completionChunk(data []byte) ([]byte, bool) {
+	var chunk map[string]interface{}
+	if err := json.Unmarshal(data, &chunk); err != nil || chunk["object"] != "chat.completion.chunk" {
+		return data, true
+	}
+
+	completion := completionObject(chunk, "text_completion", "delta")
+	choices := completion["choices"].([]interface{})
+	if len(choices) > 0 {
+		empty := true
+		for _, c := range choices {
+			choice := c.(map[string]interface{})
+			if choice["text"] != "" || choice["finish_reason"] != nil {
+				empty = false
+			}
+		}
+		if _, hasUsage := chunk["usage"]; empty && !hasUsage {
+			return nil, false
+		}
+	}
+
+	converted, err := json.Marshal(completion)
+	if err != nil {
+		return data, true
+	}
+	return converted, true
+}
+
+// completionStreamWriter rewrites the chat completion chunks written by the OpenAI
+// stream conversion into legacy completion chunks. Each write holds whole SSE events.
+type completionStreamWriter struct {
+	http.ResponseWriter
+}
+
+func (w completionStreamWriter) Write(p []byte) (int, error) {
+	if !bytes.HasPrefix(p, []byte("data: ")) {
+		return w.ResponseWriter.Write(p)
+	}
+
+	var out bytes.Buffer
+	for _, event := range bytes.SplitAfter(p, []byte("\n\n")) {
+		data, ok := bytes.CutPrefix(event, []byte("data: "))
+		if !ok {
+			out.Write(event)
+			continue
+		}
+		data = bytes.TrimSuffix(data, []byte("\n\n"))
+		if converted, keep := completionChunk(data); keep {
+			fmt.Fprintf(&out, "data: %s\n\n", converted)
+		}
+	}
+	if out.Len() > 0 {
+		if _, err := w.ResponseWriter.Write(out.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush flushes the underlying writer
+func (w completionStreamWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/requestid"
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionToChatCompletion(t *testing.T) {
+	t.Run("should wrap the prompt in a user message and keep other parameters", func(t *testing.T) {
+		// Act
+		chat, err := completionToChatCompletion([]byte(`{"model":"claude-3-5-haiku-20241022","prompt":"Once upon a time","max_tokens":50,"temperature":0.2,"stream":true}`))
+
+		// Assert
+		require.NoError(t, err)
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(chat, &request))
+		assert.Equal(t, []interface{}{map[string]interface{}{"role": "user", "content": "Once upon a time"}}, request["messages"])
+		assert.NotContains(t, request, "prompt")
+		assert.Equal(t, "claude-3-5-haiku-20241022", request["model"])
+		assert.Equal(t, float64(50), request["max_tokens"])
+		assert.Equal(t, 0.2, request["temperature"])
+		assert.Equal(t, true, request["stream"])
+	})
+
+	t.Run("should accept a prompt array with one string", func(t *testing.T) {
+		chat, err := completionToChatCompletion([]byte(`{"prompt":["Hello"]}`))
+
+		require.NoError(t, err)
+		assert.Contains(t, string(chat), `"content":"Hello"`)
+	})
+
+	t.Run("should reject prompts it cannot translate", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			reason string
+		}{
+			{"missing prompt", `{"model":"m"}`, "prompt is required"},
+			{"batched prompts", `{"prompt":["a","b"]}`, "batched prompts are not supported"},
+			{"token array", `{"prompt":[[1,2,3]]}`, "token arrays are not supported"},
+			{"not a string", `{"prompt":42}`, "prompt must be a string"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := completionToChatCompletion([]byte(tt.body))
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.reason)
+			})
+		}
+	})
+}
+
+func TestCompletionsHandler(t *testing.T) {
+	newHandler := func(upstreamURL string) http.Handler {
+		return NewCompletionsHandler(NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstreamURL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		}))
+	}
+
+	t.Run("should return a text_completion object", func(t *testing.T) {
+		// Arrange
+		var upstreamRequest map[string]interface{}
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &upstreamRequest)
+			assert.Equal(t, "/v1/messages", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-haiku-20241022","content":[{"type":"text","text":" there was a gopher."}],"stop_reason":"max_tokens","usage":{"input_tokens":5,"output_tokens":6}}`))
+		}))
+		defer upstream.Close()
+		req := httptest.NewRequest("POST", CompletionsPath, strings.NewReader(`{"model":"claude-3-5-haiku-20241022","prompt":"Once upon a time","max_tokens":6}`))
+		req = req.WithContext(requestid.WithContext(req.Context(), "abc123"))
+		w := httptest.NewRecorder()
+
+		// Act
+		newHandler(upstream.URL).ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		messages := upstreamRequest["messages"].([]interface{})
+		assert.Equal(t, map[string]interface{}{"role": "user", "content": "Once upon a time"}, messages[0])
+
+		var completion map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &completion))
+		assert.Equal(t, "cmpl-abc123", completion["id"])
+		assert.Equal(t, "text_completion", completion["object"])
+		assert.Equal(t, "claude-3-5-haiku-20241022", completion["model"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"text":          " there was a gopher.",
+			"index":         float64(0),
+			"logprobs":      nil,
+			"finish_reason": "length",
+		}}, completion["choices"])
+		usage := completion["usage"].(map[string]interface{})
+		assert.Equal(t, float64(5), usage["prompt_tokens"])
+		assert.Equal(t, float64(6), usage["completion_tokens"])
+	})
+
+	t.Run("should stream legacy completion chunks", func(t *testing.T) {
+		// Arrange
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Deltas: []string{"Hello", ", world"},
+		})
+		req := httptest.NewRequest("POST", CompletionsPath, strings.NewReader(`{"model":"claude-sonnet-4-20250514","prompt":"Say hello","stream":true}`))
+		req = req.WithContext(requestid.WithContext(req.Context(), "abc123"))
+		w := httptest.NewRecorder()
+
+		// Act
+		newHandler(upstream.URL).ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var text strings.Builder
+		var finishReason interface{}
+		var done bool
+		for _, line := range strings.Split(w.Body.String(), "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+			if data == "[DONE]" {
+				done = true
+				continue
+			}
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+			assert.Equal(t, "text_completion", chunk["object"])
+			assert.Equal(t, "cmpl-abc123", chunk["id"])
+			for _, c := range chunk["choices"].([]interface{}) {
+				choice := c.(map[string]interface{})
+				assert.NotContains(t, choice, "delta")
+				text.WriteString(choice["text"].(string))
+				if choice["finish_reason"] != nil {
+					finishReason = choice["finish_reason"]
+				}
+			}
+		}
+		assert.Equal(t, "Hello, world", text.String())
+		assert.Equal(t, "stop", finishReason)
+		assert.True(t, done, "stream should end with [DONE]")
+	})
+
+	t.Run("should only allow POST", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		newHandler("http://unused").ServeHTTP(w, httptest.NewRequest("GET", CompletionsPath, nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+	})
+}
+
+func TestCompletionChunk(t *testing.T) {
+	t.Run("should skip chunks without text or finish reason", func(t *testing.T) {
+		_, keep := completionChunk([]byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`))
+
+		assert.False(t, keep)
+	})
+
+	t.Run("should pass through data that is not a chunk", func(t *testing.T) {
+		data := []byte(`{"error":{"type":"server_error","message":"boom"}}`)
+
+		converted, keep := completionChunk(data)
+
+		assert.True(t, keep)
+		assert.Equal(t, data, converted)
+	})
+
+	t.Run("should keep chunks without choices, such as warnings", func(t *testing.T) {
+		converted, keep := completionChunk([]byte(`{"id":"chatcmpl-1","object":"chat.completion.chunk","choices":[],"x_claude_gate_warnings":["dropped n"]}`))
+
+		require.True(t, keep)
+		assert.JSONEq(t, `{"id":"cmpl-1","object":"text_completion","choices":[],"x_claude_gate_warnings":["dropped n"]}`, string(converted))
+	})
+}
@@ -0,0 +1,42 @@
+package proxy
+
+// Extension fields carrying Anthropic content blocks that have no OpenAI equivalent.
+// Non-streaming messages list them under ContentBlocksField; streaming deltas carry
+// each new block under ContentBlockField and its later deltas under ContentBlockDeltaField.
+const (
+	ContentBlocksField     = "x_claude_gate_content_blocks"
+	ContentBlockField      = "x_claude_gate_content_block"
+	ContentBlockDeltaField = "x_claude_gate_content_block_delta"
+)
+
+// translatedContentBlockTypes lists the block types the translator already handles.
+// Thinking blocks are deliberately left out of OpenAI responses.
+var translatedContentBlockTypes = map[string]bool{
+	"text":              true,
+	"tool_use":          true,
+	"thinking":          true,
+	"redacted_thinking": true,
+}
+
+// isUntranslatedContentBlock reports whether a content block would otherwise be lost
+func isUntranslatedContentBlock(block map[string]interface{}) bool {
+	blockType, _ := block["type"].(string)
+	return !translatedContentBlockTypes[blockType]
+}
+
+// deltaChunk builds a streaming chunk with the given delta
+func (c *SSEConverter) deltaChunk(delta map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      c.messageID,
+		"object":  "chat.completion.chunk",
+		"created": c.created,
+		"model":   c.model,
+		"choices": []interface{}{
+			map[string]interface{}{
+				"index":         0,
+				"delta":         delta,
+				"finish_reason": nil,
+			},
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import "encoding/json"
+
+// ContentFilterFinishReason is the OpenAI finish reason of content stopped by a
+// safety system
+const ContentFilterFinishReason = "content_filter"
+
+// SetContentFilterFinishReason toggles reporting Anthropic refusals, its safety
+// stops, with finish_reason "content_filter" instead of "stop". It is a best-effort
+// mapping: OpenAI filters content after generation, Anthropic's model declines.
+func (t *RequestTransformer) SetContentFilterFinishReason(enabled bool) {
+	t.contentFilterFinish = enabled
+}
+
+// EnableContentFilterFinishReason reports refusals with finish_reason "content_filter"
+func (c *SSEConverter) EnableContentFilterFinishReason() {
+	c.contentFilterFinish = true
+}
+
+// applyContentFilterFinishReason sets finish_reason "content_filter" on the refused
+// choices of an OpenAI chat completion, those marked by refusalFilterResults
+func applyContentFilterFinishReason(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	choices, _ := response["choices"].([]interface{})
+	changed := false
+	for _, c := range choices {
+		choice, _ := c.(map[string]interface{})
+		if refused(choice) {
+			choice["finish_reason"] = ContentFilterFinishReason
+			changed = true
+		}
+	}
+
+	if !changed {
+		return body, nil
+	}
+	return json.Marshal(response)
+}
+
+// refused reports whether a choice carries the refusal content filter result
+func refused(choice map[string]interface{}) bool {
+	results, _ := choice["content_filter_results"].(map[string]interface{})
+	refusal, _ := results["refusal"].(map[string]interface{})
+	filtered, _ := refusal["filtered"].(bool)
+	return filtered
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentFilterFinishReason(t *testing.T) {
+	const refused = `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"I can't help with that."}],"stop_reason":"refusal","usage":{"input_tokens":10,"output_tokens":6}}`
+	const completed = `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":1}}`
+
+	// finishReason sends a chat completion to an upstream answering with response
+	finishReason := func(t *testing.T, enabled bool, response string) string {
+		t.Helper()
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(response))
+		}))
+		defer upstream.Close()
+
+		transformer := NewRequestTransformer()
+		transformer.SetContentFilterFinishReason(enabled)
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   transformer,
+		})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions",
+			strings.NewReader(`{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Something disallowed"}]}`)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		choice := body["choices"].([]interface{})[0].(map[string]interface{})
+		return choice["finish_reason"].(string)
+	}
+
+	t.Run("should report a safety stop as content_filter when enabled", func(t *testing.T) {
+		assert.Equal(t, "content_filter", finishReason(t, true, refused))
+	})
+
+	t.Run("should keep stop for a safety stop by default", func(t *testing.T) {
+		assert.Equal(t, "stop", finishReason(t, false, refused))
+	})
+
+	t.Run("should leave other finish reasons alone", func(t *testing.T) {
+		assert.Equal(t, "stop", finishReason(t, true, completed))
+	})
+
+	t.Run("should report a streamed safety stop as content_filter when enabled", func(t *testing.T) {
+		// Arrange
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-20250514\"}}\n\n")
+			fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"I can't\"}}\n\n")
+			fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"refusal\"}}\n\n")
+			fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+		}))
+		defer upstream.Close()
+		transformer := NewRequestTransformer()
+		transformer.SetContentFilterFinishReason(true)
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   transformer,
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions",
+			strings.NewReader(`{"model":"claude-sonnet-4-20250514","stream":true,"messages":[{"role":"user","content":"Something disallowed"}]}`)))
+
+		// Assert
+		stream := helpers.ParseOpenAIStream(t, w.Body.String())
+		var reasons []interface{}
+		for _, chunk := range stream.Chunks {
+			choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+			if reason := choice["finish_reason"]; reason != nil {
+				reasons = append(reasons, reason)
+			}
+		}
+		require.NotEmpty(t, reasons)
+		assert.Equal(t, "content_filter", reasons[0])
+	})
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultCORSAllowedOrigins allows any origin, without credentials
+var DefaultCORSAllowedOrigins = []string{"*"}
+
+// corsPolicy decides which origins get CORS headers
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   [][2]string // Scheme and ".domain" suffix of "https://*.example.com" entries
+	credentials bool
+}
+
+// newCORSPolicy builds the policy for the allowed origins (nil = DefaultCORSAllowedOrigins).
+// An entry is an exact origin such as "https://app.example.com", "*" for any origin,
+// or "https://*.example.com" for any subdomain of example.com over https.
+func newCORSPolicy(allowed []string, credentials bool) *corsPolicy {
+	if allowed == nil {
+		allowed = DefaultCORSAllowedOrigins
+	}
+	p := &corsPolicy{origins: make(map[string]bool), credentials: credentials}
+	for _, origin := range allowed {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(origin, "*")
+			p.wildcards = append(p.wildcards, [2]string{scheme, domain})
+		case origin != "":
+			p.origins[origin] = true
+		}
+	}
+	return p
+}
+
+// conflict reports whether credentials were enabled for any origin. Browsers reject
+// credentials with "Access-Control-Allow-Origin: *", and reflecting every origin
+// instead would let any site make credentialed requests, so credentials are dropped.
+func (p *corsPolicy) conflict() bool {
+	return p.anyOrigin && p.credentials
+}
+
+// warnConflict logs a warning when credentials were enabled for any origin
+func (p *corsPolicy) warnConflict(logger *slog.Logger) {
+	if !p.conflict() {
+		return
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Warn("CORS credentials are not allowed for any origin (*); list the allowed origins to enable them")
+}
+
+// allowed reports whether origin is in the allowlist
+func (p *corsPolicy) allowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		scheme, domain := wildcard[0], wildcard[1]
+		subdomain, ok := strings.CutPrefix(origin, scheme)
+		if !ok {
+			continue
+		}
+		subdomain, ok = strings.CutSuffix(subdomain, domain)
+		if ok && subdomain != "" && !strings.ContainsAny(subdomain, "/:") {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware adds CORS headers for allowed origins and answers preflight requests
+// itself, so no handler needs to handle OPTIONS. The policy is loaded per request, so
+// a reload applies to the next request.
+func corsMiddleware(next http.Handler, policy *atomic.Pointer[corsPolicy]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy.Load().setHeaders(w, r)
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setHeaders sets the CORS headers for the request's origin. With an allowlist, an
+// origin outside it gets none, so the browser blocks the response.
+func (p *corsPolicy) setHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if p.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Add("Vary", "Origin")
+		if origin == "" || !p.allowed(origin) {
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if p.credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, X-Request-Id, X-Claude-Gate-Session")
+	w.Header().Set("Access-Control-Max-Age", "3600")
+}
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	// newMux returns the full route table with an upstream that counts requests
+	newMux := func(t *testing.T) (http.Handler, *int32) {
+		var upstreamCalls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		config := &ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			AdminKey:      "admin-secret",
+		}
+		health := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"status":"healthy"}`))
+		})
+		return CreateMux(NewProxyHandler(config), health, config), &upstreamCalls
+	}
+
+	routes := []string{
+		"/",
+		"/health",
+		"/metrics",
+		"/streams",
+		"/v1/models",
+		PricingPath,
+		"/v1/messages",
+		"/v1/messages/count_tokens",
+		"/v1/chat/completions",
+		ResponsesPath,
+		BatchesPath,
+		"/v1/unknown",
+	}
+
+	for _, route := range routes {
+		t.Run("should answer preflight for "+route, func(t *testing.T) {
+			// Arrange
+			mux, upstreamCalls := newMux(t)
+			req := httptest.NewRequest(http.MethodOptions, route, nil)
+			req.Header.Set("Origin", "https://app.example")
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			w := httptest.NewRecorder()
+
+			// Act
+			mux.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Empty(t, w.Body.String())
+			assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "POST")
+			assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+			assert.Zero(t, atomic.LoadInt32(upstreamCalls), "preflight should never reach Anthropic")
+		})
+	}
+
+	t.Run("should add CORS headers to regular responses", func(t *testing.T) {
+		mux, _ := newMux(t)
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set("Origin", "https://app.example")
+		w := httptest.NewRecorder()
+
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
+
+func TestCORSPolicy(t *testing.T) {
+	// send returns the response headers of a request from origin
+	send := func(policy *corsPolicy, method, origin string) http.Header {
+		req := httptest.NewRequest(method, "/v1/models", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		var current atomic.Pointer[corsPolicy]
+		current.Store(policy)
+		corsMiddleware(http.NotFoundHandler(), &current).ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	t.Run("should reflect a listed origin with credentials", func(t *testing.T) {
+		// Arrange
+		policy := newCORSPolicy([]string{"https://app.example.com"}, true)
+
+		// Act
+		header := send(policy, http.MethodGet, "https://app.example.com")
+
+		// Assert
+		assert.Equal(t, "https://app.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "Origin", header.Get("Vary"))
+	})
+
+	t.Run("should omit CORS headers for an unlisted origin", func(t *testing.T) {
+		policy := newCORSPolicy([]string{"https://app.example.com"}, true)
+
+		for _, method := range []string{http.MethodGet, http.MethodOptions} {
+			header := send(policy, method, "https://evil.example")
+
+			assert.Empty(t, header.Get("Access-Control-Allow-Origin"), method)
+			assert.Empty(t, header.Get("Access-Control-Allow-Credentials"), method)
+			assert.Empty(t, header.Get("Access-Control-Allow-Methods"), method)
+		}
+	})
+
+	t.Run("should match wildcard subdomains", func(t *testing.T) {
+		policy := newCORSPolicy([]string{"https://*.example.com"}, false)
+
+		assert.True(t, policy.allowed("https://app.example.com"))
+		assert.True(t, policy.allowed("https://eu.app.example.com"))
+		assert.True(t, policy.allowed("HTTPS://App.Example.com"))
+		assert.False(t, policy.allowed("https://example.com"), "the wildcard needs a subdomain")
+		assert.False(t, policy.allowed("http://app.example.com"), "the scheme must match")
+		assert.False(t, policy.allowed("https://app.example.com:8443"), "the port must match")
+		assert.False(t, policy.allowed("https://app.example.com.evil.example"))
+		assert.False(t, policy.allowed("https://evilexample.com"))
+	})
+
+	t.Run("should refuse credentials for any origin", func(t *testing.T) {
+		// Arrange
+		policy := newCORSPolicy([]string{"*"}, true)
+
+		// Act
+		header := send(policy, http.MethodGet, "https://app.example.com")
+
+		// Assert
+		assert.True(t, policy.conflict())
+		assert.Equal(t, "*", header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("should omit CORS headers without an origin when origins are listed", func(t *testing.T) {
+		header := send(newCORSPolicy([]string{"https://app.example.com"}, false), http.MethodGet, "")
+
+		assert.Empty(t, header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("should allow any origin by default", func(t *testing.T) {
+		header := send(newCORSPolicy(nil, false), http.MethodGet, "https://app.example.com")
+
+		assert.Equal(t, "*", header.Get("Access-Control-Allow-Origin"))
+	})
+}
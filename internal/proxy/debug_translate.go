@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// DebugTranslatePath is the dry-run endpoint showing how a chat completion is translated
+const DebugTranslatePath = "/v1/debug/translate"
+
+// TranslateHandler runs an OpenAI chat completion body through the proxy's request
+// pipeline and returns the Anthropic request it would send, without sending it. It is
+// a debugging aid for client payloads and only mounted in debug mode.
+type TranslateHandler struct {
+	proxy *ProxyHandler
+}
+
+// NewTranslateHandler creates a dry-run translate handler on top of proxy
+func NewTranslateHandler(proxy *ProxyHandler) *TranslateHandler {
+	return &TranslateHandler{proxy: proxy}
+}
+
+// translateSummary lists what the translation changed or lost
+type translateSummary struct {
+	OriginalModel string   `json:"original_model"`
+	Model         string   `json:"model"`
+	DroppedParams []string `json:"dropped_params"`
+	SystemTexts   int      `json:"system_texts_merged"`
+	SystemMerge   string   `json:"system_merge"`
+	CachedTools   bool     `json:"cached_tools"`
+	Betas         []string `json:"betas"`
+	Warnings      []string `json:"warnings"`
+}
+
+func (h *TranslateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		h.proxy.writeError(w, http.StatusMethodNotAllowed, "invalid_request_error",
+			"Method "+r.Method+" is not allowed on "+DebugTranslatePath+"; use POST")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.proxy.writeError(w, http.StatusBadRequest, "invalid_request_error", "Failed to read request body: "+err.Error())
+		return
+	}
+	if !json.Valid(body) {
+		h.proxy.writeError(w, http.StatusBadRequest, "invalid_request_error", "Request body is not valid JSON")
+		return
+	}
+
+	// The same steps as a real chat completion, up to the upstream call
+	transformer := h.proxy.config.Transformer
+	translated, report, err := transformer.TransformRequestBodyWithReport(body, ChatCompletionsPath)
+	if err == nil && h.proxy.config.ImageFetcher == nil {
+		// Without a fetcher this only rejects remote images; nothing is downloaded
+		translated, err = h.proxy.config.ImageFetcher.inlineRemoteImages(r.Context(), translated)
+	} else if err == nil && bytes.Contains(translated, []byte(`"type":"url"`)) {
+		report.warn("remote images are downloaded and inlined when the request is sent")
+	}
+	if err == nil {
+		translated, err = transformer.ApplyLocale(translated, r.Header.Get(LocaleHeader))
+	}
+	var betas []string
+	if err == nil {
+		betas, _, err = h.proxy.resolveBetas(translated)
+	}
+
+	var unsupportedErr *UnsupportedContentError
+	var imageErr *ImageError
+	switch {
+	case errors.As(err, &unsupportedErr):
+		h.proxy.writeUnsupportedContent(w, unsupportedErr)
+		return
+	case errors.As(err, &imageErr):
+		h.proxy.writeImageError(w, imageErr)
+		return
+	case err != nil:
+		h.proxy.writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	dropped := append([]string{}, report.DroppedParams...)
+	sort.Strings(dropped)
+	betas = append([]string{}, betas...)
+	headers := transformer.InjectHeaders(r.Header, "")
+	addBetaHeader(headers, h.proxy.config.Betas...)
+	addBetaHeader(headers, betas...)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"path": "/v1/messages",
+		"headers": map[string]string{
+			"anthropic-version": h.proxy.anthropicVersion(requestModel(translated)),
+			"anthropic-beta":    headers.Get("anthropic-beta"),
+		},
+		"body": json.RawMessage(translated),
+		"summary": translateSummary{
+			OriginalModel: report.OriginalModel,
+			Model:         report.Model,
+			DroppedParams: dropped,
+			SystemTexts:   report.SystemTexts,
+			SystemMerge:   string(report.SystemMerge),
+			CachedTools:   report.CachedTools,
+			Betas:         betas,
+			Warnings:      append([]string{}, report.Warnings...),
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateHandler(t *testing.T) {
+	// newMux returns the route table in the given debug mode with an upstream that counts requests
+	newMux := func(t *testing.T, debug bool) (http.Handler, *int32) {
+		var upstreamCalls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		config := &ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			Debug:         debug,
+		}
+		return CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config), &upstreamCalls
+	}
+	translate := func(mux http.Handler, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", DebugTranslatePath, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("should return the Anthropic request without sending it", func(t *testing.T) {
+		// Arrange
+		mux, upstreamCalls := newMux(t, true)
+
+		// Act
+		w := translate(mux, `{"model":"claude-3-5-haiku-20241022","max_tokens":100,"presence_penalty":0.5,"messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hello"}]}`)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Path    string                 `json:"path"`
+			Headers map[string]string      `json:"headers"`
+			Body    map[string]interface{} `json:"body"`
+			Summary translateSummary       `json:"summary"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "/v1/messages", response.Path)
+		assert.NotEmpty(t, response.Headers["anthropic-version"])
+		assert.Equal(t, "claude-3-5-haiku-20241022", response.Body["model"])
+		assert.NotContains(t, response.Body, "presence_penalty")
+		assert.Contains(t, response.Body, "system")
+		assert.Len(t, response.Body["messages"], 1)
+		assert.Equal(t, []string{"presence_penalty"}, response.Summary.DroppedParams)
+		assert.Equal(t, 1, response.Summary.SystemTexts)
+		assert.NotEmpty(t, response.Summary.Warnings)
+		assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+	})
+
+	t.Run("should reject remote images like the real endpoint", func(t *testing.T) {
+		mux, _ := newMux(t, true)
+
+		w := translate(mux, `{"model":"claude-3-5-haiku-20241022","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/cat.png"}}]}]}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "image_url_fetch_disabled")
+	})
+
+	t.Run("should reject a body that is not JSON", func(t *testing.T) {
+		mux, _ := newMux(t, true)
+
+		w := translate(mux, `{"model":`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should only allow POST", func(t *testing.T) {
+		mux, _ := newMux(t, true)
+		w := httptest.NewRecorder()
+
+		mux.ServeHTTP(w, httptest.NewRequest("GET", DebugTranslatePath, nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+	})
+
+	t.Run("should not be mounted outside debug mode", func(t *testing.T) {
+		// Arrange
+		mux, upstreamCalls := newMux(t, false)
+
+		// Act
+		w := translate(mux, `{"model":"claude-3-5-haiku-20241022","messages":[{"role":"user","content":"Hello"}]}`)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+	})
+}
@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// EmptyResponseMode selects what clients get when a chat completion has no content,
+// e.g. when Anthropic stops immediately
+type EmptyResponseMode string
+
+const (
+	// EmptyResponsePass returns the empty content as is, which is valid OpenAI output
+	EmptyResponsePass EmptyResponseMode = "pass"
+	// EmptyResponseSpace substitutes a single space, for clients that fail on empty content
+	EmptyResponseSpace EmptyResponseMode = "space"
+	// EmptyResponseError answers with a 502 empty_response error instead
+	EmptyResponseError EmptyResponseMode = "error"
+)
+
+// ParseEmptyResponseMode validates an empty response mode name; empty means pass
+func ParseEmptyResponseMode(mode string) (EmptyResponseMode, error) {
+	switch m := EmptyResponseMode(strings.ToLower(strings.TrimSpace(mode))); m {
+	case "", EmptyResponsePass:
+		return EmptyResponsePass, nil
+	case EmptyResponseSpace, EmptyResponseError:
+		return m, nil
+	default:
+		return EmptyResponsePass, fmt.Errorf("unknown empty response mode %q (want pass, space or error)", mode)
+	}
+}
+
+// applyToResponse handles the empty choices of a non-streaming OpenAI chat completion.
+// A choice is empty when its content is "" and it carries no refusal or untranslated
+// content blocks. empty reports whether the response should be answered with an error.
+func (m EmptyResponseMode) applyToResponse(body []byte) (processed []byte, empty bool, err error) {
+	if m == "" || m == EmptyResponsePass {
+		return body, false, nil
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, false, err
+	}
+
+	choices, _ := response["choices"].([]interface{})
+	changed := false
+	for _, c := range choices {
+		choice, _ := c.(map[string]interface{})
+		message, _ := choice["message"].(map[string]interface{})
+		if !isEmptyMessage(message) {
+			continue
+		}
+		if m == EmptyResponseError {
+			return body, true, nil
+		}
+		message["content"] = " "
+		changed = true
+	}
+
+	if !changed {
+		return body, false, nil
+	}
+	processed, err = json.Marshal(response)
+	return processed, false, err
+}
+
+// isEmptyMessage reports whether an OpenAI message has nothing for the client to show
+func isEmptyMessage(message map[string]interface{}) bool {
+	if message == nil {
+		return false
+	}
+	content, ok := message["content"].(string)
+	if !ok || content != "" {
+		return false
+	}
+	if refusal, _ := message["refusal"].(string); refusal != "" {
+		return false
+	}
+	if _, ok := message[ContentBlocksField]; ok {
+		return false
+	}
+	if _, ok := message["tool_calls"]; ok {
+		return false
+	}
+	return true
+}
+
+// writeEmptyResponse writes the 502 for an empty completion in error mode
+func (h *ProxyHandler) writeEmptyResponse(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadGateway)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "api_error",
+			"message": "Anthropic returned an empty response",
+			"param":   nil,
+			"code":    "empty_response",
+		},
+	})
+}
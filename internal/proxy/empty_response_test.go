@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyHandler_EmptyResponse(t *testing.T) {
+	serve := func(t *testing.T, mode EmptyResponseMode, upstreamBody string) *httptest.ResponseRecorder {
+		t.Helper()
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(upstreamBody))
+		}))
+		t.Cleanup(upstream.Close)
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			EmptyResponse: mode,
+		})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4","messages":[{"role":"user","content":"Hi"}]}`)))
+		return w
+	}
+	const empty = `{"id":"msg_1","type":"message","model":"claude-sonnet-4-20250514","content":[],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":0}}`
+	content := func(t *testing.T, w *httptest.ResponseRecorder) interface{} {
+		t.Helper()
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+		choice := response["choices"].([]interface{})[0].(map[string]interface{})
+		return choice["message"].(map[string]interface{})["content"]
+	}
+
+	t.Run("should pass empty content through by default", func(t *testing.T) {
+		// Act
+		w := serve(t, "", empty)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", content(t, w))
+	})
+
+	t.Run("should pass empty content through in pass mode", func(t *testing.T) {
+		w := serve(t, EmptyResponsePass, empty)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "", content(t, w))
+	})
+
+	t.Run("should substitute a space in space mode", func(t *testing.T) {
+		w := serve(t, EmptyResponseSpace, empty)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, " ", content(t, w))
+	})
+
+	t.Run("should return an error in error mode", func(t *testing.T) {
+		w := serve(t, EmptyResponseError, empty)
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.JSONEq(t, `{"error":{"type":"api_error","message":"Anthropic returned an empty response","param":null,"code":"empty_response"}}`, w.Body.String())
+	})
+
+	t.Run("should leave responses with content alone", func(t *testing.T) {
+		w := serve(t, EmptyResponseError, `{"id":"msg_1","type":"message","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Hello", content(t, w))
+	})
+
+	t.Run("should leave refusals alone", func(t *testing.T) {
+		w := serve(t, EmptyResponseSpace, `{"id":"msg_1","type":"message","content":[{"type":"text","text":"I can't help with that."}],"stop_reason":"refusal"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, content(t, w))
+	})
+
+	t.Run("should leave upstream errors alone", func(t *testing.T) {
+		w := serve(t, EmptyResponseError, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+
+		assert.NotContains(t, w.Body.String(), "empty_response")
+	})
+}
+
+func TestParseEmptyResponseMode(t *testing.T) {
+	for input, want := range map[string]EmptyResponseMode{"": EmptyResponsePass, "pass": EmptyResponsePass, "Space": EmptyResponseSpace, "error": EmptyResponseError} {
+		mode, err := ParseEmptyResponseMode(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, mode, input)
+	}
+
+	_, err := ParseEmptyResponseMode("null")
+	assert.Error(t, err)
+}
@@ -0,0 +1,22 @@
+package proxy
+
+import "encoding/json"
+
+// anthropicErrorBody reports whether a response body is an Anthropic error, such as
+// {"type":"error","error":{"type":"overloaded_error",...}}, and returns its type.
+// Such bodies occasionally arrive with a 2xx status.
+func anthropicErrorBody(body []byte) (string, bool) {
+	var response struct {
+		Type  string `json:"type"`
+		Error *struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Error == nil {
+		return "", false
+	}
+	if response.Type != "" && response.Type != "error" {
+		return "", false
+	}
+	return response.Error.Type, true
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicErrorBody(t *testing.T) {
+	t.Run("should detect error bodies", func(t *testing.T) {
+		errorType, ok := anthropicErrorBody([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+
+		assert.True(t, ok)
+		assert.Equal(t, "overloaded_error", errorType)
+	})
+
+	t.Run("should ignore completions", func(t *testing.T) {
+		for _, body := range []string{
+			`{"id":"msg_1","type":"message","content":[{"type":"text","text":"error"}]}`,
+			`{"type":"message","error":{"type":"api_error"}}`,
+			`not json`,
+		} {
+			_, ok := anthropicErrorBody([]byte(body))
+			assert.False(t, ok, body)
+		}
+	})
+}
+
+func TestProxyHandler_SuccessStatusWithErrorBody(t *testing.T) {
+	// Arrange
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	}))
+	defer upstream.Close()
+
+	handler := NewProxyHandler(&ProxyConfig{
+		UpstreamURL:   upstream.URL,
+		TokenProvider: &mockTokenProvider{token: "test-token"},
+		Transformer:   NewRequestTransformer(),
+	})
+	body := `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}]}`
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+
+	// Assert
+	assert.Equal(t, 529, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotContains(t, response, "choices", "an error must not be passed on as a completion")
+	errorObj, ok := response["error"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "overloaded_error", errorObj["type"])
+	assert.Equal(t, "Overloaded", errorObj["message"])
+}
@@ -4,16 +4,23 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
-	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 	
+	"github.com/ml0-1337/claude-gate/internal/audit"
 	"github.com/ml0-1337/claude-gate/internal/auth"
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+	"github.com/ml0-1337/claude-gate/internal/pricing"
+	"github.com/ml0-1337/claude-gate/internal/requestid"
 )
 
 // TokenProvider interface for OAuth token management
@@ -28,6 +35,185 @@ type ProxyConfig struct {
 	Transformer   *RequestTransformer
 	Timeout       time.Duration
 	Logger        *slog.Logger
+	
+	// UpstreamProxy routes upstream requests through this HTTP(S) proxy
+	// (nil = HTTPS_PROXY/NO_PROXY from the environment)
+	UpstreamProxy *url.URL
+	
+	// HTTPClient sends every upstream request, from the proxy and the models handler
+	// (nil = a client built from UpstreamProxy). Requests carry their own deadlines, so
+	// its Timeout should be 0 or it will cut long streams short.
+	HTTPClient *http.Client
+	
+	// ModelsTimeout bounds a live model list fetch (0 = DefaultModelsTimeout)
+	ModelsTimeout time.Duration
+	
+	// AccessLogFormat writes an Apache-style or JSON line per request to AccessLog (nil = stdout)
+	AccessLogFormat AccessLogFormat
+	AccessLog       io.Writer
+	
+	// AccessLogLevel is the lowest level of JSON access log lines: requests log at
+	// INFO, 4xx at WARN and 5xx at ERROR. AccessLogBodySize adds the request body size
+	// to them; bodies are never logged.
+	AccessLogLevel    slog.Level
+	AccessLogBodySize bool
+	
+	// ModelTimeouts override Timeout per model, matched by longest model prefix
+	ModelTimeouts map[string]time.Duration
+	
+	// StreamMaxDuration caps a streaming request in place of the other timeouts (0 = not set)
+	StreamMaxDuration time.Duration
+	
+	// StreamIdleTimeout ends a stream that sends nothing for this long (0 = no limit)
+	StreamIdleTimeout time.Duration
+	
+	// PartialOnTimeout streams non-streaming chat completions internally, so one that
+	// times out returns its output so far with finish_reason "length"
+	PartialOnTimeout bool
+	
+	// MaxStreamsPerClient limits concurrent streams per client (0 = unlimited)
+	MaxStreamsPerClient int
+	
+	// MaxConnections caps simultaneous connections on the listener (0 = unlimited)
+	MaxConnections int
+	
+	// TLS serves HTTPS with this configuration (nil = plain HTTP). PlainHTTP is how
+	// plain HTTP requests to the TLS port are answered: PlainHTTPReject (the default)
+	// or PlainHTTPRedirect.
+	TLS       *tls.Config
+	PlainHTTP string
+	
+	// Socket listens on this Unix domain socket instead of the TCP address, created
+	// with SocketMode permissions (0 = DefaultSocketMode) and removed on shutdown
+	Socket     string
+	SocketMode os.FileMode
+	
+	// MaxHeaderBytes caps the request header block; larger ones get 431 (0 = DefaultMaxHeaderBytes)
+	MaxHeaderBytes int
+	
+	// Sessions keeps conversation turns for clients sending SessionHeader; nil
+	// disables sessions
+	Sessions *SessionStore
+	
+	// ImageFetcher downloads remote image URLs of OpenAI requests and inlines them;
+	// nil rejects such requests
+	ImageFetcher *ImageFetcher
+	
+	// LocalAPIKeys are the keys clients must send to use the API; none leaves the
+	// proxy open
+	LocalAPIKeys []string
+	
+	// RateLimiter limits the API requests of each client; nil means no limit
+	RateLimiter RateLimiter
+	
+	// ReadinessCheckUpstream makes the readiness probe ping the upstream besides
+	// checking for an access token
+	ReadinessCheckUpstream bool
+	
+	// Betas are sent with every upstream request besides the OAuth beta, for betas the
+	// proxy does not detect from request content. Duplicates are dropped.
+	Betas []string
+	
+	// CORSAllowedOrigins get CORS headers: exact origins, "*" or wildcard subdomains
+	// such as "https://*.example.com" (nil = DefaultCORSAllowedOrigins). Other origins
+	// get none. CORSAllowCredentials allows cookies and auth headers for listed origins;
+	// it is ignored for "*".
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+	
+	// Debug mounts debugging endpoints such as DebugTranslatePath; keep it off in production
+	Debug bool
+	
+	// DisableMetrics removes the Prometheus /metrics endpoint; metrics are still recorded
+	DisableMetrics bool
+	
+	// WarmupUpstream opens a connection to the upstream at startup with a cheap
+	// model list request, so the first real request skips connection setup
+	WarmupUpstream bool
+	
+	// IncludeModelCapabilities adds context_window and max_output_tokens to /v1/models
+	IncludeModelCapabilities bool
+	
+	// ModelsCacheTTL serves the live model list cached for this long (0 = static list)
+	ModelsCacheTTL time.Duration
+	
+	// ModelsCacheFile persists the model cache across restarts (empty = memory only)
+	ModelsCacheFile string
+	
+	// ModelsEmptyNote explains an empty /v1/models list in an x_note field
+	ModelsEmptyNote bool
+	
+	// AllowedModels limits /v1/models to these model IDs (empty = all)
+	AllowedModels []string
+	
+	// Pricing is served at /v1/models/pricing (nil = built-in prices)
+	Pricing *pricing.Table
+	
+	// UpstreamRPS caps requests per second sent to Anthropic across all clients (0 = unlimited)
+	UpstreamRPS float64
+	
+	// UpstreamQueueTimeout is how long a request may wait for the upstream throttle
+	UpstreamQueueTimeout time.Duration
+	
+	// RetryAfterMaxWait is the longest Retry-After a stream rate limited before it
+	// started is waited out and retried once (0 = never retry)
+	RetryAfterMaxWait time.Duration
+	
+	// UpstreamRetries retries upstream requests that fail transiently, with exponential
+	// backoff from UpstreamRetryDelay (0 = no retries). Model list requests retry on
+	// 429 and 5xx too; completions only on connection errors, never generating twice.
+	UpstreamRetries    int
+	UpstreamRetryDelay time.Duration
+	
+	// ValidateRequests checks chat completion requests against the OpenAI schema before translation
+	ValidateRequests bool
+	
+	// MaxMessages rejects requests with more messages than this before translation (0 = unlimited)
+	MaxMessages int
+	
+	// AllowDebugHeaders honors the X-Claude-Gate-Debug request header
+	AllowDebugHeaders bool
+	
+	// CoalesceStreams shares one upstream call between identical in-flight streaming
+	// requests at temperature 0, broadcasting the stream to every waiting client
+	CoalesceStreams bool
+	
+	// ResponseWarnings lists request adjustments in OpenAI responses under x_claude_gate_warnings
+	ResponseWarnings bool
+	
+	// EmptyResponse handles non-streaming chat completions without content (empty = pass)
+	EmptyResponse EmptyResponseMode
+	
+	// Account is the stored account whose login /health reports (empty = default)
+	Account string
+	
+	// AccountUpstreams override the upstream URL, default anthropic-version and beta
+	// allowlist for the accounts they name; only Account's entry is used
+	AccountUpstreams map[string]AccountUpstream
+	
+	// AdminKey protects operator endpoints such as /streams (empty = endpoints disabled)
+	AdminKey string
+	
+	// Audit records authentication events such as rejected keys (nil = not audited)
+	Audit *audit.Logger
+	
+	// Passthrough forwards /v1/ paths the proxy does not know, for PassthroughMethods
+	// (default GET and HEAD); otherwise they get a 404
+	Passthrough        bool
+	PassthroughMethods []string
+	
+	// TagKeys allowlists the request tags, from OpenAI metadata or the X-Claude-Gate-Tags
+	// header, added to logs and the tagged request metric (empty = tags ignored)
+	TagKeys []string
+	
+	// TokenBudgets caps the tokens each client key may use per period, by client key
+	// (see ParseTokenBudgets). Usage is kept in memory and resets on restart.
+	TokenBudgets map[string]TokenBudget
+	
+	// Mock answers requests with canned responses instead of calling Anthropic, so no
+	// credentials are needed. MockResponse is the reply text (empty = echo the prompt).
+	Mock         bool
+	MockResponse string
 }
 
 // ProxyHandler handles HTTP requests and proxies them to Anthropic API
@@ -35,6 +221,14 @@ type ProxyHandler struct {
 	config     *ProxyConfig
 	httpClient *http.Client
 	logger     *slog.Logger
+	streams    *streamLimiter
+	throttle   *upstreamThrottle
+	budgets    *budgetTracker
+	coalescer  *streamCoalescer
+	tagger     *requestTagger
+	
+	// activeStreams lists in-flight streams for the /streams endpoint
+	activeStreams *streamRegistry
 }
 
 // NewProxyHandler creates a new proxy handler
@@ -49,51 +243,85 @@ func NewProxyHandler(config *ProxyConfig) *ProxyHandler {
 		logger = slog.Default()
 	}
 	
-	// Create HTTP client with custom transport for better streaming support
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 20,
-		IdleConnTimeout:     90 * time.Second,
-		DisableCompression:  true, // Important for SSE
+	// Create HTTP client with custom transport for better streaming support. Requests
+	// carry their own deadline from resolveTimeout instead of a client-wide timeout.
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:               upstreamProxyFunc(config.UpstreamProxy),
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 20,
+				IdleConnTimeout:     90 * time.Second,
+				DisableCompression:  true, // Important for SSE
+			},
+		}
+	}
+	httpClient = withMetrics(withRetries(httpClient, config.UpstreamRetries, config.UpstreamRetryDelay))
+	if config.Mock {
+		httpClient = &http.Client{Transport: newMockTransport(config.MockResponse)}
+		config.TokenProvider = mockToken{}
 	}
 	
-	return &ProxyHandler{
-		config: config,
-		httpClient: &http.Client{
-			Transport: transport,
-			Timeout:   config.Timeout,
-		},
-		logger: logger,
+	handler := &ProxyHandler{
+		config:        config,
+		httpClient:    httpClient,
+		logger:        logger,
+		activeStreams: newStreamRegistry(),
+	}
+	if config.MaxStreamsPerClient > 0 {
+		handler.streams = newStreamLimiter(config.MaxStreamsPerClient)
+	}
+	if config.UpstreamRPS > 0 {
+		if config.UpstreamQueueTimeout == 0 {
+			config.UpstreamQueueTimeout = 30 * time.Second
+		}
+		handler.throttle = newUpstreamThrottle(config.UpstreamRPS, config.UpstreamQueueTimeout)
+	}
+	if len(config.TokenBudgets) > 0 {
+		handler.budgets = newBudgetTracker(config.TokenBudgets)
 	}
+	if config.CoalesceStreams {
+		handler.coalescer = newStreamCoalescer()
+	}
+	handler.tagger = newRequestTagger(config.TagKeys)
+	
+	return handler
 }
 
 // ServeHTTP implements http.Handler interface
 func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Every request gets an ID shared by the response header, logs, the upstream request
+	// and OpenAI completion IDs; requestid.Middleware assigns it when mounted by CreateMux
+	requestID := requestid.FromContext(r.Context())
+	if requestID == "" {
+		requestID = requestid.New()
+	}
+	logger := h.logger.With("request_id", requestID)
+	w.Header().Set(requestid.Header, requestID)
+	
 	// Log request details
-	h.logger.Info("incoming request",
+	logger.Info("incoming request",
 		"method", r.Method,
 		"path", r.URL.Path,
 		"remote_addr", r.RemoteAddr,
 		"user_agent", r.Header.Get("User-Agent"),
 	)
 	
-	// Handle CORS preflight requests
-	if r.Method == "OPTIONS" {
-		h.handleCORS(w, r)
+	// Only known endpoints are forwarded unless passthrough is enabled
+	if !h.allowPassthrough(w, r) {
+		logger.Info("rejected request to unknown endpoint", "method", r.Method, "path", r.URL.Path)
 		return
 	}
 	
-	// Set CORS headers for all requests
-	h.setCORSHeaders(w, r)
-	
 	// Get OAuth token
 	token, err := h.config.TokenProvider.GetAccessToken()
 	if err != nil {
-		h.logger.Error("failed to get OAuth token", "error", err)
+		logger.Error("failed to get OAuth token", "error", err)
 		h.writeError(w, http.StatusUnauthorized, "OAuth token error", err.Error())
 		return
 	}
-	h.logger.Debug("OAuth token retrieved successfully")
+	logger.Debug("OAuth token retrieved successfully")
 	
 	// Read request body
 	body, err := io.ReadAll(r.Body)
@@ -103,6 +331,49 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 	
+	// Allowlisted client tags label the rest of the request's logs and the tag metric
+	if h.tagger != nil {
+		if tags := h.tagger.extract(body, r.Header); len(tags) > 0 {
+			logger = logger.With(tagsAttr(tags))
+			h.tagger.count(tags)
+		}
+	}
+	
+	// Reject malformed chat completion requests before spending any upstream tokens
+	if h.config.ValidateRequests && r.Method == http.MethodPost && r.URL.Path == "/v1/chat/completions" {
+		if err := ValidateChatCompletionRequest(body); err != nil {
+			var validationErr *RequestValidationError
+			if errors.As(err, &validationErr) {
+				logger.Info("rejected invalid chat completion request", "violations", len(validationErr.Violations))
+				h.writeValidationError(w, validationErr)
+				return
+			}
+		}
+	}
+	
+	// Hard limit on conversation length, checked before any translation work
+	if h.config.MaxMessages > 0 && r.Method == http.MethodPost && (r.URL.Path == "/v1/chat/completions" || r.URL.Path == "/v1/messages") {
+		if err := checkMessageLimit(body, h.config.MaxMessages); err != nil {
+			var limitErr *TooManyMessagesError
+			if errors.As(err, &limitErr) {
+				logger.Info("rejected request over the message limit", "messages", limitErr.Count, "max", limitErr.Max)
+				h.writeTooManyMessages(w, limitErr)
+				return
+			}
+		}
+	}
+	
+	// Refuse clients that have used up their token budget for the period
+	budgetKey := ""
+	if h.budgets != nil && r.Method == http.MethodPost && (r.URL.Path == "/v1/chat/completions" || r.URL.Path == ResponsesPath || r.URL.Path == CompletionsPath || r.URL.Path == "/v1/messages") {
+		budgetKey = budgetClientKey(r)
+		if allowed, resetIn := h.budgets.Allow(budgetKey); !allowed {
+			logger.Warn("token budget exhausted", "client", budgetKey, "resets_in", resetIn)
+			h.writeBudgetExhausted(w, resetIn)
+			return
+		}
+	}
+	
 	// Check if this is a streaming request
 	isStreamingRequest := false
 	if len(body) > 0 {
@@ -113,24 +384,127 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	h.logger.Debug("streaming detection", "is_streaming", isStreamingRequest, "body_length", len(body))
+	logger.Debug("streaming detection", "is_streaming", isStreamingRequest, "body_length", len(body))
+	
+	// OpenAI streams end with a usage chunk when the client asks for one
+	includeUsage := isStreamingRequest && includeUsageRequested(body)
+	
+	// Enforce the per-client stream limit; the slot is held until the handler returns
+	if isStreamingRequest && h.streams != nil {
+		key := clientKey(r)
+		if !h.streams.Acquire(key) {
+			logger.Warn("per-client stream limit reached", "client", key, "limit", h.config.MaxStreamsPerClient)
+			h.writeError(w, http.StatusTooManyRequests, "rate_limit_error", "Too many concurrent streams for this client")
+			return
+		}
+		defer h.streams.Release(key)
+	}
 	
 	// Transform request body if needed
 	path := r.URL.Path
-	transformedBody, err := h.config.Transformer.TransformRequestBody(body, path)
+	transformedBody, transformReport, err := h.config.Transformer.TransformRequestBodyWithReport(body, path)
+	var unsupportedErr *UnsupportedContentError
+	if errors.As(err, &unsupportedErr) {
+		logger.Info("rejected request with unsupported content", "content_type", unsupportedErr.ContentType, "param", unsupportedErr.Param)
+		h.writeUnsupportedContent(w, unsupportedErr)
+		return
+	}
+	var imageErr *ImageError
+	if errors.As(err, &imageErr) {
+		logger.Info("rejected request with an unusable image", "param", imageErr.Param, "code", imageErr.Code)
+		h.writeImageError(w, imageErr)
+		return
+	}
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "Failed to transform request", err.Error())
 		return
 	}
 	
+	// Inline the remote images of translated OpenAI requests, or reject them when
+	// fetching is off
+	if transformReport.ConvertedFromOpenAI {
+		transformedBody, err = h.config.ImageFetcher.inlineRemoteImages(r.Context(), transformedBody)
+		if errors.As(err, &imageErr) {
+			logger.Info("rejected request with an unusable image", "param", imageErr.Param, "code", imageErr.Code)
+			h.writeImageError(w, imageErr)
+			return
+		}
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, "Failed to transform request", err.Error())
+			return
+		}
+	}
+	
+	// Ask for responses in the requested language
+	if r.Method == http.MethodPost && (path == "/v1/messages" || path == "/v1/chat/completions" || path == ResponsesPath || path == CompletionsPath) {
+		transformedBody, err = h.config.Transformer.ApplyLocale(transformedBody, r.Header.Get(LocaleHeader))
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, "Failed to transform request", err.Error())
+			return
+		}
+	}
+	
+	// Continue the conversation of a session with its earlier turns
+	sessionID := ""
+	var sessionTurn []interface{}
+	if h.config.Sessions != nil && r.Method == http.MethodPost && (path == "/v1/chat/completions" || path == "/v1/messages") {
+		if sessionID = r.Header.Get(SessionHeader); sessionID != "" {
+			transformedBody, sessionTurn, err = h.config.Sessions.continueSession(sessionID, transformedBody)
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, "invalid_request_error", "Invalid session: "+err.Error())
+				return
+			}
+		}
+	}
+	
+	// Work out which beta features the request needs and whether they are allowed
+	betas, disallowedBetas, err := h.resolveBetas(transformedBody)
+	if err != nil {
+		logger.Warn("rejected request requiring disallowed betas", "betas", disallowedBetas)
+		h.writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if len(disallowedBetas) > 0 {
+		logger.Info("not enabling disallowed betas", "betas", disallowedBetas)
+	}
+	
+	// Track the stream for operators until the handler returns
+	if isStreamingRequest {
+		var streamData map[string]interface{}
+		json.Unmarshal(transformedBody, &streamData)
+		model, _ := streamData["model"].(string)
+		
+		streamToken := h.activeStreams.Register(ActiveStream{
+			ID:        requestID,
+			Model:     model,
+			ClientIP:  clientIP(r),
+			Path:      path,
+			StartedAt: time.Now(),
+		})
+		defer h.activeStreams.Unregister(streamToken)
+		metrics.ActiveStreams.Inc()
+		defer metrics.ActiveStreams.Dec()
+	}
+	
 	// Transform path for OpenAI endpoints
 	upstreamPath := path
-	if path == "/v1/chat/completions" {
+	if path == "/v1/chat/completions" || path == ResponsesPath || path == CompletionsPath {
 		upstreamPath = "/v1/messages"
 	}
 	
+	// Non-streaming chat completions are streamed internally when enabled, so a request
+	// that times out still returns the output produced until then
+	collectPartial := h.config.PartialOnTimeout && !isStreamingRequest && r.Method == http.MethodPost && path == "/v1/chat/completions"
+	if collectPartial {
+		if streamed, err := withStream(transformedBody); err == nil {
+			transformedBody = streamed
+		} else {
+			collectPartial = false
+		}
+	}
+	
 	// Build upstream URL
-	upstreamURL, err := url.Parse(h.config.UpstreamURL)
+	upstreamURL, err := url.Parse(h.config.upstream().BaseURL)
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "Invalid upstream URL", err.Error())
 		return
@@ -138,8 +512,12 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	upstreamURL.Path = upstreamPath
 	upstreamURL.RawQuery = r.URL.RawQuery
 	
-	// Create upstream request
-	upstreamReq, err := http.NewRequest(r.Method, upstreamURL.String(), bytes.NewReader(transformedBody))
+	// Create upstream request, bounded by the timeouts resolved for its model and
+	// cancelled as soon as the client goes away
+	timeouts := h.config.resolveTimeout(requestModel(transformedBody), isStreamingRequest)
+	upstreamCtx, cancelUpstream := timeouts.withDeadline(r.Context())
+	defer cancelUpstream()
+	upstreamReq, err := http.NewRequestWithContext(upstreamCtx, r.Method, upstreamURL.String(), bytes.NewReader(transformedBody))
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, "Failed to create upstream request", err.Error())
 		return
@@ -154,23 +532,115 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	
 	// Inject OAuth headers
 	upstreamReq.Header = h.config.Transformer.InjectHeaders(r.Header, token)
+	addBetaHeader(upstreamReq.Header, h.config.Betas...)
+	upstreamReq.Header.Set("anthropic-version", h.anthropicVersion(requestModel(transformedBody)))
+	upstreamReq.Header.Set(requestid.Header, requestID)
+	addBetaHeader(upstreamReq.Header, betas...)
+	
+	// Summarize the applied transformations for clients that asked for it
+	if h.config.AllowDebugHeaders && wantsTransformDebug(r) {
+		transformReport.Betas = requestBetas(upstreamReq.Header)
+		transformReport.WriteHeaders(w.Header())
+	}
 	
 	// Make upstream request
-	h.logger.Debug("sending request to upstream",
+	logger.Debug("sending request to upstream",
 		"url", upstreamReq.URL.String(),
 		"method", upstreamReq.Method,
 		"has_connection_header", upstreamReq.Header.Get("Connection") != "",
 	)
 	
-	resp, err := h.httpClient.Do(upstreamReq)
+	// Identical deterministic streams share one upstream call when coalescing is on
+	var flight *streamFlight
+	leader := true
+	if h.coalescer != nil && isStreamingRequest {
+		if key := coalesceKey(transformedBody, upstreamReq.Header); key != "" {
+			deadline, _ := upstreamCtx.Deadline()
+			flight, leader = h.coalescer.Acquire(key, deadline)
+			// The shared call outlives any one client; a client that disconnects only
+			// leaves the flight, through upstreamCtx, without ending it for the others
+			upstreamReq = upstreamReq.WithContext(flight.Context())
+			logger.Debug("coalescing stream", "leader", leader)
+		}
+	}
+	
+	// Queue behind the global upstream throttle
+	if h.throttle != nil && leader {
+		if err := h.throttle.Wait(r.Context()); err != nil {
+			if flight != nil {
+				flight.Fail(err)
+			}
+			logger.Warn("upstream throttle rejected request", "error", err, "rps", h.config.UpstreamRPS)
+			w.Header().Set("Retry-After", "1")
+			h.writeError(w, http.StatusTooManyRequests, "rate_limit_error", "Upstream request rate limit reached, try again later")
+			return
+		}
+	}
+	
+	var resp *http.Response
+	if flight == nil {
+		resp, err = h.httpClient.Do(upstreamReq)
+	} else {
+		if leader {
+			flight.Start(h.httpClient.Do(upstreamReq))
+		}
+		resp, err = flight.Response(upstreamCtx)
+	}
 	if err != nil {
-		h.logger.Error("upstream request failed", "error", err)
+		logger.Error("upstream request failed", "error", err)
 		h.writeError(w, http.StatusBadGateway, "Upstream request failed", err.Error())
 		return
 	}
+	
+	// A 429 before any stream bytes is retried once if short enough, otherwise
+	// answered with a plain 429 rather than an empty stream
+	if isStreamingRequest && resp.StatusCode == http.StatusTooManyRequests {
+		resp = h.retryRateLimitedStream(r.Context(), upstreamReq, resp, logger)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			defer resp.Body.Close()
+			logger.Warn("stream rate limited before it started", "retry_after", resp.Header.Get("Retry-After"))
+			h.writeRateLimited(w, resp)
+			return
+		}
+	}
+	
+	// Rebuild the non-streaming response from the internal stream, cut short at the deadline
+	if collectPartial && resp.StatusCode < 300 && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		partial, err := collectStream(resp)
+		if err != nil {
+			logger.Error("upstream stream failed before any output", "error", err)
+			h.writeError(w, http.StatusGatewayTimeout, "timeout_error", "Upstream request timed out before producing any output")
+			return
+		}
+		if partial {
+			logger.Warn("returning partial output of a timed out request", "timeout", timeouts.Deadline)
+			transformReport.warn(partialTimeoutWarning)
+		}
+	}
+	
+	// A stream that stalls for longer than the idle timeout is cancelled
+	if timeouts.Idle > 0 {
+		resp.Body = newIdleTimeoutBody(resp.Body, timeouts.Idle, cancelUpstream)
+	}
+	
+	// Count the response's usage against the client's token budget as it is read
+	if budgetKey != "" && h.budgets.Limited(budgetKey) && resp.StatusCode < 300 {
+		streaming := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+		resp.Body = newUsageReader(resp.Body, streaming, func(tokens int64) {
+			h.budgets.Record(budgetKey, tokens)
+		})
+	}
+	
+	// Record the reply as the session's next turn once it has been read in full
+	if sessionID != "" && resp.StatusCode < 300 {
+		streaming := strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+		resp.Body = newSessionRecorder(resp.Body, streaming, func(reply map[string]interface{}) {
+			h.config.Sessions.Append(sessionID, sessionTurn, reply)
+		})
+	}
 	defer resp.Body.Close()
 	
-	h.logger.Debug("received upstream response",
+	logger.Debug("received upstream response",
 		"status", resp.StatusCode,
 		"content_type", resp.Header.Get("Content-Type"),
 		"transfer_encoding", resp.Header.Get("Transfer-Encoding"),
@@ -192,51 +662,83 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	h.logger.Info("response type determined",
+	logger.Info("response type determined",
 		"is_streaming", isStreaming,
 		"path", path,
 		"status", resp.StatusCode,
 	)
 	
+	// Adjustments made to an OpenAI request are reported back in the response when enabled
+	var warnings []string
+	if h.config.ResponseWarnings && (path == "/v1/chat/completions" || path == ResponsesPath || path == CompletionsPath) {
+		warnings = transformReport.Warnings
+	}
+	
 	// Handle response body
 	if isStreaming {
+		// The status and stream headers are held back until the first byte, so
+		// failures before then still get a normal HTTP error response
+		stream := newDeferredStreamWriter(w, resp.StatusCode)
+		
 		// Copy response headers for streaming
 		for key, values := range resp.Header {
 			for _, value := range values {
-				w.Header().Add(key, value)
+				stream.StreamHeader().Add(key, value)
 			}
 		}
 		
 		// Add headers to prevent proxy buffering and connection reuse
-		w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "close") // Close connection after SSE stream
-		
-		// Write status code
-		w.WriteHeader(resp.StatusCode)
+		stream.StreamHeader().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+		stream.StreamHeader().Set("Cache-Control", "no-cache")
+		stream.StreamHeader().Set("Connection", "close") // Close connection after SSE stream
 		
 		// For OpenAI endpoints, convert SSE format
 		if path == "/v1/chat/completions" {
-			h.logger.Info("streaming OpenAI-compatible response", "path", path)
-			h.streamOpenAIResponse(w, resp, path)
+			logger.Info("streaming OpenAI-compatible response", "path", path)
+			h.streamOpenAIResponse(stream, resp, requestID, warnings, includeUsage, logger)
+		} else if path == ResponsesPath {
+			logger.Info("streaming Responses API response", "path", path)
+			h.streamResponsesAPI(stream, resp, requestID, logger)
+		} else if path == CompletionsPath {
+			// Legacy completions reuse the chat conversion, rewriting its chunks
+			logger.Info("streaming legacy completions response", "path", path)
+			stream.ResponseWriter = completionStreamWriter{ResponseWriter: w}
+			h.streamOpenAIResponse(stream, resp, requestID, warnings, includeUsage, logger)
 		} else {
 			// For SSE, we need to flush after each write
-			h.logger.Info("streaming native Anthropic response", "path", path)
-			h.streamResponse(w, resp)
+			logger.Info("streaming native Anthropic response", "path", path)
+			h.streamResponse(stream, resp, logger)
 		}
 	} else {
 		// For OpenAI endpoints, transform response back
-		if path == "/v1/chat/completions" {
+		if path == "/v1/chat/completions" || path == ResponsesPath || path == CompletionsPath {
 			respBody, err := io.ReadAll(resp.Body)
 			if err != nil {
 				h.writeError(w, http.StatusInternalServerError, "Failed to read response", err.Error())
 				return
 			}
 			
+			// A 2xx carrying an error body is reported with the error's own status
+			// instead of as a successful completion
+			status := resp.StatusCode
+			if status >= 200 && status < 300 {
+				if errorType, ok := anthropicErrorBody(respBody); ok {
+					status = anthropicErrorStatus(errorType)
+					logger.Warn("upstream returned an error body with a success status",
+						"upstream_status", resp.StatusCode, "error_type", errorType, "status", status)
+				}
+			}
+			
 			// Transform Anthropic response to OpenAI format
-			transformedResp, err := h.config.Transformer.TransformResponseBody(respBody, path)
+			responseID := requestid.ChatCompletionID(requestID)
+			if path == ResponsesPath {
+				responseID = requestid.ResponseID(requestID)
+			} else if path == CompletionsPath {
+				responseID = requestid.CompletionID(requestID)
+			}
+			transformedResp, err := h.config.Transformer.TransformResponseBodyWithID(respBody, path, responseID)
 			if err != nil {
-				// If transformation fails, return original
+				// If transformation fails, return original, with the status of an error body
 				// Copy headers excluding Content-Length
 				for key, values := range resp.Header {
 					if strings.ToLower(key) != "content-length" {
@@ -245,11 +747,26 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 						}
 					}
 				}
-				w.WriteHeader(resp.StatusCode)
+				w.WriteHeader(status)
 				w.Write(respBody)
 				return
 			}
 			
+			if status >= 200 && status < 300 && path == "/v1/chat/completions" {
+				processed, empty, err := h.config.EmptyResponse.applyToResponse(transformedResp)
+				if empty {
+					logger.Warn("upstream returned an empty response", "mode", h.config.EmptyResponse)
+					h.writeEmptyResponse(w)
+					return
+				}
+				if err == nil {
+					transformedResp = processed
+				}
+			}
+			if status >= 200 && status < 300 {
+				transformedResp = addResponseWarnings(transformedResp, warnings)
+			}
+			
 			// Copy headers excluding Content-Length and Content-Encoding
 			for key, values := range resp.Header {
 				if strings.ToLower(key) != "content-length" && strings.ToLower(key) != "content-encoding" {
@@ -260,7 +777,7 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 			
 			// Write status code
-			w.WriteHeader(resp.StatusCode)
+			w.WriteHeader(status)
 			
 			// Write transformed response (Go will set correct Content-Length)
 			w.Write(transformedResp)
@@ -282,16 +799,16 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // streamResponse handles Server-Sent Events streaming
-func (h *ProxyHandler) streamResponse(w http.ResponseWriter, resp *http.Response) {
-	flusher, ok := w.(http.Flusher)
+func (h *ProxyHandler) streamResponse(w *deferredStreamWriter, resp *http.Response, logger *slog.Logger) {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
 	if !ok {
-		h.logger.Warn("response writer does not support flushing, falling back to copy")
+		logger.Warn("response writer does not support flushing, falling back to copy")
 		// Fallback to regular copy if flusher not available
 		io.Copy(w, resp.Body)
 		return
 	}
 	
-	h.logger.Debug("starting native SSE streaming")
+	logger.Debug("starting native SSE streaming")
 	
 	// Create a custom writer that flushes after each write
 	buf := make([]byte, 4096)
@@ -300,47 +817,65 @@ func (h *ProxyHandler) streamResponse(w http.ResponseWriter, resp *http.Response
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
 			if _, writeErr := w.Write(buf[:n]); writeErr != nil {
-				h.logger.Error("error writing to response", "error", writeErr)
+				logger.Error("error writing to response", "error", writeErr)
 				return
 			}
 			flusher.Flush()
 			bytesStreamed += n
-			h.logger.Debug("streamed chunk", "bytes", n, "total_bytes", bytesStreamed)
+			logger.Debug("streamed chunk", "bytes", n, "total_bytes", bytesStreamed)
 		}
 		if err != nil {
-			if err != io.EOF {
-				h.logger.Error("error reading from upstream", "error", err)
+			if !w.Committed() {
+				logger.Error("upstream stream ended before sending any data", "error", err)
+				h.writeError(w.ResponseWriter, http.StatusBadGateway, "api_error", "Upstream stream ended before sending any data")
+			} else if err != io.EOF {
+				logger.Error("error reading from upstream", "error", err)
 			} else {
-				h.logger.Debug("streaming completed", "total_bytes", bytesStreamed)
+				logger.Debug("streaming completed", "total_bytes", bytesStreamed)
 			}
 			return
 		}
 	}
 }
 
-// streamOpenAIResponse converts Anthropic SSE to OpenAI SSE format
-func (h *ProxyHandler) streamOpenAIResponse(w http.ResponseWriter, resp *http.Response, path string) {
-	flusher, ok := w.(http.Flusher)
+// streamOpenAIResponse converts Anthropic SSE to OpenAI SSE format, ending a completed
+// stream with a usage chunk when includeUsage is set and a chunk carrying any warnings
+func (h *ProxyHandler) streamOpenAIResponse(w *deferredStreamWriter, resp *http.Response, requestID string, warnings []string, includeUsage bool, logger *slog.Logger) {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
 	if !ok {
-		h.logger.Warn("response writer does not support flushing for OpenAI streaming")
+		logger.Warn("response writer does not support flushing for OpenAI streaming")
 		// Fallback to regular streaming if flusher not available
-		h.streamResponse(w, resp)
+		h.streamResponse(w, resp, logger)
 		return
 	}
 	
-	h.logger.Debug("starting OpenAI SSE conversion")
+	logger.Debug("starting OpenAI SSE conversion")
 	
-	// Generate message ID and timestamp for consistency
-	messageID := "chatcmpl-" + generateRandomID()
+	// Every chunk shares the request's completion ID and the stream's start time
+	messageID := requestid.ChatCompletionID(requestID)
 	created := time.Now().Unix()
 	model := "claude-3-5-sonnet-20241022" // Default model
 	
-	h.logger.Debug("OpenAI SSE session",
+	logger.Debug("OpenAI SSE session",
 		"message_id", messageID,
 		"created", created,
 		"default_model", model,
 	)
 	
+	converter := NewSSEConverter(messageID, model, created, logger)
+	if h.config.Transformer.trimWhitespace {
+		converter.EnableWhitespaceTrim()
+	}
+	if h.config.Transformer.repairToolArgs {
+		converter.EnableToolArgumentsRepair()
+	}
+	if h.config.Transformer.contentFilterFinish {
+		converter.EnableContentFilterFinishReason()
+	}
+	if includeUsage {
+		converter.EnableUsageChunk()
+	}
+	
 	scanner := bufio.NewScanner(resp.Body)
 	var currentEvent string
 	eventCount := 0
@@ -350,7 +885,7 @@ func (h *ProxyHandler) streamOpenAIResponse(w http.ResponseWriter, resp *http.Re
 		
 		if strings.HasPrefix(line, "event: ") {
 			currentEvent = strings.TrimPrefix(line, "event: ")
-			h.logger.Debug("SSE event received", "event", currentEvent)
+			logger.Debug("SSE event received", "event", currentEvent)
 		} else if strings.HasPrefix(line, "data: ") {
 			data := strings.TrimPrefix(line, "data: ")
 			
@@ -361,17 +896,36 @@ func (h *ProxyHandler) streamOpenAIResponse(w http.ResponseWriter, resp *http.Re
 					if msg, ok := msgData["message"].(map[string]interface{}); ok {
 						if m, ok := msg["model"].(string); ok {
 							model = m
-							h.logger.Debug("extracted model from message_start", "model", model)
+							converter.SetModel(model)
+							logger.Debug("extracted model from message_start", "model", model)
 						}
 					}
 				}
 			}
 			
+			// Append the truncation notice ahead of the finish chunk when configured
+			if currentEvent == "message_delta" {
+				if notice := h.config.Transformer.postProcess.streamingNoticeChunk(data, messageID, model, created); notice != "" {
+					if _, writeErr := w.Write([]byte(notice)); writeErr != nil {
+						logger.Error("failed to write truncation notice", "error", writeErr)
+						return
+					}
+					flusher.Flush()
+				}
+			}
+			
+			// Before any output the client still expects a plain HTTP response
+			if currentEvent == "error" && !w.Committed() {
+				logger.Warn("upstream error event before any stream output", "data", data)
+				h.writeStreamErrorEvent(w.ResponseWriter, data)
+				return
+			}
+			
 			// Convert the SSE event
-			converted, err := ConvertAnthropicSSEToOpenAIWithLogger(currentEvent, data, messageID, model, created, h.logger)
+			converted, err := converter.Convert(currentEvent, data)
 			if err == nil && converted != "" {
 				eventCount++
-				h.logger.Debug("converted SSE event",
+				logger.Debug("converted SSE event",
 					"event_type", currentEvent,
 					"event_count", eventCount,
 					"output_length", len(converted),
@@ -379,44 +933,64 @@ func (h *ProxyHandler) streamOpenAIResponse(w http.ResponseWriter, resp *http.Re
 				
 				n, writeErr := w.Write([]byte(converted))
 				if writeErr != nil {
-					h.logger.Error("failed to write converted event", "error", writeErr)
+					logger.Error("failed to write converted event", "error", writeErr)
 					return
 				}
 				flusher.Flush()
-				h.logger.Debug("flushed SSE event", "bytes_written", n)
+				logger.Debug("flushed SSE event", "bytes_written", n)
+				
+				// An upstream error ends the stream without a [DONE] marker
+				if currentEvent == "error" {
+					logger.Warn("upstream error event ended the OpenAI stream", "data", data)
+					return
+				}
 			} else if err != nil {
-				h.logger.Error("failed to convert SSE event", "event", currentEvent, "error", err)
+				logger.Error("failed to convert SSE event", "event", currentEvent, "error", err)
 			}
 		}
 	}
 	
+	// A stream that failed or ended before producing any output is reported as a
+	// gateway error instead of an empty 200 stream
+	if !w.Committed() {
+		logger.Error("upstream stream ended before sending any data", "error", scanner.Err())
+		h.writeError(w.ResponseWriter, http.StatusBadGateway, "api_error", "Upstream stream ended before sending any data")
+		return
+	}
+	
 	// Check for scanner errors
 	if err := scanner.Err(); err != nil {
-		h.logger.Error("scanner error during SSE streaming", "error", err)
+		logger.Error("scanner error during SSE streaming", "error", err)
 		// The connection might have been closed by the client
 		return
 	}
 	
-	h.logger.Info("SSE streaming completed, sending [DONE] marker", "total_events", eventCount)
+	if chunk := converter.UsageChunk(); chunk != "" {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			logger.Error("failed to write usage chunk", "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+	
+	if chunk := warningsChunk(messageID, model, created, warnings); chunk != "" {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			logger.Error("failed to write warnings chunk", "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+	
+	logger.Info("SSE streaming completed, sending [DONE] marker", "total_events", eventCount)
 	
 	// Send the [DONE] marker to properly close the OpenAI SSE stream
 	n, err := w.Write([]byte("data: [DONE]\n\n"))
 	if err != nil {
-		h.logger.Error("failed to write [DONE] marker", "error", err)
+		logger.Error("failed to write [DONE] marker", "error", err)
 		return
 	}
 	flusher.Flush()
-	h.logger.Debug("sent [DONE] marker", "bytes_written", n)
-}
-
-// generateRandomID generates a random ID for OpenAI format
-func generateRandomID() string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, 29)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(b)
+	logger.Debug("sent [DONE] marker", "bytes_written", n)
 }
 
 // writeError writes an error response in Anthropic's error format
@@ -434,58 +1008,130 @@ func (h *ProxyHandler) writeError(w http.ResponseWriter, statusCode int, errorTy
 	json.NewEncoder(w).Encode(errorResp)
 }
 
-// setCORSHeaders sets CORS headers for all responses
-func (h *ProxyHandler) setCORSHeaders(w http.ResponseWriter, r *http.Request) {
-	origin := r.Header.Get("Origin")
-	if origin == "" {
-		origin = "*"
-	}
-	
-	w.Header().Set("Access-Control-Allow-Origin", origin)
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Set("Access-Control-Max-Age", "3600")
+// writeValidationError writes an OpenAI invalid_request_error listing every schema violation
+func (h *ProxyHandler) writeValidationError(w http.ResponseWriter, err *RequestValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":       "invalid_request_error",
+			"message":    err.Error(),
+			"param":      nil,
+			"code":       "schema_validation_failed",
+			"violations": err.Violations,
+		},
+	})
+}
+
+// DefaultMaxHeaderBytes is the largest request header block accepted by default,
+// well above real API clients and far below Go's 1 MB default
+const DefaultMaxHeaderBytes = 64 << 10
+
+// maxHeaderBytes returns the configured header size limit
+func (c *ProxyConfig) maxHeaderBytes() int {
+	if c.MaxHeaderBytes > 0 {
+		return c.MaxHeaderBytes
+	}
+	return DefaultMaxHeaderBytes
 }
 
-// handleCORS handles CORS preflight requests
-func (h *ProxyHandler) handleCORS(w http.ResponseWriter, r *http.Request) {
-	h.setCORSHeaders(w, r)
-	w.WriteHeader(http.StatusNoContent)
+// socketMode returns the permissions of the Unix socket
+func (c *ProxyConfig) socketMode() os.FileMode {
+	if c.SocketMode != 0 {
+		return c.SocketMode
+	}
+	return DefaultSocketMode
 }
 
 // ProxyServer wraps the handler with additional server functionality
 type ProxyServer struct {
 	handler *ProxyHandler
 	server  *http.Server
+	
+	// maxConnections caps simultaneous client connections (0 = unlimited)
+	maxConnections int
+	
+	// redirectPlainHTTP sends plain HTTP requests to the TLS port to https://
+	redirectPlainHTTP bool
+	
+	// socket and socketMode replace the TCP address with a Unix domain socket
+	socket     string
+	socketMode os.FileMode
+	
+	// conns and cancelRequests let Shutdown drain, count and cancel requests
+	conns          *connTracker
+	cancelRequests context.CancelFunc
+	
+	// reload holds the parts whose settings Reload swaps
+	reload *reloadTargets
 }
 
 // NewProxyServer creates a new proxy server with health endpoints
 func NewProxyServer(config *ProxyConfig, addr string, storage auth.StorageBackend) *ProxyServer {
 	proxyHandler := NewProxyHandler(config)
-	healthHandler := NewHealthHandler(storage)
-	mux := CreateMux(proxyHandler, healthHandler, config.TokenProvider, config.UpstreamURL)
+	healthHandler := NewHealthHandlerForAccount(storage, config.Account)
+	healthHandler.SetProxyAuth(newLocalKeyGate(config.LocalAPIKeys) != nil)
+	mux, reload := createMux(proxyHandler, healthHandler, config)
 	
-	return &ProxyServer{
-		handler: proxyHandler,
+	server := &ProxyServer{
+		handler:        proxyHandler,
+		reload:            reload,
+		maxConnections:    config.MaxConnections,
+		redirectPlainHTTP: config.TLS != nil && config.PlainHTTP == PlainHTTPRedirect,
+		socket:            config.Socket,
+		socketMode:        config.socketMode(),
 		server: &http.Server{
-			Addr:         addr,
-			Handler:      mux,
-			ReadTimeout:  30 * time.Second,
-			WriteTimeout: config.Timeout + 10*time.Second, // Slightly more than request timeout
-			IdleTimeout:  120 * time.Second,
+			Addr:           addr,
+			Handler:        mux,
+			TLSConfig:      config.TLS,
+			ReadTimeout:    30 * time.Second,
+			WriteTimeout:   config.longestDeadline() + 10*time.Second, // Slightly more than any request deadline
+			IdleTimeout:    120 * time.Second,
+			MaxHeaderBytes: config.maxHeaderBytes(), // Larger header blocks get 431
 		},
 	}
+	server.trackRequests()
+	return server
 }
 
-// Start starts the proxy server
+// Start starts the proxy server on its Unix socket, or else its TCP address
 func (s *ProxyServer) Start() error {
-	return s.server.ListenAndServe()
+	if s.socket != "" {
+		listener, err := ListenUnix(s.socket, s.socketMode)
+		if err != nil {
+			return err
+		}
+		return s.Serve(listener)
+	}
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves on listener, accepting at most the configured number of connections
+// at a time (connections beyond the limit wait in the listen backlog), over TLS when
+// configured. The upstream connection is warmed up in the background first when enabled.
+func (s *ProxyServer) Serve(listener net.Listener) error {
+	if s.maxConnections > 0 {
+		listener = newLimitListener(listener, s.maxConnections)
+	}
+	if s.handler != nil && s.handler.config.WarmupUpstream {
+		s.handler.warmup()
+	}
+	if s.server.TLSConfig == nil {
+		return s.server.Serve(listener)
+	}
+	if s.redirectPlainHTTP {
+		listener = newPlainHTTPListener(listener)
+	}
+	return s.server.ServeTLS(listener, "", "")
 }
 
-// Stop gracefully stops the proxy server
+// Stop gracefully stops the proxy server, like Shutdown
 func (s *ProxyServer) Stop(timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	return s.server.Shutdown(ctx)
+	_, err := s.Shutdown(timeout)
+	return err
 }
\ No newline at end of file
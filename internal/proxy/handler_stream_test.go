@@ -0,0 +1,257 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamChatCompletion sends a streaming chat completion through a proxy to the upstream
+func streamChatCompletion(t *testing.T, upstreamURL string) *helpers.OpenAIStream {
+	t.Helper()
+
+	handler := NewProxyHandler(&ProxyConfig{
+		UpstreamURL:   upstreamURL,
+		TokenProvider: &mockTokenProvider{token: "test-token"},
+		Transformer:   NewRequestTransformer(),
+	})
+
+	body := `{"model":"claude-sonnet-4-20250514","stream":true,"messages":[{"role":"user","content":"Hello"}]}`
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	return helpers.ParseOpenAIStream(t, w.Body.String())
+}
+
+func TestProxyHandler_StreamingErrors(t *testing.T) {
+	t.Run("should complete a healthy stream", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Deltas: []string{"Hello", ", world"},
+		})
+
+		stream := streamChatCompletion(t, upstream.URL)
+
+		helpers.AssertStreamCompleted(t, stream, "Hello, world")
+	})
+
+	t.Run("should forward an upstream error event after partial output", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Deltas:       []string{"Partial"},
+			Failure:      helpers.StreamErrorEvent,
+			ErrorType:    "api_error",
+			ErrorMessage: "Internal server error",
+		})
+
+		stream := streamChatCompletion(t, upstream.URL)
+
+		helpers.AssertStreamErrored(t, stream, "Partial", "server_error")
+	})
+
+	t.Run("should not mark a dropped upstream stream as done", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Deltas:  []string{"Partial", " output"},
+			Failure: helpers.StreamAbruptClose,
+		})
+
+		stream := streamChatCompletion(t, upstream.URL)
+
+		helpers.AssertStreamTruncated(t, stream, "Partial output")
+	})
+}
+
+func TestProxyHandler_StreamingErrorsBeforeFirstByte(t *testing.T) {
+	// serveStream sends a streaming request for path through a proxy to the upstream
+	serveStream := func(t *testing.T, upstreamURL, path string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstreamURL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		body := `{"model":"claude-sonnet-4-20250514","max_tokens":10,"stream":true,"messages":[{"role":"user","content":"Hello"}]}`
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should return an HTTP error status for an error event before any output", func(t *testing.T) {
+		// Arrange
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Failure:      helpers.StreamErrorFirst,
+			ErrorType:    "overloaded_error",
+			ErrorMessage: "Overloaded",
+		})
+
+		// Act
+		w := serveStream(t, upstream.URL, "/v1/chat/completions")
+
+		// Assert
+		assert.Equal(t, 529, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get("X-Accel-Buffering"), "stream headers must not be sent")
+
+		var response struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "overloaded_error", response.Error.Type)
+		assert.Equal(t, "Overloaded", response.Error.Message)
+		assert.NotContains(t, w.Body.String(), "data:")
+	})
+
+	t.Run("should map the error type to its status", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Failure:   helpers.StreamErrorFirst,
+			ErrorType: "invalid_request_error",
+		})
+
+		w := serveStream(t, upstream.URL, "/v1/chat/completions")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should return a gateway error for an empty OpenAI stream", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Failure: helpers.StreamEmpty})
+
+		w := serveStream(t, upstream.URL, "/v1/chat/completions")
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.NotContains(t, w.Body.String(), "[DONE]")
+	})
+
+	t.Run("should return a gateway error for an empty native stream", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Failure: helpers.StreamEmpty})
+
+		w := serveStream(t, upstream.URL, "/v1/messages")
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("should keep errors after the first byte in the stream", func(t *testing.T) {
+		// Arrange
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Failure: helpers.StreamErrorEvent,
+		})
+
+		// Act
+		w := serveStream(t, upstream.URL, "/v1/chat/completions")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no", w.Header().Get("X-Accel-Buffering"))
+		stream := helpers.ParseOpenAIStream(t, w.Body.String())
+		helpers.AssertStreamErrored(t, stream, "", "overloaded_error")
+	})
+}
+
+func TestStreamCreatedTimestamp(t *testing.T) {
+	t.Run("should reuse the created value given at stream start for every chunk", func(t *testing.T) {
+		// Arrange
+		const created = int64(1719331200) // Far from now, so a recomputed value would differ
+		converter := NewSSEConverter("chatcmpl-1", "claude-sonnet-4-20250514", created, nil)
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","usage":{"input_tokens":5,"output_tokens":0}}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		}
+
+		// Act
+		var body strings.Builder
+		for _, event := range events {
+			chunk, err := converter.Convert(event[0], event[1])
+			require.NoError(t, err)
+			body.WriteString(chunk)
+		}
+		body.WriteString(warningsChunk("chatcmpl-1", "claude-sonnet-4-20250514", created, []string{"temperature 1.5 clamped to 1"}))
+
+		// Assert
+		stream := helpers.ParseOpenAIStream(t, body.String())
+		require.NotEmpty(t, stream.Chunks)
+		for _, chunk := range stream.Chunks {
+			assert.Equal(t, float64(created), chunk["created"], "chunk %v", chunk)
+		}
+	})
+
+	t.Run("should send one created value across a proxied stream", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{
+			Deltas: []string{"One", " two", " three"},
+		})
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:      upstream.URL,
+			TokenProvider:    &mockTokenProvider{token: "test-token"},
+			Transformer:      NewRequestTransformer(),
+			ResponseWarnings: true,
+		})
+		body := `{"model":"claude-sonnet-4-20250514","stream":true,"temperature":1.5,"messages":[{"role":"user","content":"Count"}]}`
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+
+		stream := helpers.ParseOpenAIStream(t, w.Body.String())
+		require.Greater(t, len(stream.Chunks), 3)
+		created := stream.Chunks[0]["created"]
+		require.NotNil(t, created)
+		for _, chunk := range stream.Chunks {
+			assert.Equal(t, created, chunk["created"])
+		}
+	})
+}
+
+func TestProxyHandler_StreamClientDisconnect(t *testing.T) {
+	t.Run("should cancel the upstream stream when the client disconnects", func(t *testing.T) {
+		// Arrange
+		upstreamURL, calls, cancelled := newHeldStreamUpstream(t, make(chan struct{}))
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstreamURL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		ctx, disconnect := context.WithCancel(context.Background())
+		body := `{"model":"claude-sonnet-4-20250514","stream":true,"messages":[{"role":"user","content":"Hello"}]}`
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		served := make(chan struct{})
+
+		// Act
+		go func() {
+			defer close(served)
+			handler.ServeHTTP(w, req)
+		}()
+		require.Eventually(t, func() bool { return atomic.LoadInt32(calls) == 1 }, 2*time.Second, 5*time.Millisecond)
+		disconnect()
+
+		// Assert
+		select {
+		case <-cancelled:
+		case <-time.After(2 * time.Second):
+			t.Fatal("upstream stream was not cancelled")
+		}
+		select {
+		case <-served:
+		case <-time.After(2 * time.Second):
+			t.Fatal("handler did not return after the client disconnected")
+		}
+	})
+}
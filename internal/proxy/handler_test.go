@@ -220,6 +220,252 @@ func TestProxyHandler(t *testing.T) {
 		assert.True(t, strings.HasSuffix(strings.TrimSpace(body), "data: [DONE]"))
 	})
 	
+	t.Run("shares the request ID across all OpenAI streaming chunks", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			
+			events := []string{
+				"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-3-opus-20240229\"}}\n\n",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n\n",
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n\n",
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"}}\n\n",
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+			}
+			for _, event := range events {
+				w.Write([]byte(event))
+			}
+		}))
+		defer upstream.Close()
+		
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		
+		bodyBytes, _ := json.Marshal(map[string]interface{}{
+			"model":    "gpt-4",
+			"stream":   true,
+			"messages": []map[string]interface{}{{"role": "user", "content": "Hello"}},
+		})
+		req := httptest.NewRequest("POST", "/v1/chat/completions", bytes.NewReader(bodyBytes))
+		w := httptest.NewRecorder()
+		
+		handler.ServeHTTP(w, req)
+		
+		requestID := w.Header().Get("X-Request-Id")
+		require.NotEmpty(t, requestID)
+		
+		var ids []string
+		for _, line := range strings.Split(w.Body.String(), "\n") {
+			if !strings.HasPrefix(line, "data: {") {
+				continue
+			}
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk))
+			ids = append(ids, chunk["id"].(string))
+		}
+		
+		require.Greater(t, len(ids), 2)
+		for _, id := range ids {
+			assert.Equal(t, "chatcmpl-"+requestID, id)
+		}
+	})
+	
+	t.Run("uses the request ID for non-streaming OpenAI responses", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_123","type":"message","model":"claude-3-opus-20240229","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		defer upstream.Close()
+		
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		
+		bodyBytes, _ := json.Marshal(map[string]interface{}{
+			"model":    "gpt-4",
+			"messages": []map[string]interface{}{{"role": "user", "content": "Hello"}},
+		})
+		req := httptest.NewRequest("POST", "/v1/chat/completions", bytes.NewReader(bodyBytes))
+		w := httptest.NewRecorder()
+		
+		handler.ServeHTTP(w, req)
+		
+		requestID := w.Header().Get("X-Request-Id")
+		require.NotEmpty(t, requestID)
+		
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "chatcmpl-"+requestID, response["id"])
+	})
+	
+	t.Run("propagates the client's request ID through the mux to the upstream", func(t *testing.T) {
+		var upstreamID string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			upstreamID = r.Header.Get("X-Request-Id")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_123","type":"message","model":"claude-3-opus-20240229","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		defer upstream.Close()
+		
+		var accessLog bytes.Buffer
+		config := &ProxyConfig{
+			UpstreamURL:     upstream.URL,
+			TokenProvider:   &mockTokenProvider{token: "test-token"},
+			Transformer:     NewRequestTransformer(),
+			AccessLogFormat: AccessLogJSON,
+			AccessLog:       &accessLog,
+		}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+		
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"gpt-4","messages":[{"role":"user","content":"Hello"}]}`))
+		req.Header.Set("X-Request-Id", "trace-42_a.b")
+		w := httptest.NewRecorder()
+		
+		mux.ServeHTTP(w, req)
+		
+		assert.Equal(t, "trace-42_a.b", w.Header().Get("X-Request-Id"))
+		assert.Equal(t, "trace-42_a.b", upstreamID)
+		assert.Contains(t, w.Body.String(), `"id":"chatcmpl-trace-42_a.b"`)
+		assert.Contains(t, accessLog.String(), `"request_id":"trace-42_a.b"`)
+	})
+	
+	t.Run("rejects streams beyond the per-client limit", func(t *testing.T) {
+		release := make(chan struct{})
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-release
+			w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+		}))
+		defer upstream.Close()
+		
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:         upstream.URL,
+			TokenProvider:       &mockTokenProvider{token: "test-token"},
+			Transformer:         NewRequestTransformer(),
+			MaxStreamsPerClient: 2,
+		})
+		
+		newStreamRequest := func(remoteAddr string) *http.Request {
+			req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"model":"claude-3-opus-20240229","stream":true}`))
+			req.RemoteAddr = remoteAddr
+			return req
+		}
+		
+		// Open streams up to the limit and keep them open
+		done := make(chan *httptest.ResponseRecorder, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, newStreamRequest("10.0.0.1:1234"))
+				done <- w
+			}()
+		}
+		assert.Eventually(t, func() bool {
+			return handler.streams.Active("ip:10.0.0.1") == 2
+		}, time.Second, 10*time.Millisecond)
+		
+		// A third stream from the same client is rejected
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newStreamRequest("10.0.0.1:5678"))
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), "rate_limit_error")
+		
+		// Other clients are not affected
+		go func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newStreamRequest("10.0.0.2:1234"))
+			done <- w
+		}()
+		assert.Eventually(t, func() bool {
+			return handler.streams.Active("ip:10.0.0.2") == 1
+		}, time.Second, 10*time.Millisecond)
+		
+		// Finishing the streams frees the slots
+		close(release)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, (<-done).Code)
+		}
+		assert.Equal(t, 0, handler.streams.Active("ip:10.0.0.1"))
+		assert.Equal(t, 0, handler.streams.Active("ip:10.0.0.2"))
+	})
+	
+	t.Run("returns a valid OpenAI completion for refused requests", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"I can't help with that."}],"stop_reason":"refusal","usage":{"input_tokens":10,"output_tokens":6}}`))
+		}))
+		defer upstream.Close()
+		
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		
+		bodyBytes, _ := json.Marshal(map[string]interface{}{
+			"model":    "claude-sonnet-4-20250514",
+			"messages": []map[string]interface{}{{"role": "user", "content": "Something disallowed"}},
+		})
+		req := httptest.NewRequest("POST", "/v1/chat/completions", bytes.NewReader(bodyBytes))
+		w := httptest.NewRecorder()
+		
+		handler.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "chat.completion", response["object"])
+		assert.NotContains(t, response, "error")
+		
+		choice := response["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "stop", choice["finish_reason"])
+		assert.Contains(t, choice, "content_filter_results")
+		assert.Equal(t, "I can't help with that.", choice["message"].(map[string]interface{})["refusal"])
+	})
+	
+	t.Run("echoes the routed model for claude-auto requests", func(t *testing.T) {
+		var upstreamModel string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			upstreamModel, _ = body["model"].(string)
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": "msg_1", "type": "message", "role": "assistant", "model": upstreamModel,
+				"content":     []map[string]interface{}{{"type": "text", "text": "Hi"}},
+				"stop_reason": "end_turn",
+				"usage":       map[string]interface{}{"input_tokens": 5, "output_tokens": 1},
+			})
+		}))
+		defer upstream.Close()
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(autoRequest(10, "")))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, DefaultAutoSmallModel, upstreamModel)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, DefaultAutoSmallModel, response["model"])
+	})
+
 	t.Run("handles token provider errors", func(t *testing.T) {
 		handler := NewProxyHandler(&ProxyConfig{
 			UpstreamURL:   "http://example.com",
@@ -0,0 +1,232 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// maxImageBytes is the largest image Anthropic accepts, and the most fetched per URL
+	maxImageBytes = 5 << 20
+	// imageFetchTimeout bounds the download of one remote image
+	imageFetchTimeout = 15 * time.Second
+)
+
+// imageMediaTypes are the image formats Anthropic accepts
+var imageMediaTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ImageError reports an OpenAI image part that cannot be sent to Anthropic
+type ImageError struct {
+	Param  string // Where the image appeared, e.g. "messages[1].content[0]"
+	Reason string
+	Code   string // OpenAI error code, e.g. "invalid_image_url"
+}
+
+func (e *ImageError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Reason, e.Param)
+}
+
+// convertContentParts translates the content parts of OpenAI message i to Anthropic
+// content blocks. image_url parts become image blocks: data URLs are decoded into
+// base64 sources, remote URLs become url sources for an ImageFetcher to inline.
+// Other parts, such as text, pass through unchanged.
+func convertContentParts(parts []interface{}, i int) ([]interface{}, error) {
+	converted := make([]interface{}, 0, len(parts))
+	for j, part := range parts {
+		partMap, ok := part.(map[string]interface{})
+		if !ok || partMap["type"] != "image_url" {
+			converted = append(converted, part)
+			continue
+		}
+		block, err := imageBlock(partMap["image_url"], fmt.Sprintf("messages[%d].content[%d]", i, j))
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, block)
+	}
+	return converted, nil
+}
+
+// imageBlock converts the image_url of an OpenAI image part, either {"url": ...} or
+// the bare URL some clients send, to an Anthropic image block
+func imageBlock(imageURL interface{}, param string) (map[string]interface{}, error) {
+	rawURL, _ := imageURL.(string)
+	if imageMap, ok := imageURL.(map[string]interface{}); ok {
+		rawURL, _ = imageMap["url"].(string)
+	}
+
+	if strings.HasPrefix(rawURL, "data:") {
+		mediaType, data, err := decodeImageDataURL(rawURL)
+		if err != nil {
+			return nil, &ImageError{Param: param, Reason: err.Error(), Code: "invalid_image_url"}
+		}
+		return base64ImageBlock(mediaType, data), nil
+	}
+
+	if u, err := url.Parse(rawURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return map[string]interface{}{
+			"type":   "image",
+			"source": map[string]interface{}{"type": "url", "url": rawURL},
+		}, nil
+	}
+	return nil, &ImageError{Param: param, Reason: "image_url must be a data URL or an http(s) URL", Code: "invalid_image_url"}
+}
+
+// decodeImageDataURL returns the media type and base64 data of a data URL such as
+// "data:image/png;base64,iVBOR...", checking that the data decodes
+func decodeImageDataURL(dataURL string) (mediaType, data string, err error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok {
+		return "", "", errors.New("malformed image data URL")
+	}
+	mediaType, encoding, _ := strings.Cut(header, ";")
+	mediaType = strings.ToLower(mediaType)
+	if encoding != "base64" {
+		return "", "", errors.New("image data URLs must be base64 encoded")
+	}
+	if !imageMediaTypes[mediaType] {
+		return "", "", fmt.Errorf("unsupported image type %q (want image/jpeg, image/png, image/gif or image/webp)", mediaType)
+	}
+	if _, err := base64.StdEncoding.DecodeString(data); err != nil {
+		return "", "", errors.New("image data URL is not valid base64")
+	}
+	return mediaType, data, nil
+}
+
+// base64ImageBlock returns an Anthropic image block with inline data
+func base64ImageBlock(mediaType, data string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "image",
+		"source": map[string]interface{}{
+			"type":       "base64",
+			"media_type": mediaType,
+			"data":       data,
+		},
+	}
+}
+
+// ImageFetcher downloads the remote images of translated OpenAI requests, so they
+// reach Anthropic inline
+type ImageFetcher struct {
+	client *http.Client
+}
+
+// NewImageFetcher returns a fetcher downloading with client, or with a default client
+// when client is nil
+func NewImageFetcher(client *http.Client) *ImageFetcher {
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &ImageFetcher{client: client}
+}
+
+// inlineRemoteImages replaces the url image sources of a translated OpenAI request
+// with the downloaded data. Without a fetcher, remote images are rejected with an
+// ImageError.
+func (f *ImageFetcher) inlineRemoteImages(ctx context.Context, body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return body, nil
+	}
+
+	changed := false
+	messages, _ := request["messages"].([]interface{})
+	for i, msg := range messages {
+		msgMap, _ := msg.(map[string]interface{})
+		blocks, _ := msgMap["content"].([]interface{})
+		for j, block := range blocks {
+			blockMap, _ := block.(map[string]interface{})
+			source, _ := blockMap["source"].(map[string]interface{})
+			if blockMap["type"] != "image" || source["type"] != "url" {
+				continue
+			}
+			rawURL, _ := source["url"].(string)
+			param := fmt.Sprintf("messages[%d].content[%d]", i, j)
+			if f == nil {
+				return nil, &ImageError{
+					Param:  param,
+					Reason: "remote image URLs are not fetched by this proxy; send the image as a base64 data URL",
+					Code:   "image_url_fetch_disabled",
+				}
+			}
+
+			mediaType, data, err := f.fetch(ctx, rawURL)
+			if err != nil {
+				return nil, &ImageError{Param: param, Reason: "failed to fetch image: " + err.Error(), Code: "invalid_image_url"}
+			}
+			blocks[j] = base64ImageBlock(mediaType, data)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return body, nil
+	}
+	return json.Marshal(request)
+}
+
+// fetch downloads an image, returning its media type and base64 data
+func (f *ImageFetcher) fetch(ctx context.Context, rawURL string) (mediaType, data string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, imageFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("%s returned %s", rawURL, resp.Status)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return "", "", err
+	}
+	if len(image) > maxImageBytes {
+		return "", "", fmt.Errorf("image is larger than %d MB", maxImageBytes>>20)
+	}
+
+	// Trust the Content-Type when it names an image format, else sniff the data
+	mediaType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !imageMediaTypes[mediaType] {
+		mediaType = http.DetectContentType(image)
+	}
+	if !imageMediaTypes[mediaType] {
+		return "", "", fmt.Errorf("unsupported image type %q", mediaType)
+	}
+	return mediaType, base64.StdEncoding.EncodeToString(image), nil
+}
+
+// writeImageError writes the 400 for a request with an unusable image
+func (h *ProxyHandler) writeImageError(w http.ResponseWriter, err *ImageError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": err.Error(),
+			"param":   err.Param,
+			"code":    err.Code,
+		},
+	})
+}
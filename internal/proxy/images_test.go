@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngBytes is the start of a PNG file, enough for content sniffing
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func imageRequest(url string) string {
+	return `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":[` +
+		`{"type":"text","text":"What is in this picture?"},` +
+		`{"type":"image_url","image_url":{"url":"` + url + `","detail":"high"}}]}]}`
+}
+
+// userContent returns the content blocks of the first message of an Anthropic request
+func userContent(t *testing.T, body []byte) []interface{} {
+	t.Helper()
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &request))
+	messages := request["messages"].([]interface{})
+	return messages[0].(map[string]interface{})["content"].([]interface{})
+}
+
+func TestConvertOpenAIToAnthropic_Images(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString(pngBytes)
+
+	t.Run("should convert data URLs to base64 image blocks next to text", func(t *testing.T) {
+		// Act
+		converted, err := ConvertOpenAIToAnthropic([]byte(imageRequest("data:image/png;base64," + data)))
+
+		// Assert
+		require.NoError(t, err)
+		content := userContent(t, converted)
+		require.Len(t, content, 2)
+		assert.Equal(t, map[string]interface{}{"type": "text", "text": "What is in this picture?"}, content[0])
+		assert.Equal(t, map[string]interface{}{
+			"type": "image",
+			"source": map[string]interface{}{
+				"type":       "base64",
+				"media_type": "image/png",
+				"data":       data,
+			},
+		}, content[1])
+	})
+
+	t.Run("should accept a bare image_url string", func(t *testing.T) {
+		body := `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":[{"type":"image_url","image_url":"data:image/jpeg;base64,` + data + `"}]}]}`
+
+		converted, err := ConvertOpenAIToAnthropic([]byte(body))
+
+		require.NoError(t, err)
+		source := userContent(t, converted)[0].(map[string]interface{})["source"].(map[string]interface{})
+		assert.Equal(t, "image/jpeg", source["media_type"])
+	})
+
+	t.Run("should keep remote URLs as url sources for the fetcher", func(t *testing.T) {
+		converted, err := ConvertOpenAIToAnthropic([]byte(imageRequest("https://example.com/cat.png")))
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"type":   "image",
+			"source": map[string]interface{}{"type": "url", "url": "https://example.com/cat.png"},
+		}, userContent(t, converted)[1])
+	})
+
+	t.Run("should reject unusable image URLs", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			url    string
+			reason string
+		}{
+			{"unsupported media type", "data:image/tiff;base64," + data, "unsupported image type"},
+			{"not base64 encoded", "data:image/png," + data, "must be base64 encoded"},
+			{"invalid base64", "data:image/png;base64,not-base64!", "not valid base64"},
+			{"malformed data URL", "data:image/png;base64", "malformed"},
+			{"other scheme", "file:///etc/passwd", "data URL or an http(s) URL"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ConvertOpenAIToAnthropic([]byte(imageRequest(tt.url)))
+
+				var imageErr *ImageError
+				require.True(t, errors.As(err, &imageErr), "got %v", err)
+				assert.Equal(t, "messages[0].content[1]", imageErr.Param)
+				assert.Equal(t, "invalid_image_url", imageErr.Code)
+				assert.Contains(t, imageErr.Reason, tt.reason)
+			})
+		}
+	})
+}
+
+func TestProxyHandler_RemoteImages(t *testing.T) {
+	setup := func(t *testing.T, fetcher *ImageFetcher) (*ProxyHandler, *int32, *[]byte) {
+		var upstreamCalls int32
+		var upstreamBody []byte
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+			upstreamBody, _ = io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"A cat"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":2}}`))
+		}))
+		t.Cleanup(upstream.Close)
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			ImageFetcher:  fetcher,
+		})
+		return handler, &upstreamCalls, &upstreamBody
+	}
+
+	errorResponse := func(t *testing.T, w *httptest.ResponseRecorder) (param, code, message string) {
+		var response struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+				Param   string `json:"param"`
+				Code    string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "invalid_request_error", response.Error.Type)
+		return response.Error.Param, response.Error.Code, response.Error.Message
+	}
+
+	t.Run("should reject remote image URLs when fetching is disabled", func(t *testing.T) {
+		// Arrange
+		handler, upstreamCalls, _ := setup(t, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(imageRequest("https://example.com/cat.png"))))
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+		param, code, message := errorResponse(t, w)
+		assert.Equal(t, "messages[0].content[1]", param)
+		assert.Equal(t, "image_url_fetch_disabled", code)
+		assert.Contains(t, message, "base64 data URL")
+	})
+
+	t.Run("should fetch remote images and send them inline", func(t *testing.T) {
+		// Arrange
+		images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(pngBytes)
+		}))
+		defer images.Close()
+		handler, _, upstreamBody := setup(t, NewImageFetcher(images.Client()))
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(imageRequest(images.URL+"/cat.png"))))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		content := userContent(t, *upstreamBody)
+		require.Len(t, content, 2)
+		assert.Equal(t, "What is in this picture?", content[0].(map[string]interface{})["text"])
+		assert.Equal(t, base64ImageBlock("image/png", base64.StdEncoding.EncodeToString(pngBytes)), content[1])
+	})
+
+	t.Run("should reject images that cannot be fetched", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+			message string
+		}{
+			{"not found", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) }, "404"},
+			{"not an image", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("<html></html>")) }, "unsupported image type"},
+			{"too large", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "image/png")
+				w.Write(make([]byte, maxImageBytes+1))
+			}, "larger than 5 MB"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Arrange
+				images := httptest.NewServer(tt.handler)
+				defer images.Close()
+				handler, upstreamCalls, _ := setup(t, NewImageFetcher(images.Client()))
+				w := httptest.NewRecorder()
+
+				// Act
+				handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(imageRequest(images.URL+"/cat.png"))))
+
+				// Assert
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+				_, code, message := errorResponse(t, w)
+				assert.Equal(t, "invalid_image_url", code)
+				assert.Contains(t, message, tt.message)
+			})
+		}
+	})
+
+	t.Run("should leave url image sources of native messages requests alone", func(t *testing.T) {
+		// Arrange
+		handler, upstreamCalls, upstreamBody := setup(t, nil)
+		body := `{"model":"claude-3-5-sonnet-20241022","max_tokens":100,"messages":[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"https://example.com/cat.png"}}]}]}`
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(upstreamCalls))
+		source := userContent(t, *upstreamBody)[0].(map[string]interface{})["source"].(map[string]interface{})
+		assert.Equal(t, "url", source["type"])
+	})
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"net"
+	"sync"
+)
+
+// limitListener accepts at most n simultaneous connections. Beyond the limit Accept
+// waits for a connection to close, so new clients queue in the listen backlog instead
+// of being served. It behaves like golang.org/x/net/netutil.LimitListener, which this
+// module does not otherwise depend on.
+type limitListener struct {
+	net.Listener
+	sem       chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// newLimitListener limits l to n simultaneous connections
+func newLimitListener(l net.Listener, n int) net.Listener {
+	return &limitListener{
+		Listener: l,
+		sem:      make(chan struct{}, n),
+		done:     make(chan struct{}),
+	}
+}
+
+// acquire takes a connection slot, reporting false once the listener is closed
+func (l *limitListener) acquire() bool {
+	select {
+	case <-l.done:
+		return false
+	case l.sem <- struct{}{}:
+		return true
+	}
+}
+
+func (l *limitListener) release() {
+	<-l.sem
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	if !l.acquire() {
+		// Let the underlying listener report its closed error
+		conn, err := l.Listener.Accept()
+		if err == nil {
+			conn.Close()
+			err = net.ErrClosed
+		}
+		return nil, err
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	return &limitListenerConn{Conn: conn, release: l.release}, nil
+}
+
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitListenerConn frees its slot when closed
+type limitListenerConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitListenerConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
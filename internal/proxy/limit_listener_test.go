@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitListener(t *testing.T) {
+	t.Run("should hold connections beyond the limit until one closes", func(t *testing.T) {
+		// Arrange
+		inner, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listener := newLimitListener(inner, 2)
+		defer listener.Close()
+
+		var accepted int32
+		conns := make(chan net.Conn, 3)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				atomic.AddInt32(&accepted, 1)
+				conns <- conn
+			}
+		}()
+
+		// Act
+		for i := 0; i < 3; i++ {
+			client, err := net.Dial("tcp", inner.Addr().String())
+			require.NoError(t, err)
+			defer client.Close()
+		}
+
+		// Assert
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&accepted) == 2 }, time.Second, 5*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&accepted), "accepted past the limit")
+
+		(<-conns).Close()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&accepted) == 3 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should stop accepting once closed", func(t *testing.T) {
+		inner, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listener := newLimitListener(inner, 1)
+
+		require.NoError(t, listener.Close())
+		_, err = listener.Accept()
+
+		assert.Error(t, err)
+	})
+}
+
+func TestProxyServer_MaxConnections(t *testing.T) {
+	t.Run("should serve at most the configured number of connections at once", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		var active, peak int32
+		server := &ProxyServer{
+			maxConnections: 2,
+			server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&active, 1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+						break
+					}
+				}
+				<-release
+				atomic.AddInt32(&active, -1)
+			})},
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.Serve(listener)
+		defer server.Stop(time.Second)
+
+		// Act
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		done := make(chan struct{}, 4)
+		for i := 0; i < 4; i++ {
+			go func() {
+				if resp, err := client.Get("http://" + listener.Addr().String()); err == nil {
+					resp.Body.Close()
+				}
+				done <- struct{}{}
+			}()
+		}
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&active) == 2 }, time.Second, 5*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		for i := 0; i < 4; i++ {
+			<-done
+		}
+
+		// Assert
+		assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+	})
+}
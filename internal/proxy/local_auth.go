@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ml0-1337/claude-gate/internal/audit"
+)
+
+// localKeyGate checks the local API keys clients must send to use the proxy. Keys
+// are kept as SHA-256 digests, which also gives every comparison the same length.
+type localKeyGate struct {
+	digests [][sha256.Size]byte
+}
+
+// newLocalKeyGate returns a gate accepting the given keys, or nil when there are none:
+// the gate is off unless keys are configured
+func newLocalKeyGate(keys []string) *localKeyGate {
+	var digests [][sha256.Size]byte
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			digests = append(digests, sha256.Sum256([]byte(key)))
+		}
+	}
+	if len(digests) == 0 {
+		return nil
+	}
+	return &localKeyGate{digests: digests}
+}
+
+// valid reports whether key is one of the local keys. Every key is compared in
+// constant time, so the timing does not reveal which key came close.
+func (g *localKeyGate) valid(key string) bool {
+	digest := sha256.Sum256([]byte(key))
+	match := 0
+	for i := range g.digests {
+		match |= subtle.ConstantTimeCompare(digest[:], g.digests[i][:])
+	}
+	return match == 1
+}
+
+// requestAPIKey returns the key a client sent, as a bearer token or, for Anthropic
+// SDKs, in X-Api-Key
+func requestAPIKey(r *http.Request) string {
+	if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(key)
+	}
+	return strings.TrimSpace(r.Header.Get("X-Api-Key"))
+}
+
+// localKeyContextKey is the context key of the local API key requireLocalKey validated
+type localKeyContextKey struct{}
+
+// validatedLocalKey returns the local API key requireLocalKey accepted for the request,
+// or "" when local keys are off
+func validatedLocalKey(r *http.Request) string {
+	key, _ := r.Context().Value(localKeyContextKey{}).(string)
+	return key
+}
+
+// requireLocalKey refuses API requests without a valid local key with an OpenAI-style
+// 401. Only /v1/ paths are gated; health, metrics and probes stay open. The local key
+// never reaches Anthropic, since upstream requests get fresh OAuth headers.
+func requireLocalKey(keys []string, auditLog *audit.Logger, next http.Handler) http.Handler {
+	gate := newLocalKeyGate(keys)
+	if gate == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := requestAPIKey(r)
+		if key != "" && gate.valid(key) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), localKeyContextKey{}, key)))
+			return
+		}
+
+		message := "Invalid API key provided"
+		if key == "" {
+			message = "Missing API key: send your claude-gate key as 'Authorization: Bearer <key>'"
+		}
+		auditLog.Record(audit.LocalKeyRejected,
+			slog.String("key", "local"),
+			slog.String("key_fingerprint", audit.Fingerprint(key)),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("WWW-Authenticate", `Bearer realm="claude-gate"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"type":    "invalid_request_error",
+				"message": message,
+				"param":   nil,
+				"code":    "invalid_api_key",
+			},
+		})
+	})
+}
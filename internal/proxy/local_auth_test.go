@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireLocalKey(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	send := func(handler http.Handler, path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	keys := []string{"key-alice", "key-bob"}
+
+	t.Run("should accept every configured key as a bearer token", func(t *testing.T) {
+		handler := requireLocalKey(keys, nil, ok)
+
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/chat/completions", "Authorization", "Bearer key-alice").Code)
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/chat/completions", "Authorization", "Bearer key-bob").Code)
+	})
+
+	t.Run("should accept a key in X-Api-Key", func(t *testing.T) {
+		handler := requireLocalKey(keys, nil, ok)
+
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/messages", "X-Api-Key", "key-bob").Code)
+	})
+
+	t.Run("should reject an invalid key with an OpenAI-style 401", func(t *testing.T) {
+		// Arrange
+		handler := requireLocalKey(keys, nil, ok)
+
+		// Act
+		w := send(handler, "/v1/chat/completions", "Authorization", "Bearer key-mallory")
+
+		// Assert
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":{"type":"invalid_request_error","message":"Invalid API key provided","param":null,"code":"invalid_api_key"}}`, w.Body.String())
+		assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("should reject a missing key", func(t *testing.T) {
+		handler := requireLocalKey(keys, nil, ok)
+
+		w := send(handler, "/v1/models")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Missing API key")
+	})
+
+	t.Run("should reject a prefix of a valid key", func(t *testing.T) {
+		handler := requireLocalKey(keys, nil, ok)
+
+		assert.Equal(t, http.StatusUnauthorized, send(handler, "/v1/models", "Authorization", "Bearer key-ali").Code)
+	})
+
+	t.Run("should leave paths outside the API open", func(t *testing.T) {
+		handler := requireLocalKey(keys, nil, ok)
+
+		assert.Equal(t, http.StatusOK, send(handler, "/health").Code)
+		assert.Equal(t, http.StatusOK, send(handler, "/metrics").Code)
+	})
+
+	t.Run("should be disabled without keys", func(t *testing.T) {
+		handler := requireLocalKey([]string{"", " "}, nil, ok)
+
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/chat/completions").Code)
+	})
+
+	t.Run("should audit rejected keys by fingerprint", func(t *testing.T) {
+		// Arrange
+		var out bytes.Buffer
+		handler := requireLocalKey(keys, audit.New(&out), ok)
+
+		// Act
+		send(handler, "/v1/chat/completions", "Authorization", "Bearer key-mallory")
+
+		// Assert
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &event))
+		assert.Equal(t, string(audit.LocalKeyRejected), event["event"])
+		assert.Equal(t, "local", event["key"])
+		assert.Equal(t, audit.Fingerprint("key-mallory"), event["key_fingerprint"])
+		assert.NotContains(t, out.String(), "key-mallory")
+	})
+
+	t.Run("should not forward the local key upstream", func(t *testing.T) {
+		// Arrange
+		var authorization string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		defer upstream.Close()
+		config := &ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "oauth-token"},
+			Transformer:   NewRequestTransformer(),
+			LocalAPIKeys:  keys,
+		}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+		req := httptest.NewRequest("POST", "/v1/messages", bytes.NewReader([]byte(`{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Hi"}]}`)))
+		req.Header.Set("Authorization", "Bearer key-alice")
+		w := httptest.NewRecorder()
+
+		// Act
+		mux.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Bearer oauth-token", authorization)
+	})
+}
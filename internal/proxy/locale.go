@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// LocaleHeader is the request header naming the language responses should be written in
+const LocaleHeader = "X-Claude-Gate-Locale"
+
+// maxLocaleLength bounds the locale accepted from the header
+const maxLocaleLength = 64
+
+// localeLanguages names the languages of common locale codes
+var localeLanguages = map[string]string{
+	"ar": "Arabic", "cs": "Czech", "da": "Danish", "de": "German", "el": "Greek",
+	"en": "English", "es": "Spanish", "fi": "Finnish", "fr": "French", "he": "Hebrew",
+	"hi": "Hindi", "hu": "Hungarian", "id": "Indonesian", "it": "Italian", "ja": "Japanese",
+	"ko": "Korean", "nl": "Dutch", "no": "Norwegian", "pl": "Polish", "pt": "Portuguese",
+	"ro": "Romanian", "ru": "Russian", "sv": "Swedish", "th": "Thai", "tr": "Turkish",
+	"uk": "Ukrainian", "vi": "Vietnamese", "zh": "Chinese",
+	"pt-br": "Brazilian Portuguese", "zh-cn": "Simplified Chinese", "zh-tw": "Traditional Chinese",
+}
+
+// SetLocale sets the language every response is written in, unless a request names
+// its own with LocaleHeader (empty = no instruction)
+func (t *RequestTransformer) SetLocale(locale string) {
+	t.locale = locale
+}
+
+// localeLanguage returns the language for a locale code such as "fr" or "pt_BR", or
+// the locale itself when it is already a language name
+func localeLanguage(locale string) string {
+	code := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if language, ok := localeLanguages[code]; ok {
+		return language
+	}
+	if base, _, ok := strings.Cut(code, "-"); ok {
+		if language, ok := localeLanguages[base]; ok {
+			return language
+		}
+	}
+	return locale
+}
+
+// sanitizeLocale trims a client-supplied locale and rejects anything that is not a
+// short, single-line name
+func sanitizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if len(locale) > maxLocaleLength || strings.IndexFunc(locale, unicode.IsControl) >= 0 {
+		return ""
+	}
+	return locale
+}
+
+// ApplyLocale adds a "Respond in {language}" system instruction to an Anthropic
+// messages request, for the header locale or else the configured one. The instruction
+// joins the existing system prompt per the system merge strategy: its own block for
+// blocks, otherwise appended to the last block, never to the Claude Code prompt.
+func (t *RequestTransformer) ApplyLocale(body []byte, headerLocale string) ([]byte, error) {
+	locale := sanitizeLocale(headerLocale)
+	if locale == "" {
+		locale = t.locale
+	}
+	if locale == "" {
+		return body, nil
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return body, nil // Leave non-JSON bodies alone
+	}
+
+	instruction := "Respond in " + localeLanguage(locale) + "."
+	var system []interface{}
+	switch existing := data["system"].(type) {
+	case string:
+		system = []interface{}{map[string]interface{}{"type": "text", "text": existing}}
+	case []interface{}:
+		system = existing
+	}
+
+	separator := ""
+	switch t.systemMerge {
+	case SystemMergeNewline:
+		separator = "\n"
+	case SystemMergeSpace:
+		separator = " "
+	}
+
+	merged := false
+	if separator != "" && len(system) > 0 {
+		if last, ok := system[len(system)-1].(map[string]interface{}); ok && last["type"] == "text" {
+			if text, _ := last["text"].(string); text != ClaudeCodePrompt {
+				last["text"] = strings.TrimSpace(text) + separator + instruction
+				merged = true
+			}
+		}
+	}
+	if !merged {
+		system = append(system, map[string]interface{}{"type": "text", "text": instruction})
+	}
+	data["system"] = system
+
+	return json.Marshal(data)
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstreamSystem returns the system field of the request sent upstream
+func upstreamSystem(t *testing.T, transformer *RequestTransformer, path, body string, headers map[string]string) interface{} {
+	t.Helper()
+
+	var received []byte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+	}))
+	defer upstream.Close()
+
+	handler := NewProxyHandler(&ProxyConfig{
+		UpstreamURL:   upstream.URL,
+		TokenProvider: &mockTokenProvider{token: "test-token"},
+		Transformer:   transformer,
+	})
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(received, &request))
+	return request["system"]
+}
+
+// textBlocks builds the expected system text blocks
+func textBlocks(texts ...string) []interface{} {
+	blocks := make([]interface{}, 0, len(texts))
+	for _, text := range texts {
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+	}
+	return blocks
+}
+
+func TestRequestTransformer_Locale(t *testing.T) {
+	const chatRequest = `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hi"}]}`
+
+	t.Run("should inject the header locale as its own system block", func(t *testing.T) {
+		system := upstreamSystem(t, NewRequestTransformer(), "/v1/chat/completions", chatRequest,
+			map[string]string{LocaleHeader: "fr"})
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Be brief.", "Respond in French."), system)
+	})
+
+	t.Run("should inject the configured locale", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+		transformer.SetLocale("ja")
+
+		// Act
+		system := upstreamSystem(t, transformer, "/v1/messages",
+			`{"model":"claude-3-5-sonnet-20241022","max_tokens":10,"messages":[{"role":"user","content":"Hi"}]}`, nil)
+
+		// Assert
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Respond in Japanese."), system)
+	})
+
+	t.Run("should prefer the header locale over the configured one", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetLocale("ja")
+
+		system := upstreamSystem(t, transformer, "/v1/chat/completions", chatRequest,
+			map[string]string{LocaleHeader: "pt_BR"})
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Be brief.", "Respond in Brazilian Portuguese."), system)
+	})
+
+	t.Run("should use a language name as given", func(t *testing.T) {
+		system := upstreamSystem(t, NewRequestTransformer(), "/v1/chat/completions", chatRequest,
+			map[string]string{LocaleHeader: "Esperanto"})
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Be brief.", "Respond in Esperanto."), system)
+	})
+
+	t.Run("should merge into the last system text with the newline strategy", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetSystemMergeStrategy(SystemMergeNewline)
+
+		system := upstreamSystem(t, transformer, "/v1/chat/completions", chatRequest,
+			map[string]string{LocaleHeader: "de"})
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Be brief.\nRespond in German."), system)
+	})
+
+	t.Run("should never merge into the Claude Code prompt", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetSystemMergeStrategy(SystemMergeSpace)
+
+		system := upstreamSystem(t, transformer, "/v1/chat/completions",
+			`{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}]}`,
+			map[string]string{LocaleHeader: "es"})
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Respond in Spanish."), system)
+	})
+
+	t.Run("should leave the system prompt alone without a locale", func(t *testing.T) {
+		system := upstreamSystem(t, NewRequestTransformer(), "/v1/chat/completions", chatRequest, nil)
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Be brief."), system)
+	})
+
+	t.Run("should ignore a malformed header locale", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetLocale("it")
+
+		system := upstreamSystem(t, transformer, "/v1/chat/completions", chatRequest,
+			map[string]string{LocaleHeader: strings.Repeat("x", maxLocaleLength+1)})
+
+		assert.Equal(t, textBlocks(ClaudeCodePrompt, "Be brief.", "Respond in Italian."), system)
+	})
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyServer_MaxHeaderBytes(t *testing.T) {
+	serve := func(t *testing.T, maxHeaderBytes int) string {
+		config := &ProxyConfig{
+			UpstreamURL:    "http://example.com",
+			TokenProvider:  &mockTokenProvider{token: "test-token"},
+			Transformer:    NewRequestTransformer(),
+			MaxHeaderBytes: maxHeaderBytes,
+		}
+		server := NewProxyServer(config, "127.0.0.1:0", nil)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.Serve(listener)
+		t.Cleanup(func() { server.Stop(time.Second) })
+		return "http://" + listener.Addr().String()
+	}
+	get := func(t *testing.T, url string, cookie string) int {
+		req, err := http.NewRequest("GET", url+"/v1/models", nil)
+		require.NoError(t, err)
+		req.Header.Set("Cookie", cookie)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("should reject headers over the default limit with 431", func(t *testing.T) {
+		// Arrange
+		url := serve(t, 0)
+
+		// Act
+		status := get(t, url, strings.Repeat("a", 2*DefaultMaxHeaderBytes))
+
+		// Assert
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, status)
+	})
+
+	t.Run("should accept headers within the limit", func(t *testing.T) {
+		url := serve(t, 0)
+
+		assert.Equal(t, http.StatusOK, get(t, url, strings.Repeat("a", 8<<10)))
+	})
+
+	t.Run("should apply a configured limit", func(t *testing.T) {
+		url := serve(t, 8<<10)
+
+		// Go allows 4 KB of slack above MaxHeaderBytes
+		assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, get(t, url, strings.Repeat("a", 16<<10)))
+	})
+}
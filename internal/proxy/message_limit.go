@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TooManyMessagesError reports a request with more messages than MaxMessages allows
+type TooManyMessagesError struct {
+	Count int
+	Max   int
+}
+
+func (e *TooManyMessagesError) Error() string {
+	return fmt.Sprintf("request has %d messages, more than the maximum of %d allowed by this proxy", e.Count, e.Max)
+}
+
+// checkMessageLimit rejects chat completion and messages requests with more than max
+// messages. Unlike context trimming nothing is dropped; the request fails as a whole.
+func checkMessageLimit(body []byte, max int) error {
+	var request struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil // Malformed bodies are reported by translation or the upstream
+	}
+	if len(request.Messages) > max {
+		return &TooManyMessagesError{Count: len(request.Messages), Max: max}
+	}
+	return nil
+}
+
+// writeTooManyMessages writes the 400 for a request over the message limit
+func (h *ProxyHandler) writeTooManyMessages(w http.ResponseWriter, err *TooManyMessagesError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": err.Error(),
+			"param":   "messages",
+			"code":    "too_many_messages",
+		},
+	})
+}
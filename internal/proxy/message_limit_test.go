@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conversation builds a chat completion request with n user messages
+func conversation(n int) string {
+	messages := make([]string, n)
+	for i := range messages {
+		messages[i] = fmt.Sprintf(`{"role":"user","content":"message %d"}`, i)
+	}
+	return `{"model":"claude-3-5-sonnet-20241022","max_tokens":10,"messages":[` + strings.Join(messages, ",") + `]}`
+}
+
+func TestProxyHandler_MaxMessages(t *testing.T) {
+	newHandler := func(t *testing.T, max int) (*ProxyHandler, *int32) {
+		var upstreamCalls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			MaxMessages:   max,
+		}), &upstreamCalls
+	}
+
+	t.Run("should reject a request over the limit before translation", func(t *testing.T) {
+		// Arrange
+		handler, upstreamCalls := newHandler(t, 3)
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(conversation(4)))
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+
+		var response struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+				Param   string `json:"param"`
+				Code    string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "invalid_request_error", response.Error.Type)
+		assert.Equal(t, "too_many_messages", response.Error.Code)
+		assert.Equal(t, "messages", response.Error.Param)
+		assert.Contains(t, response.Error.Message, "4 messages")
+	})
+
+	t.Run("should also limit native messages requests", func(t *testing.T) {
+		handler, upstreamCalls := newHandler(t, 3)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(conversation(5))))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+	})
+
+	t.Run("should accept a request at the limit", func(t *testing.T) {
+		handler, upstreamCalls := newHandler(t, 3)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(conversation(3))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(upstreamCalls))
+	})
+
+	t.Run("should not limit unless configured", func(t *testing.T) {
+		handler, upstreamCalls := newHandler(t, 0)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(conversation(200))))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(upstreamCalls))
+	})
+}
@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+)
+
+// metricsPaths are the routes counted under their own path label
+var metricsPaths = map[string]bool{
+	"/":                         true,
+	"/health":                   true,
+	"/metrics":                  true,
+	"/streams":                  true,
+	LivenessPath:                true,
+	ReadinessPath:               true,
+	PricingPath:                 true,
+	"/v1/messages":              true,
+	"/v1/messages/count_tokens": true,
+	ChatCompletionsPath:         true,
+	ResponsesPath:               true,
+	CompletionsPath:             true,
+	"/v1/models":                true,
+	BatchesPath:                 true,
+	DebugTranslatePath:          true,
+}
+
+// metricsPath returns the label a request path is counted under. Paths carrying IDs
+// are reduced to their route and anything unknown, such as passthrough paths, is
+// counted as "other" to keep metric cardinality bounded.
+func metricsPath(path string) string {
+	if metricsPaths[path] {
+		return path
+	}
+	if strings.HasPrefix(path, "/v1/models/") {
+		return "/v1/models/{model}"
+	}
+	if rest, ok := strings.CutPrefix(path, BatchesPath+"/"); ok {
+		if _, action, ok := strings.Cut(rest, "/"); ok && (action == "results" || action == "cancel") {
+			return BatchesPath + "/{id}/" + action
+		}
+		return BatchesPath + "/{id}"
+	}
+	return "other"
+}
+
+// metricsMiddleware counts every request served by next by route and status
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		metrics.RequestsTotal.WithLabelValues(metricsPath(r.URL.Path), strconv.Itoa(recorder.status)).Inc()
+	})
+}
+
+// metricsTransport records the latency and failures of upstream requests
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+// withMetrics returns a copy of client whose requests are recorded in the upstream
+// metrics. Wrapped around the retries, each request is observed once.
+func withMetrics(client *http.Client) *http.Client {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	observed := *client
+	observed.Transport = &metricsTransport{next: next}
+	return &observed
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		metrics.UpstreamErrors.WithLabelValues(transportErrorType(err)).Inc()
+		return resp, err
+	}
+	metrics.UpstreamDuration.WithLabelValues(metricsPath(req.URL.Path)).Observe(time.Since(start).Seconds())
+	if resp.StatusCode >= 400 {
+		metrics.UpstreamErrors.WithLabelValues(upstreamErrorType(resp.StatusCode)).Inc()
+	}
+	return resp, nil
+}
+
+// transportErrorType classifies an upstream request that got no response
+func transportErrorType(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "connection"
+	}
+}
+
+// upstreamErrorType maps an upstream error status to the Anthropic error type it
+// stands for, the reverse of anthropicErrorStatus
+func upstreamErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	}
+	if status >= 500 {
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/messages", "/v1/messages"},
+		{"/v1/chat/completions", "/v1/chat/completions"},
+		{"/healthz", "/healthz"},
+		{"/v1/models/pricing", "/v1/models/pricing"},
+		{"/v1/models/claude-3-5-haiku-20241022", "/v1/models/{model}"},
+		{"/v1/messages/batches", "/v1/messages/batches"},
+		{"/v1/messages/batches/msgbatch_01abc", "/v1/messages/batches/{id}"},
+		{"/v1/messages/batches/msgbatch_01abc/results", "/v1/messages/batches/{id}/results"},
+		{"/v1/messages/batches/msgbatch_01abc/other", "/v1/messages/batches/{id}"},
+		{"/v1/files/file_01abc", "other"},
+		{"/req_0123456789", "other"},
+	}
+	for _, tt := range tests {
+		t.Run("should label "+tt.path+" as "+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, metricsPath(tt.path))
+		})
+	}
+}
+
+func TestCreateMux_Metrics(t *testing.T) {
+	config := func(disable bool) *ProxyConfig {
+		return &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}, DisableMetrics: disable}
+	}
+
+	t.Run("should count requests by route and status", func(t *testing.T) {
+		// Arrange
+		mux := CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), config(false))
+		found := metrics.RequestsTotal.WithLabelValues(PricingPath, "200")
+		missing := metrics.RequestsTotal.WithLabelValues("other", "404")
+		foundBefore, missingBefore := testutil.ToFloat64(found), testutil.ToFloat64(missing)
+
+		// Act
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", PricingPath, nil))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/unknown/req_0123456789", nil))
+
+		// Assert
+		assert.Equal(t, foundBefore+1, testutil.ToFloat64(found))
+		assert.Equal(t, missingBefore+1, testutil.ToFloat64(missing))
+	})
+
+	t.Run("should serve the metrics in Prometheus format", func(t *testing.T) {
+		mux := CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), config(false))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "claude_gate_requests_total{")
+		assert.Contains(t, w.Body.String(), "claude_gate_active_streams")
+	})
+
+	t.Run("should remove the endpoint when disabled", func(t *testing.T) {
+		// Arrange
+		mux := CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), config(true))
+
+		// Act
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		root := httptest.NewRecorder()
+		mux.ServeHTTP(root, httptest.NewRequest("GET", "/", nil))
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response struct {
+			Endpoints map[string]interface{} `json:"endpoints"`
+		}
+		require.NoError(t, json.Unmarshal(root.Body.Bytes(), &response))
+		assert.NotContains(t, response.Endpoints, "metrics")
+		assert.Contains(t, response.Endpoints, "health")
+	})
+}
+
+func TestMetricsTransport(t *testing.T) {
+	t.Run("should count error responses by Anthropic error type", func(t *testing.T) {
+		// Arrange
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(529)
+		}))
+		defer upstream.Close()
+		overloaded := metrics.UpstreamErrors.WithLabelValues("overloaded_error")
+		before := testutil.ToFloat64(overloaded)
+
+		// Act
+		resp, err := withMetrics(upstream.Client()).Post(upstream.URL+"/v1/messages", "application/json", strings.NewReader("{}"))
+
+		// Assert
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, before+1, testutil.ToFloat64(overloaded))
+		assert.Positive(t, testutil.CollectAndCount(metrics.UpstreamDuration, "claude_gate_upstream_request_duration_seconds"))
+	})
+
+	t.Run("should count requests without a response as connection errors", func(t *testing.T) {
+		upstream := httptest.NewServer(http.NotFoundHandler())
+		upstream.Close()
+		connection := metrics.UpstreamErrors.WithLabelValues("connection")
+		before := testutil.ToFloat64(connection)
+
+		_, err := withMetrics(&http.Client{}).Get(upstream.URL + "/v1/models")
+
+		require.Error(t, err)
+		assert.Equal(t, before+1, testutil.ToFloat64(connection))
+	})
+
+	t.Run("should map error statuses to Anthropic error types", func(t *testing.T) {
+		assert.Equal(t, "rate_limit_error", upstreamErrorType(http.StatusTooManyRequests))
+		assert.Equal(t, "api_error", upstreamErrorType(http.StatusBadGateway))
+		assert.Equal(t, "invalid_request_error", upstreamErrorType(http.StatusUnprocessableEntity))
+	})
+}
@@ -0,0 +1,191 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MockMessageID is the id of every message returned in mock mode
+const MockMessageID = "msg_mock"
+
+// mockToken stands in for the OAuth token in mock mode; it never leaves the process
+type mockToken struct{}
+
+func (mockToken) GetAccessToken() (string, error) {
+	return "mock", nil
+}
+
+// mockTransport answers upstream requests in-process with canned, deterministic
+// responses so the proxy can be exercised without credentials or network access.
+// Messages echo the last user text, or return reply when it is set.
+type mockTransport struct {
+	reply string
+}
+
+// newMockTransport creates a transport that returns reply, or echoes the prompt if empty
+func newMockTransport(reply string) *mockTransport {
+	return &mockTransport{reply: reply}
+}
+
+// mockRequest is the part of an Anthropic messages request the mock looks at
+type mockRequest struct {
+	Model    string `json:"model"`
+	Stream   bool   `json:"stream"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+func (t *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	if req.Method != http.MethodPost || (path != "/v1/messages" && path != "/v1/messages/count_tokens") {
+		return mockJSONResponse(req, http.StatusNotFound, map[string]interface{}{
+			"type":  "error",
+			"error": map[string]interface{}{"type": "not_found_error", "message": "mock mode does not serve " + req.URL.Path},
+		}), nil
+	}
+
+	var parsed mockRequest
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return mockJSONResponse(req, http.StatusBadRequest, map[string]interface{}{
+			"type":  "error",
+			"error": map[string]interface{}{"type": "invalid_request_error", "message": "invalid JSON body: " + err.Error()},
+		}), nil
+	}
+
+	prompt := parsed.lastUserText()
+	inputTokens := mockTokenCount(prompt)
+	if path == "/v1/messages/count_tokens" {
+		return mockJSONResponse(req, http.StatusOK, map[string]interface{}{"input_tokens": inputTokens}), nil
+	}
+
+	reply := t.reply
+	if reply == "" {
+		reply = prompt
+	}
+	if parsed.Stream {
+		return mockStreamResponse(req, parsed.Model, reply, inputTokens), nil
+	}
+	return mockJSONResponse(req, http.StatusOK, map[string]interface{}{
+		"id":            MockMessageID,
+		"type":          "message",
+		"role":          "assistant",
+		"model":         parsed.Model,
+		"content":       []map[string]interface{}{{"type": "text", "text": reply}},
+		"stop_reason":   "end_turn",
+		"stop_sequence": nil,
+		"usage":         map[string]int{"input_tokens": inputTokens, "output_tokens": mockTokenCount(reply)},
+	}), nil
+}
+
+// lastUserText joins the text of the last user message
+func (r *mockRequest) lastUserText() string {
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role != "user" {
+			continue
+		}
+		var text string
+		if json.Unmarshal(r.Messages[i].Content, &text) == nil {
+			return text
+		}
+		var blocks []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		json.Unmarshal(r.Messages[i].Content, &blocks)
+		var parts []string
+		for _, block := range blocks {
+			if block.Type == "text" {
+				parts = append(parts, block.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// mockTokenCount is a fixed approximation of four characters per token
+func mockTokenCount(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// mockStreamResponse renders reply as an Anthropic event stream, one delta per word
+func mockStreamResponse(req *http.Request, model, reply string, inputTokens int) *http.Response {
+	var buf bytes.Buffer
+	event := func(name string, data map[string]interface{}) {
+		encoded, _ := json.Marshal(data)
+		fmt.Fprintf(&buf, "event: %s\ndata: %s\n\n", name, encoded)
+	}
+
+	event("message_start", map[string]interface{}{
+		"type": "message_start",
+		"message": map[string]interface{}{
+			"id":            MockMessageID,
+			"type":          "message",
+			"role":          "assistant",
+			"model":         model,
+			"content":       []interface{}{},
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage":         map[string]int{"input_tokens": inputTokens, "output_tokens": 0},
+		},
+	})
+	event("content_block_start", map[string]interface{}{
+		"type":          "content_block_start",
+		"index":         0,
+		"content_block": map[string]interface{}{"type": "text", "text": ""},
+	})
+	for _, chunk := range strings.SplitAfter(reply, " ") {
+		if chunk == "" {
+			continue
+		}
+		event("content_block_delta", map[string]interface{}{
+			"type":  "content_block_delta",
+			"index": 0,
+			"delta": map[string]interface{}{"type": "text_delta", "text": chunk},
+		})
+	}
+	event("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": 0})
+	event("message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]interface{}{"stop_reason": "end_turn", "stop_sequence": nil},
+		"usage": map[string]int{"output_tokens": mockTokenCount(reply)},
+	})
+	event("message_stop", map[string]interface{}{"type": "message_stop"})
+
+	return mockResponse(req, http.StatusOK, "text/event-stream", buf.Bytes())
+}
+
+func mockJSONResponse(req *http.Request, status int, body interface{}) *http.Response {
+	encoded, _ := json.Marshal(body)
+	return mockResponse(req, status, "application/json", encoded)
+}
+
+func mockResponse(req *http.Request, status int, contentType string, body []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
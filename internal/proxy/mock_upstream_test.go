@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockMode(t *testing.T) {
+	newHandler := func(reply string) *ProxyHandler {
+		// No token provider and an unreachable upstream: mock mode needs neither
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:  "http://127.0.0.1:1",
+			Transformer:  NewRequestTransformer(),
+			Mock:         true,
+			MockResponse: reply,
+		})
+	}
+	serve := func(handler *ProxyHandler, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("should echo the last user message in a chat completion", func(t *testing.T) {
+		// Arrange
+		handler := newHandler("")
+		body := `{"model":"gpt-4","messages":[{"role":"user","content":"first"},{"role":"assistant","content":"ok"},{"role":"user","content":"echo me please"}]}`
+
+		// Act
+		w := serve(handler, "/v1/chat/completions", body)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Choices, 1)
+		assert.Equal(t, "echo me please", response.Choices[0].Message.Content)
+		assert.Equal(t, "stop", response.Choices[0].FinishReason)
+		assert.Equal(t, 4, response.Usage.PromptTokens)
+		assert.Equal(t, 4, response.Usage.CompletionTokens)
+	})
+
+	t.Run("should return the configured reply for native messages", func(t *testing.T) {
+		handler := newHandler("Hello from the mock")
+		body := `{"model":"claude-3-5-sonnet-20241022","max_tokens":10,"messages":[{"role":"user","content":[{"type":"text","text":"anything"}]}]}`
+
+		w := serve(handler, "/v1/messages", body)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, MockMessageID, response["id"])
+		assert.Equal(t, "end_turn", response["stop_reason"])
+		content := response["content"].([]interface{})
+		assert.Equal(t, "Hello from the mock", content[0].(map[string]interface{})["text"])
+	})
+
+	t.Run("should stream the reply word by word", func(t *testing.T) {
+		handler := newHandler("one two three")
+		body := `{"model":"gpt-4","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+
+		w := serve(handler, "/v1/chat/completions", body)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+		var text strings.Builder
+		for _, line := range strings.Split(w.Body.String(), "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok || data == "[DONE]" {
+				continue
+			}
+			var chunk struct {
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+				} `json:"choices"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+			for _, choice := range chunk.Choices {
+				text.WriteString(choice.Delta.Content)
+			}
+		}
+		assert.Equal(t, "one two three", text.String())
+		assert.True(t, strings.HasSuffix(strings.TrimSpace(w.Body.String()), "data: [DONE]"))
+	})
+
+	t.Run("should be deterministic across requests", func(t *testing.T) {
+		handler := newHandler("")
+		body := `{"model":"claude-3-5-sonnet-20241022","max_tokens":10,"stream":true,"messages":[{"role":"user","content":"same every time"}]}`
+
+		first := serve(handler, "/v1/messages", body)
+		second := serve(handler, "/v1/messages", body)
+
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Contains(t, first.Body.String(), `"id":"msg_mock"`)
+	})
+
+	t.Run("should count tokens without an upstream", func(t *testing.T) {
+		handler := newHandler("")
+
+		w := serve(handler, "/v1/messages/count_tokens", `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"12345678"}]}`)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `{"input_tokens":2}`, w.Body.String())
+	})
+}
@@ -0,0 +1,35 @@
+package proxy
+
+import "strings"
+
+// ModelCapabilities describes the token limits of a model family
+type ModelCapabilities struct {
+	ContextWindow   int
+	MaxOutputTokens int
+}
+
+// modelFamilyCapabilities maps model family prefixes to their limits. More specific
+// prefixes must come before shorter ones that would also match.
+var modelFamilyCapabilities = []struct {
+	family       string
+	capabilities ModelCapabilities
+}{
+	{"claude-opus-4", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 32000}},
+	{"claude-sonnet-4", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 64000}},
+	{"claude-3-7-sonnet", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 64000}},
+	{"claude-3-5-sonnet", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 8192}},
+	{"claude-3-5-haiku", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 8192}},
+	{"claude-3-opus", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 4096}},
+	{"claude-3-sonnet", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 4096}},
+	{"claude-3-haiku", ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 4096}},
+}
+
+// LookupModelCapabilities returns the limits of the model's family
+func LookupModelCapabilities(model string) (ModelCapabilities, bool) {
+	for _, entry := range modelFamilyCapabilities {
+		if strings.HasPrefix(model, entry.family) {
+			return entry.capabilities, true
+		}
+	}
+	return ModelCapabilities{}, false
+}
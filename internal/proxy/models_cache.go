@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// modelsCacheFile is the on-disk form of the cached model list
+type modelsCacheFile struct {
+	FetchedAt time.Time     `json:"fetched_at"`
+	Models    []interface{} `json:"models"`
+}
+
+// SetModelsCache serves the live Anthropic model list, cached for ttl (0 = static list
+// only). With a non-empty path the cache is persisted there, and a cache left by a
+// previous run is served immediately on start, stale or not, while a refresh runs in
+// the background.
+func (h *ModelsHandler) SetModelsCache(ttl time.Duration, path string) {
+	h.mu.Lock()
+	h.ttl = ttl
+	h.cachePath = path
+	h.mu.Unlock()
+
+	if ttl > 0 && path != "" {
+		h.loadCache()
+	}
+}
+
+// cachedModels returns the model list to serve, or nil when live models are disabled
+// and nothing could be fetched
+func (h *ModelsHandler) cachedModels() []interface{} {
+	h.mu.RLock()
+	ttl, cache, fetchedAt := h.ttl, h.cache, h.fetchedAt
+	h.mu.RUnlock()
+
+	if ttl <= 0 {
+		return nil
+	}
+	if cache == nil {
+		return h.refresh()
+	}
+	if time.Since(fetchedAt) > ttl {
+		h.refreshInBackground()
+	}
+	return cache
+}
+
+// refresh fetches the live model list and stores it, returning nil on failure
+func (h *ModelsHandler) refresh() []interface{} {
+	models, err := h.fetchModelsFromAnthropic()
+	if err != nil {
+		slog.Warn("failed to fetch models from Anthropic", "error", err)
+		return nil
+	}
+	data, _ := models["data"].([]interface{})
+
+	h.mu.Lock()
+	h.cache = data
+	h.fetchedAt = time.Now()
+	fetchedAt, path := h.fetchedAt, h.cachePath
+	h.mu.Unlock()
+
+	if path != "" {
+		if err := saveModelsCache(path, modelsCacheFile{FetchedAt: fetchedAt, Models: data}); err != nil {
+			slog.Warn("failed to persist model cache", "path", path, "error", err)
+		}
+	}
+	return data
+}
+
+// refreshInBackground starts a refresh unless one is already running
+func (h *ModelsHandler) refreshInBackground() {
+	h.mu.Lock()
+	if h.refreshing {
+		h.mu.Unlock()
+		return
+	}
+	h.refreshing = true
+	h.mu.Unlock()
+
+	go func() {
+		defer func() {
+			h.mu.Lock()
+			h.refreshing = false
+			h.mu.Unlock()
+		}()
+		h.refresh()
+	}()
+}
+
+// loadCache restores a persisted model list. A missing or unreadable file is ignored;
+// a stale one is served until the background refresh it triggers completes.
+func (h *ModelsHandler) loadCache() {
+	data, err := os.ReadFile(h.cachePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("failed to read model cache", "path", h.cachePath, "error", err)
+		}
+		return
+	}
+
+	var file modelsCacheFile
+	if err := json.Unmarshal(data, &file); err != nil || len(file.Models) == 0 || file.FetchedAt.IsZero() {
+		slog.Warn("ignoring invalid model cache", "path", h.cachePath)
+		return
+	}
+
+	h.mu.Lock()
+	h.cache = file.Models
+	h.fetchedAt = file.FetchedAt
+	stale := time.Since(file.FetchedAt) > h.ttl
+	h.mu.Unlock()
+
+	if stale {
+		h.refreshInBackground()
+	}
+}
+
+// saveModelsCache writes the cache file atomically so a crash never leaves it half written
+func saveModelsCache(path string, file modelsCacheFile) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".models-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// copyModelList shallow-copies each model so per-request fields never touch the cache
+func copyModelList(models []interface{}) []interface{} {
+	copied := make([]interface{}, len(models))
+	for i, item := range models {
+		model, ok := item.(map[string]interface{})
+		if !ok {
+			copied[i] = item
+			continue
+		}
+		clone := make(map[string]interface{}, len(model)+2)
+		for key, value := range model {
+			clone[key] = value
+		}
+		copied[i] = clone
+	}
+	return copied
+}
@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelIDs returns the IDs of the models served by handler
+func modelIDs(t *testing.T, handler http.Handler) []string {
+	t.Helper()
+
+	var ids []string
+	for _, model := range fetchModels(t, handler) {
+		ids = append(ids, model["id"].(string))
+	}
+	return ids
+}
+
+// writeModelsCache persists a cache file holding the given model IDs
+func writeModelsCache(t *testing.T, path string, fetchedAt time.Time, ids ...string) {
+	t.Helper()
+
+	var models []interface{}
+	for _, id := range ids {
+		models = append(models, anthropicModelToOpenAI(id))
+	}
+	require.NoError(t, saveModelsCache(path, modelsCacheFile{FetchedAt: fetchedAt, Models: models}))
+}
+
+// newModelsUpstream serves an Anthropic model list with the given IDs once release is closed
+func newModelsUpstream(t *testing.T, release <-chan struct{}, ids ...string) (string, *int32) {
+	t.Helper()
+
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":[`)
+		for i, id := range ids {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"type":"model","id":%q}`, id)
+		}
+		fmt.Fprint(w, `],"has_more":false}`)
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream.URL, &calls
+}
+
+func TestModelsHandler_PersistentCache(t *testing.T) {
+	t.Run("should serve a stale persisted cache on start and refresh it in the background", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "models.json")
+		writeModelsCache(t, path, time.Now().Add(-2*time.Hour), "claude-cached")
+
+		release := make(chan struct{})
+		upstreamURL, calls := newModelsUpstream(t, release, "claude-fresh")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+
+		// Act
+		handler.SetModelsCache(time.Hour, path)
+
+		// Assert: the last-known list is served while the upstream is still answering
+		assert.Equal(t, []string{"claude-cached"}, modelIDs(t, handler))
+
+		close(release)
+		assert.Eventually(t, func() bool {
+			ids := modelIDs(t, handler)
+			return len(ids) == 1 && ids[0] == "claude-fresh"
+		}, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var file modelsCacheFile
+		require.NoError(t, json.Unmarshal(data, &file))
+		require.Len(t, file.Models, 1)
+		assert.Equal(t, "claude-fresh", file.Models[0].(map[string]interface{})["id"])
+		assert.WithinDuration(t, time.Now(), file.FetchedAt, time.Minute)
+	})
+
+	t.Run("should serve a fresh persisted cache without fetching", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "models.json")
+		writeModelsCache(t, path, time.Now().Add(-time.Minute), "claude-cached")
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, calls := newModelsUpstream(t, release, "claude-fresh")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+
+		handler.SetModelsCache(time.Hour, path)
+
+		assert.Equal(t, []string{"claude-cached"}, modelIDs(t, handler))
+		assert.Zero(t, atomic.LoadInt32(calls))
+	})
+
+	t.Run("should fetch and persist when there is no cache file", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "nested", "models.json")
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, calls := newModelsUpstream(t, release, "claude-a", "claude-b")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+		handler.SetModelsCache(time.Hour, path)
+
+		// Act
+		first := modelIDs(t, handler)
+		second := modelIDs(t, handler)
+
+		// Assert
+		assert.Equal(t, []string{"claude-a", "claude-b"}, first)
+		assert.Equal(t, first, second)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls), "the second request should be a cache hit")
+		assert.FileExists(t, path)
+	})
+
+	t.Run("should ignore a corrupt cache file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "models.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"fetched_at":`), 0600))
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, _ := newModelsUpstream(t, release, "claude-fresh")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+
+		handler.SetModelsCache(time.Hour, path)
+
+		assert.Equal(t, []string{"claude-fresh"}, modelIDs(t, handler))
+	})
+
+	t.Run("should fall back to the static list when the fetch fails", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer upstream.Close()
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstream.URL)
+
+		handler.SetModelsCache(time.Hour, "")
+
+		assert.Contains(t, modelIDs(t, handler), "claude-sonnet-4-20250514")
+	})
+}
+
+func TestNewModelsHandler_LiveByDefault(t *testing.T) {
+	t.Run("should fetch the live list and serve it from cache within the TTL", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, calls := newModelsUpstream(t, release, "claude-live-1")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+
+		// Act
+		first := modelIDs(t, handler)
+		second := modelIDs(t, handler)
+
+		// Assert
+		assert.Equal(t, []string{"claude-live-1"}, first)
+		assert.Equal(t, first, second)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		assert.Equal(t, DefaultModelsCacheTTL, handler.ttl)
+	})
+
+	t.Run("should serve only the static list with a zero TTL", func(t *testing.T) {
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, calls := newModelsUpstream(t, release, "claude-live-1")
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, upstreamURL, 0)
+
+		assert.Contains(t, modelIDs(t, handler), "claude-sonnet-4-20250514")
+		assert.Zero(t, atomic.LoadInt32(calls))
+	})
+
+	t.Run("should fall back to the static list when the live fetch fails", func(t *testing.T) {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer upstream.Close()
+
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstream.URL)
+
+		assert.Contains(t, modelIDs(t, handler), "claude-sonnet-4-20250514")
+	})
+}
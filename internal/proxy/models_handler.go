@@ -1,10 +1,15 @@
 package proxy
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,34 +18,286 @@ type ModelsHandler struct {
 	tokenProvider TokenProvider
 	upstreamURL   string
 	httpClient    *http.Client
+	timeout       time.Duration
+	
+	// Retries of failed model list fetches, enabled by SetRetries
+	retries    int
+	retryDelay time.Duration
+	
+	// betas are sent besides the OAuth beta, set by SetBetas
+	betas []string
+	
+	// anthropicVersion is the anthropic-version header of model list fetches
+	// (empty = DefaultAnthropicVersion), set by SetAnthropicVersion
+	anthropicVersion string
+	
+	// includeCapabilities adds non-standard context_window and max_output_tokens fields
+	includeCapabilities bool
+	
+	// emptyListNote explains an empty model list in a non-standard x_note field
+	emptyListNote bool
+	
+	// allowedModels limits the served list to these model IDs (empty = all); guarded
+	// by mu, as a reload may replace it while serving
+	allowedModels     []string
+	allowlistWarnOnce sync.Once
+	
+	// Live model list cache, enabled by SetModelsCache
+	mu         sync.RWMutex
+	ttl        time.Duration
+	cachePath  string
+	cache      []interface{}
+	fetchedAt  time.Time
+	refreshing bool
 }
 
-// NewModelsHandler creates a new models handler
+// DefaultModelsCacheTTL is how long NewModelsHandler caches the live model list
+const DefaultModelsCacheTTL = time.Hour
+
+// DefaultModelsTimeout bounds a live model list fetch
+const DefaultModelsTimeout = 30 * time.Second
+
+// NewModelsHandler creates a models handler serving the live Anthropic model list,
+// cached for DefaultModelsCacheTTL
 func NewModelsHandler(tokenProvider TokenProvider, upstreamURL string) *ModelsHandler {
+	return NewModelsHandlerWithTTL(tokenProvider, upstreamURL, DefaultModelsCacheTTL)
+}
+
+// NewModelsHandlerWithTTL creates a models handler that caches the live model list for
+// ttl. The built-in list is served when a fetch fails, and always when ttl is 0.
+func NewModelsHandlerWithTTL(tokenProvider TokenProvider, upstreamURL string, ttl time.Duration) *ModelsHandler {
 	return &ModelsHandler{
 		tokenProvider: tokenProvider,
 		upstreamURL:   upstreamURL,
-		httpClient:    &http.Client{Timeout: 30 * time.Second},
+		httpClient:    &http.Client{},
+		timeout:       DefaultModelsTimeout,
+		ttl:           ttl,
 	}
 }
 
-// ServeHTTP handles the models endpoint
+// SetBetas sets the anthropic-beta values sent besides the OAuth beta when fetching
+// the model list
+func (h *ModelsHandler) SetBetas(betas []string) {
+	h.betas = betas
+}
+
+// SetAnthropicVersion sets the anthropic-version header sent when fetching the model
+// list, normally the default version of messages requests
+func (h *ModelsHandler) SetAnthropicVersion(version string) {
+	h.anthropicVersion = version
+}
+
+// SetIncludeCapabilities toggles the context_window and max_output_tokens model fields.
+// They are off by default for strict OpenAI compatibility.
+func (h *ModelsHandler) SetIncludeCapabilities(include bool) {
+	h.includeCapabilities = include
+}
+
+// SetEmptyListNote toggles the x_note field explaining why the model list is empty.
+// The explanation is always logged; the field is off by default for strict OpenAI
+// compatibility.
+func (h *ModelsHandler) SetEmptyListNote(enabled bool) {
+	h.emptyListNote = enabled
+}
+
+// SetAllowedModels limits /v1/models to the given model IDs, matched exactly. Both
+// the built-in and the live list are filtered; an empty allowlist serves every model.
+func (h *ModelsHandler) SetAllowedModels(models []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.allowedModels = models
+}
+
+// allowlist returns the model IDs set by SetAllowedModels
+func (h *ModelsHandler) allowlist() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.allowedModels
+}
+
+// SetUpstreamProxy routes model list requests through proxyURL instead of the proxy
+// from the environment
+func (h *ModelsHandler) SetUpstreamProxy(proxyURL *url.URL) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = upstreamProxyFunc(proxyURL)
+	h.httpClient = &http.Client{Transport: transport}
+}
+
+// SetHTTPClient sends model list requests with client, e.g. one shared with the
+// proxy handler. The fetch timeout still applies on top of the client's own.
+func (h *ModelsHandler) SetHTTPClient(client *http.Client) {
+	h.httpClient = client
+}
+
+// SetTimeout bounds each live model list fetch; 0 removes the limit
+func (h *ModelsHandler) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
+}
+
+// SetRetries retries model list fetches failing with a connection error, 429 or 5xx
+// up to retries times, with exponential backoff from baseDelay and honoring
+// Retry-After
+func (h *ModelsHandler) SetRetries(retries int, baseDelay time.Duration) {
+	h.retries = retries
+	h.retryDelay = baseDelay
+}
+
+// ServeHTTP handles the models endpoint: the list at /v1/models and single models
+// at /v1/models/{id}
 func (h *ModelsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Handle CORS
-	if r.Method == "OPTIONS" {
-		setCORSHeadersStandalone(w, r)
-		w.WriteHeader(http.StatusNoContent)
+	if id, ok := strings.CutPrefix(r.URL.Path, "/v1/models/"); ok && id != "" {
+		h.serveModel(w, id)
 		return
 	}
 	
-	setCORSHeadersStandalone(w, r)
+	w.Header().Set("Content-Type", "application/json")
+	data := h.servedModels()
 	
-	// Anthropic's /v1/models endpoint doesn't support OAuth authentication
-	// So we use a comprehensive static list of OAuth-accessible models
-	models := h.getOAuthModels()
+	var note string
+	if len(data) == 0 {
+		reason := h.emptyListReason()
+		slog.Warn("serving an empty model list", "reason", reason)
+		if h.emptyListNote {
+			note = reason
+		}
+	}
+	writeModelListWithNote(w, data, note)
+}
+
+// filterAllowedModels keeps the models whose ID is on the allowlist
+func (h *ModelsHandler) filterAllowedModels(list interface{}, allowlist []string) []interface{} {
+	data, _ := list.([]interface{})
+	allowed := make(map[string]bool, len(allowlist))
+	for _, id := range allowlist {
+		allowed[id] = true
+	}
 	
+	filtered := []interface{}{}
+	for _, item := range data {
+		model, _ := item.(map[string]interface{})
+		if id, _ := model["id"].(string); allowed[id] {
+			filtered = append(filtered, item)
+		}
+	}
+	if len(filtered) == 0 && len(data) > 0 {
+		h.allowlistWarnOnce.Do(func() {
+			slog.Warn("the models allowlist matches none of the available models",
+				"allowed", allowlist, "available", len(data))
+		})
+	}
+	return filtered
+}
+
+// servedModels returns the models this proxy lists: the cached live list when
+// enabled, otherwise the static list of OAuth-accessible models
+func (h *ModelsHandler) servedModels() []interface{} {
+	models := h.getOAuthModels()
+	if cached := h.cachedModels(); cached != nil {
+		models = map[string]interface{}{"object": "list", "data": copyModelList(cached)}
+	}
+	if allowlist := h.allowlist(); len(allowlist) > 0 {
+		models["data"] = h.filterAllowedModels(models["data"], allowlist)
+	}
+	if h.includeCapabilities {
+		addModelCapabilities(models)
+	}
+	data, _ := models["data"].([]interface{})
+	return data
+}
+
+// serveModel writes the one served model with the given ID, or an OpenAI
+// model_not_found error
+func (h *ModelsHandler) serveModel(w http.ResponseWriter, id string) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(models)
+	for _, item := range h.servedModels() {
+		if model, ok := item.(map[string]interface{}); ok && model["id"] == id {
+			json.NewEncoder(w).Encode(model)
+			return
+		}
+	}
+	
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": fmt.Sprintf("The model '%s' does not exist", id),
+			"param":   "model",
+			"code":    "model_not_found",
+		},
+	})
+}
+
+// emptyListReason explains why no models are available
+func (h *ModelsHandler) emptyListReason() string {
+	if len(h.allowlist()) > 0 {
+		return "the models allowlist matches none of the available models"
+	}
+	
+	h.mu.RLock()
+	live := h.ttl > 0
+	h.mu.RUnlock()
+	
+	if live {
+		return "Anthropic returned no models for this account; the OAuth token may lack the scope to list models. " +
+			"Disable the live model list (models cache TTL 0) to serve the built-in list instead."
+	}
+	return "no models are available on this proxy"
+}
+
+// writeModelList writes an OpenAI model list one model at a time, so the encoded
+// response is never held in memory as a whole
+func writeModelList(w io.Writer, models []interface{}) error {
+	return writeModelListWithNote(w, models, "")
+}
+
+// writeModelListWithNote writes a model list like writeModelList, with an x_note
+// field ahead of the data when note is set
+func writeModelListWithNote(w io.Writer, models []interface{}, note string) error {
+	prefix := `{"object":"list",`
+	if note != "" {
+		noteJSON, err := json.Marshal(note)
+		if err != nil {
+			return err
+		}
+		prefix += `"x_note":` + string(noteJSON) + `,`
+	}
+	if _, err := io.WriteString(w, prefix+`"data":[`); err != nil {
+		return err
+	}
+	
+	for i, model := range models {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		modelJSON, err := json.Marshal(model)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(modelJSON); err != nil {
+			return err
+		}
+	}
+	
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// addModelCapabilities annotates each model in an OpenAI models list with its limits
+func addModelCapabilities(models map[string]interface{}) {
+	data, _ := models["data"].([]interface{})
+	for _, item := range data {
+		model, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		modelID, _ := model["id"].(string)
+		if capabilities, ok := LookupModelCapabilities(modelID); ok {
+			model["context_window"] = capabilities.ContextWindow
+			model["max_output_tokens"] = capabilities.MaxOutputTokens
+		}
+	}
 }
 
 // fetchModelsFromAnthropic fetches available models from Anthropic's API
@@ -52,19 +309,30 @@ func (h *ModelsHandler) fetchModelsFromAnthropic() (map[string]interface{}, erro
 	}
 	
 	// Create request to Anthropic's models endpoint
-	req, err := http.NewRequest("GET", h.upstreamURL+"/v1/models", nil)
+	ctx := context.Background()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", h.upstreamURL+"/v1/models", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	// Set headers
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	req.Header.Set("anthropic-version", "2023-06-01")
-	req.Header.Set("anthropic-beta", "oauth-2025-04-20")
+	version := h.anthropicVersion
+	if version == "" {
+		version = DefaultAnthropicVersion
+	}
+	req.Header.Set("anthropic-version", version)
+	req.Header.Set("anthropic-beta", oauthBeta)
+	addBetaHeader(req.Header, h.betas...)
 	req.Header.Set("Content-Type", "application/json")
 	
 	// Make request
-	resp, err := h.httpClient.Do(req)
+	resp, err := withRetries(h.httpClient, h.retries, h.retryDelay).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -74,20 +342,68 @@ func (h *ModelsHandler) fetchModelsFromAnthropic() (map[string]interface{}, erro
 		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 	
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	// Convert each model as it is decoded instead of parsing the whole response first
+	models := []interface{}{}
+	err = decodeAnthropicModels(resp.Body, func(model map[string]interface{}) {
+		if modelID, ok := model["id"].(string); ok {
+			models = append(models, anthropicModelToOpenAI(modelID))
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 	
-	// Parse Anthropic response
-	var anthropicResponse map[string]interface{}
-	if err := json.Unmarshal(body, &anthropicResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	return map[string]interface{}{
+		"object": "list",
+		"data":   models,
+	}, nil
+}
+
+// decodeAnthropicModels streams the "data" array of an Anthropic models response,
+// calling fn for each model without holding the full response in memory
+func decodeAnthropicModels(r io.Reader, fn func(model map[string]interface{})) error {
+	decoder := json.NewDecoder(r)
+	
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if token != json.Delim('{') {
+		return fmt.Errorf("expected JSON object, got %v", token)
 	}
 	
-	// Convert to OpenAI format
-	return h.convertAnthropicModelsToOpenAI(anthropicResponse), nil
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		
+		if key, _ := keyToken.(string); key != "data" {
+			// Skip pagination fields and anything else we don't need
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return err
+			}
+			continue
+		}
+		
+		if token, err := decoder.Token(); err != nil {
+			return err
+		} else if token != json.Delim('[') {
+			return fmt.Errorf("expected data array, got %v", token)
+		}
+		for decoder.More() {
+			var model map[string]interface{}
+			if err := decoder.Decode(&model); err != nil {
+				return err
+			}
+			fn(model)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	}
+	
+	_, err := decoder.Token()
+	return err
 }
 
 // convertAnthropicModelsToOpenAI converts Anthropic models response to OpenAI format
@@ -104,30 +420,7 @@ func (h *ModelsHandler) convertAnthropicModelsToOpenAI(anthropicResponse map[str
 		for _, item := range data {
 			if model, ok := item.(map[string]interface{}); ok {
 				if modelID, ok := model["id"].(string); ok {
-					// Convert to OpenAI format
-					openAIModel := map[string]interface{}{
-						"id":       modelID,
-						"object":   "model",
-						"created":  int(time.Now().Unix()),
-						"owned_by": "anthropic",
-						"permission": []interface{}{
-							map[string]interface{}{
-								"allow_create_engine":  false,
-								"allow_fine_tuning":    false,
-								"allow_logprobs":       false,
-								"allow_sampling":       true,
-								"allow_search_indices": false,
-								"allow_view":           true,
-								"created":              int(time.Now().Unix()),
-								"group":                nil,
-								"id":                   "modelperm-" + modelID,
-								"is_blocking":          false,
-								"object":               "model_permission",
-								"organization":         "*",
-							},
-						},
-					}
-					models = append(models, openAIModel)
+					models = append(models, anthropicModelToOpenAI(modelID))
 				}
 			}
 		}
@@ -138,6 +431,33 @@ func (h *ModelsHandler) convertAnthropicModelsToOpenAI(anthropicResponse map[str
 	return openAIModels
 }
 
+// anthropicModelToOpenAI builds the OpenAI model object for an Anthropic model ID
+func anthropicModelToOpenAI(modelID string) map[string]interface{} {
+	now := int(time.Now().Unix())
+	return map[string]interface{}{
+		"id":       modelID,
+		"object":   "model",
+		"created":  now,
+		"owned_by": "anthropic",
+		"permission": []interface{}{
+			map[string]interface{}{
+				"allow_create_engine":  false,
+				"allow_fine_tuning":    false,
+				"allow_logprobs":       false,
+				"allow_sampling":       true,
+				"allow_search_indices": false,
+				"allow_view":           true,
+				"created":              now,
+				"group":                nil,
+				"id":                   "modelperm-" + modelID,
+				"is_blocking":          false,
+				"object":               "model_permission",
+				"organization":         "*",
+			},
+		},
+	}
+}
+
 // getOAuthModels returns comprehensive list of OAuth-accessible models
 func (h *ModelsHandler) getOAuthModels() map[string]interface{} {
 	return map[string]interface{}{
@@ -352,17 +672,3 @@ func (h *ModelsHandler) getOAuthModels() map[string]interface{} {
 		},
 	}
 }
-
-// setCORSHeadersStandalone is a standalone CORS header setter
-func setCORSHeadersStandalone(w http.ResponseWriter, r *http.Request) {
-	origin := r.Header.Get("Origin")
-	if origin == "" {
-		origin = "*"
-	}
-	
-	w.Header().Set("Access-Control-Allow-Origin", origin)
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Set("Access-Control-Max-Age", "3600")
-}
\ No newline at end of file
@@ -0,0 +1,462 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fetchModels(t *testing.T, handler http.Handler) []map[string]interface{} {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", "/v1/models", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Object string                   `json:"object"`
+		Data   []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, "list", response.Object)
+	require.NotEmpty(t, response.Data)
+	return response.Data
+}
+
+func TestModelsHandler_Capabilities(t *testing.T) {
+	t.Run("should omit capability fields by default", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+
+		for _, model := range fetchModels(t, handler) {
+			assert.NotContains(t, model, "context_window")
+			assert.NotContains(t, model, "max_output_tokens")
+		}
+	})
+
+	t.Run("should include capability fields when enabled", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+		handler.SetIncludeCapabilities(true)
+
+		models := fetchModels(t, handler)
+
+		byID := make(map[string]map[string]interface{})
+		for _, model := range models {
+			assert.Contains(t, model, "context_window", model["id"])
+			assert.Contains(t, model, "max_output_tokens", model["id"])
+			byID[model["id"].(string)] = model
+		}
+		assert.Equal(t, float64(200000), byID["claude-sonnet-4-20250514"]["context_window"])
+		assert.Equal(t, float64(64000), byID["claude-sonnet-4-20250514"]["max_output_tokens"])
+		assert.Equal(t, float64(4096), byID["claude-3-haiku-20240307"]["max_output_tokens"])
+	})
+}
+
+func TestLookupModelCapabilities(t *testing.T) {
+	t.Run("should match the most specific family", func(t *testing.T) {
+		capabilities, ok := LookupModelCapabilities("claude-3-5-sonnet-20241022")
+
+		require.True(t, ok)
+		assert.Equal(t, ModelCapabilities{ContextWindow: 200000, MaxOutputTokens: 8192}, capabilities)
+	})
+
+	t.Run("should report unknown models", func(t *testing.T) {
+		_, ok := LookupModelCapabilities("gpt-4")
+
+		assert.False(t, ok)
+	})
+}
+
+// largeAnthropicModelList streams a synthetic Anthropic models response with n models
+func largeAnthropicModelList(w io.Writer, n int) {
+	io.WriteString(w, `{"data":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		fmt.Fprintf(w, `{"type":"model","id":"claude-synthetic-%06d","display_name":"Synthetic %d","created_at":"2025-01-01T00:00:00Z"}`, i, i)
+	}
+	io.WriteString(w, `],"has_more":false,"first_id":"claude-synthetic-000000","last_id":null}`)
+}
+
+func TestModelsHandler_LargeModelLists(t *testing.T) {
+	const modelCount = 20000
+
+	t.Run("should convert a very large upstream list", func(t *testing.T) {
+		// Arrange
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			largeAnthropicModelList(w, modelCount)
+		}))
+		defer upstream.Close()
+
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstream.URL)
+
+		// Act
+		models, err := handler.fetchModelsFromAnthropic()
+
+		// Assert
+		require.NoError(t, err)
+		data := models["data"].([]interface{})
+		require.Len(t, data, modelCount)
+		assert.Equal(t, "claude-synthetic-000000", data[0].(map[string]interface{})["id"])
+		assert.Equal(t, fmt.Sprintf("claude-synthetic-%06d", modelCount-1), data[modelCount-1].(map[string]interface{})["id"])
+		assert.Equal(t, "model", data[0].(map[string]interface{})["object"])
+	})
+
+	t.Run("should encode a very large list without buffering the whole response", func(t *testing.T) {
+		// Arrange
+		models := make([]interface{}, modelCount)
+		for i := range models {
+			models[i] = anthropicModelToOpenAI(fmt.Sprintf("claude-synthetic-%06d", i))
+		}
+
+		output := &countingWriter{}
+
+		// Act
+		err := writeModelList(output, models)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Greater(t, output.n, 1<<20)
+		assert.Less(t, output.largestWrite, 1024,
+			"encoding %d bytes should happen one model at a time", output.n)
+	})
+
+	t.Run("should produce a valid OpenAI list", func(t *testing.T) {
+		// Arrange
+		var output countingWriter
+		models := []interface{}{anthropicModelToOpenAI("claude-a"), anthropicModelToOpenAI("claude-b")}
+
+		// Act
+		err := writeModelList(&output, models)
+
+		// Assert
+		require.NoError(t, err)
+		var response struct {
+			Object string                   `json:"object"`
+			Data   []map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(output.buf, &response))
+		assert.Equal(t, "list", response.Object)
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, "claude-b", response.Data[1]["id"])
+	})
+
+	t.Run("should reject malformed upstream lists", func(t *testing.T) {
+		err := decodeAnthropicModels(io.MultiReader(
+			strings.NewReader(`{"data":[{"id":"claude-a"},`),
+			strings.NewReader(`{"id":`),
+		), func(map[string]interface{}) {})
+
+		assert.Error(t, err)
+	})
+}
+
+// countingWriter counts written bytes and keeps them for small outputs
+type countingWriter struct {
+	n            int
+	largestWrite int
+	buf          []byte
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	if len(p) > w.largestWrite {
+		w.largestWrite = len(p)
+	}
+	if len(w.buf) < 1<<16 {
+		w.buf = append(w.buf, p...)
+	}
+	return len(p), nil
+}
+
+func TestModelsHandler_EmptyListNote(t *testing.T) {
+	newEmptyLiveHandler := func(t *testing.T) *ModelsHandler {
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, _ := newModelsUpstream(t, release)
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+		handler.SetModelsCache(time.Hour, "")
+		return handler
+	}
+	serve := func(handler http.Handler) map[string]interface{} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/models", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("should explain an empty live list when enabled", func(t *testing.T) {
+		// Arrange
+		handler := newEmptyLiveHandler(t)
+		handler.SetEmptyListNote(true)
+
+		// Act
+		response := serve(handler)
+
+		// Assert
+		assert.Equal(t, "list", response["object"])
+		assert.Empty(t, response["data"])
+		assert.Contains(t, response["x_note"], "may lack the scope to list models")
+	})
+
+	t.Run("should keep the strict OpenAI shape by default", func(t *testing.T) {
+		response := serve(newEmptyLiveHandler(t))
+
+		assert.Empty(t, response["data"])
+		assert.NotContains(t, response, "x_note")
+	})
+
+	t.Run("should not add a note to a non-empty list", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+		handler.SetEmptyListNote(true)
+
+		response := serve(handler)
+
+		assert.NotEmpty(t, response["data"])
+		assert.NotContains(t, response, "x_note")
+	})
+}
+
+func TestModelsHandler_AllowedModels(t *testing.T) {
+	serveIDs := func(handler http.Handler) []string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/models", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Data, w.Body.String())
+		ids := []string{}
+		for _, model := range response.Data {
+			ids = append(ids, model["id"].(string))
+		}
+		return ids
+	}
+
+	t.Run("should filter the built-in list to the allowlist", func(t *testing.T) {
+		// Arrange
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+		handler.SetAllowedModels([]string{"claude-3-haiku-20240307", "claude-sonnet-4-20250514"})
+
+		// Act
+		ids := serveIDs(handler)
+
+		// Assert
+		assert.ElementsMatch(t, []string{"claude-3-haiku-20240307", "claude-sonnet-4-20250514"}, ids)
+	})
+
+	t.Run("should filter the live list to the allowlist", func(t *testing.T) {
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, _ := newModelsUpstream(t, release, "claude-live-a", "claude-live-b", "claude-live-c")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+		handler.SetModelsCache(time.Hour, "")
+		handler.SetAllowedModels([]string{"claude-live-b"})
+
+		assert.Equal(t, []string{"claude-live-b"}, serveIDs(handler))
+	})
+
+	t.Run("should match model IDs exactly", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+		handler.SetAllowedModels([]string{"claude-sonnet-4"})
+
+		assert.Empty(t, serveIDs(handler))
+	})
+
+	t.Run("should serve an empty list when nothing matches", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+		handler.SetAllowedModels([]string{"gpt-4"})
+		handler.SetEmptyListNote(true)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/models", nil))
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []interface{}{}, response["data"])
+		assert.Contains(t, response["x_note"], "allowlist")
+	})
+
+	t.Run("should serve every model without an allowlist", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+
+		assert.Greater(t, len(serveIDs(handler)), 2)
+	})
+}
+
+func TestModelsHandler_RetrieveModel(t *testing.T) {
+	retrieve := func(handler http.Handler, id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/models/"+id, nil))
+		return w
+	}
+
+	t.Run("should return a single model object", func(t *testing.T) {
+		// Arrange
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+
+		// Act
+		w := retrieve(handler, "claude-sonnet-4-20250514")
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var model map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &model))
+		assert.Equal(t, "claude-sonnet-4-20250514", model["id"])
+		assert.Equal(t, "model", model["object"])
+		assert.NotContains(t, model, "data")
+	})
+
+	t.Run("should return 404 for models that are not served", func(t *testing.T) {
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, "http://example.com", 0)
+		handler.SetAllowedModels([]string{"claude-3-haiku-20240307"})
+
+		for _, id := range []string{"gpt-4", "claude-sonnet-4-20250514"} {
+			w := retrieve(handler, id)
+
+			require.Equal(t, http.StatusNotFound, w.Code, id)
+			var response struct {
+				Error map[string]interface{} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "model_not_found", response.Error["code"])
+			assert.Contains(t, response.Error["message"], id)
+		}
+	})
+
+	t.Run("should look models up in the live list", func(t *testing.T) {
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, _ := newModelsUpstream(t, release, "claude-live-a")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+		handler.SetModelsCache(time.Hour, "")
+
+		assert.Equal(t, http.StatusOK, retrieve(handler, "claude-live-a").Code)
+		assert.Equal(t, http.StatusNotFound, retrieve(handler, "claude-sonnet-4-20250514").Code)
+	})
+
+	t.Run("should be routed next to the pricing endpoint", func(t *testing.T) {
+		config := &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}, UpstreamURL: "http://example.com"}
+		mux := CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), config)
+
+		model := retrieve(mux, "claude-sonnet-4-20250514")
+		pricing := retrieve(mux, "pricing")
+
+		assert.Equal(t, http.StatusOK, model.Code)
+		assert.Contains(t, model.Body.String(), `"id":"claude-sonnet-4-20250514"`)
+		assert.Equal(t, http.StatusOK, pricing.Code)
+		assert.Contains(t, pricing.Body.String(), `"currency":"USD"`)
+	})
+}
+
+// recordingTransport answers every request with body and records the request paths
+type recordingTransport struct {
+	mu       sync.Mutex
+	paths    []string
+	versions []string
+	body     string
+}
+
+func (t *recordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.paths = append(t.paths, r.URL.Path)
+	t.versions = append(t.versions, r.Header.Get("anthropic-version"))
+	t.mu.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(t.body)),
+		Request:    r,
+	}, nil
+}
+
+func TestModelsHandler_Timeout(t *testing.T) {
+	t.Run("should give up on a slow model list fetch", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		defer close(release)
+		upstreamURL, _ := newModelsUpstream(t, release, "claude-live-a")
+		handler := NewModelsHandler(&mockTokenProvider{token: "test-token"}, upstreamURL)
+		handler.SetTimeout(50 * time.Millisecond)
+
+		// Act
+		start := time.Now()
+		_, err := handler.fetchModelsFromAnthropic()
+
+		// Assert
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("should share the configured HTTP client with the proxy handler", func(t *testing.T) {
+		transport := &recordingTransport{body: `{"data":[{"type":"model","id":"claude-shared"}],"has_more":false}`}
+		config := &ProxyConfig{
+			UpstreamURL:    "http://anthropic.invalid",
+			TokenProvider:  &mockTokenProvider{token: "test-token"},
+			Transformer:    NewRequestTransformer(),
+			HTTPClient:     &http.Client{Transport: transport},
+			ModelsCacheTTL: time.Hour,
+		}
+		proxyHandler := NewProxyHandler(config)
+		mux := CreateMux(proxyHandler, http.NotFoundHandler(), config)
+
+		models := fetchModels(t, mux)
+		require.NoError(t, proxyHandler.Warmup(context.Background()))
+
+		assert.Equal(t, "claude-shared", models[0]["id"])
+		assert.Equal(t, []string{"/v1/models", "/v1/models"}, transport.paths)
+	})
+}
+
+func TestModelsHandler_AnthropicVersion(t *testing.T) {
+	fetchVersion := func(t *testing.T, transformer *RequestTransformer) string {
+		t.Helper()
+		transport := &recordingTransport{body: `{"data":[{"type":"model","id":"claude-live"}],"has_more":false}`}
+		config := &ProxyConfig{
+			UpstreamURL:    "http://anthropic.invalid",
+			TokenProvider:  &mockTokenProvider{token: "test-token"},
+			Transformer:    transformer,
+			HTTPClient:     &http.Client{Transport: transport},
+			ModelsCacheTTL: time.Hour,
+		}
+		fetchModels(t, CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config))
+		require.Len(t, transport.versions, 1)
+		return transport.versions[0]
+	}
+
+	t.Run("should fetch the model list with the configured default version", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+		transformer.SetAnthropicVersions("2025-01-01", map[string]string{"claude-opus-4": "2025-02-02"})
+
+		// Act
+		version := fetchVersion(t, transformer)
+
+		// Assert
+		assert.Equal(t, "2025-01-01", version)
+	})
+
+	t.Run("should fall back to the built-in version", func(t *testing.T) {
+		// Act
+		version := fetchVersion(t, NewRequestTransformer())
+
+		// Assert
+		assert.Equal(t, DefaultAnthropicVersion, version)
+	})
+}
@@ -0,0 +1,25 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NotFoundHandler answers requests for unknown routes with an OpenAI-style error
+// envelope, so SDK clients report a readable error instead of failing to parse
+// a plain text body
+type NotFoundHandler struct{}
+
+func (h NotFoundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": fmt.Sprintf("Invalid URL (%s %s)", r.Method, r.URL.Path),
+			"param":   nil,
+			"code":    "unknown_url",
+		},
+	})
+}
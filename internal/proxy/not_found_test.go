@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotFoundHandler(t *testing.T) {
+	newMux := func() http.Handler {
+		config := &ProxyConfig{
+			TokenProvider:      &mockTokenProvider{token: "test-token"},
+			UpstreamURL:        "http://example.com",
+			CORSAllowedOrigins: []string{"https://app.example.com"},
+		}
+		return CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), config)
+	}
+
+	t.Run("should answer unknown paths with an OpenAI error envelope", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest("GET", "/unknown/path", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+
+		// Act
+		newMux().ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		var response struct {
+			Error map[string]interface{} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "invalid_request_error", response.Error["type"])
+		assert.Equal(t, "unknown_url", response.Error["code"])
+		assert.Equal(t, "Invalid URL (GET /unknown/path)", response.Error["message"])
+	})
+
+	t.Run("should still serve the root endpoint", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		newMux().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"service"`)
+	})
+}
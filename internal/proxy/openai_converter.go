@@ -2,13 +2,60 @@ package proxy
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"strings"
 	"time"
+	
+	"github.com/ml0-1337/claude-gate/internal/metrics"
 )
 
+// passthroughParams are request parameters with the same name and meaning in both APIs
+var passthroughParams = map[string]bool{
+	"max_tokens":     true,
+	"temperature":    true,
+	"top_p":          true,
+	"top_k":          true,
+	"stream":         true,
+	"stop_sequences": true,
+	"thinking":       true,
+}
+
+// knownUnsupportedParams are OpenAI parameters that Anthropic has no equivalent for.
+// Anything not listed here is reported as "other" to keep metric cardinality bounded.
+var knownUnsupportedParams = map[string]bool{
+	"n":                   true,
+	"presence_penalty":    true,
+	"frequency_penalty":   true,
+	"logit_bias":          true,
+	"logprobs":            true,
+	"top_logprobs":        true,
+	"seed":                true,
+	"service_tier":        true,
+	"store":               true,
+	"modalities":          true,
+	"audio":               true,
+	"prediction":          true,
+	"reasoning_effort":    true,
+	"web_search_options":  true,
+	"functions":           true,
+	"function_call":       true,
+	"metadata":            true,
+}
+
 // ConvertOpenAIToAnthropic converts OpenAI chat/completions format to Anthropic messages format
 func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
+	return ConvertOpenAIToAnthropicWithLogger(body, nil)
+}
+
+// ConvertOpenAIToAnthropicWithLogger converts OpenAI chat/completions format to Anthropic messages format with optional logging
+func ConvertOpenAIToAnthropicWithLogger(body []byte, logger *slog.Logger) ([]byte, error) {
+	return convertOpenAIToAnthropic(body, logger, SystemMergeBlocks, nil)
+}
+
+// convertOpenAIToAnthropic converts an OpenAI request, merging system messages with the
+// given strategy. Dropped parameters and merged system texts are recorded in report if set.
+func convertOpenAIToAnthropic(body []byte, logger *slog.Logger, systemMerge SystemMergeStrategy, report *TransformReport) ([]byte, error) {
 	var openAIRequest map[string]interface{}
 	if err := json.Unmarshal(body, &openAIRequest); err != nil {
 		return nil, err
@@ -23,11 +70,17 @@ func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
 	}
 	
 	// Extract system messages and convert messages array
+	// anthropicMessages starts non-nil so it always encodes as an array, never null
 	var systemContents []string
-	var anthropicMessages []interface{}
+	anthropicMessages := []interface{}{}
 	
 	if messages, ok := openAIRequest["messages"].([]interface{}); ok {
-		for _, msg := range messages {
+		// Fail on content that would otherwise be dropped, such as audio
+		if err := checkUnsupportedContent(messages); err != nil {
+			return nil, err
+		}
+		
+		for i, msg := range messages {
 			msgMap, ok := msg.(map[string]interface{})
 			if !ok {
 				continue
@@ -35,6 +88,19 @@ func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
 			
 			role, _ := msgMap["role"].(string)
 			
+			// Replayed tool calls and their results become tool_use and tool_result blocks
+			if toolCalls, ok := msgMap["tool_calls"].([]interface{}); ok && role == "assistant" && len(toolCalls) > 0 {
+				anthropicMessages = append(anthropicMessages, map[string]interface{}{
+					"role":    "assistant",
+					"content": assistantToolUseContent(msgMap["content"], toolCalls, logger),
+				})
+				continue
+			}
+			if role == "tool" {
+				anthropicMessages = appendToolResult(anthropicMessages, msgMap)
+				continue
+			}
+			
 			// Handle content - can be string or array
 			var content interface{}
 			if msgContent, ok := msgMap["content"]; ok {
@@ -42,30 +108,21 @@ func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
 				case string:
 					content = v
 				case []interface{}:
-					// Handle structured content array
-					content = v
+					// Handle structured content array; image parts become image blocks
+					parts, err := convertContentParts(v, i)
+					if err != nil {
+						return nil, err
+					}
+					content = parts
 				default:
 					continue
 				}
 			}
 			
-			if role == "system" {
-				// Extract text from system message
-				switch v := content.(type) {
-				case string:
-					systemContents = append(systemContents, v)
-				case []interface{}:
-					// Handle array of content items
-					for _, item := range v {
-						if itemMap, ok := item.(map[string]interface{}); ok {
-							if itemMap["type"] == "text" {
-								if text, ok := itemMap["text"].(string); ok {
-									systemContents = append(systemContents, text)
-								}
-							}
-						}
-					}
-				}
+			if role == "system" || role == "developer" {
+				// Extract text from system message, either a string or content parts.
+				// Newer OpenAI models send the same instructions as developer messages.
+				systemContents = append(systemContents, systemTexts(content)...)
 			} else {
 				// Convert to Anthropic message format
 				anthropicMsg := map[string]interface{}{
@@ -77,6 +134,12 @@ func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
 		}
 	}
 	
+	// Anthropic has no JSON mode, so structured output is requested via the system prompt
+	if instruction := responseFormatInstruction(openAIRequest["response_format"]); instruction != "" {
+		systemContents = append(systemContents, instruction)
+		report.warn("response_format is emulated with a system prompt instruction")
+	}
+	
 	// Set messages
 	anthropicRequest["messages"] = anthropicMessages
 	
@@ -86,21 +149,15 @@ func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
 	}
 	
 	// Add extracted system messages
-	for _, systemContent := range systemContents {
-		systemArray = append(systemArray, map[string]interface{}{
-			"type": "text",
-			"text": systemContent,
-		})
+	systemArray = append(systemArray, systemMerge.Merge(systemContents)...)
+	if report != nil {
+		report.SystemTexts = len(systemContents)
 	}
 	
 	anthropicRequest["system"] = systemArray
 	
-	// Copy other fields
-	for key, value := range openAIRequest {
-		if key != "model" && key != "messages" {
-			anthropicRequest[key] = value
-		}
-	}
+	// Normalize the remaining parameters
+	normalizeOpenAIParams(openAIRequest, anthropicRequest, logger, report)
 	
 	// Set default max_tokens if not provided (Claude requires this field)
 	if _, hasMaxTokens := anthropicRequest["max_tokens"]; !hasMaxTokens {
@@ -131,8 +188,103 @@ func ConvertOpenAIToAnthropic(body []byte) ([]byte, error) {
 	return json.Marshal(anthropicRequest)
 }
 
+// normalizeOpenAIParams copies parameters Anthropic understands, renames the ones that
+// differ only in name, and drops (and counts) everything else
+func normalizeOpenAIParams(openAIRequest, anthropicRequest map[string]interface{}, logger *slog.Logger, report *TransformReport) {
+	for key, value := range openAIRequest {
+		switch {
+		case key == "model" || key == "messages" || key == "response_format":
+			// Already handled
+		case key == "stream_options":
+			// Honored by the stream conversion (include_usage), nothing to send upstream
+		case key == "tools":
+			if tools, ok := value.([]interface{}); ok {
+				anthropicRequest["tools"] = convertOpenAITools(tools)
+			}
+		case key == "tool_choice" || key == "parallel_tool_calls":
+			if choice := convertOpenAIToolChoice(openAIRequest["tool_choice"], openAIRequest["parallel_tool_calls"], logger); choice != nil {
+				anthropicRequest["tool_choice"] = choice
+			}
+		case key == "temperature":
+			// OpenAI allows up to 2, Anthropic only up to 1
+			if temperature, ok := value.(float64); ok && temperature > 1 {
+				report.warn(fmt.Sprintf("temperature %g clamped to 1", temperature))
+				value = 1.0
+			}
+			anthropicRequest[key] = value
+		case passthroughParams[key]:
+			anthropicRequest[key] = value
+		case key == "stop":
+			// OpenAI accepts a single string or an array of strings
+			switch v := value.(type) {
+			case string:
+				anthropicRequest["stop_sequences"] = []interface{}{v}
+			case []interface{}:
+				anthropicRequest["stop_sequences"] = v
+			}
+		case key == "max_completion_tokens":
+			if _, ok := openAIRequest["max_tokens"]; !ok {
+				anthropicRequest["max_tokens"] = value
+			}
+		case key == "user":
+			// Anthropic's only metadata field is the end-user identifier
+			if userID, ok := value.(string); ok {
+				anthropicRequest["metadata"] = map[string]interface{}{"user_id": userID}
+			}
+		default:
+			recordUnsupportedParam(key, logger)
+			report.dropParam(key)
+		}
+	}
+}
+
+// responseFormatInstruction returns the system instruction for an OpenAI response_format.
+// The default "text" type (and anything unrecognized) needs no instruction.
+func responseFormatInstruction(responseFormat interface{}) string {
+	format, ok := responseFormat.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	
+	switch format["type"] {
+	case "json_object":
+		return "Respond only with a valid JSON object. Do not include any text outside of the JSON."
+	case "json_schema":
+		instruction := "Respond only with valid JSON that conforms to the following JSON schema. Do not include any text outside of the JSON."
+		if jsonSchema, ok := format["json_schema"].(map[string]interface{}); ok {
+			if schema, ok := jsonSchema["schema"]; ok {
+				if schemaJSON, err := json.Marshal(schema); err == nil {
+					instruction += "\n\n" + string(schemaJSON)
+				}
+			}
+		}
+		return instruction
+	default:
+		return ""
+	}
+}
+
+// recordUnsupportedParam counts and logs a dropped OpenAI parameter
+func recordUnsupportedParam(param string, logger *slog.Logger) {
+	label := param
+	if !knownUnsupportedParams[param] {
+		label = "other"
+	}
+	metrics.UnsupportedParams.WithLabelValues(label).Inc()
+	
+	if logger != nil {
+		logger.Debug("dropped unsupported OpenAI parameter", "param", param)
+	}
+}
+
 // ConvertAnthropicToOpenAI converts Anthropic response format to OpenAI chat/completions format
 func ConvertAnthropicToOpenAI(body []byte) ([]byte, error) {
+	return ConvertAnthropicToOpenAIWithID(body, "")
+}
+
+// ConvertAnthropicToOpenAIWithID converts an Anthropic response using the given ID.
+// An empty ID keeps the upstream message ID.
+func ConvertAnthropicToOpenAIWithID(body []byte, responseID string) ([]byte, error) {
 	var anthropicResponse map[string]interface{}
 	if err := json.Unmarshal(body, &anthropicResponse); err != nil {
 		return nil, err
@@ -148,7 +300,9 @@ func ConvertAnthropicToOpenAI(body []byte) ([]byte, error) {
 	openAIResponse := make(map[string]interface{})
 	
 	// Copy basic fields
-	if id, ok := anthropicResponse["id"].(string); ok {
+	if responseID != "" {
+		openAIResponse["id"] = responseID
+	} else if id, ok := anthropicResponse["id"].(string); ok {
 		openAIResponse["id"] = id
 	}
 	openAIResponse["object"] = "chat.completion"
@@ -157,8 +311,9 @@ func ConvertAnthropicToOpenAI(body []byte) ([]byte, error) {
 	}
 	openAIResponse["created"] = int(time.Now().Unix())
 	
-	// Convert content to OpenAI format
+	// Convert content to OpenAI format, keeping blocks OpenAI has no equivalent for
 	var messageContent string
+	var untranslatedBlocks []interface{}
 	if content, ok := anthropicResponse["content"].([]interface{}); ok {
 		for _, item := range content {
 			if contentMap, ok := item.(map[string]interface{}); ok {
@@ -166,60 +321,111 @@ func ConvertAnthropicToOpenAI(body []byte) ([]byte, error) {
 					if text, ok := contentMap["text"].(string); ok {
 						messageContent += text
 					}
+				} else if isUntranslatedContentBlock(contentMap) {
+					untranslatedBlocks = append(untranslatedBlocks, contentMap)
 				}
 			}
 		}
 	}
 	
 	// Build choices array
-	finishReason := "stop"
-	if stopReason, ok := anthropicResponse["stop_reason"].(string); ok {
-		switch stopReason {
-		case "end_turn":
-			finishReason = "stop"
-		case "max_tokens":
-			finishReason = "length"
-		case "stop_sequence":
-			finishReason = "stop"
-		default:
-			finishReason = stopReason
+	stopReason, _ := anthropicResponse["stop_reason"].(string)
+	finishReason := openAIFinishReason(stopReason, nil)
+	
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": messageContent,
+	}
+	if len(untranslatedBlocks) > 0 {
+		message[ContentBlocksField] = untranslatedBlocks
+	}
+	
+	// Tool use blocks become tool_calls; OpenAI sends null content with tool calls
+	// when there is no text
+	content, _ := anthropicResponse["content"].([]interface{})
+	if toolCalls := openAIToolCalls(content); len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		if messageContent == "" {
+			message["content"] = nil
 		}
 	}
 	
-	choices := []interface{}{
-		map[string]interface{}{
-			"index": 0,
-			"message": map[string]interface{}{
-				"role":    "assistant",
-				"content": messageContent,
-			},
-			"finish_reason": finishReason,
-		},
+	// Refusals use OpenAI's dedicated refusal field instead of regular content. A refusal
+	// without any text still yields a valid completion with empty content.
+	if stopReason == "refusal" && messageContent != "" {
+		message["content"] = nil
+		message["refusal"] = messageContent
 	}
-	openAIResponse["choices"] = choices
+	
+	choice := map[string]interface{}{
+		"index":         0,
+		"message":       message,
+		"finish_reason": finishReason,
+	}
+	
+	// Mark refusals the way content-filtered OpenAI-compatible responses are marked
+	if stopReason == "refusal" {
+		choice["content_filter_results"] = refusalFilterResults()
+	}
+	
+	// pause_turn and unknown stop reasons have no OpenAI equivalent, so pass them on
+	// for clients that want to continue the turn or tell them apart
+	if keepStopReason(stopReason) {
+		choice["x_claude_gate_stop_reason"] = stopReason
+	}
+	
+	openAIResponse["choices"] = []interface{}{choice}
 	
 	// Convert usage
 	if anthropicUsage, ok := anthropicResponse["usage"].(map[string]interface{}); ok {
-		inputTokens := 0
-		outputTokens := 0
-		
-		if val, ok := anthropicUsage["input_tokens"].(float64); ok {
-			inputTokens = int(val)
-		}
-		if val, ok := anthropicUsage["output_tokens"].(float64); ok {
-			outputTokens = int(val)
-		}
-		
-		openAIResponse["usage"] = map[string]interface{}{
-			"prompt_tokens":     inputTokens,
-			"completion_tokens": outputTokens,
-			"total_tokens":      inputTokens + outputTokens,
-		}
+		openAIResponse["usage"] = openAIUsage(anthropicUsage)
 	}
 	
 	return json.Marshal(openAIResponse)
 }
 
+// openAIFinishReasons maps the known Anthropic stop reasons to OpenAI finish reasons
+var openAIFinishReasons = map[string]string{
+	"end_turn":                      "stop",
+	"stop_sequence":                 "stop",
+	"pause_turn":                    "stop",
+	"refusal":                       "stop",
+	"max_tokens":                    "length",
+	"model_context_window_exceeded": "length",
+	"tool_use":                      "tool_calls",
+}
+
+// openAIFinishReason maps an Anthropic stop_reason to an OpenAI finish_reason, for
+// both complete responses and streams. Unknown stop reasons map to "stop" and are
+// logged to logger (nil = slog.Default()); a missing one is "stop" too.
+func openAIFinishReason(stopReason string, logger *slog.Logger) string {
+	if stopReason == "" {
+		return "stop"
+	}
+	if finishReason, ok := openAIFinishReasons[stopReason]; ok {
+		return finishReason
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Warn("unknown Anthropic stop reason, reporting finish_reason stop", "stop_reason", stopReason)
+	return "stop"
+}
+
+// keepStopReason reports whether the original stop reason is worth passing on in
+// x_claude_gate_stop_reason: pause_turn and unknown reasons have no OpenAI equivalent
+func keepStopReason(stopReason string) bool {
+	_, known := openAIFinishReasons[stopReason]
+	return stopReason == "pause_turn" || (stopReason != "" && !known)
+}
+
+// refusalFilterResults returns the content_filter_results marking a choice as refused
+func refusalFilterResults() map[string]interface{} {
+	return map[string]interface{}{
+		"refusal": map[string]interface{}{"filtered": true},
+	}
+}
+
 // convertAnthropicErrorToOpenAI converts Anthropic error format to OpenAI error format
 func convertAnthropicErrorToOpenAI(errorObj interface{}) ([]byte, error) {
 	openAIError := map[string]interface{}{
@@ -261,17 +467,79 @@ func convertAnthropicErrorToOpenAI(errorObj interface{}) ([]byte, error) {
 	return json.Marshal(openAIError)
 }
 
-// toolState tracks tool use information across SSE events
-// Key is Anthropic content block index, value contains tool info and OpenAI tool index
-var toolState = make(map[int]map[string]interface{})
+// SSEConverter converts one Anthropic SSE stream into OpenAI chat completion chunks.
+// It tracks per-stream state, so each stream needs its own converter.
+type SSEConverter struct {
+	messageID string
+	model     string
+	created   int64
+	logger    *slog.Logger
+	
+	// toolState tracks tool use information across SSE events
+	// Key is Anthropic content block index, value contains tool info and OpenAI tool index
+	toolState map[int]map[string]interface{}
+	
+	// toolCallIndex tracks the next available OpenAI tool call index
+	toolCallIndex int
+	
+	// roleSent records whether the assistant role has been emitted; OpenAI clients
+	// expect it in the first chunk's delta only
+	roleSent bool
+	
+	// untranslatedBlocks holds the indexes of open blocks forwarded via ContentBlockField
+	untranslatedBlocks map[int]bool
+	
+	// trimmer trims the streamed text as a whole when whitespace trimming is enabled
+	trimmer *whitespaceTrimmer
+	
+	// repairToolArgs completes truncated tool call arguments at the end of their block
+	repairToolArgs bool
+	
+	// contentFilterFinish reports refusals with finish_reason "content_filter"
+	contentFilterFinish bool
+	
+	// includeUsage ends the stream with a usage chunk, as for stream_options.include_usage
+	includeUsage bool
+	
+	// usage collects the Anthropic usage of message_start and message_delta
+	usage map[string]interface{}
+	
+	// text collects the streamed text content, for the refusal delta of a refused message
+	text strings.Builder
+	
+	// finishSent records whether a message_delta chunk has carried the finish_reason,
+	// so message_stop does not send a second one
+	finishSent bool
+}
+
+// NewSSEConverter creates a converter for a single stream
+func NewSSEConverter(messageID string, model string, created int64, logger *slog.Logger) *SSEConverter {
+	return &SSEConverter{
+		messageID:          messageID,
+		model:              model,
+		created:            created,
+		logger:             logger,
+		toolState:          make(map[int]map[string]interface{}),
+		untranslatedBlocks: make(map[int]bool),
+	}
+}
 
-// toolCallIndex tracks the next available OpenAI tool call index
-var toolCallIndex = 0
+// SetModel sets the model reported in subsequent chunks
+func (c *SSEConverter) SetModel(model string) {
+	c.model = model
+}
+
+// EnableWhitespaceTrim trims leading and trailing whitespace from the streamed content
+func (c *SSEConverter) EnableWhitespaceTrim() {
+	c.trimmer = &whitespaceTrimmer{}
+}
+
+// defaultSSEConverter backs the package-level conversion functions
+var defaultSSEConverter = NewSSEConverter("", "", 0, nil)
 
 // ResetSSEConverterState resets the converter state (useful for testing)
 func ResetSSEConverterState() {
-	toolState = make(map[int]map[string]interface{})
-	toolCallIndex = 0
+	defaultSSEConverter = NewSSEConverter("", "", 0, nil)
 }
 
 // ConvertAnthropicSSEToOpenAI converts a single Anthropic SSE event to OpenAI format
@@ -279,39 +547,83 @@ func ConvertAnthropicSSEToOpenAI(event, data string, messageID string, model str
 	return ConvertAnthropicSSEToOpenAIWithLogger(event, data, messageID, model, created, nil)
 }
 
-// ConvertAnthropicSSEToOpenAIWithLogger converts a single Anthropic SSE event to OpenAI format with optional logging
+// ConvertAnthropicSSEToOpenAIWithLogger converts a single Anthropic SSE event to OpenAI format with optional logging.
+// State is shared between calls; concurrent streams should each use their own SSEConverter.
 func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string, model string, created int64, logger *slog.Logger) (string, error) {
+	c := defaultSSEConverter
+	c.messageID, c.model, c.created, c.logger = messageID, model, created, logger
+	return c.Convert(event, data)
+}
+
+// Convert converts a single Anthropic SSE event into an OpenAI SSE chunk, returning
+// an empty string for events without an OpenAI equivalent
+func (c *SSEConverter) Convert(event, data string) (string, error) {
+	chunk, err := c.convertEvent(event, data)
+	if err != nil || chunk == nil {
+		return "", err
+	}
+	
+	// The first chunk of a stream carries the assistant role, later ones never do
+	if !c.roleSent {
+		if choices, ok := chunk["choices"].([]interface{}); ok && len(choices) > 0 {
+			if choice, ok := choices[0].(map[string]interface{}); ok {
+				if delta, ok := choice["delta"].(map[string]interface{}); ok {
+					delta["role"] = "assistant"
+					c.roleSent = true
+				}
+			}
+		}
+	}
+	
+	chunkJSON, err := json.Marshal(chunk)
+	if err != nil {
+		return "", err
+	}
+	return "data: " + string(chunkJSON) + "\n\n", nil
+}
+
+// convertEvent builds the OpenAI chunk for an Anthropic SSE event, or nil to skip it
+func (c *SSEConverter) convertEvent(event, data string) (map[string]interface{}, error) {
 	// Parse the data as JSON
 	var eventData map[string]interface{}
 	if err := json.Unmarshal([]byte(data), &eventData); err != nil {
-		return "", err
+		return nil, err
 	}
 	
 	eventType, _ := eventData["type"].(string)
 	
 	switch eventType {
 	case "message_start":
-		// Reset tool call index for new message
-		toolCallIndex = 0
+		// Reset per-message state for new message
+		c.toolState = make(map[int]map[string]interface{})
+		c.toolCallIndex = 0
+		c.roleSent = false
+		c.untranslatedBlocks = make(map[int]bool)
+		c.usage = nil
+		c.text.Reset()
+		c.finishSent = false
+		if message, ok := eventData["message"].(map[string]interface{}); ok {
+			c.mergeUsage(message["usage"])
+		}
+		if c.trimmer != nil {
+			c.trimmer = &whitespaceTrimmer{}
+		}
 		
 		// Convert message_start to initial OpenAI chunk
 		chunk := map[string]interface{}{
-			"id":      messageID,
+			"id":      c.messageID,
 			"object":  "chat.completion.chunk",
-			"created": created,
-			"model":   model,
+			"created": c.created,
+			"model":   c.model,
 			"choices": []interface{}{
 				map[string]interface{}{
 					"index": 0,
-					"delta": map[string]interface{}{
-						"role": "assistant",
-					},
+					"delta": map[string]interface{}{},
 					"finish_reason": nil,
 				},
 			},
 		}
-		chunkJSON, _ := json.Marshal(chunk)
-		return "data: " + string(chunkJSON) + "\n\n", nil
+		return chunk, nil
 		
 	case "content_block_start":
 		// Check if this is a tool use block
@@ -323,20 +635,21 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 				toolName, _ := contentBlock["name"].(string)
 				
 				// Store tool info for this index with OpenAI tool index
-				currentToolIndex := toolCallIndex
-				toolState[index] = map[string]interface{}{
+				currentToolIndex := c.toolCallIndex
+				c.toolState[index] = map[string]interface{}{
 					"id":        toolID,
 					"name":      toolName,
 					"toolIndex": currentToolIndex,
+					"arguments": &strings.Builder{},
 				}
-				toolCallIndex++
+				c.toolCallIndex++
 				
 				// Send initial tool call chunk
 				chunk := map[string]interface{}{
-					"id":      messageID,
+					"id":      c.messageID,
 					"object":  "chat.completion.chunk",
-					"created": created,
-					"model":   model,
+					"created": c.created,
+					"model":   c.model,
 					"choices": []interface{}{
 						map[string]interface{}{
 							"index": 0,
@@ -357,37 +670,68 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 						},
 					},
 				}
-				chunkJSON, _ := json.Marshal(chunk)
-				return "data: " + string(chunkJSON) + "\n\n", nil
+				return chunk, nil
+			}
+			
+			// Forward blocks OpenAI has no equivalent for instead of dropping them
+			if isUntranslatedContentBlock(contentBlock) {
+				index, _ := eventData["index"].(float64)
+				c.untranslatedBlocks[int(index)] = true
+				return c.deltaChunk(map[string]interface{}{
+					ContentBlockField: map[string]interface{}{
+						"index":         int(index),
+						"content_block": contentBlock,
+					},
+				}), nil
 			}
 		}
-		// For non-tool blocks, return empty to skip
-		return "", nil
+		// Text and thinking blocks start empty, so there is nothing to send yet
+		return nil, nil
 		
 	case "content_block_stop":
-		// Clear tool state for the completed block if it was a tool block
+		// Clear tool state for the completed block if it was a tool block, checking
+		// the arguments it streamed
 		if eventData["index"] != nil {
 			index := int(eventData["index"].(float64))
-			if _, exists := toolState[index]; exists {
-				delete(toolState, index)
-				if logger != nil {
-					logger.Debug("completed tool use block", "index", index)
+			delete(c.untranslatedBlocks, index)
+			if toolInfo, exists := c.toolState[index]; exists {
+				delete(c.toolState, index)
+				if c.logger != nil {
+					c.logger.Debug("completed tool use block", "index", index)
 				}
+				return c.finishToolCall(toolInfo), nil
 			}
 		}
-		// No output for content_block_stop
-		return "", nil
+		// No output for other blocks
+		return nil, nil
 		
 	case "content_block_delta":
 		// Convert content delta to OpenAI chunk
 		if delta, ok := eventData["delta"].(map[string]interface{}); ok {
+			// Deltas of forwarded blocks are forwarded the same way
+			index, _ := eventData["index"].(float64)
+			if c.untranslatedBlocks[int(index)] {
+				return c.deltaChunk(map[string]interface{}{
+					ContentBlockDeltaField: map[string]interface{}{
+						"index": int(index),
+						"delta": delta,
+					},
+				}), nil
+			}
+			
 			if delta["type"] == "text_delta" {
 				if text, ok := delta["text"].(string); ok {
+					if c.trimmer != nil {
+						if text = c.trimmer.Process(text); text == "" {
+							return nil, nil
+						}
+					}
+					c.text.WriteString(text)
 					chunk := map[string]interface{}{
-						"id":      messageID,
+						"id":      c.messageID,
 						"object":  "chat.completion.chunk",
-						"created": created,
-						"model":   model,
+						"created": c.created,
+						"model":   c.model,
 						"choices": []interface{}{
 							map[string]interface{}{
 								"index": 0,
@@ -398,8 +742,7 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 							},
 						},
 					}
-					chunkJSON, _ := json.Marshal(chunk)
-					return "data: " + string(chunkJSON) + "\n\n", nil
+					return chunk, nil
 				}
 			} else if delta["type"] == "input_json_delta" {
 				// Handle tool use deltas
@@ -408,16 +751,17 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 					blockIndex := int(eventData["index"].(float64))
 					
 					// Look up the tool info for this block
-					if toolInfo, exists := toolState[blockIndex]; exists {
+					if toolInfo, exists := c.toolState[blockIndex]; exists {
 						toolIndex := toolInfo["toolIndex"].(int)
 						toolID := toolInfo["id"].(string)
+						toolInfo["arguments"].(*strings.Builder).WriteString(partialJSON)
 						
 						// Create tool delta chunk with tool ID
 						chunk := map[string]interface{}{
-							"id":      messageID,
+							"id":      c.messageID,
 							"object":  "chat.completion.chunk",
-							"created": created,
-							"model":   model,
+							"created": c.created,
+							"model":   c.model,
 							"choices": []interface{}{
 								map[string]interface{}{
 									"index": 0,
@@ -436,21 +780,23 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 								},
 							},
 						}
-						chunkJSON, _ := json.Marshal(chunk)
-						return "data: " + string(chunkJSON) + "\n\n", nil
+						return chunk, nil
 					}
 				}
 			}
 		}
 		
 	case "message_stop":
-		// Send final chunk with finish_reason
+		// Send a final chunk with finish_reason unless message_delta already did
 		// Note: [DONE] marker should be sent separately by the stream handler
+		if c.finishSent {
+			return nil, nil
+		}
 		chunk := map[string]interface{}{
-			"id":      messageID,
+			"id":      c.messageID,
 			"object":  "chat.completion.chunk",
-			"created": created,
-			"model":   model,
+			"created": c.created,
+			"model":   c.model,
 			"choices": []interface{}{
 				map[string]interface{}{
 					"index":         0,
@@ -459,40 +805,66 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 				},
 			},
 		}
-		chunkJSON, _ := json.Marshal(chunk)
-		return "data: " + string(chunkJSON) + "\n\n", nil
+		return chunk, nil
 		
 	case "message_delta":
+		// The final usage arrives with the stop reason; output_tokens is cumulative
+		c.mergeUsage(eventData["usage"])
+		
 		// Handle stop reasons from message_delta
 		if delta, ok := eventData["delta"].(map[string]interface{}); ok {
 			if stopReason, ok := delta["stop_reason"].(string); ok {
-				finishReason := "stop"
-				if stopReason == "max_tokens" {
-					finishReason = "length"
+				finishReason := openAIFinishReason(stopReason, c.logger)
+				
+				choice := map[string]interface{}{
+					"index":         0,
+					"delta":         map[string]interface{}{},
+					"finish_reason": finishReason,
+				}
+				if keepStopReason(stopReason) {
+					choice["x_claude_gate_stop_reason"] = stopReason
+				}
+				if stopReason == "refusal" {
+					// The refusal only shows at the end, after its text streamed as
+					// content, so the text is repeated in OpenAI's refusal field for
+					// clients that read it as they do in a complete response
+					if c.text.Len() > 0 {
+						choice["delta"] = map[string]interface{}{"refusal": c.text.String()}
+					}
+					choice["content_filter_results"] = refusalFilterResults()
+					if c.contentFilterFinish {
+						choice["finish_reason"] = ContentFilterFinishReason
+					}
 				}
 				
 				chunk := map[string]interface{}{
-					"id":      messageID,
+					"id":      c.messageID,
 					"object":  "chat.completion.chunk",
-					"created": created,
-					"model":   model,
-					"choices": []interface{}{
-						map[string]interface{}{
-							"index":         0,
-							"delta":         map[string]interface{}{},
-							"finish_reason": finishReason,
-						},
-					},
+					"created": c.created,
+					"model":   c.model,
+					"choices": []interface{}{choice},
 				}
-				chunkJSON, _ := json.Marshal(chunk)
-				return "data: " + string(chunkJSON) + "\n\n", nil
+				c.finishSent = true
+				return chunk, nil
 			}
 		}
 		
+	case "error":
+		// Forward mid-stream upstream errors as an OpenAI error chunk
+		errorJSON, err := convertAnthropicErrorToOpenAI(eventData["error"])
+		if err != nil {
+			return nil, err
+		}
+		var chunk map[string]interface{}
+		if err := json.Unmarshal(errorJSON, &chunk); err != nil {
+			return nil, err
+		}
+		return chunk, nil
+		
 	default:
 		// Log unhandled event types for debugging
-		if logger != nil {
-			logger.Debug("unhandled SSE event type",
+		if c.logger != nil {
+			c.logger.Debug("unhandled SSE event type",
 				"event", event,
 				"type", eventType,
 				"data", data,
@@ -501,5 +873,5 @@ func ConvertAnthropicSSEToOpenAIWithLogger(event, data string, messageID string,
 	}
 	
 	// Skip other event types
-	return "", nil
+	return nil, nil
 }
\ No newline at end of file
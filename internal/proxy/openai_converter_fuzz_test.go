@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func FuzzTranslateRequest(f *testing.F) {
+	seeds := []string{
+		`{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hello"}]}`,
+		`{"model":"anthropic/claude-3-opus","messages":[{"role":"system","content":"Be brief"},{"role":"user","content":[{"type":"text","text":"Hi"}]}],"max_tokens":100}`,
+		`{"messages":[{"role":"system","content":[{"type":"text","text":"sys"},{"type":"image"}]}],"stop":"END","user":"u1"}`,
+		`{"messages":[],"stop":["a","b"],"max_completion_tokens":10,"response_format":{"type":"json_schema","json_schema":{"schema":{"type":"object"}}}}`,
+		`{"messages":[{"role":"user"}],"response_format":{"type":"json_object"},"n":2,"seed":1,"logit_bias":{}}`,
+		`{"model":1,"messages":{"role":"user"},"stop":3,"user":false,"response_format":"json"}`,
+		`{"messages":[null,1,"x",[],{"role":7,"content":{}}]}`,
+		`null`,
+		`[]`,
+		`{}`,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		result, err := ConvertOpenAIToAnthropic(body)
+		if err != nil {
+			return
+		}
+
+		var anthropicRequest map[string]interface{}
+		if err := json.Unmarshal(result, &anthropicRequest); err != nil {
+			t.Fatalf("translation produced invalid JSON: %v", err)
+		}
+		if _, ok := anthropicRequest["messages"].([]interface{}); !ok {
+			t.Fatalf("messages must be an array, got %T", anthropicRequest["messages"])
+		}
+		if _, ok := anthropicRequest["system"].([]interface{}); !ok {
+			t.Fatalf("system must be an array, got %T", anthropicRequest["system"])
+		}
+		if _, ok := anthropicRequest["max_tokens"]; !ok {
+			t.Fatal("max_tokens must always be set")
+		}
+	})
+}
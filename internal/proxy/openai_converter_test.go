@@ -1,10 +1,15 @@
 package proxy
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
 
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -64,6 +69,32 @@ func TestConvertOpenAIToAnthropic(t *testing.T) {
 		assert.Equal(t, false, anthropicRequest["stream"])
 	})
 	
+	t.Run("should merge developer messages into the system field like system messages", func(t *testing.T) {
+		// Arrange
+		requestBody := []byte(`{"model":"claude-sonnet-4-20250514","messages":[
+			{"role":"developer","content":"Answer in French."},
+			{"role":"system","content":"Be brief."},
+			{"role":"user","content":"Hello"},
+			{"role":"developer","content":[{"type":"text","text":"Use metric units."}]}]}`)
+		
+		// Act
+		result, err := ConvertOpenAIToAnthropic(requestBody)
+		
+		// Assert
+		require.NoError(t, err)
+		var anthropicRequest map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &anthropicRequest))
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"type": "text", "text": ClaudeCodePrompt},
+			map[string]interface{}{"type": "text", "text": "Answer in French."},
+			map[string]interface{}{"type": "text", "text": "Be brief."},
+			map[string]interface{}{"type": "text", "text": "Use metric units."},
+		}, anthropicRequest["system"])
+		messages := anthropicRequest["messages"].([]interface{})
+		require.Len(t, messages, 1)
+		assert.Equal(t, "user", messages[0].(map[string]interface{})["role"])
+	})
+	
 	t.Run("should handle OpenAI format without system message", func(t *testing.T) {
 		// Arrange
 		openAIRequest := map[string]interface{}{
@@ -265,11 +296,158 @@ func TestConvertOpenAIToAnthropic(t *testing.T) {
 		assistantMsg := messages[1].(map[string]interface{})
 		assert.Equal(t, "assistant", assistantMsg["role"])
 		
-		// Check tools are preserved
-		assert.Equal(t, openAIRequest["tools"], anthropicRequest["tools"])
+		// Check tools are translated to Anthropic's shape
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"name":         "str_replace_editor",
+				"description":  "Replace text in a file",
+				"input_schema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			},
+		}, anthropicRequest["tools"])
+	})
+}
+
+func TestNormalizeOpenAIParams(t *testing.T) {
+	t.Run("should map renamed parameters", func(t *testing.T) {
+		// Arrange
+		openAIRequest := map[string]interface{}{
+			"model":                 "claude-3-5-sonnet-20241022",
+			"messages":              []interface{}{map[string]interface{}{"role": "user", "content": "Hi"}},
+			"stop":                  "END",
+			"max_completion_tokens": 256,
+			"user":                  "user-42",
+		}
+		
+		requestBody, err := json.Marshal(openAIRequest)
+		require.NoError(t, err)
+		
+		// Act
+		result, err := ConvertOpenAIToAnthropic(requestBody)
+		
+		// Assert
+		require.NoError(t, err)
+		
+		var anthropicRequest map[string]interface{}
+		err = json.Unmarshal(result, &anthropicRequest)
+		require.NoError(t, err)
+		
+		assert.Equal(t, []interface{}{"END"}, anthropicRequest["stop_sequences"])
+		assert.Equal(t, float64(256), anthropicRequest["max_tokens"])
+		assert.Equal(t, map[string]interface{}{"user_id": "user-42"}, anthropicRequest["metadata"])
+		assert.NotContains(t, anthropicRequest, "stop")
+		assert.NotContains(t, anthropicRequest, "max_completion_tokens")
+		assert.NotContains(t, anthropicRequest, "user")
+	})
+	
+	t.Run("should drop unsupported parameters and count them", func(t *testing.T) {
+		// Arrange
+		openAIRequest := map[string]interface{}{
+			"model":             "claude-3-5-sonnet-20241022",
+			"messages":          []interface{}{map[string]interface{}{"role": "user", "content": "Hi"}},
+			"n":                 1,
+			"presence_penalty":  0.5,
+			"frequency_penalty": 0.5,
+			"x_custom_field":    true,
+		}
+		
+		requestBody, err := json.Marshal(openAIRequest)
+		require.NoError(t, err)
+		
+		nBefore := testutil.ToFloat64(metrics.UnsupportedParams.WithLabelValues("n"))
+		presenceBefore := testutil.ToFloat64(metrics.UnsupportedParams.WithLabelValues("presence_penalty"))
+		otherBefore := testutil.ToFloat64(metrics.UnsupportedParams.WithLabelValues("other"))
+		
+		// Act
+		result, err := ConvertOpenAIToAnthropic(requestBody)
+		
+		// Assert
+		require.NoError(t, err)
+		
+		var anthropicRequest map[string]interface{}
+		err = json.Unmarshal(result, &anthropicRequest)
+		require.NoError(t, err)
+		
+		for _, param := range []string{"n", "presence_penalty", "frequency_penalty", "x_custom_field"} {
+			assert.NotContains(t, anthropicRequest, param)
+		}
+		
+		assert.Equal(t, nBefore+1, testutil.ToFloat64(metrics.UnsupportedParams.WithLabelValues("n")))
+		assert.Equal(t, presenceBefore+1, testutil.ToFloat64(metrics.UnsupportedParams.WithLabelValues("presence_penalty")))
+		assert.Equal(t, otherBefore+1, testutil.ToFloat64(metrics.UnsupportedParams.WithLabelValues("other")))
 	})
 }
 
+func TestConvertOpenAIToAnthropic_ResponseFormat(t *testing.T) {
+	tests := []struct {
+		name           string
+		responseFormat map[string]interface{}
+		wantSystem     []string
+	}{
+		{
+			name:           "explicit text type is a no-op",
+			responseFormat: map[string]interface{}{"type": "text"},
+			wantSystem:     []string{ClaudeCodePrompt},
+		},
+		{
+			name:           "json_object requests JSON output",
+			responseFormat: map[string]interface{}{"type": "json_object"},
+			wantSystem: []string{
+				ClaudeCodePrompt,
+				"Respond only with a valid JSON object. Do not include any text outside of the JSON.",
+			},
+		},
+		{
+			name: "json_schema includes the schema",
+			responseFormat: map[string]interface{}{
+				"type": "json_schema",
+				"json_schema": map[string]interface{}{
+					"name": "weather",
+					"schema": map[string]interface{}{
+						"type":     "object",
+						"required": []interface{}{"city"},
+					},
+				},
+			},
+			wantSystem: []string{
+				ClaudeCodePrompt,
+				"Respond only with valid JSON that conforms to the following JSON schema. Do not include any text outside of the JSON." +
+					"\n\n" + `{"required":["city"],"type":"object"}`,
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			openAIRequest := map[string]interface{}{
+				"model":           "claude-3-5-sonnet-20241022",
+				"messages":        []interface{}{map[string]interface{}{"role": "user", "content": "Weather in Paris?"}},
+				"response_format": tt.responseFormat,
+			}
+			
+			requestBody, err := json.Marshal(openAIRequest)
+			require.NoError(t, err)
+			
+			// Act
+			result, err := ConvertOpenAIToAnthropic(requestBody)
+			
+			// Assert
+			require.NoError(t, err)
+			
+			var anthropicRequest map[string]interface{}
+			err = json.Unmarshal(result, &anthropicRequest)
+			require.NoError(t, err)
+			
+			var systemTexts []string
+			for _, block := range anthropicRequest["system"].([]interface{}) {
+				systemTexts = append(systemTexts, block.(map[string]interface{})["text"].(string))
+			}
+			assert.Equal(t, tt.wantSystem, systemTexts)
+			assert.NotContains(t, anthropicRequest, "response_format")
+		})
+	}
+}
+
 func TestConvertAnthropicToOpenAI(t *testing.T) {
 	t.Run("should convert Anthropic response to OpenAI format", func(t *testing.T) {
 		// Arrange
@@ -359,6 +537,466 @@ func TestConvertAnthropicToOpenAI(t *testing.T) {
 		assert.Nil(t, errorObj["param"])
 		assert.Nil(t, errorObj["code"])
 	})
+	
+	t.Run("should map refusal stop reason to OpenAI refusal field", func(t *testing.T) {
+		// Arrange
+		anthropicResponse := map[string]interface{}{
+			"id":   "msg_refusal",
+			"type": "message",
+			"role": "assistant",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "I can't help with that."},
+			},
+			"model":       "claude-sonnet-4-20250514",
+			"stop_reason": "refusal",
+		}
+		
+		responseBody, err := json.Marshal(anthropicResponse)
+		require.NoError(t, err)
+		
+		// Act
+		result, err := ConvertAnthropicToOpenAI(responseBody)
+		
+		// Assert
+		require.NoError(t, err)
+		
+		var openAIResponse map[string]interface{}
+		err = json.Unmarshal(result, &openAIResponse)
+		require.NoError(t, err)
+		
+		choice := openAIResponse["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "stop", choice["finish_reason"])
+		
+		assert.Equal(t, map[string]interface{}{"refusal": map[string]interface{}{"filtered": true}}, choice["content_filter_results"])
+		
+		message := choice["message"].(map[string]interface{})
+		assert.Nil(t, message["content"])
+		assert.Equal(t, "I can't help with that.", message["refusal"])
+	})
+	
+	t.Run("should return an empty but valid completion for a refusal without text", func(t *testing.T) {
+		// Arrange
+		responseBody := []byte(`{"id":"msg_refusal","type":"message","role":"assistant","content":[],"model":"claude-sonnet-4-20250514","stop_reason":"refusal"}`)
+		
+		// Act
+		result, err := ConvertAnthropicToOpenAI(responseBody)
+		
+		// Assert
+		require.NoError(t, err)
+		
+		var openAIResponse map[string]interface{}
+		err = json.Unmarshal(result, &openAIResponse)
+		require.NoError(t, err)
+		
+		choice := openAIResponse["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "stop", choice["finish_reason"])
+		assert.Contains(t, choice, "content_filter_results")
+		
+		message := choice["message"].(map[string]interface{})
+		assert.Equal(t, "assistant", message["role"])
+		assert.Equal(t, "", message["content"])
+		assert.NotContains(t, message, "refusal")
+	})
+	
+	t.Run("should map pause_turn stop reason to stop with a flag", func(t *testing.T) {
+		// Arrange
+		anthropicResponse := map[string]interface{}{
+			"id":   "msg_pause",
+			"type": "message",
+			"role": "assistant",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "Still working on it"},
+			},
+			"model":       "claude-sonnet-4-20250514",
+			"stop_reason": "pause_turn",
+		}
+		
+		responseBody, err := json.Marshal(anthropicResponse)
+		require.NoError(t, err)
+		
+		// Act
+		result, err := ConvertAnthropicToOpenAI(responseBody)
+		
+		// Assert
+		require.NoError(t, err)
+		
+		var openAIResponse map[string]interface{}
+		err = json.Unmarshal(result, &openAIResponse)
+		require.NoError(t, err)
+		
+		choice := openAIResponse["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "stop", choice["finish_reason"])
+		assert.Equal(t, "pause_turn", choice["x_claude_gate_stop_reason"])
+		
+		message := choice["message"].(map[string]interface{})
+		assert.Equal(t, "Still working on it", message["content"])
+		assert.NotContains(t, message, "refusal")
+	})
+}
+
+func TestOpenAIFinishReason(t *testing.T) {
+	tests := []struct {
+		stopReason string
+		want       string
+	}{
+		{"end_turn", "stop"},
+		{"stop_sequence", "stop"},
+		{"max_tokens", "length"},
+		{"tool_use", "tool_calls"},
+		{"pause_turn", "stop"},
+		{"refusal", "stop"},
+		{"", "stop"},
+	}
+	for _, tt := range tests {
+		t.Run("should map "+tt.stopReason+" to "+tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, openAIFinishReason(tt.stopReason, nil))
+		})
+	}
+	
+	t.Run("should map an unknown stop reason to stop with a warning", func(t *testing.T) {
+		// Arrange
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&logs, nil))
+		
+		// Act
+		finishReason := openAIFinishReason("brand_new_reason", logger)
+		
+		// Assert
+		assert.Equal(t, "stop", finishReason)
+		assert.Contains(t, logs.String(), "level=WARN")
+		assert.Contains(t, logs.String(), "stop_reason=brand_new_reason")
+	})
+	
+	t.Run("should keep an unknown stop reason in both translators", func(t *testing.T) {
+		// Act
+		response, err := ConvertAnthropicToOpenAI([]byte(`{"id":"msg_1","content":[{"type":"text","text":"Hi"}],"stop_reason":"brand_new_reason"}`))
+		require.NoError(t, err)
+		chunk, err := NewSSEConverter("chatcmpl-1", "claude-sonnet-4-20250514", 1700000000, nil).
+			Convert("message_delta", `{"type":"message_delta","delta":{"stop_reason":"brand_new_reason"}}`)
+		require.NoError(t, err)
+		
+		// Assert
+		for _, result := range []string{string(response), chunk} {
+			assert.Contains(t, result, `"finish_reason":"stop"`)
+			assert.Contains(t, result, `"x_claude_gate_stop_reason":"brand_new_reason"`)
+		}
+	})
+}
+
+func TestSSEConverter_RoleInFirstChunkOnly(t *testing.T) {
+	countRoles := func(t *testing.T, converter *SSEConverter, events [][2]string) (int, bool) {
+		t.Helper()
+		roles := 0
+		firstHasRole := false
+		chunks := 0
+		for _, e := range events {
+			result, err := converter.Convert(e[0], e[1])
+			require.NoError(t, err)
+			if result == "" {
+				continue
+			}
+			
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(result, "data: "))), &chunk))
+			delta := chunk["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+			if delta["role"] == "assistant" {
+				roles++
+				if chunks == 0 {
+					firstHasRole = true
+				}
+			}
+			chunks++
+		}
+		return roles, firstHasRole
+	}
+	
+	t.Run("should emit the role exactly once in the first chunk", func(t *testing.T) {
+		// Arrange
+		converter := NewSSEConverter("chatcmpl-test123", "claude-3-opus-20240229", 1719331200, nil)
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_123","role":"assistant","model":"claude-3-opus-20240229"}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}`},
+			{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{}"}}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		}
+		
+		// Act
+		roles, firstHasRole := countRoles(t, converter, events)
+		
+		// Assert
+		assert.Equal(t, 1, roles)
+		assert.True(t, firstHasRole)
+	})
+	
+	t.Run("should put the role on the first content chunk without message_start", func(t *testing.T) {
+		// Arrange
+		converter := NewSSEConverter("chatcmpl-test123", "claude-3-opus-20240229", 1719331200, nil)
+		events := [][2]string{
+			{"ping", `{"type":"ping"}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" again"}}`},
+		}
+		
+		// Act
+		roles, firstHasRole := countRoles(t, converter, events)
+		
+		// Assert
+		assert.Equal(t, 1, roles)
+		assert.True(t, firstHasRole)
+	})
+	
+	t.Run("should keep concurrent streams independent", func(t *testing.T) {
+		// Arrange
+		first := NewSSEConverter("chatcmpl-a", "claude-3-opus-20240229", 1719331200, nil)
+		second := NewSSEConverter("chatcmpl-b", "claude-3-opus-20240229", 1719331200, nil)
+		delta := `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`
+		
+		// Act
+		firstResult, err := first.Convert("content_block_delta", delta)
+		require.NoError(t, err)
+		secondResult, err := second.Convert("content_block_delta", delta)
+		require.NoError(t, err)
+		
+		// Assert
+		assert.Contains(t, firstResult, `"role":"assistant"`)
+		assert.Contains(t, secondResult, `"role":"assistant"`)
+		assert.Contains(t, secondResult, `"id":"chatcmpl-b"`)
+	})
+}
+
+func TestSSEConverter_Refusal(t *testing.T) {
+	finishChoice := func(t *testing.T, events [][2]string) map[string]interface{} {
+		t.Helper()
+		converter := NewSSEConverter("chatcmpl-test123", "claude-sonnet-4-20250514", 1719331200, nil)
+		var finish map[string]interface{}
+		for _, e := range events {
+			result, err := converter.Convert(e[0], e[1])
+			require.NoError(t, err)
+			if result == "" {
+				continue
+			}
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(result, "data: "))), &chunk))
+			choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+			if choice["finish_reason"] != nil {
+				require.Nil(t, finish, "stream should carry a single finish_reason")
+				finish = choice
+			}
+		}
+		require.NotNil(t, finish, "stream should carry a finish_reason")
+		return finish
+	}
+	
+	t.Run("should send the refused text in a refusal delta as a complete response does", func(t *testing.T) {
+		// Arrange
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_1","role":"assistant"}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"I can't "}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"help with that."}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"refusal"}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		}
+		
+		// Act
+		choice := finishChoice(t, events)
+		
+		// Assert
+		assert.Equal(t, map[string]interface{}{"refusal": "I can't help with that."}, choice["delta"])
+		assert.Equal(t, map[string]interface{}{"refusal": map[string]interface{}{"filtered": true}}, choice["content_filter_results"])
+	})
+	
+	t.Run("should send no refusal delta for a refusal without text", func(t *testing.T) {
+		// Arrange
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_1","role":"assistant"}}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"refusal"}}`},
+		}
+		
+		// Act
+		choice := finishChoice(t, events)
+		
+		// Assert
+		assert.NotContains(t, choice["delta"], "refusal")
+	})
+}
+
+func TestSSEConverter_FinishReason(t *testing.T) {
+	// finishReasons converts a stream ending with the given message_delta, if any,
+	// and returns the finish reasons of its chunks
+	finishReasons := func(t *testing.T, converter *SSEConverter, messageDelta string) []interface{} {
+		t.Helper()
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_1","role":"assistant"}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		}
+		if messageDelta != "" {
+			events = append(events, [2]string{"message_delta", messageDelta})
+		}
+		events = append(events, [2]string{"message_stop", `{"type":"message_stop"}`})
+		
+		var reasons []interface{}
+		for _, e := range events {
+			result, err := converter.Convert(e[0], e[1])
+			require.NoError(t, err)
+			if result == "" {
+				continue
+			}
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(result, "data: "))), &chunk))
+			choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+			if choice["finish_reason"] != nil {
+				reasons = append(reasons, choice["finish_reason"])
+			}
+		}
+		return reasons
+	}
+	newConverter := func() *SSEConverter {
+		return NewSSEConverter("chatcmpl-test123", "claude-sonnet-4-20250514", 1719331200, nil)
+	}
+	
+	t.Run("should send the mapped finish_reason of message_delta only once", func(t *testing.T) {
+		tests := []struct {
+			stopReason string
+			expected   string
+		}{
+			{"tool_use", "tool_calls"},
+			{"max_tokens", "length"},
+			{"refusal", "stop"},
+			{"end_turn", "stop"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.stopReason, func(t *testing.T) {
+				// Act
+				reasons := finishReasons(t, newConverter(), `{"type":"message_delta","delta":{"stop_reason":"`+tt.stopReason+`"}}`)
+				
+				// Assert
+				assert.Equal(t, []interface{}{tt.expected}, reasons)
+			})
+		}
+	})
+	
+	t.Run("should send content_filter only once for a refusal", func(t *testing.T) {
+		// Arrange
+		converter := newConverter()
+		converter.EnableContentFilterFinishReason()
+		
+		// Act
+		reasons := finishReasons(t, converter, `{"type":"message_delta","delta":{"stop_reason":"refusal"}}`)
+		
+		// Assert
+		assert.Equal(t, []interface{}{ContentFilterFinishReason}, reasons)
+	})
+	
+	t.Run("should finish with stop when no stop_reason arrived", func(t *testing.T) {
+		// Act
+		reasons := finishReasons(t, newConverter(), "")
+		
+		// Assert
+		assert.Equal(t, []interface{}{"stop"}, reasons)
+	})
+	
+	t.Run("should finish each message of a reused converter", func(t *testing.T) {
+		// Arrange
+		converter := newConverter()
+		finishReasons(t, converter, `{"type":"message_delta","delta":{"stop_reason":"max_tokens"}}`)
+		
+		// Act
+		reasons := finishReasons(t, converter, "")
+		
+		// Assert
+		assert.Equal(t, []interface{}{"stop"}, reasons)
+	})
+}
+
+func TestConvertAnthropicToOpenAI_UntranslatedBlocks(t *testing.T) {
+	t.Run("should carry novel block types in an extension field", func(t *testing.T) {
+		// Arrange
+		anthropicResp := `{
+			"id": "msg_123",
+			"type": "message",
+			"role": "assistant",
+			"model": "claude-sonnet-4-20250514",
+			"content": [
+				{"type": "text", "text": "See the attached report."},
+				{"type": "container_upload", "file_id": "file_abc", "name": "report.pdf"},
+				{"type": "thinking", "thinking": "hidden", "signature": "sig"}
+			],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 10, "output_tokens": 5}
+		}`
+		
+		// Act
+		result, err := ConvertAnthropicToOpenAI([]byte(anthropicResp))
+		
+		// Assert
+		require.NoError(t, err)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &response))
+		message := response["choices"].([]interface{})[0].(map[string]interface{})["message"].(map[string]interface{})
+		assert.Equal(t, "See the attached report.", message["content"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"type": "container_upload", "file_id": "file_abc", "name": "report.pdf"},
+		}, message[ContentBlocksField])
+	})
+	
+	t.Run("should omit the extension field for plain responses", func(t *testing.T) {
+		// Arrange
+		anthropicResp := `{"id":"msg_123","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`
+		
+		// Act
+		result, err := ConvertAnthropicToOpenAI([]byte(anthropicResp))
+		
+		// Assert
+		require.NoError(t, err)
+		assert.NotContains(t, string(result), ContentBlocksField)
+	})
+	
+	t.Run("should forward novel blocks and their deltas when streaming", func(t *testing.T) {
+		// Arrange
+		converter := NewSSEConverter("chatcmpl-test123", "claude-sonnet-4-20250514", 1719331200, nil)
+		events := [][2]string{
+			{"message_start", `{"type":"message_start","message":{"id":"msg_123","role":"assistant","model":"claude-sonnet-4-20250514"}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"document_reference","document_id":"doc_1"}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"reference_delta","page":3}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Done"}}`},
+		}
+		
+		// Act
+		var deltas []map[string]interface{}
+		for _, e := range events {
+			result, err := converter.Convert(e[0], e[1])
+			require.NoError(t, err)
+			if result == "" {
+				continue
+			}
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(result, "data: "))), &chunk))
+			deltas = append(deltas, chunk["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{}))
+		}
+		
+		// Assert
+		require.Len(t, deltas, 4)
+		assert.Equal(t, map[string]interface{}{
+			"index":         float64(0),
+			"content_block": map[string]interface{}{"type": "document_reference", "document_id": "doc_1"},
+		}, deltas[1][ContentBlockField])
+		assert.Equal(t, map[string]interface{}{
+			"index": float64(0),
+			"delta": map[string]interface{}{"type": "reference_delta", "page": float64(3)},
+		}, deltas[2][ContentBlockDeltaField])
+		assert.Equal(t, "Done", deltas[3]["content"])
+		assert.NotContains(t, deltas[3], ContentBlockDeltaField)
+	})
 }
 
 func TestConvertAnthropicSSEToOpenAI(t *testing.T) {
@@ -426,6 +1064,28 @@ func TestConvertAnthropicSSEToOpenAI(t *testing.T) {
 		assert.Contains(t, result, `"finish_reason":"length"`)
 	})
 	
+	t.Run("should map refusal and pause_turn in message_delta to stop", func(t *testing.T) {
+		for _, stopReason := range []string{"refusal", "pause_turn"} {
+			// Arrange
+			event := "message_delta"
+			data := fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":"%s"}}`, stopReason)
+			
+			// Act
+			result, err := ConvertAnthropicSSEToOpenAI(event, data, messageID, model, created)
+			
+			// Assert
+			require.NoError(t, err)
+			assert.Contains(t, result, `"finish_reason":"stop"`)
+			if stopReason == "pause_turn" {
+				assert.Contains(t, result, `"x_claude_gate_stop_reason":"pause_turn"`)
+				assert.NotContains(t, result, "content_filter_results")
+			} else {
+				assert.NotContains(t, result, "x_claude_gate_stop_reason")
+				assert.Contains(t, result, `"content_filter_results":{"refusal":{"filtered":true}}`)
+			}
+		}
+	})
+	
 	t.Run("should skip unhandled events", func(t *testing.T) {
 		// Arrange
 		event := "ping"
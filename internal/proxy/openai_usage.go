@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"encoding/json"
+)
+
+// openAIUsage converts Anthropic usage into OpenAI usage. OpenAI prompt tokens include
+// cached input, which Anthropic counts separately as cache reads and writes.
+func openAIUsage(usage map[string]interface{}) map[string]interface{} {
+	inputTokens, _ := usage["input_tokens"].(float64)
+	outputTokens, _ := usage["output_tokens"].(float64)
+	cachedTokens, _ := usage["cache_read_input_tokens"].(float64)
+	cacheWriteTokens, _ := usage["cache_creation_input_tokens"].(float64)
+	promptTokens := int(inputTokens + cachedTokens + cacheWriteTokens)
+	return map[string]interface{}{
+		"prompt_tokens":         promptTokens,
+		"completion_tokens":     int(outputTokens),
+		"total_tokens":          promptTokens + int(outputTokens),
+		"prompt_tokens_details": map[string]interface{}{"cached_tokens": int(cachedTokens)},
+	}
+}
+
+// includeUsageRequested reports whether an OpenAI request asks for a usage chunk at
+// the end of its stream with stream_options.include_usage
+func includeUsageRequested(body []byte) bool {
+	var request struct {
+		StreamOptions struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options"`
+	}
+	return json.Unmarshal(body, &request) == nil && request.StreamOptions.IncludeUsage
+}
+
+// EnableUsageChunk makes UsageChunk return the stream's usage, for clients that set
+// stream_options.include_usage
+func (c *SSEConverter) EnableUsageChunk() {
+	c.includeUsage = true
+}
+
+// mergeUsage keeps the latest value of each Anthropic usage field
+func (c *SSEConverter) mergeUsage(usage interface{}) {
+	usageMap, _ := usage.(map[string]interface{})
+	for key, value := range usageMap {
+		if c.usage == nil {
+			c.usage = make(map[string]interface{})
+		}
+		c.usage[key] = value
+	}
+}
+
+// UsageChunk returns the chunk that ends a stream with its usage, as OpenAI sends it
+// for stream_options.include_usage: no choices and the usage of the whole stream. It
+// is empty unless enabled and the upstream reported usage.
+func (c *SSEConverter) UsageChunk() string {
+	if !c.includeUsage || c.usage == nil {
+		return ""
+	}
+	chunk, err := json.Marshal(map[string]interface{}{
+		"id":      c.messageID,
+		"object":  "chat.completion.chunk",
+		"created": c.created,
+		"model":   c.model,
+		"choices": []interface{}{},
+		"usage":   openAIUsage(c.usage),
+	})
+	if err != nil {
+		return ""
+	}
+	return "data: " + string(chunk) + "\n\n"
+}
@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIUsage(t *testing.T) {
+	t.Run("should map input and output tokens", func(t *testing.T) {
+		usage := openAIUsage(map[string]interface{}{"input_tokens": float64(12), "output_tokens": float64(34)})
+
+		assert.Equal(t, 12, usage["prompt_tokens"])
+		assert.Equal(t, 34, usage["completion_tokens"])
+		assert.Equal(t, 46, usage["total_tokens"])
+		assert.Equal(t, map[string]interface{}{"cached_tokens": 0}, usage["prompt_tokens_details"])
+	})
+
+	t.Run("should count cache reads and writes as prompt tokens", func(t *testing.T) {
+		usage := openAIUsage(map[string]interface{}{
+			"input_tokens":                float64(10),
+			"cache_read_input_tokens":     float64(100),
+			"cache_creation_input_tokens": float64(50),
+			"output_tokens":               float64(5),
+		})
+
+		assert.Equal(t, 160, usage["prompt_tokens"])
+		assert.Equal(t, 165, usage["total_tokens"])
+		assert.Equal(t, map[string]interface{}{"cached_tokens": 100}, usage["prompt_tokens_details"])
+	})
+
+	t.Run("should report usage in non-streaming chat completions", func(t *testing.T) {
+		converted, err := ConvertAnthropicToOpenAI([]byte(`{"id":"msg_1","model":"claude-3-5-haiku-20241022","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":7,"output_tokens":3}}`))
+
+		require.NoError(t, err)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(converted, &response))
+		usage := response["usage"].(map[string]interface{})
+		assert.Equal(t, float64(7), usage["prompt_tokens"])
+		assert.Equal(t, float64(3), usage["completion_tokens"])
+		assert.Equal(t, float64(10), usage["total_tokens"])
+	})
+}
+
+func TestSSEConverter_UsageChunk(t *testing.T) {
+	events := [][2]string{
+		{"message_start", `{"type":"message_start","message":{"id":"msg_1","model":"claude-3-5-haiku-20241022","usage":{"input_tokens":25,"cache_read_input_tokens":5,"output_tokens":1}}}`},
+		{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+		{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`},
+		{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}`},
+		{"message_stop", `{"type":"message_stop"}`},
+	}
+	convert := func(t *testing.T, enable bool) *SSEConverter {
+		converter := NewSSEConverter("chatcmpl-1", "claude-3-5-haiku-20241022", 1700000000, nil)
+		if enable {
+			converter.EnableUsageChunk()
+		}
+		for _, event := range events {
+			_, err := converter.Convert(event[0], event[1])
+			require.NoError(t, err)
+		}
+		return converter
+	}
+
+	t.Run("should return the stream's final usage when enabled", func(t *testing.T) {
+		// Act
+		chunk := convert(t, true).UsageChunk()
+
+		// Assert
+		require.True(t, strings.HasPrefix(chunk, "data: "))
+		var parsed map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(chunk, "data: "))), &parsed))
+		assert.Equal(t, "chat.completion.chunk", parsed["object"])
+		assert.Equal(t, "chatcmpl-1", parsed["id"])
+		assert.Equal(t, []interface{}{}, parsed["choices"])
+		assert.Equal(t, map[string]interface{}{
+			"prompt_tokens":         float64(30),
+			"completion_tokens":     float64(15),
+			"total_tokens":          float64(45),
+			"prompt_tokens_details": map[string]interface{}{"cached_tokens": float64(5)},
+		}, parsed["usage"])
+	})
+
+	t.Run("should return nothing unless enabled", func(t *testing.T) {
+		assert.Empty(t, convert(t, false).UsageChunk())
+	})
+}
+
+func TestProxyHandler_StreamIncludeUsage(t *testing.T) {
+	stream := func(t *testing.T, body string) []map[string]interface{} {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Deltas: []string{"Hello", ", world"}})
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var chunks []map[string]interface{}
+		for _, line := range strings.Split(w.Body.String(), "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok || data == "[DONE]" {
+				continue
+			}
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+			chunks = append(chunks, chunk)
+		}
+		return chunks
+	}
+
+	t.Run("should end the stream with a usage chunk for include_usage", func(t *testing.T) {
+		// Act
+		chunks := stream(t, `{"model":"claude-sonnet-4-20250514","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hello"}]}`)
+
+		// Assert
+		last := chunks[len(chunks)-1]
+		assert.Equal(t, []interface{}{}, last["choices"])
+		usage := last["usage"].(map[string]interface{})
+		assert.Equal(t, float64(10), usage["prompt_tokens"])
+		assert.Equal(t, float64(2), usage["completion_tokens"])
+		assert.Equal(t, float64(12), usage["total_tokens"])
+	})
+
+	t.Run("should send no usage chunk by default", func(t *testing.T) {
+		chunks := stream(t, `{"model":"claude-sonnet-4-20250514","stream":true,"messages":[{"role":"user","content":"Hello"}]}`)
+
+		for _, chunk := range chunks {
+			assert.NotContains(t, chunk, "usage")
+		}
+	})
+
+	t.Run("should not report stream_options as a dropped parameter", func(t *testing.T) {
+		report := &TransformReport{}
+		_, err := convertOpenAIToAnthropic([]byte(`{"model":"m","stream":true,"stream_options":{"include_usage":true},"messages":[]}`), nil, SystemMergeBlocks, report)
+
+		require.NoError(t, err)
+		assert.Empty(t, report.DroppedParams)
+	})
+}
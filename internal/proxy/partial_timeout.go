@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// partialTimeoutWarning is reported when a response was cut short by the request timeout
+const partialTimeoutWarning = "the request timed out; the response holds the output produced until then"
+
+// errNoStreamOutput means a stream ended before its message_start event
+var errNoStreamOutput = errors.New("upstream stream ended before any output")
+
+// withStream returns an Anthropic request body asking for a streamed response
+func withStream(body []byte) ([]byte, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	data["stream"] = true
+	return json.Marshal(data)
+}
+
+// collectStream turns a streamed upstream response back into a non-streaming one. A
+// stream cut short, by the request deadline or otherwise, still yields the message
+// produced so far with stop_reason max_tokens; partial reports whether that happened.
+func collectStream(resp *http.Response) (partial bool, err error) {
+	defer resp.Body.Close()
+
+	message, partial, err := aggregateMessageStream(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(message))
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(message))
+	return partial, nil
+}
+
+// aggregateMessageStream rebuilds the Anthropic message of an SSE stream. An error
+// event is returned as the Anthropic error body it carries.
+func aggregateMessageStream(body io.Reader) (message []byte, partial bool, err error) {
+	aggregator := &streamAggregator{toolInputs: map[int]*strings.Builder{}}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var currentEvent string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			currentEvent = strings.TrimPrefix(line, "event: ")
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+
+		if currentEvent == "error" {
+			return []byte(data), false, nil
+		}
+		if err := aggregator.add(currentEvent, data); err != nil {
+			return nil, false, err
+		}
+		if aggregator.complete {
+			break
+		}
+	}
+
+	if aggregator.message == nil {
+		if err := scanner.Err(); err != nil {
+			return nil, false, err
+		}
+		return nil, false, errNoStreamOutput
+	}
+	message, err = aggregator.result()
+	return message, !aggregator.complete, err
+}
+
+// streamAggregator accumulates the events of one Anthropic message stream
+type streamAggregator struct {
+	message    map[string]interface{}
+	blocks     []map[string]interface{}
+	toolInputs map[int]*strings.Builder
+	complete   bool
+}
+
+func (a *streamAggregator) add(event, data string) error {
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return err
+	}
+
+	switch event {
+	case "message_start":
+		a.message, _ = payload["message"].(map[string]interface{})
+		if a.message == nil {
+			a.message = map[string]interface{}{}
+		}
+
+	case "content_block_start":
+		block, _ := payload["content_block"].(map[string]interface{})
+		if block == nil {
+			return nil
+		}
+		a.blocks = append(a.blocks, block)
+		if block["type"] == "tool_use" {
+			a.toolInputs[len(a.blocks)-1] = &strings.Builder{}
+		}
+
+	case "content_block_delta":
+		if len(a.blocks) == 0 {
+			return nil
+		}
+		index := len(a.blocks) - 1
+		if i, ok := payload["index"].(float64); ok && i >= 0 && int(i) < len(a.blocks) {
+			index = int(i)
+		}
+		block := a.blocks[index]
+		delta, _ := payload["delta"].(map[string]interface{})
+		switch delta["type"] {
+		case "text_delta":
+			text, _ := delta["text"].(string)
+			existing, _ := block["text"].(string)
+			block["text"] = existing + text
+		case "thinking_delta":
+			thinking, _ := delta["thinking"].(string)
+			existing, _ := block["thinking"].(string)
+			block["thinking"] = existing + thinking
+		case "signature_delta":
+			block["signature"] = delta["signature"]
+		case "input_json_delta":
+			if input, ok := a.toolInputs[index]; ok {
+				partialJSON, _ := delta["partial_json"].(string)
+				input.WriteString(partialJSON)
+			}
+		}
+
+	case "message_delta":
+		if a.message == nil {
+			return nil
+		}
+		if delta, ok := payload["delta"].(map[string]interface{}); ok {
+			for key, value := range delta {
+				a.message[key] = value
+			}
+		}
+		if usage, ok := payload["usage"].(map[string]interface{}); ok {
+			merged, _ := a.message["usage"].(map[string]interface{})
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			for key, value := range usage {
+				merged[key] = value
+			}
+			a.message["usage"] = merged
+		}
+
+	case "message_stop":
+		a.complete = true
+	}
+	return nil
+}
+
+// result encodes the message built so far. Tool calls whose input never finished
+// arriving are dropped, since their arguments cannot be parsed.
+func (a *streamAggregator) result() ([]byte, error) {
+	content := []interface{}{}
+	for i, block := range a.blocks {
+		if input, ok := a.toolInputs[i]; ok {
+			arguments := input.String()
+			if arguments == "" {
+				arguments = "{}"
+			}
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(arguments), &parsed); err != nil {
+				continue
+			}
+			block["input"] = parsed
+		}
+		content = append(content, block)
+	}
+	a.message["content"] = content
+
+	if !a.complete {
+		a.message["stop_reason"] = "max_tokens"
+	}
+	return json.Marshal(a.message)
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStallingUpstream streams a text block and a partial tool call, then stalls
+// until the request is cancelled. It records whether the request asked for a stream.
+func newStallingUpstream(t *testing.T, streamed *bool) string {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		*streamed = request["stream"] == true
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := func(name, data string) {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+			w.(http.Flusher).Flush()
+		}
+		event("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[],"stop_reason":null,"usage":{"input_tokens":12,"output_tokens":1}}}`)
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Partial"}}`)
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" answer"}}`)
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		event("content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`)
+		event("content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"query\":\"wea"}}`)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream.URL
+}
+
+func TestProxyHandler_PartialOnTimeout(t *testing.T) {
+	const request = `{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Hi"}]}`
+
+	t.Run("should return the output produced before the timeout", func(t *testing.T) {
+		// Arrange
+		var streamed bool
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:      newStallingUpstream(t, &streamed),
+			TokenProvider:    &mockTokenProvider{token: "test-token"},
+			Transformer:      NewRequestTransformer(),
+			Timeout:          200 * time.Millisecond,
+			PartialOnTimeout: true,
+			ResponseWarnings: true,
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(request)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.True(t, streamed, "the upstream request should be streamed internally")
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		choice := response["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "length", choice["finish_reason"])
+		message := choice["message"].(map[string]interface{})
+		assert.Equal(t, "Partial answer", message["content"])
+		assert.NotContains(t, message, "tool_calls", "an unfinished tool call has no usable arguments")
+		assert.Equal(t, float64(12), response["usage"].(map[string]interface{})["prompt_tokens"])
+		assert.Contains(t, response["x_claude_gate_warnings"], partialTimeoutWarning)
+	})
+
+	t.Run("should fail as before when disabled", func(t *testing.T) {
+		var streamed bool
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   newStallingUpstream(t, &streamed),
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			Timeout:       200 * time.Millisecond,
+		})
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(request)))
+
+		assert.False(t, streamed, "the upstream request should stay non-streaming")
+		assert.NotContains(t, w.Body.String(), `"finish_reason":"length"`)
+	})
+
+	t.Run("should return complete responses unchanged", func(t *testing.T) {
+		handler := NewProxyHandler(&ProxyConfig{
+			TokenProvider:    &mockTokenProvider{token: "test-token"},
+			Transformer:      NewRequestTransformer(),
+			Mock:             true,
+			MockResponse:     "All done here",
+			PartialOnTimeout: true,
+		})
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(request)))
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		choice := response["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "stop", choice["finish_reason"])
+		assert.Equal(t, "All done here", choice["message"].(map[string]interface{})["content"])
+	})
+}
+
+func TestAggregateMessageStream(t *testing.T) {
+	t.Run("should rebuild a complete message with tool calls", func(t *testing.T) {
+		// Arrange
+		stream := strings.Join([]string{
+			`event: message_start`,
+			`data: {"type":"message_start","message":{"id":"msg_1","role":"assistant","content":[],"usage":{"input_tokens":3,"output_tokens":1}}}`,
+			`event: content_block_start`,
+			`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+			`event: content_block_delta`,
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\":"}}`,
+			`event: content_block_delta`,
+			`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"weather\"}"}}`,
+			`event: message_delta`,
+			`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`,
+			`event: message_stop`,
+			`data: {"type":"message_stop"}`,
+		}, "\n")
+
+		// Act
+		message, partial, err := aggregateMessageStream(strings.NewReader(stream))
+
+		// Assert
+		require.NoError(t, err)
+		assert.False(t, partial)
+		assert.JSONEq(t, `{"id":"msg_1","role":"assistant","stop_reason":"tool_use",
+			"content":[{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"query":"weather"}}],
+			"usage":{"input_tokens":3,"output_tokens":9}}`, string(message))
+	})
+
+	t.Run("should pass an error event through as an error body", func(t *testing.T) {
+		stream := "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n"
+
+		message, _, err := aggregateMessageStream(strings.NewReader(stream))
+
+		require.NoError(t, err)
+		errorType, ok := anthropicErrorBody(message)
+		assert.True(t, ok)
+		assert.Equal(t, "overloaded_error", errorType)
+	})
+
+	t.Run("should fail a stream without output", func(t *testing.T) {
+		_, _, err := aggregateMessageStream(strings.NewReader(""))
+
+		assert.ErrorIs(t, err, errNoStreamOutput)
+	})
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultPassthroughMethods are the read-only methods forwarded to unknown paths
+var DefaultPassthroughMethods = []string{http.MethodGet, http.MethodHead}
+
+// knownEndpoints are the Anthropic and OpenAI paths the proxy always forwards
+var knownEndpoints = map[string]bool{
+	"/v1/messages":              true,
+	"/v1/messages/count_tokens": true,
+	"/v1/chat/completions":      true,
+	ResponsesPath:               true,
+	CompletionsPath:             true,
+	"/v1/models":                true,
+}
+
+// allowPassthrough reports whether a request may be forwarded. Paths outside
+// knownEndpoints and the Message Batches API are only forwarded when passthrough is
+// enabled, and then only for the configured methods; otherwise the rejection is
+// written to w.
+func (h *ProxyHandler) allowPassthrough(w http.ResponseWriter, r *http.Request) bool {
+	if knownEndpoints[r.URL.Path] || isBatchPath(r.URL.Path) {
+		return true
+	}
+
+	if !h.config.Passthrough {
+		h.writeError(w, http.StatusNotFound, "not_found_error",
+			fmt.Sprintf("Unknown endpoint %s; enable passthrough to forward it to Anthropic", r.URL.Path))
+		return false
+	}
+
+	methods := h.config.PassthroughMethods
+	if len(methods) == 0 {
+		methods = DefaultPassthroughMethods
+	}
+	for _, method := range methods {
+		if strings.EqualFold(method, r.Method) {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.ToUpper(strings.Join(methods, ", ")))
+	h.writeError(w, http.StatusMethodNotAllowed, "invalid_request_error",
+		fmt.Sprintf("Method %s is not allowed for passthrough path %s", r.Method, r.URL.Path))
+	return false
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyHandler_Passthrough(t *testing.T) {
+	// newPassthroughHandler returns a handler forwarding to an upstream that records request paths
+	newPassthroughHandler := func(t *testing.T, enabled bool, methods ...string) (*ProxyHandler, *[]*http.Request) {
+		var received []*http.Request
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"file_1","type":"file"}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:        upstream.URL,
+			TokenProvider:      &mockTokenProvider{token: "test-token"},
+			Transformer:        NewRequestTransformer(),
+			Passthrough:        enabled,
+			PassthroughMethods: methods,
+		}), &received
+	}
+
+	t.Run("should forward an arbitrary path with auth, version and beta headers", func(t *testing.T) {
+		// Arrange
+		handler, received := newPassthroughHandler(t, true)
+		req := httptest.NewRequest("GET", "/v1/files/file_1?limit=5", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id":"file_1","type":"file"}`, w.Body.String())
+		if assert.Len(t, *received, 1) {
+			upstreamReq := (*received)[0]
+			assert.Equal(t, "/v1/files/file_1", upstreamReq.URL.Path)
+			assert.Equal(t, "limit=5", upstreamReq.URL.RawQuery)
+			assert.Equal(t, "Bearer test-token", upstreamReq.Header.Get("Authorization"))
+			assert.Equal(t, DefaultAnthropicVersion, upstreamReq.Header.Get("anthropic-version"))
+			assert.Contains(t, upstreamReq.Header.Get("anthropic-beta"), oauthBeta)
+		}
+	})
+
+	t.Run("should reject unknown paths unless enabled", func(t *testing.T) {
+		handler, received := newPassthroughHandler(t, false)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/files", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "not_found_error")
+		assert.Empty(t, *received)
+	})
+
+	t.Run("should only forward read-only methods by default", func(t *testing.T) {
+		handler, received := newPassthroughHandler(t, true)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("DELETE", "/v1/files/file_1", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+		assert.Empty(t, *received)
+	})
+
+	t.Run("should forward configured methods", func(t *testing.T) {
+		handler, received := newPassthroughHandler(t, true, "get", "post")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/files", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, *received, 1)
+	})
+
+	t.Run("should always forward known endpoints", func(t *testing.T) {
+		handler, received := newPassthroughHandler(t, false)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/models", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, *received, 1)
+	})
+}
@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// FinishReasonPostProcess selects how the final content of a truncated response is adjusted
+type FinishReasonPostProcess string
+
+const (
+	// PostProcessNone leaves responses untouched
+	PostProcessNone FinishReasonPostProcess = "none"
+	// PostProcessTrimToSentence drops the trailing partial sentence of a truncated response
+	PostProcessTrimToSentence FinishReasonPostProcess = "trim-to-sentence"
+	// PostProcessAppendNotice appends a notice telling the reader the response was cut off
+	PostProcessAppendNotice FinishReasonPostProcess = "append-notice"
+)
+
+// TruncationNotice is appended to truncated responses in append-notice mode
+const TruncationNotice = "\n\n[Response truncated: the maximum token limit was reached.]"
+
+// ParseFinishReasonPostProcess validates a post-processing mode name; empty means none
+func ParseFinishReasonPostProcess(mode string) (FinishReasonPostProcess, error) {
+	switch p := FinishReasonPostProcess(strings.ToLower(strings.TrimSpace(mode))); p {
+	case "", PostProcessNone:
+		return PostProcessNone, nil
+	case PostProcessTrimToSentence, PostProcessAppendNotice:
+		return p, nil
+	default:
+		return PostProcessNone, fmt.Errorf("unknown finish reason post-processing mode %q (want none, trim-to-sentence or append-notice)", mode)
+	}
+}
+
+// Apply transforms the final content according to the finish reason.
+// Only responses cut off by the token limit ("length") are changed.
+func (p FinishReasonPostProcess) Apply(content, finishReason string) string {
+	if finishReason != "length" {
+		return content
+	}
+
+	switch p {
+	case PostProcessTrimToSentence:
+		return trimToLastSentence(content)
+	case PostProcessAppendNotice:
+		return content + TruncationNotice
+	default:
+		return content
+	}
+}
+
+// applyToResponse post-processes every choice of an OpenAI chat completion response
+func (p FinishReasonPostProcess) applyToResponse(body []byte) ([]byte, error) {
+	if p == "" || p == PostProcessNone {
+		return body, nil
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	choices, _ := response["choices"].([]interface{})
+	changed := false
+	for _, c := range choices {
+		choice, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		finishReason, _ := choice["finish_reason"].(string)
+		message, ok := choice["message"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if content, ok := message["content"].(string); ok {
+			if processed := p.Apply(content, finishReason); processed != content {
+				message["content"] = processed
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return body, nil
+	}
+	return json.Marshal(response)
+}
+
+// streamingNoticeChunk returns an OpenAI content chunk carrying the truncation notice when an
+// Anthropic message_delta reports max_tokens in append-notice mode. Trimming is not possible
+// once content has been streamed, so trim-to-sentence only affects non-streaming responses.
+func (p FinishReasonPostProcess) streamingNoticeChunk(data, messageID, model string, created int64) string {
+	if p != PostProcessAppendNotice {
+		return ""
+	}
+
+	var eventData map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &eventData); err != nil {
+		return ""
+	}
+	delta, _ := eventData["delta"].(map[string]interface{})
+	if stopReason, _ := delta["stop_reason"].(string); stopReason != "max_tokens" {
+		return ""
+	}
+
+	chunk := map[string]interface{}{
+		"id":      messageID,
+		"object":  "chat.completion.chunk",
+		"created": created,
+		"model":   model,
+		"choices": []interface{}{
+			map[string]interface{}{
+				"index":         0,
+				"delta":         map[string]interface{}{"content": TruncationNotice},
+				"finish_reason": nil,
+			},
+		},
+	}
+	chunkJSON, _ := json.Marshal(chunk)
+	return "data: " + string(chunkJSON) + "\n\n"
+}
+
+// trimToLastSentence cuts content after the last sentence terminator (., ! or ?) that is
+// followed by whitespace or the end of the text. Content without a complete sentence is
+// returned unchanged rather than emptied.
+func trimToLastSentence(content string) string {
+	runes := []rune(content)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] != '.' && runes[i] != '!' && runes[i] != '?' {
+			continue
+		}
+
+		// Keep closing quotes and brackets that belong to the sentence
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(`"')]`+"”’", runes[end]) {
+			end++
+		}
+		if end == len(runes) || unicode.IsSpace(runes[end]) {
+			return string(runes[:end])
+		}
+	}
+	return content
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFinishReasonPostProcess(t *testing.T) {
+	t.Run("should accept known modes", func(t *testing.T) {
+		for input, want := range map[string]FinishReasonPostProcess{
+			"":                 PostProcessNone,
+			"none":             PostProcessNone,
+			"trim-to-sentence": PostProcessTrimToSentence,
+			"Append-Notice":    PostProcessAppendNotice,
+		} {
+			got, err := ParseFinishReasonPostProcess(input)
+			require.NoError(t, err, input)
+			assert.Equal(t, want, got, input)
+		}
+	})
+
+	t.Run("should reject unknown modes", func(t *testing.T) {
+		_, err := ParseFinishReasonPostProcess("summarize")
+		assert.Error(t, err)
+	})
+}
+
+func TestFinishReasonPostProcess_Apply(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         FinishReasonPostProcess
+		content      string
+		finishReason string
+		want         string
+	}{
+		{"none leaves truncated content", PostProcessNone, "One. Two", "length", "One. Two"},
+		{"trim drops partial sentence", PostProcessTrimToSentence, "First sentence. Second one is cut", "length", "First sentence."},
+		{"trim keeps closing quotes", PostProcessTrimToSentence, `He said "stop!" and then`, "length", `He said "stop!"`},
+		{"trim ignores decimal points", PostProcessTrimToSentence, "Done? Pi is 3.14 and", "length", "Done?"},
+		{"trim keeps content without a full sentence", PostProcessTrimToSentence, "no terminator here", "length", "no terminator here"},
+		{"trim ignores complete responses", PostProcessTrimToSentence, "One. Two", "stop", "One. Two"},
+		{"notice appended to truncated content", PostProcessAppendNotice, "Partial", "length", "Partial" + TruncationNotice},
+		{"notice skipped for tool calls", PostProcessAppendNotice, "Calling", "tool_calls", "Calling"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.mode.Apply(tt.content, tt.finishReason))
+		})
+	}
+}
+
+func TestFinishReasonPostProcess_ApplyToResponse(t *testing.T) {
+	anthropicResponse := []byte(`{"id":"msg_1","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Complete. Incompl"}],"stop_reason":"max_tokens"}`)
+
+	tests := []struct {
+		name string
+		mode FinishReasonPostProcess
+		want string
+	}{
+		{"none", PostProcessNone, "Complete. Incompl"},
+		{"trim-to-sentence", PostProcessTrimToSentence, "Complete."},
+		{"append-notice", PostProcessAppendNotice, "Complete. Incompl" + TruncationNotice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			transformer := NewRequestTransformer()
+			transformer.SetFinishReasonPostProcess(tt.mode)
+
+			// Act
+			result, err := transformer.TransformResponseBody(anthropicResponse, "/v1/chat/completions")
+
+			// Assert
+			require.NoError(t, err)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(result, &response))
+			choice := response["choices"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, "length", choice["finish_reason"])
+			assert.Equal(t, tt.want, choice["message"].(map[string]interface{})["content"])
+		})
+	}
+}
+
+func TestFinishReasonPostProcess_StreamingNoticeChunk(t *testing.T) {
+	maxTokens := `{"type":"message_delta","delta":{"stop_reason":"max_tokens"}}`
+	endTurn := `{"type":"message_delta","delta":{"stop_reason":"end_turn"}}`
+
+	t.Run("should emit the notice for truncated streams", func(t *testing.T) {
+		chunk := PostProcessAppendNotice.streamingNoticeChunk(maxTokens, "chatcmpl-1", "claude", 1)
+
+		require.True(t, strings.HasPrefix(chunk, "data: "))
+		var parsed map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(chunk, "data: "))), &parsed))
+		delta := parsed["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+		assert.Equal(t, TruncationNotice, delta["content"])
+	})
+
+	t.Run("should emit nothing otherwise", func(t *testing.T) {
+		assert.Empty(t, PostProcessAppendNotice.streamingNoticeChunk(endTurn, "chatcmpl-1", "claude", 1))
+		assert.Empty(t, PostProcessTrimToSentence.streamingNoticeChunk(maxTokens, "chatcmpl-1", "claude", 1))
+		assert.Empty(t, PostProcessNone.streamingNoticeChunk(maxTokens, "chatcmpl-1", "claude", 1))
+	})
+}
@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ml0-1337/claude-gate/internal/pricing"
+)
+
+// PricingPath is the claude-gate extension endpoint listing model prices. It is not
+// part of the OpenAI or Anthropic APIs.
+const PricingPath = "/v1/models/pricing"
+
+// PricingHandler serves the model price table
+type PricingHandler struct {
+	table *pricing.Table
+}
+
+// NewPricingHandler creates a handler serving table, or the built-in prices when nil
+func NewPricingHandler(table *pricing.Table) *PricingHandler {
+	if table == nil {
+		table = pricing.Default()
+	}
+	return &PricingHandler{table: table}
+}
+
+// ServeHTTP handles the pricing endpoint
+func (h *PricingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object":   "list",
+		"currency": "USD",
+		"unit":     "per_million_tokens",
+		"data":     h.table.Entries(),
+	})
+}
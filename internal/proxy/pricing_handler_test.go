@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/pricing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPricingHandler(t *testing.T) {
+	// fetchPricing returns the prices served by the mux, by model
+	fetchPricing := func(t *testing.T, config *ProxyConfig) map[string]pricing.Entry {
+		t.Helper()
+
+		mux := CreateMux(http.NotFoundHandler(), http.NotFoundHandler(), config)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", PricingPath, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Object   string          `json:"object"`
+			Currency string          `json:"currency"`
+			Data     []pricing.Entry `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "list", response.Object)
+		assert.Equal(t, "USD", response.Currency)
+
+		entries := make(map[string]pricing.Entry)
+		for _, entry := range response.Data {
+			entries[entry.Model] = entry
+		}
+		return entries
+	}
+
+	t.Run("should serve built-in prices for known models", func(t *testing.T) {
+		entries := fetchPricing(t, &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}})
+
+		assert.Equal(t, 15.0, entries["claude-opus-4"].InputPerMTok)
+		assert.Equal(t, 75.0, entries["claude-opus-4"].OutputPerMTok)
+		assert.Equal(t, 3.0, entries["claude-sonnet-4"].InputPerMTok)
+		assert.Equal(t, 15.0, entries["claude-sonnet-4"].OutputPerMTok)
+		assert.InDelta(t, 0.08, entries["claude-3-5-haiku"].CacheReadPerMTok, 1e-9)
+	})
+
+	t.Run("should serve configured prices", func(t *testing.T) {
+		table := pricing.Default().WithOverrides(map[string]pricing.Price{"claude-custom": {InputPerMTok: 1, OutputPerMTok: 2}})
+
+		entries := fetchPricing(t, &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}, Pricing: table})
+
+		assert.Equal(t, 2.0, entries["claude-custom"].OutputPerMTok)
+		assert.Contains(t, entries, "claude-opus-4")
+	})
+}
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// LivenessPath answers 200 while the process serves requests
+	LivenessPath = "/healthz"
+	// ReadinessPath answers 200 once requests can be forwarded, 503 otherwise
+	ReadinessPath = "/readyz"
+)
+
+// readinessUpstreamTimeout bounds the upstream ping of a readiness check, well within
+// the default probe timeouts of Kubernetes
+const readinessUpstreamTimeout = 3 * time.Second
+
+// LivenessHandler answers liveness probes; reaching it means the process is up
+type LivenessHandler struct{}
+
+func (LivenessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(`{"status":"ok"}` + "\n"))
+}
+
+// ReadinessHandler answers readiness probes with the result of each check, so
+// operators can tell a missing token from an unreachable upstream
+type ReadinessHandler struct {
+	tokenProvider TokenProvider
+	upstreamURL   string
+	httpClient    *http.Client
+}
+
+// readinessCheck is the result of one readiness check
+type readinessCheck struct {
+	Status    string `json:"status"` // ok, error or skipped
+	Error     string `json:"error,omitempty"`
+	LatencyMs *int64 `json:"latency_ms,omitempty"`
+}
+
+// NewReadinessHandler creates a readiness handler checking that tokenProvider has an
+// access token. With a non-nil httpClient the upstream is pinged too.
+func NewReadinessHandler(tokenProvider TokenProvider, upstreamURL string, httpClient *http.Client) *ReadinessHandler {
+	return &ReadinessHandler{
+		tokenProvider: tokenProvider,
+		upstreamURL:   upstreamURL,
+		httpClient:    httpClient,
+	}
+}
+
+func (h *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]readinessCheck{
+		"token":    h.checkToken(),
+		"upstream": h.checkUpstream(r.Context()),
+	}
+
+	status, code := "ready", http.StatusOK
+	for _, check := range checks {
+		if check.Status == "error" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// checkToken gets an access token, refreshing it if it expired
+func (h *ReadinessHandler) checkToken() readinessCheck {
+	if h.tokenProvider == nil {
+		return readinessCheck{Status: "error", Error: "no token provider configured"}
+	}
+	token, err := h.tokenProvider.GetAccessToken()
+	if err != nil {
+		return readinessCheck{Status: "error", Error: err.Error()}
+	}
+	if token == "" {
+		return readinessCheck{Status: "error", Error: "empty access token"}
+	}
+	return readinessCheck{Status: "ok"}
+}
+
+// checkUpstream sends an unauthenticated HEAD to the upstream. Any HTTP response counts
+// as reachable; only connection errors and timeouts fail the check.
+func (h *ReadinessHandler) checkUpstream(ctx context.Context) readinessCheck {
+	if h.httpClient == nil {
+		return readinessCheck{Status: "skipped"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessUpstreamTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, h.upstreamURL, nil)
+	if err != nil {
+		return readinessCheck{Status: "error", Error: err.Error()}
+	}
+
+	start := time.Now()
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return readinessCheck{Status: "error", Error: err.Error()}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	latency := time.Since(start).Milliseconds()
+	return readinessCheck{Status: "ok", LatencyMs: &latency}
+}
+
+// probesMiddleware answers the liveness and readiness probes before next, so probes
+// skip CORS, auth and access logging
+func probesMiddleware(next http.Handler, readiness http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case LivenessPath:
+			LivenessHandler{}.ServeHTTP(w, r)
+		case ReadinessPath:
+			readiness.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbes(t *testing.T) {
+	readyz := func(t *testing.T, handler http.Handler) (int, map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", ReadinessPath, nil))
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+		return w.Code, body
+	}
+	check := func(body map[string]interface{}, name string) map[string]interface{} {
+		return body["checks"].(map[string]interface{})[name].(map[string]interface{})
+	}
+
+	t.Run("should answer liveness without CORS headers", func(t *testing.T) {
+		// Arrange
+		config := &ProxyConfig{TokenProvider: &mockTokenProvider{err: errors.New("no token")}}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+		req := httptest.NewRequest("GET", LivenessPath, nil)
+		req.Header.Set("Origin", "https://example.com")
+		w := httptest.NewRecorder()
+
+		// Act
+		mux.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.NotEmpty(t, w.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("should be ready with a token and skip the upstream by default", func(t *testing.T) {
+		config := &ProxyConfig{TokenProvider: &mockTokenProvider{token: "test-token"}, UpstreamURL: "http://127.0.0.1:0"}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+
+		code, body := readyz(t, mux)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", body["status"])
+		assert.Equal(t, "ok", check(body, "token")["status"])
+		assert.Equal(t, "skipped", check(body, "upstream")["status"])
+	})
+
+	t.Run("should not be ready without a token", func(t *testing.T) {
+		handler := NewReadinessHandler(&mockTokenProvider{err: errors.New("no token for account default")}, "", nil)
+
+		code, body := readyz(t, handler)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not_ready", body["status"])
+		assert.Equal(t, "error", check(body, "token")["status"])
+		assert.Equal(t, "no token for account default", check(body, "token")["error"])
+	})
+
+	t.Run("should ping a reachable upstream", func(t *testing.T) {
+		// Arrange
+		var method string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			w.WriteHeader(http.StatusNotFound) // any response means reachable
+		}))
+		defer upstream.Close()
+		config := &ProxyConfig{
+			TokenProvider:          &mockTokenProvider{token: "test-token"},
+			UpstreamURL:            upstream.URL,
+			ReadinessCheckUpstream: true,
+		}
+		mux := CreateMux(NewProxyHandler(config), http.NotFoundHandler(), config)
+
+		// Act
+		code, body := readyz(t, mux)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, http.MethodHead, method)
+		assert.Equal(t, "ok", check(body, "upstream")["status"])
+		assert.Contains(t, check(body, "upstream"), "latency_ms")
+	})
+
+	t.Run("should not be ready when the upstream is unreachable", func(t *testing.T) {
+		upstream := httptest.NewServer(http.NotFoundHandler())
+		upstream.Close()
+		handler := NewReadinessHandler(&mockTokenProvider{token: "test-token"}, upstream.URL, &http.Client{})
+
+		code, body := readyz(t, handler)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "ok", check(body, "token")["status"])
+		assert.Equal(t, "error", check(body, "upstream")["status"])
+		assert.NotEmpty(t, check(body, "upstream")["error"])
+	})
+}
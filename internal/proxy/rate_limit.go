@@ -0,0 +1,167 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitBurst is the burst of the client rate limiter when none is set
+const DefaultRateLimitBurst = 10
+
+// RateLimiter decides whether a client may send another request. The in-memory
+// MemoryRateLimiter limits each proxy instance on its own; deployments running
+// several instances can implement it on a shared store instead.
+type RateLimiter interface {
+	// Allow takes one request from the client's allowance. When the request is
+	// refused, retryAfter is how long until the next one would be allowed.
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// MemoryRateLimiter is a token bucket per client, held in memory
+type MemoryRateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+// tokenBucket is the allowance of one client
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryRateLimiter creates a limiter allowing each client requestsPerMinute
+// requests on average, and up to burst requests at once (0 = DefaultRateLimitBurst)
+func NewMemoryRateLimiter(requestsPerMinute, burst int) *MemoryRateLimiter {
+	if burst <= 0 {
+		burst = DefaultRateLimitBurst
+	}
+	return &MemoryRateLimiter{
+		rate:    float64(requestsPerMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimits changes the rate and burst of every client, e.g. on a configuration
+// reload; clients keep the tokens they have, up to the new burst
+func (l *MemoryRateLimiter) SetLimits(requestsPerMinute, burst int) {
+	if burst <= 0 {
+		burst = DefaultRateLimitBurst
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(requestsPerMinute) / 60
+	l.burst = float64(burst)
+}
+
+// Allow implements RateLimiter
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = l.refill(bucket, now)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+	if l.rate <= 0 {
+		return false, time.Minute, nil
+	}
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// refill returns the tokens of bucket at now
+func (l *MemoryRateLimiter) refill(bucket *tokenBucket, now time.Time) float64 {
+	elapsed := now.Sub(bucket.updated).Seconds()
+	return math.Min(l.burst, bucket.tokens+elapsed*l.rate)
+}
+
+// sweep drops the buckets that have refilled completely, which behave like new
+// ones, so clients that went away do not hold memory. It runs at most once a minute.
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if l.refill(bucket, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitMiddleware refuses API requests of clients over their rate with a 429.
+// Clients are keyed by API key, or remote IP without one. Only /v1/ paths count;
+// health, metrics and probes are never limited. Requests are let through when the
+// limiter fails, so an unavailable shared store does not take the proxy down.
+func rateLimitMiddleware(next http.Handler, limiter RateLimiter, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := clientKey(r)
+		allowed, retryAfter, err := limiter.Allow(r.Context(), key)
+		if err != nil {
+			if logger != nil {
+				logger.Error("rate limiter failed, allowing request", "client", key, "error", err)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if allowed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if logger != nil {
+			logger.Warn("client rate limit exceeded", "client", key, "retry_after", retryAfter)
+		}
+		writeClientRateLimited(w, retryAfter)
+	})
+}
+
+// writeClientRateLimited writes the OpenAI-style 429 of the client rate limiter
+func writeClientRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := max(1, int(math.Ceil(retryAfter.Seconds())))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "rate_limit_error",
+			"message": fmt.Sprintf("Rate limit exceeded for this client, retry after %d seconds", seconds),
+			"param":   nil,
+			"code":    "rate_limit_exceeded",
+		},
+	})
+}
@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+	// newLimiter returns a limiter on a clock the test advances
+	newLimiter := func(perMinute, burst int) (*MemoryRateLimiter, *time.Time) {
+		now := time.Unix(1700000000, 0)
+		limiter := NewMemoryRateLimiter(perMinute, burst)
+		limiter.now = func() time.Time { return now }
+		return limiter, &now
+	}
+
+	t.Run("should allow a burst and then refuse", func(t *testing.T) {
+		// Arrange
+		limiter, _ := newLimiter(60, 3)
+
+		// Act & Assert
+		for i := 0; i < 3; i++ {
+			allowed, _, err := limiter.Allow(context.Background(), "client")
+			require.NoError(t, err)
+			assert.True(t, allowed, "request %d", i)
+		}
+		allowed, retryAfter, err := limiter.Allow(context.Background(), "client")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+		assert.Equal(t, time.Second, retryAfter)
+	})
+
+	t.Run("should refill at the configured rate", func(t *testing.T) {
+		limiter, now := newLimiter(60, 1)
+		allowed, _, _ := limiter.Allow(context.Background(), "client")
+		require.True(t, allowed)
+
+		*now = now.Add(500 * time.Millisecond)
+		allowed, retryAfter, _ := limiter.Allow(context.Background(), "client")
+		assert.False(t, allowed)
+		assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+		*now = now.Add(500 * time.Millisecond)
+		allowed, _, _ = limiter.Allow(context.Background(), "client")
+		assert.True(t, allowed)
+	})
+
+	t.Run("should keep clients apart", func(t *testing.T) {
+		limiter, _ := newLimiter(60, 1)
+
+		first, _, _ := limiter.Allow(context.Background(), "a")
+		second, _, _ := limiter.Allow(context.Background(), "b")
+
+		assert.True(t, first)
+		assert.True(t, second)
+	})
+
+	t.Run("should forget clients whose bucket refilled", func(t *testing.T) {
+		limiter, now := newLimiter(60, 2)
+		limiter.Allow(context.Background(), "gone")
+
+		*now = now.Add(2 * time.Minute)
+		limiter.Allow(context.Background(), "active")
+
+		assert.NotContains(t, limiter.buckets, "gone")
+		assert.Contains(t, limiter.buckets, "active")
+	})
+
+	t.Run("should use the default burst when none is set", func(t *testing.T) {
+		limiter, _ := newLimiter(60, 0)
+
+		assert.Equal(t, float64(DefaultRateLimitBurst), limiter.burst)
+	})
+}
+
+// failingRateLimiter stands in for a shared store that is unavailable
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Allow(context.Context, string) (bool, time.Duration, error) {
+	return false, 0, errors.New("store unavailable")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	send := func(handler http.Handler, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, nil)
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should answer over-limit requests with an OpenAI-style 429", func(t *testing.T) {
+		// Arrange
+		handler := rateLimitMiddleware(ok, NewMemoryRateLimiter(6, 1), nil)
+		require.Equal(t, http.StatusOK, send(handler, "/v1/chat/completions", "key-a").Code)
+
+		// Act
+		w := send(handler, "/v1/chat/completions", "key-a")
+
+		// Assert
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "10", w.Header().Get("Retry-After"))
+		assert.JSONEq(t, `{"error":{"type":"rate_limit_error","message":"Rate limit exceeded for this client, retry after 10 seconds","param":null,"code":"rate_limit_exceeded"}}`, w.Body.String())
+	})
+
+	t.Run("should limit each local API key separately", func(t *testing.T) {
+		handler := requireLocalKey([]string{"key-a", "key-b"}, nil, rateLimitMiddleware(ok, NewMemoryRateLimiter(6, 1), nil))
+
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/messages", "key-a").Code)
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/messages", "key-b").Code)
+		assert.Equal(t, http.StatusTooManyRequests, send(handler, "/v1/messages", "key-a").Code)
+	})
+
+	t.Run("should limit by IP when API keys are not validated", func(t *testing.T) {
+		// Arrange
+		handler := rateLimitMiddleware(ok, NewMemoryRateLimiter(6, 1), nil)
+		require.Equal(t, http.StatusOK, send(handler, "/v1/messages", "key-1").Code)
+
+		// Act
+		w := send(handler, "/v1/messages", "key-2")
+
+		// Assert
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, "rotating the key must not reset the allowance")
+	})
+
+	t.Run("should not limit endpoints outside the API", func(t *testing.T) {
+		handler := rateLimitMiddleware(ok, NewMemoryRateLimiter(6, 1), nil)
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, send(handler, "/health", "").Code)
+		}
+	})
+
+	t.Run("should allow requests when the limiter fails", func(t *testing.T) {
+		handler := rateLimitMiddleware(ok, failingRateLimiter{}, nil)
+
+		assert.Equal(t, http.StatusOK, send(handler, "/v1/messages", "").Code)
+	})
+
+	t.Run("should be wired in by CreateMux", func(t *testing.T) {
+		// Arrange
+		config := &ProxyConfig{
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			RateLimiter:   NewMemoryRateLimiter(60, 1),
+		}
+		mux := CreateMux(ok, http.NotFoundHandler(), config)
+		send(mux, "/v1/messages", "key-a")
+
+		// Act
+		w := send(mux, "/v1/messages", "key-a")
+
+		// Assert
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Access-Control-Allow-Origin"), "429s keep CORS headers so browsers can read them")
+	})
+}
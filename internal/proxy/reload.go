@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// RuntimeSettings are the settings a running server can change without a restart
+type RuntimeSettings struct {
+	AllowedModels        []string // Model IDs listed by /v1/models (empty = all)
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+	RateLimitPerMinute   int // Ignored when the server started without a rate limit
+	RateLimitBurst       int
+}
+
+// reloadTargets are the parts of a server whose settings Reload swaps
+type reloadTargets struct {
+	models      *ModelsHandler
+	cors        atomic.Pointer[corsPolicy]
+	rateLimiter RateLimiter
+	logger      *slog.Logger
+}
+
+// rateLimitSetter is a RateLimiter whose limits can change while it runs
+type rateLimitSetter interface {
+	SetLimits(requestsPerMinute, burst int)
+}
+
+// Reload applies new runtime settings to the running server. Each applies from the
+// next request on; requests in flight finish with the settings they started with.
+// Enabling or disabling the rate limit needs a restart, so only the limits of a
+// server started with one change.
+func (s *ProxyServer) Reload(settings RuntimeSettings) {
+	if s.reload == nil {
+		return
+	}
+
+	s.reload.models.SetAllowedModels(settings.AllowedModels)
+
+	cors := newCORSPolicy(settings.CORSAllowedOrigins, settings.CORSAllowCredentials)
+	cors.warnConflict(s.reload.logger)
+	s.reload.cors.Store(cors)
+
+	if limiter, ok := s.reload.rateLimiter.(rateLimitSetter); ok && settings.RateLimitPerMinute > 0 {
+		limiter.SetLimits(settings.RateLimitPerMinute, settings.RateLimitBurst)
+	}
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyServer_Reload(t *testing.T) {
+	newServer := func(config *ProxyConfig) *ProxyServer {
+		config.UpstreamURL = "http://example.com"
+		config.TokenProvider = &mockTokenProvider{token: "test-token"}
+		config.Transformer = NewRequestTransformer()
+		return NewProxyServer(config, "127.0.0.1:0", nil)
+	}
+	send := func(s *ProxyServer, path, origin, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	modelIDs := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var list struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		var ids []string
+		for _, model := range list.Data {
+			ids = append(ids, model.ID)
+		}
+		return ids
+	}
+
+	t.Run("should swap the models allowlist and CORS origins", func(t *testing.T) {
+		// Arrange
+		s := newServer(&ProxyConfig{CORSAllowedOrigins: []string{"https://old.example.com"}})
+		assert.Greater(t, len(modelIDs(t, send(s, "/v1/models", "", ""))), 1)
+
+		// Act
+		s.Reload(RuntimeSettings{
+			AllowedModels:      []string{"claude-3-haiku-20240307"},
+			CORSAllowedOrigins: []string{"https://new.example.com"},
+		})
+
+		// Assert
+		assert.Equal(t, []string{"claude-3-haiku-20240307"}, modelIDs(t, send(s, "/v1/models", "", "")))
+		assert.Empty(t, send(s, "/v1/models", "https://old.example.com", "").Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "https://new.example.com", send(s, "/v1/models", "https://new.example.com", "").Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("should change the limits of a running rate limiter", func(t *testing.T) {
+		// Arrange
+		s := newServer(&ProxyConfig{RateLimiter: NewMemoryRateLimiter(60, 1), LocalAPIKeys: []string{"key-a", "key-b"}})
+		send(s, "/v1/models", "", "key-a")
+		assert.Equal(t, http.StatusTooManyRequests, send(s, "/v1/models", "", "key-a").Code)
+
+		// Act
+		s.Reload(RuntimeSettings{RateLimitPerMinute: 60, RateLimitBurst: 5})
+
+		// Assert
+		assert.Equal(t, http.StatusTooManyRequests, send(s, "/v1/models", "", "key-a").Code, "spent tokens are not refunded")
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, send(s, "/v1/models", "", "key-b").Code, "request %d", i)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, send(s, "/v1/models", "", "key-b").Code)
+	})
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+)
+
+// TagsHeader carries request tags as comma-separated key=value pairs, for clients
+// whose requests have no OpenAI metadata field
+const TagsHeader = "X-Claude-Gate-Tags"
+
+const (
+	// maxTagValueLength truncates tag values, which clients control
+	maxTagValueLength = 64
+	// maxTagValues caps the distinct metric values per tag key; later ones count as
+	// otherTagValue so a misbehaving client cannot blow up the metric's cardinality
+	maxTagValues  = 100
+	otherTagValue = "other"
+)
+
+// requestTagger extracts allowlisted tags from requests for logs and metrics
+type requestTagger struct {
+	allowed map[string]bool
+
+	mu     sync.Mutex
+	values map[string]map[string]bool
+}
+
+// newRequestTagger returns a tagger exposing only the given tag keys, or nil when
+// there are none: tags are off unless keys are allowlisted
+func newRequestTagger(keys []string) *requestTagger {
+	allowed := make(map[string]bool)
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			allowed[key] = true
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	return &requestTagger{allowed: allowed, values: make(map[string]map[string]bool)}
+}
+
+// extract returns the allowlisted tags of a request, from the OpenAI metadata field
+// of its body and the TagsHeader. Header tags win over metadata ones.
+func (t *requestTagger) extract(body []byte, header http.Header) map[string]string {
+	tags := make(map[string]string)
+	add := func(key, value string) {
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !t.allowed[key] || value == "" {
+			return
+		}
+		if len(value) > maxTagValueLength {
+			value = value[:maxTagValueLength]
+		}
+		tags[key] = value
+	}
+
+	var request struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if len(body) > 0 && json.Unmarshal(body, &request) == nil {
+		for key, value := range request.Metadata {
+			if s, ok := value.(string); ok {
+				add(key, s)
+			}
+		}
+	}
+
+	for _, pair := range strings.Split(header.Get(TagsHeader), ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			add(key, value)
+		}
+	}
+	return tags
+}
+
+// count records one tagged request per tag
+func (t *requestTagger) count(tags map[string]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, value := range tags {
+		seen := t.values[key]
+		if seen == nil {
+			seen = make(map[string]bool)
+			t.values[key] = seen
+		}
+		if !seen[value] {
+			if len(seen) >= maxTagValues {
+				value = otherTagValue
+			} else {
+				seen[value] = true
+			}
+		}
+		metrics.TaggedRequests.WithLabelValues(key, value).Inc()
+	}
+}
+
+// tagsAttr groups tags under "tags" for log lines, in key order
+func tagsAttr(tags map[string]string) slog.Attr {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.String(key, tags[key]))
+	}
+	return slog.Group("tags", attrs...)
+}
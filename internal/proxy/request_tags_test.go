@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTagger_Extract(t *testing.T) {
+	tagger := newRequestTagger([]string{"team", " feature "})
+
+	t.Run("should keep only allowlisted metadata keys", func(t *testing.T) {
+		// Arrange
+		body := []byte(`{"model":"gpt-4","metadata":{"team":"search","feature":"summaries","user_email":"a@example.com","cost_center":"42"}}`)
+
+		// Act
+		tags := tagger.extract(body, http.Header{})
+
+		// Assert
+		assert.Equal(t, map[string]string{"team": "search", "feature": "summaries"}, tags)
+	})
+
+	t.Run("should read tags from the header and prefer them over metadata", func(t *testing.T) {
+		header := http.Header{}
+		header.Set(TagsHeader, "team=ads, secret=x ,feature=")
+
+		tags := tagger.extract([]byte(`{"metadata":{"team":"search","feature":"summaries"}}`), header)
+
+		assert.Equal(t, map[string]string{"team": "ads", "feature": "summaries"}, tags)
+	})
+
+	t.Run("should ignore non-string values and bodies that are not JSON", func(t *testing.T) {
+		assert.Empty(t, tagger.extract([]byte(`{"metadata":{"team":7}}`), http.Header{}))
+		assert.Empty(t, tagger.extract([]byte(`not json`), http.Header{}))
+	})
+
+	t.Run("should truncate long values", func(t *testing.T) {
+		tags := tagger.extract([]byte(`{"metadata":{"team":"`+strings.Repeat("x", 100)+`"}}`), http.Header{})
+
+		assert.Len(t, tags["team"], maxTagValueLength)
+	})
+
+	t.Run("should be disabled without an allowlist", func(t *testing.T) {
+		assert.Nil(t, newRequestTagger(nil))
+		assert.Nil(t, newRequestTagger([]string{" "}))
+	})
+}
+
+func TestRequestTagger_Count(t *testing.T) {
+	t.Run("should bound the distinct values per key", func(t *testing.T) {
+		// Arrange
+		tagger := newRequestTagger([]string{"bounded_key"})
+		otherBefore := testutil.ToFloat64(metrics.TaggedRequests.WithLabelValues("bounded_key", otherTagValue))
+
+		// Act
+		for i := 0; i < maxTagValues+5; i++ {
+			tagger.count(map[string]string{"bounded_key": fmt.Sprintf("value-%d", i)})
+		}
+		tagger.count(map[string]string{"bounded_key": "value-0"})
+
+		// Assert
+		assert.Equal(t, float64(5), testutil.ToFloat64(metrics.TaggedRequests.WithLabelValues("bounded_key", otherTagValue))-otherBefore)
+		assert.Equal(t, float64(2), testutil.ToFloat64(metrics.TaggedRequests.WithLabelValues("bounded_key", "value-0")))
+	})
+}
+
+func TestProxyHandler_RequestTags(t *testing.T) {
+	t.Run("should label logs and metrics with allowlisted tags only", func(t *testing.T) {
+		// Arrange
+		var logs bytes.Buffer
+		handler := NewProxyHandler(&ProxyConfig{
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			Mock:          true,
+			Logger:        slog.New(slog.NewTextHandler(&logs, nil)),
+			TagKeys:       []string{"handler_team"},
+		})
+		before := testutil.ToFloat64(metrics.TaggedRequests.WithLabelValues("handler_team", "search"))
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(
+			`{"model":"gpt-4","metadata":{"handler_team":"search","customer":"acme"},"messages":[{"role":"user","content":"Hi"}]}`))
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, logs.String(), "tags.handler_team=search")
+		assert.NotContains(t, logs.String(), "acme")
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.TaggedRequests.WithLabelValues("handler_team", "search"))-before)
+	})
+}
@@ -0,0 +1,220 @@
+package proxy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// openAIChatSchemaJSON is the JSON schema incoming chat completion requests are checked against
+//
+//go:embed openai_chat_schema.json
+var openAIChatSchemaJSON []byte
+
+// openAIChatSchema is the parsed chat completion request schema
+var openAIChatSchema = mustParseSchema(openAIChatSchemaJSON)
+
+// jsonSchema is the subset of JSON Schema needed to describe OpenAI requests
+type jsonSchema struct {
+	Type       schemaTypes            `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	Enum       []interface{}          `json:"enum"`
+	AnyOf      []*jsonSchema          `json:"anyOf"`
+	Minimum    *float64               `json:"minimum"`
+	Maximum    *float64               `json:"maximum"`
+	MinLength  *int                   `json:"minLength"`
+	MinItems   *int                   `json:"minItems"`
+	MaxItems   *int                   `json:"maxItems"`
+}
+
+// schemaTypes holds a schema's "type", which may be a single name or a list
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// mustParseSchema parses an embedded schema, panicking on a malformed one
+func mustParseSchema(data []byte) *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		panic(fmt.Sprintf("invalid embedded JSON schema: %v", err))
+	}
+	return &schema
+}
+
+// RequestValidationError lists every way a request violates the schema
+type RequestValidationError struct {
+	Violations []string
+}
+
+func (e *RequestValidationError) Error() string {
+	return "invalid request: " + strings.Join(e.Violations, "; ")
+}
+
+// ValidateChatCompletionRequest checks an OpenAI chat completion request body against
+// the schema, returning a RequestValidationError listing all violations
+func ValidateChatCompletionRequest(body []byte) error {
+	var request interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return &RequestValidationError{Violations: []string{"request body is not valid JSON: " + err.Error()}}
+	}
+
+	var violations []string
+	openAIChatSchema.validate(request, "", &violations)
+	if len(violations) > 0 {
+		return &RequestValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validate appends a violation for every way value breaks the schema
+func (s *jsonSchema) validate(value interface{}, path string, violations *[]string) {
+	report := func(format string, args ...interface{}) {
+		location := path
+		if location == "" {
+			location = "request"
+		}
+		*violations = append(*violations, location+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.AnyOf) > 0 {
+		for _, option := range s.AnyOf {
+			var optionViolations []string
+			option.validate(value, path, &optionViolations)
+			if len(optionViolations) == 0 {
+				return
+			}
+		}
+		var forms []string
+		for _, option := range s.AnyOf {
+			forms = append(forms, strings.Join(option.Type, "|"))
+		}
+		report("must be one of: %s", strings.Join(forms, ", "))
+		return
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(value) {
+		report("must be of type %s, got %s", strings.Join(s.Type, " or "), jsonTypeName(value))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, option := range s.Enum {
+			if option == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			options := make([]string, 0, len(s.Enum))
+			for _, option := range s.Enum {
+				options = append(options, fmt.Sprint(option))
+			}
+			report("must be one of %s, got %v", strings.Join(options, ", "), value)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.MinLength != nil && len(v) < *s.MinLength {
+			report("must be at least %d characters", *s.MinLength)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			report("must be at least %v, got %v", *s.Minimum, v)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			report("must be at most %v, got %v", *s.Maximum, v)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			report("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			report("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				report("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if propertyValue, ok := v[name]; ok {
+				propertyPath := name
+				if path != "" {
+					propertyPath = path + "." + name
+				}
+				s.Properties[name].validate(propertyValue, propertyPath, violations)
+			}
+		}
+	}
+}
+
+// matches reports whether value has one of the schema types
+func (t schemaTypes) matches(value interface{}) bool {
+	for _, name := range t {
+		switch name {
+		case "integer":
+			if n, ok := value.(float64); ok && n == math.Trunc(n) {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		default:
+			if jsonTypeName(value) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonTypeName returns the JSON Schema type name of a decoded JSON value
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
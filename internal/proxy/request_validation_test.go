@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChatCompletionRequest(t *testing.T) {
+	t.Run("should accept valid requests", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"minimal":       `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}]}`,
+			"content parts": `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":[{"type":"text","text":"Hi"}]}]}`,
+			"full": `{
+				"model": "claude-3-5-sonnet-20241022",
+				"messages": [
+					{"role": "system", "content": "Be brief."},
+					{"role": "user", "content": "Hi"},
+					{"role": "assistant", "content": null, "tool_calls": []}
+				],
+				"temperature": 0.7, "top_p": 1, "n": 1, "stream": true,
+				"stop": ["END"], "max_tokens": 100, "presence_penalty": -1,
+				"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}}],
+				"tool_choice": "auto",
+				"response_format": {"type": "json_object"}
+			}`,
+		} {
+			assert.NoError(t, ValidateChatCompletionRequest([]byte(body)), name)
+		}
+	})
+
+	t.Run("should list every violation", func(t *testing.T) {
+		// Arrange
+		body := `{
+			"messages": [
+				{"role": "robot", "content": "Hi"},
+				{"content": 42}
+			],
+			"temperature": 3,
+			"n": 1.5,
+			"stop": ["a", "b", "c", "d", "e"],
+			"tools": [{"type": "function", "function": {"name": ""}}],
+			"tool_choice": "sometimes"
+		}`
+
+		// Act
+		err := ValidateChatCompletionRequest([]byte(body))
+
+		// Assert
+		var validationErr *RequestValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.ElementsMatch(t, []string{
+			`request: missing required field "model"`,
+			`messages[0].role: must be one of system, developer, user, assistant, tool, function, got robot`,
+			`messages[1]: missing required field "role"`,
+			`messages[1].content: must be one of: string, array, null`,
+			`n: must be of type integer or null, got number`,
+			`stop: must be one of: string, array, null`,
+			`temperature: must be at most 2, got 3`,
+			`tool_choice: must be one of: string, object`,
+			`tools[0].function.name: must be at least 1 characters`,
+		}, validationErr.Violations)
+	})
+
+	t.Run("should reject invalid JSON", func(t *testing.T) {
+		err := ValidateChatCompletionRequest([]byte(`{"model":`))
+
+		var validationErr *RequestValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Len(t, validationErr.Violations, 1)
+		assert.Contains(t, validationErr.Violations[0], "not valid JSON")
+	})
+}
+
+func TestProxyHandler_ValidateRequests(t *testing.T) {
+	newHandler := func(validate bool) (*ProxyHandler, *int32) {
+		var upstreamCalls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:      upstream.URL,
+			TokenProvider:    &mockTokenProvider{token: "test-token"},
+			Transformer:      NewRequestTransformer(),
+			ValidateRequests: validate,
+		}), &upstreamCalls
+	}
+	invalidBody := `{"model":"claude-3-5-sonnet-20241022","messages":[],"top_p":2}`
+
+	t.Run("should reject invalid requests before calling upstream", func(t *testing.T) {
+		// Arrange
+		handler, upstreamCalls := newHandler(true)
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(invalidBody))
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, atomic.LoadInt32(upstreamCalls))
+
+		var response struct {
+			Error struct {
+				Type       string   `json:"type"`
+				Message    string   `json:"message"`
+				Violations []string `json:"violations"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "invalid_request_error", response.Error.Type)
+		assert.Equal(t, []string{
+			"messages: must contain at least 1 items",
+			"top_p: must be at most 1, got 2",
+		}, response.Error.Violations)
+		assert.Contains(t, response.Error.Message, "top_p")
+	})
+
+	t.Run("should pass valid requests through", func(t *testing.T) {
+		handler, upstreamCalls := newHandler(true)
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}]}`))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(upstreamCalls))
+	})
+
+	t.Run("should not validate unless enabled", func(t *testing.T) {
+		handler, upstreamCalls := newHandler(false)
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(invalidBody))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(upstreamCalls))
+	})
+}
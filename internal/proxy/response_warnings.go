@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WarningsField is the OpenAI response extension field listing request adjustments
+const WarningsField = "x_claude_gate_warnings"
+
+// addResponseWarnings adds the warnings to an OpenAI response object. Bodies that are
+// not JSON objects are returned unchanged.
+func addResponseWarnings(body []byte, warnings []string) []byte {
+	if len(warnings) == 0 {
+		return body
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body
+	}
+	response[WarningsField] = warnings
+
+	withWarnings, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return withWarnings
+}
+
+// warningsChunk returns the final streaming chunk carrying the warnings, sent just
+// before [DONE]. It has no choices, so clients reading only deltas skip it.
+func warningsChunk(id, model string, created int64, warnings []string) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+
+	chunk, err := json.Marshal(map[string]interface{}{
+		"id":          id,
+		"object":      "chat.completion.chunk",
+		"created":     created,
+		"model":       model,
+		"choices":     []interface{}{},
+		WarningsField: warnings,
+	})
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("data: %s\n\n", chunk)
+}
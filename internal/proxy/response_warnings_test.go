@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformReport_Warnings(t *testing.T) {
+	transform := func(t *testing.T, body string) []string {
+		t.Helper()
+		_, report, err := NewRequestTransformer().TransformRequestBodyWithReport([]byte(body), "/v1/chat/completions")
+		require.NoError(t, err)
+		return report.Warnings
+	}
+
+	t.Run("should warn about dropped parameters", func(t *testing.T) {
+		warnings := transform(t, `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}],"logit_bias":{"1":2}}`)
+
+		assert.Equal(t, []string{`dropped unsupported parameter "logit_bias"`}, warnings)
+	})
+
+	t.Run("should clamp and warn about a temperature above 1", func(t *testing.T) {
+		// Arrange
+		body := `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}],"temperature":1.5}`
+
+		// Act
+		transformed, report, err := NewRequestTransformer().TransformRequestBodyWithReport([]byte(body), "/v1/chat/completions")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, []string{"temperature 1.5 clamped to 1"}, report.Warnings)
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(transformed, &request))
+		assert.Equal(t, 1.0, request["temperature"])
+	})
+
+	t.Run("should warn about a substituted model", func(t *testing.T) {
+		warnings := transform(t, `{"model":"claude-3-5-sonnet-latest","messages":[{"role":"user","content":"Hi"}]}`)
+
+		assert.Equal(t, []string{`model "claude-3-5-sonnet-latest" was substituted with "claude-3-5-sonnet-20241022"`}, warnings)
+	})
+
+	t.Run("should warn about an emulated response_format", func(t *testing.T) {
+		warnings := transform(t, `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}],"response_format":{"type":"json_object"}}`)
+
+		assert.Equal(t, []string{"response_format is emulated with a system prompt instruction"}, warnings)
+	})
+
+	t.Run("should not warn about a request passed through unchanged", func(t *testing.T) {
+		warnings := transform(t, `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}],"temperature":0.5}`)
+
+		assert.Empty(t, warnings)
+	})
+}
+
+func TestProxyHandler_ResponseWarnings(t *testing.T) {
+	const request = `{"model":"claude-3-5-sonnet-latest","messages":[{"role":"user","content":"Hi"}],"temperature":2,"seed":7}`
+	expected := []interface{}{
+		`dropped unsupported parameter "seed"`,
+		"temperature 2 clamped to 1",
+		`model "claude-3-5-sonnet-latest" was substituted with "claude-3-5-sonnet-20241022"`,
+	}
+
+	newHandler := func(upstreamURL string, enabled bool) *ProxyHandler {
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:      upstreamURL,
+			TokenProvider:    &mockTokenProvider{token: "test-token"},
+			Transformer:      NewRequestTransformer(),
+			ResponseWarnings: enabled,
+		})
+	}
+	newUpstream := func(t *testing.T) *httptest.Server {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn"}`))
+		}))
+		t.Cleanup(upstream.Close)
+		return upstream
+	}
+
+	t.Run("should list warnings in a non-streaming response", func(t *testing.T) {
+		// Arrange
+		handler := newHandler(newUpstream(t).URL, true)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(request)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.ElementsMatch(t, expected, response[WarningsField])
+		assert.Equal(t, "chat.completion", response["object"])
+	})
+
+	t.Run("should leave responses unchanged when disabled", func(t *testing.T) {
+		handler := newHandler(newUpstream(t).URL, false)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(request)))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), WarningsField)
+	})
+
+	t.Run("should send warnings in a final chunk before [DONE]", func(t *testing.T) {
+		// Arrange
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Deltas: []string{"Hello"}})
+		handler := newHandler(upstream.URL, true)
+		body := strings.Replace(request, `"messages"`, `"stream":true,"messages"`, 1)
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		events := strings.Split(strings.TrimSpace(w.Body.String()), "\n\n")
+		require.GreaterOrEqual(t, len(events), 2)
+		assert.Equal(t, "data: [DONE]", events[len(events)-1])
+
+		var chunk map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[len(events)-2], "data: ")), &chunk))
+		assert.ElementsMatch(t, expected, chunk[WarningsField])
+		assert.Empty(t, chunk["choices"])
+		helpers.AssertStreamCompleted(t, helpers.ParseOpenAIStream(t, w.Body.String()), "Hello")
+	})
+}
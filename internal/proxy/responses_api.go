@@ -0,0 +1,287 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ResponsesPath is the OpenAI Responses API endpoint
+const ResponsesPath = "/v1/responses"
+
+// responsesParams are Responses API parameters with the same meaning in chat completions
+var responsesParams = map[string]string{
+	"model":               "model",
+	"max_output_tokens":   "max_tokens",
+	"temperature":         "temperature",
+	"top_p":               "top_p",
+	"stream":              "stream",
+	"user":                "user",
+	"tool_choice":         "tool_choice",
+	"parallel_tool_calls": "parallel_tool_calls",
+}
+
+// responsesToChatCompletion rewrites a Responses API request as the equivalent chat
+// completion request, so it goes through the same translation to Anthropic. Parameters
+// without an equivalent are kept and then dropped, and reported, like any other
+// unsupported chat completion parameter.
+func responsesToChatCompletion(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	if previous, ok := request["previous_response_id"]; ok && previous != nil {
+		return nil, fmt.Errorf("previous_response_id is not supported; send the full conversation in input")
+	}
+
+	messages := []interface{}{}
+	if instructions, ok := request["instructions"].(string); ok && instructions != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": instructions})
+	}
+
+	switch input := request["input"].(type) {
+	case string:
+		messages = append(messages, map[string]interface{}{"role": "user", "content": input})
+	case []interface{}:
+		for i, item := range input {
+			message, err := responsesInputMessage(item)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			messages = append(messages, message)
+		}
+	case nil:
+		return nil, fmt.Errorf("input is required")
+	default:
+		return nil, fmt.Errorf("input must be a string or an array of input items")
+	}
+
+	chatRequest := map[string]interface{}{"messages": messages}
+	for key, value := range request {
+		switch {
+		case key == "input" || key == "instructions" || key == "previous_response_id":
+			// Already handled
+		case key == "text":
+			if responseFormat := responsesTextFormat(value); responseFormat != nil {
+				chatRequest["response_format"] = responseFormat
+			}
+		case responsesParams[key] != "":
+			chatRequest[responsesParams[key]] = value
+		default:
+			chatRequest[key] = value
+		}
+	}
+
+	return json.Marshal(chatRequest)
+}
+
+// responsesInputMessage converts one Responses API input item into a chat message
+func responsesInputMessage(item interface{}) (map[string]interface{}, error) {
+	itemMap, ok := item.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("input items must be objects")
+	}
+
+	switch itemMap["type"] {
+	case "function_call":
+		return map[string]interface{}{
+			"role": "assistant",
+			"tool_calls": []interface{}{
+				map[string]interface{}{
+					"id":   itemMap["call_id"],
+					"type": "function",
+					"function": map[string]interface{}{
+						"name":      itemMap["name"],
+						"arguments": itemMap["arguments"],
+					},
+				},
+			},
+		}, nil
+	case "function_call_output":
+		return map[string]interface{}{
+			"role":         "tool",
+			"tool_call_id": itemMap["call_id"],
+			"content":      itemMap["output"],
+		}, nil
+	case "message", nil:
+		// Handled below
+	default:
+		return nil, fmt.Errorf("unsupported input item type %v", itemMap["type"])
+	}
+
+	role, _ := itemMap["role"].(string)
+	switch role {
+	case "user", "assistant", "system":
+	case "developer":
+		role = "system"
+	default:
+		return nil, fmt.Errorf("unsupported role %q", role)
+	}
+
+	switch content := itemMap["content"].(type) {
+	case string:
+		return map[string]interface{}{"role": role, "content": content}, nil
+	case []interface{}:
+		parts := make([]interface{}, 0, len(content))
+		for _, part := range content {
+			partMap, _ := part.(map[string]interface{})
+			switch partMap["type"] {
+			case "input_text", "output_text":
+				parts = append(parts, map[string]interface{}{"type": "text", "text": partMap["text"]})
+			default:
+				return nil, fmt.Errorf("unsupported content type %v", partMap["type"])
+			}
+		}
+		return map[string]interface{}{"role": role, "content": parts}, nil
+	default:
+		return nil, fmt.Errorf("content must be a string or an array of content parts")
+	}
+}
+
+// responsesTextFormat converts the Responses API text.format into a chat response_format
+func responsesTextFormat(text interface{}) map[string]interface{} {
+	textMap, _ := text.(map[string]interface{})
+	format, _ := textMap["format"].(map[string]interface{})
+	switch format["type"] {
+	case "json_object":
+		return map[string]interface{}{"type": "json_object"}
+	case "json_schema":
+		return map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   format["name"],
+				"schema": format["schema"],
+			},
+		}
+	default:
+		return nil
+	}
+}
+
+// convertAnthropicToResponses converts an Anthropic message into a Responses API
+// response object with the given ID
+func convertAnthropicToResponses(body []byte, responseID string) ([]byte, error) {
+	var message map[string]interface{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, err
+	}
+
+	if errorObj, hasError := message["error"]; hasError {
+		return convertAnthropicErrorToOpenAI(errorObj)
+	}
+
+	var messageContent []interface{}
+	var output []interface{}
+	content, _ := message["content"].([]interface{})
+	for _, block := range content {
+		blockMap, _ := block.(map[string]interface{})
+		switch blockMap["type"] {
+		case "text":
+			messageContent = append(messageContent, outputTextPart(blockMap["text"]))
+		case "tool_use":
+			output = append(output, functionCallItem(blockMap))
+		}
+	}
+	if len(messageContent) > 0 {
+		messageID, _ := message["id"].(string)
+		output = append([]interface{}{outputMessageItem(messageID, "completed", messageContent)}, output...)
+	}
+
+	model, _ := message["model"].(string)
+	response := newResponseObject(responseID, model, time.Now().Unix())
+	response["output"] = nonNilList(output)
+	if usage, ok := message["usage"].(map[string]interface{}); ok {
+		response["usage"] = responsesUsage(usage)
+	}
+	setResponseStatus(response, message["stop_reason"])
+
+	return json.Marshal(response)
+}
+
+// newResponseObject returns an in-progress Responses API response envelope
+func newResponseObject(responseID, model string, createdAt int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                 responseID,
+		"object":             "response",
+		"created_at":         createdAt,
+		"status":             "in_progress",
+		"model":              model,
+		"output":             []interface{}{},
+		"error":              nil,
+		"incomplete_details": nil,
+		"usage":              nil,
+	}
+}
+
+// setResponseStatus completes a response, marking it incomplete when the token limit cut it short
+func setResponseStatus(response map[string]interface{}, stopReason interface{}) {
+	if stopReason == "max_tokens" {
+		response["status"] = "incomplete"
+		response["incomplete_details"] = map[string]interface{}{"reason": "max_output_tokens"}
+		return
+	}
+	response["status"] = "completed"
+}
+
+// outputMessageItem returns an assistant message output item
+func outputMessageItem(id, status string, content []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "message",
+		"id":      id,
+		"status":  status,
+		"role":    "assistant",
+		"content": nonNilList(content),
+	}
+}
+
+// outputTextPart returns an output_text content part
+func outputTextPart(text interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "output_text",
+		"text":        text,
+		"annotations": []interface{}{},
+	}
+}
+
+// functionCallItem converts an Anthropic tool_use block into a function_call output item
+func functionCallItem(block map[string]interface{}) map[string]interface{} {
+	arguments := "{}"
+	if input, err := json.Marshal(block["input"]); err == nil && block["input"] != nil {
+		arguments = string(input)
+	}
+	callID, _ := block["id"].(string)
+	return map[string]interface{}{
+		"type":      "function_call",
+		"id":        "fc_" + callID,
+		"call_id":   callID,
+		"name":      block["name"],
+		"arguments": arguments,
+		"status":    "completed",
+	}
+}
+
+// responsesUsage converts Anthropic usage into Responses API usage, where input tokens
+// include cache reads and writes
+func responsesUsage(usage map[string]interface{}) map[string]interface{} {
+	inputTokens, _ := usage["input_tokens"].(float64)
+	outputTokens, _ := usage["output_tokens"].(float64)
+	cachedTokens, _ := usage["cache_read_input_tokens"].(float64)
+	cacheWriteTokens, _ := usage["cache_creation_input_tokens"].(float64)
+	inputTokens += cachedTokens + cacheWriteTokens
+	return map[string]interface{}{
+		"input_tokens":          int(inputTokens),
+		"input_tokens_details":  map[string]interface{}{"cached_tokens": int(cachedTokens)},
+		"output_tokens":         int(outputTokens),
+		"output_tokens_details": map[string]interface{}{"reasoning_tokens": 0},
+		"total_tokens":          int(inputTokens + outputTokens),
+	}
+}
+
+// nonNilList returns list, or an empty list so it encodes as [] rather than null
+func nonNilList(list []interface{}) []interface{} {
+	if list == nil {
+		return []interface{}{}
+	}
+	return list
+}
@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/requestid"
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transformResponsesRequest translates a Responses API request into the Anthropic request sent upstream
+func transformResponsesRequest(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+
+	transformed, err := NewRequestTransformer().TransformRequestBody([]byte(body), ResponsesPath)
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(transformed, &request))
+	return request
+}
+
+// systemPromptTexts returns the texts of an Anthropic system field
+func systemPromptTexts(request map[string]interface{}) []string {
+	var texts []string
+	blocks, _ := request["system"].([]interface{})
+	for _, block := range blocks {
+		texts = append(texts, block.(map[string]interface{})["text"].(string))
+	}
+	return texts
+}
+
+func TestResponsesAPI_RequestTranslation(t *testing.T) {
+	t.Run("should translate string input and instructions", func(t *testing.T) {
+		// Act
+		request := transformResponsesRequest(t, `{"model":"claude-3-5-sonnet-20241022","instructions":"Be brief.","input":"Hello","max_output_tokens":64,"temperature":0.5}`)
+
+		// Assert
+		assert.Equal(t, "claude-3-5-sonnet-20241022", request["model"])
+		assert.Equal(t, float64(64), request["max_tokens"])
+		assert.Equal(t, 0.5, request["temperature"])
+		assert.Equal(t, []string{ClaudeCodePrompt, "Be brief."}, systemPromptTexts(request))
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"role": "user", "content": "Hello"},
+		}, request["messages"])
+	})
+
+	t.Run("should translate input items", func(t *testing.T) {
+		request := transformResponsesRequest(t, `{
+			"model": "claude-3-5-sonnet-20241022",
+			"input": [
+				{"role": "developer", "content": "Use metric units."},
+				{"type": "message", "role": "user", "content": [{"type": "input_text", "text": "Weather?"}]},
+				{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Oslo\"}"},
+				{"type": "function_call_output", "call_id": "call_1", "output": "12C"},
+				{"role": "assistant", "content": [{"type": "output_text", "text": "It is 12C."}]}
+			]
+		}`)
+
+		assert.Equal(t, []string{ClaudeCodePrompt, "Use metric units."}, systemPromptTexts(request))
+		messages := request["messages"].([]interface{})
+		require.Len(t, messages, 4)
+		assert.Equal(t, map[string]interface{}{
+			"role":    "user",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "Weather?"}},
+		}, messages[0])
+		assert.Equal(t, "tool_use", messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["type"])
+		assert.Equal(t, "tool_result", messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["type"])
+		assert.Equal(t, "assistant", messages[3].(map[string]interface{})["role"])
+	})
+
+	t.Run("should translate a JSON text format into a system instruction", func(t *testing.T) {
+		request := transformResponsesRequest(t, `{"model":"claude-3-5-sonnet-20241022","input":"List colors","text":{"format":{"type":"json_object"}}}`)
+
+		texts := systemPromptTexts(request)
+		require.Len(t, texts, 2)
+		assert.Contains(t, texts[1], "valid JSON object")
+	})
+
+	t.Run("should reject requests it cannot translate", func(t *testing.T) {
+		for _, body := range []string{
+			`{"model":"claude-3-5-sonnet-20241022","input":"Hi","previous_response_id":"resp_1"}`,
+			`{"model":"claude-3-5-sonnet-20241022"}`,
+			`{"model":"claude-3-5-sonnet-20241022","input":[{"type":"web_search_call"}]}`,
+			`{"model":"claude-3-5-sonnet-20241022","input":[{"role":"user","content":[{"type":"input_file","file_id":"file_1"}]}]}`,
+		} {
+			_, err := NewRequestTransformer().TransformRequestBody([]byte(body), ResponsesPath)
+			assert.Error(t, err, body)
+		}
+	})
+}
+
+func TestResponsesAPI_Response(t *testing.T) {
+	// sendResponsesRequest proxies a Responses API request to an upstream returning upstreamBody
+	sendResponsesRequest := func(t *testing.T, status int, upstreamBody string) (*httptest.ResponseRecorder, *http.Request) {
+		t.Helper()
+
+		var received *http.Request
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(upstreamBody))
+		}))
+		defer upstream.Close()
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", ResponsesPath, strings.NewReader(`{"model":"claude-3-5-sonnet-20241022","input":"Hi"}`)))
+		return w, received
+	}
+
+	t.Run("should return the Responses API envelope", func(t *testing.T) {
+		// Act
+		w, received := sendResponsesRequest(t, http.StatusOK, `{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-3-5-sonnet-20241022",
+			"content": [{"type": "text", "text": "Hello"}, {"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "x"}}],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 4}
+		}`)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, received)
+		assert.Equal(t, "/v1/messages", received.URL.Path)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, requestid.ResponseID(w.Header().Get(requestid.Header)), response["id"])
+		assert.Equal(t, "response", response["object"])
+		assert.Equal(t, "completed", response["status"])
+		assert.Equal(t, "claude-3-5-sonnet-20241022", response["model"])
+		assert.NotZero(t, response["created_at"])
+		assert.Nil(t, response["error"])
+		assert.Nil(t, response["incomplete_details"])
+
+		output := response["output"].([]interface{})
+		require.Len(t, output, 2)
+		assert.Equal(t, map[string]interface{}{
+			"type":   "message",
+			"id":     "msg_1",
+			"status": "completed",
+			"role":   "assistant",
+			"content": []interface{}{
+				map[string]interface{}{"type": "output_text", "text": "Hello", "annotations": []interface{}{}},
+			},
+		}, output[0])
+		assert.Equal(t, map[string]interface{}{
+			"type":      "function_call",
+			"id":        "fc_toolu_1",
+			"call_id":   "toolu_1",
+			"name":      "lookup",
+			"arguments": `{"q":"x"}`,
+			"status":    "completed",
+		}, output[1])
+
+		usage := response["usage"].(map[string]interface{})
+		assert.Equal(t, float64(14), usage["input_tokens"])
+		assert.Equal(t, float64(5), usage["output_tokens"])
+		assert.Equal(t, float64(19), usage["total_tokens"])
+		assert.Equal(t, float64(4), usage["input_tokens_details"].(map[string]interface{})["cached_tokens"])
+	})
+
+	t.Run("should mark a response cut off by the token limit incomplete", func(t *testing.T) {
+		w, _ := sendResponsesRequest(t, http.StatusOK, `{"id":"msg_1","type":"message","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Hel"}],"stop_reason":"max_tokens"}`)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "incomplete", response["status"])
+		assert.Equal(t, map[string]interface{}{"reason": "max_output_tokens"}, response["incomplete_details"])
+	})
+
+	t.Run("should return upstream errors in the OpenAI error format", func(t *testing.T) {
+		w, _ := sendResponsesRequest(t, http.StatusBadRequest, `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: too large"}}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "max_tokens: too large", response["error"].(map[string]interface{})["message"])
+	})
+}
+
+func TestResponsesAPI_Streaming(t *testing.T) {
+	// parseResponsesEvents returns the data of each event in a Responses API stream
+	parseResponsesEvents := func(t *testing.T, body io.Reader) []map[string]interface{} {
+		t.Helper()
+
+		var events []map[string]interface{}
+		var eventType string
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				eventType = name
+			} else if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var event map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(data), &event))
+				assert.Equal(t, eventType, event["type"], "the event name should match its type")
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	t.Run("should stream output_text deltas inside the Responses API event sequence", func(t *testing.T) {
+		// Arrange
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Deltas: []string{"Hello", ", world"}})
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", ResponsesPath, strings.NewReader(`{"model":"claude-sonnet-4-20250514","input":"Hi","stream":true}`)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		events := parseResponsesEvents(t, w.Body)
+
+		var types []string
+		for i, event := range events {
+			types = append(types, event["type"].(string))
+			assert.Equal(t, float64(i), event["sequence_number"])
+		}
+		assert.Equal(t, []string{
+			"response.created",
+			"response.output_item.added",
+			"response.content_part.added",
+			"response.output_text.delta",
+			"response.output_text.delta",
+			"response.output_text.done",
+			"response.content_part.done",
+			"response.output_item.done",
+			"response.completed",
+		}, types)
+
+		assert.Equal(t, "Hello", events[3]["delta"])
+		assert.Equal(t, "Hello, world", events[5]["text"])
+
+		completed := events[len(events)-1]["response"].(map[string]interface{})
+		assert.Equal(t, requestid.ResponseID(w.Header().Get(requestid.Header)), completed["id"])
+		assert.Equal(t, "completed", completed["status"])
+		assert.Equal(t, "claude-sonnet-4-20250514", completed["model"])
+		message := completed["output"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Hello, world", message["content"].([]interface{})[0].(map[string]interface{})["text"])
+		assert.Equal(t, float64(2), completed["usage"].(map[string]interface{})["output_tokens"])
+	})
+
+	t.Run("should return an HTTP error for an error before any output", func(t *testing.T) {
+		upstream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Failure: helpers.StreamErrorFirst})
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, httptest.NewRequest("POST", ResponsesPath, strings.NewReader(`{"model":"claude-sonnet-4-20250514","input":"Hi","stream":true}`)))
+
+		assert.Equal(t, 529, w.Code)
+	})
+}
@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/requestid"
+)
+
+// responsesStreamConverter converts one Anthropic SSE stream into Responses API stream
+// events. Text is streamed as output_text deltas of a single assistant message; tool
+// calls are only returned by non-streaming requests.
+type responsesStreamConverter struct {
+	response map[string]interface{}
+	itemID   string
+	sequence int
+
+	itemOpen   bool
+	parts      []interface{}
+	text       strings.Builder
+	textOpen   bool
+	usage      map[string]interface{}
+	stopReason interface{}
+}
+
+// newResponsesStreamConverter creates a converter for the response with the given ID
+func newResponsesStreamConverter(responseID string, createdAt int64) *responsesStreamConverter {
+	return &responsesStreamConverter{
+		response: newResponseObject(responseID, "", createdAt),
+		usage:    map[string]interface{}{},
+	}
+}
+
+// Convert returns the Responses API events for one Anthropic event, if any
+func (c *responsesStreamConverter) Convert(event, data string) (string, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	switch event {
+	case "message_start":
+		message, _ := payload["message"].(map[string]interface{})
+		c.response["model"], _ = message["model"].(string)
+		c.itemID, _ = message["id"].(string)
+		c.mergeUsage(message["usage"])
+		c.emit(&out, "response.created", map[string]interface{}{"response": c.response})
+
+	case "content_block_start":
+		block, _ := payload["content_block"].(map[string]interface{})
+		if block["type"] != "text" {
+			break
+		}
+		if !c.itemOpen {
+			c.itemOpen = true
+			c.emit(&out, "response.output_item.added", map[string]interface{}{
+				"output_index": 0,
+				"item":         outputMessageItem(c.itemID, "in_progress", nil),
+			})
+		}
+		c.textOpen = true
+		c.text.Reset()
+		c.emit(&out, "response.content_part.added", c.partEvent(map[string]interface{}{"part": outputTextPart("")}))
+
+	case "content_block_delta":
+		delta, _ := payload["delta"].(map[string]interface{})
+		if text, ok := delta["text"].(string); ok && c.textOpen && delta["type"] == "text_delta" {
+			c.text.WriteString(text)
+			c.emit(&out, "response.output_text.delta", c.partEvent(map[string]interface{}{"delta": text}))
+		}
+
+	case "content_block_stop":
+		if !c.textOpen {
+			break
+		}
+		part := outputTextPart(c.text.String())
+		c.emit(&out, "response.output_text.done", c.partEvent(map[string]interface{}{"text": c.text.String()}))
+		c.emit(&out, "response.content_part.done", c.partEvent(map[string]interface{}{"part": part}))
+		c.parts = append(c.parts, part)
+		c.textOpen = false
+
+	case "message_delta":
+		delta, _ := payload["delta"].(map[string]interface{})
+		if stopReason, ok := delta["stop_reason"]; ok {
+			c.stopReason = stopReason
+		}
+		c.mergeUsage(payload["usage"])
+
+	case "message_stop":
+		if c.itemOpen {
+			item := outputMessageItem(c.itemID, "completed", c.parts)
+			c.emit(&out, "response.output_item.done", map[string]interface{}{"output_index": 0, "item": item})
+			c.response["output"] = []interface{}{item}
+		}
+		c.response["usage"] = responsesUsage(c.usage)
+		setResponseStatus(c.response, c.stopReason)
+		if c.response["status"] == "incomplete" {
+			c.emit(&out, "response.incomplete", map[string]interface{}{"response": c.response})
+		} else {
+			c.emit(&out, "response.completed", map[string]interface{}{"response": c.response})
+		}
+
+	case "error":
+		errorObj, _ := payload["error"].(map[string]interface{})
+		errorType, _ := errorObj["type"].(string)
+		c.emit(&out, "error", map[string]interface{}{
+			"code":    errorType,
+			"message": errorObj["message"],
+			"param":   nil,
+		})
+	}
+
+	return out.String(), nil
+}
+
+// partEvent adds the position of the current content part to an event
+func (c *responsesStreamConverter) partEvent(fields map[string]interface{}) map[string]interface{} {
+	fields["item_id"] = c.itemID
+	fields["output_index"] = 0
+	fields["content_index"] = len(c.parts)
+	return fields
+}
+
+// mergeUsage keeps the latest value of each usage field
+func (c *responsesStreamConverter) mergeUsage(usage interface{}) {
+	usageMap, _ := usage.(map[string]interface{})
+	for key, value := range usageMap {
+		c.usage[key] = value
+	}
+}
+
+// emit writes one Responses API event
+func (c *responsesStreamConverter) emit(out *strings.Builder, eventType string, fields map[string]interface{}) {
+	fields["type"] = eventType
+	fields["sequence_number"] = c.sequence
+	c.sequence++
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(out, "event: %s\ndata: %s\n\n", eventType, data)
+}
+
+// streamResponsesAPI converts Anthropic SSE into Responses API stream events
+func (h *ProxyHandler) streamResponsesAPI(w *deferredStreamWriter, resp *http.Response, requestID string, logger *slog.Logger) {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if !ok {
+		logger.Warn("response writer does not support flushing for Responses API streaming")
+		h.streamResponse(w, resp, logger)
+		return
+	}
+
+	converter := newResponsesStreamConverter(requestid.ResponseID(requestID), time.Now().Unix())
+	scanner := bufio.NewScanner(resp.Body)
+	var currentEvent string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "event: ") {
+			currentEvent = strings.TrimPrefix(line, "event: ")
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+
+		// Before any output the client still expects a plain HTTP response
+		if currentEvent == "error" && !w.Committed() {
+			logger.Warn("upstream error event before any stream output", "data", data)
+			h.writeStreamErrorEvent(w.ResponseWriter, data)
+			return
+		}
+
+		converted, err := converter.Convert(currentEvent, data)
+		if err != nil {
+			logger.Error("failed to convert SSE event", "event", currentEvent, "error", err)
+			continue
+		}
+		if converted == "" {
+			continue
+		}
+		if _, err := w.Write([]byte(converted)); err != nil {
+			logger.Error("failed to write converted event", "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+
+	// A stream that failed or ended before producing any output is reported as a
+	// gateway error instead of an empty 200 stream
+	if !w.Committed() {
+		logger.Error("upstream stream ended before sending any data", "error", scanner.Err())
+		h.writeError(w.ResponseWriter, http.StatusBadGateway, "api_error", "Upstream stream ended before sending any data")
+		return
+	}
+
+	if err := scanner.Err(); err != nil {
+		logger.Error("scanner error during Responses API streaming", "error", err)
+	}
+}
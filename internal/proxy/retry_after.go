@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// retryRateLimitedStream handles a 429 received before a stream started. When the
+// upstream's Retry-After fits within RetryAfterMaxWait it waits and retries once,
+// returning the new response; otherwise it returns resp unchanged.
+func (h *ProxyHandler) retryRateLimitedStream(ctx context.Context, req *http.Request, resp *http.Response, logger *slog.Logger) *http.Response {
+	maxWait := h.config.RetryAfterMaxWait
+	if maxWait <= 0 || req.GetBody == nil {
+		return resp
+	}
+
+	wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok || wait > maxWait {
+		logger.Info("not retrying rate limited stream", "retry_after", resp.Header.Get("Retry-After"), "max_wait", maxWait)
+		return resp
+	}
+
+	logger.Info("upstream rate limited stream before it started, retrying once", "wait", wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return resp
+	}
+
+	if h.throttle != nil {
+		if err := h.throttle.Wait(ctx); err != nil {
+			return resp
+		}
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return resp
+	}
+	retryReq := req.Clone(ctx)
+	retryReq.Body = body
+
+	retryResp, err := h.httpClient.Do(retryReq)
+	if err != nil {
+		logger.Error("retry after rate limit failed", "error", err)
+		return resp
+	}
+	resp.Body.Close()
+	return retryResp
+}
+
+// writeRateLimited answers a stream that was rate limited before it started with a
+// plain 429, echoing the upstream's Retry-After so the client can back off
+func (h *ProxyHandler) writeRateLimited(w http.ResponseWriter, resp *http.Response) {
+	retryAfter := resp.Header.Get("Retry-After")
+	message := "Rate limited by Anthropic"
+	if retryAfter != "" {
+		w.Header().Set("Retry-After", retryAfter)
+		if _, err := strconv.Atoi(retryAfter); err == nil {
+			message = fmt.Sprintf("Rate limited by Anthropic, retry after %s seconds", retryAfter)
+		} else {
+			message = "Rate limited by Anthropic, retry after " + retryAfter
+		}
+	}
+
+	var upstreamErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil {
+		if json.Unmarshal(body, &upstreamErr) == nil && upstreamErr.Error.Message != "" {
+			message = upstreamErr.Error.Message
+		}
+	}
+
+	h.writeError(w, http.StatusTooManyRequests, "rate_limit_error", message)
+}
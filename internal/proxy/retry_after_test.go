@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should parse seconds", func(t *testing.T) {
+		wait, ok := parseRetryAfter("30", now)
+
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Second, wait)
+	})
+
+	t.Run("should parse an HTTP date", func(t *testing.T) {
+		wait, ok := parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now)
+
+		assert.True(t, ok)
+		assert.Equal(t, 90*time.Second, wait)
+	})
+
+	t.Run("should reject missing or malformed values", func(t *testing.T) {
+		for _, value := range []string{"", "soon", "-5"} {
+			_, ok := parseRetryAfter(value, now)
+			assert.False(t, ok, value)
+		}
+	})
+}
+
+func TestProxyHandler_PreStreamRateLimit(t *testing.T) {
+	// newRateLimitedUpstream answers the first rateLimited requests with a 429 and then streams normally
+	newRateLimitedUpstream := func(t *testing.T, rateLimited int32, retryAfter string) (string, *int32) {
+		stream := helpers.CreateMockStreamingServer(t, helpers.MockStreamOptions{Deltas: []string{"Hello"}})
+
+		var calls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) <= rateLimited {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`))
+				return
+			}
+			stream.Config.Handler.ServeHTTP(w, r)
+		}))
+		t.Cleanup(upstream.Close)
+		return upstream.URL, &calls
+	}
+
+	serve := func(upstreamURL string, maxWait time.Duration) *httptest.ResponseRecorder {
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:       upstreamURL,
+			TokenProvider:     &mockTokenProvider{token: "test-token"},
+			Transformer:       NewRequestTransformer(),
+			RetryAfterMaxWait: maxWait,
+		})
+		body := `{"model":"claude-sonnet-4-20250514","stream":true,"messages":[{"role":"user","content":"Hello"}]}`
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should wait out a short Retry-After and retry once", func(t *testing.T) {
+		// Arrange
+		upstreamURL, calls := newRateLimitedUpstream(t, 1, "0")
+
+		// Act
+		w := serve(upstreamURL, 5*time.Second)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+		helpers.AssertStreamCompleted(t, helpers.ParseOpenAIStream(t, w.Body.String()), "Hello")
+	})
+
+	t.Run("should return a 429 with Retry-After when the wait exceeds the bound", func(t *testing.T) {
+		// Arrange
+		upstreamURL, calls := newRateLimitedUpstream(t, 1, "120")
+
+		// Act
+		w := serve(upstreamURL, 5*time.Second)
+
+		// Assert
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "120", w.Header().Get("Retry-After"))
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+		var response struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "rate_limit_error", response.Error.Type)
+		assert.Contains(t, response.Error.Message, "rate limit")
+	})
+
+	t.Run("should retry only once", func(t *testing.T) {
+		upstreamURL, calls := newRateLimitedUpstream(t, 2, "0")
+
+		w := serve(upstreamURL, 5*time.Second)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("Retry-After"))
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+
+	t.Run("should not retry when retries are disabled", func(t *testing.T) {
+		upstreamURL, calls := newRateLimitedUpstream(t, 1, "0")
+
+		w := serve(upstreamURL, 0)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+}
@@ -3,26 +3,45 @@ package proxy
 import (
 	"encoding/json"
 	"net/http"
+	"os"
 	
 	"github.com/ml0-1337/claude-gate/internal/auth"
+	"github.com/ml0-1337/claude-gate/internal/metrics"
+	"github.com/ml0-1337/claude-gate/internal/requestid"
 )
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	storage auth.StorageBackend
+	storage   auth.StorageBackend
+	account   string
+	proxyAuth bool
 }
 
-// NewHealthHandler creates a new health handler
+// NewHealthHandler creates a new health handler for the default account
 func NewHealthHandler(storage auth.StorageBackend) *HealthHandler {
+	return NewHealthHandlerForAccount(storage, auth.DefaultAccount)
+}
+
+// NewHealthHandlerForAccount creates a health handler reporting the named account
+func NewHealthHandlerForAccount(storage auth.StorageBackend, account string) *HealthHandler {
+	if account == "" {
+		account = auth.DefaultAccount
+	}
 	return &HealthHandler{
 		storage: storage,
+		account: account,
 	}
 }
 
+// SetProxyAuth reports whether clients need a local API key
+func (h *HealthHandler) SetProxyAuth(enabled bool) {
+	h.proxyAuth = enabled
+}
+
 func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Check OAuth status
 	oauthStatus := "not_configured"
-	if token, err := h.storage.Get("anthropic"); err == nil && token != nil {
+	if token, err := h.storage.Get(auth.AccountKey(h.account)); err == nil && token != nil {
 		if token.Type == "oauth" {
 			oauthStatus = "ready"
 		}
@@ -31,47 +50,167 @@ func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"status":       "healthy",
 		"oauth_status": oauthStatus,
-		"proxy_auth":   "disabled", // TODO: get from config
+		"account":      h.account,
+		"proxy_auth":   proxyAuthStatus(h.proxyAuth),
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// proxyAuthStatus describes whether local API keys are required
+func proxyAuthStatus(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
 // RootHandler handles the root endpoint
-type RootHandler struct{}
+type RootHandler struct {
+	proxyAuth bool
+	metrics   bool
+}
 
 func (h *RootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoints := map[string]interface{}{
+		"health":       "/health",
+		"liveness":     LivenessPath,
+		"readiness":    ReadinessPath,
+		"pricing":      PricingPath,
+		"chat_completions": ChatCompletionsPath,
+		"completions":  CompletionsPath,
+		"anthropic_api": "/*",
+	}
+	if h.metrics {
+		endpoints["metrics"] = "/metrics"
+	}
 	response := map[string]interface{}{
 		"service":     "Claude OAuth Proxy",
 		"description": "Anthropic API proxy with OAuth authentication injection",
-		"endpoints": map[string]interface{}{
-			"health":       "/health",
-			"anthropic_api": "/*",
-		},
+		"endpoints":   endpoints,
 		"oauth_required": true,
-		"proxy_auth": "disabled", // TODO: get from config
+		"proxy_auth": proxyAuthStatus(h.proxyAuth),
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-// CreateMux creates the HTTP mux with all routes
-func CreateMux(proxyHandler http.Handler, healthHandler http.Handler, tokenProvider TokenProvider, upstreamURL string) http.Handler {
+// CreateMux creates the HTTP mux with all routes, behind the request ID, CORS and access
+// log middleware
+func CreateMux(proxyHandler http.Handler, healthHandler http.Handler, config *ProxyConfig) http.Handler {
+	handler, _ := createMux(proxyHandler, healthHandler, config)
+	return handler
+}
+
+// createMux builds the handler of CreateMux and returns the parts Reload swaps settings in
+func createMux(proxyHandler http.Handler, healthHandler http.Handler, config *ProxyConfig) (http.Handler, *reloadTargets) {
 	mux := http.NewServeMux()
 	
 	// Health check endpoint
 	mux.Handle("/health", healthHandler)
 	
-	// Root endpoint
-	mux.Handle("/", &RootHandler{})
+	// Prometheus metrics endpoint
+	if !config.DisableMetrics {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+	
+	// Root endpoint; every other unmatched path gets an OpenAI-style 404
+	mux.Handle("/{$}", &RootHandler{
+		proxyAuth: newLocalKeyGate(config.LocalAPIKeys) != nil,
+		metrics:   !config.DisableMetrics,
+	})
+	mux.Handle("/", NotFoundHandler{})
 	
 	// Models endpoint for OpenAI compatibility
-	mux.Handle("/v1/models", NewModelsHandler(tokenProvider, upstreamURL))
+	ttl := config.ModelsCacheTTL
+	if config.Mock {
+		ttl = 0 // Mock mode never calls Anthropic
+	}
+	upstream := config.upstream()
+	modelsHandler := NewModelsHandlerWithTTL(config.TokenProvider, upstream.BaseURL, ttl)
+	modelsHandler.SetIncludeCapabilities(config.IncludeModelCapabilities)
+	modelsHandler.SetEmptyListNote(config.ModelsEmptyNote)
+	modelsHandler.SetAllowedModels(config.AllowedModels)
+	modelsHandler.SetBetas(config.Betas)
+	if config.Transformer != nil {
+		// The list is not for a model, so per-model overrides do not apply
+		modelsHandler.SetAnthropicVersion(config.Transformer.versionFor("", upstream.AnthropicVersion))
+	}
+	modelsHandler.SetRetries(config.UpstreamRetries, config.UpstreamRetryDelay)
+	if config.ModelsTimeout > 0 {
+		modelsHandler.SetTimeout(config.ModelsTimeout)
+	}
+	if config.HTTPClient != nil {
+		modelsHandler.SetHTTPClient(config.HTTPClient)
+	} else if config.UpstreamProxy != nil {
+		modelsHandler.SetUpstreamProxy(config.UpstreamProxy)
+	}
+	if config.ModelsCacheFile != "" {
+		modelsHandler.SetModelsCache(ttl, config.ModelsCacheFile)
+	}
+	mux.Handle("/v1/models", modelsHandler)
+	mux.Handle("/v1/models/", modelsHandler)
+	
+	// Model prices for cost dashboards, a claude-gate extension
+	mux.Handle(PricingPath, NewPricingHandler(config.Pricing))
+	
+	if handler, ok := proxyHandler.(*ProxyHandler); ok {
+		// OpenAI chat completions, translated to Anthropic messages
+		mux.Handle(ChatCompletionsPath, NewChatCompletionsHandler(handler))
+		
+		// Legacy OpenAI text completions, for older tooling
+		mux.Handle(CompletionsPath, NewCompletionsHandler(handler))
+		
+		// Dry-run translation of chat completions, only in debug mode
+		if config.Debug {
+			mux.Handle(DebugTranslatePath, NewTranslateHandler(handler))
+		}
+		
+		// Operator endpoints, only available with an admin key
+		if config.AdminKey != "" {
+			mux.Handle("/streams", requireAdminKey(config.AdminKey, config.Audit, NewStreamsHandler(handler)))
+		}
+	}
 	
 	// All other paths go to the proxy
 	mux.Handle("/v1/", proxyHandler)
 	
-	return mux
+	var handler http.Handler = mux
+	if config.RateLimiter != nil {
+		handler = rateLimitMiddleware(handler, config.RateLimiter, config.Logger)
+	}
+	// Checked before the rate limit, so unauthenticated clients hold no buckets
+	handler = requireLocalKey(config.LocalAPIKeys, config.Audit, handler)
+	cors := newCORSPolicy(config.CORSAllowedOrigins, config.CORSAllowCredentials)
+	cors.warnConflict(config.Logger)
+	targets := &reloadTargets{models: modelsHandler, rateLimiter: config.RateLimiter, logger: config.Logger}
+	targets.cors.Store(cors)
+	handler = corsMiddleware(handler, &targets.cors)
+	
+	// Access log lines for existing log pipelines, alongside the structured logger
+	if config.AccessLogFormat != "" && config.AccessLogFormat != AccessLogNone {
+		out := config.AccessLog
+		if out == nil {
+			out = os.Stdout
+		}
+		handler = accessLogMiddleware(handler, config.AccessLogFormat, out, accessLogOptions{
+			level:    config.AccessLogLevel,
+			bodySize: config.AccessLogBodySize,
+		})
+	}
+	
+	// Kubernetes probes, outside CORS and the access log
+	var upstreamClient *http.Client
+	if handler, ok := proxyHandler.(*ProxyHandler); ok && config.ReadinessCheckUpstream && !config.Mock {
+		upstreamClient = handler.httpClient
+	}
+	handler = probesMiddleware(handler, NewReadinessHandler(config.TokenProvider, upstream.BaseURL, upstreamClient))
+	
+	// Request counts by route and status, probes included
+	handler = metricsMiddleware(handler)
+	
+	// Outermost, so every route and the access log share the request ID
+	return requestid.Middleware(handler), targets
 }
\ No newline at end of file
@@ -19,31 +19,49 @@ type EnhancedProxyServer struct {
 func NewEnhancedProxyServer(config *ProxyConfig, address string, storage auth.StorageBackend) *EnhancedProxyServer {
 	// Create base proxy server components
 	handler := NewProxyHandler(config)
-	healthHandler := NewHealthHandler(storage)
+	healthHandler := NewHealthHandlerForAccount(storage, config.Account)
+	healthHandler.SetProxyAuth(newLocalKeyGate(config.LocalAPIKeys) != nil)
 	
 	// Create dashboard
-	dashboardModel := dashboard.New(fmt.Sprintf("http://%s", address))
+	scheme := "http"
+	if config.TLS != nil {
+		scheme = "https"
+	}
+	serverURL := fmt.Sprintf("%s://%s", scheme, address)
+	if config.Socket != "" {
+		serverURL = "unix:" + config.Socket
+	}
+	dashboardModel := dashboard.New(serverURL)
 	
 	// Create middleware that logs to dashboard
 	middleware := &dashboardMiddleware{
-		handler:   CreateMux(handler, healthHandler, config.TokenProvider, config.UpstreamURL),
+		handler:   CreateMux(handler, healthHandler, config),
 		dashboard: dashboardModel,
 	}
 	
 	// Create enhanced server
 	server := &http.Server{
-		Addr:         address,
-		Handler:      middleware,
-		ReadTimeout:  time.Minute,
-		WriteTimeout: 10 * time.Minute, // Long timeout for streaming
+		Addr:           address,
+		Handler:        middleware,
+		ReadTimeout:    time.Minute,
+		WriteTimeout:   max(10*time.Minute, config.longestDeadline()+10*time.Second), // Long timeout for streaming
+		MaxHeaderBytes: config.maxHeaderBytes(),
+		TLSConfig:      config.TLS,
 	}
 	
+	proxyServer := &ProxyServer{
+		handler:           handler,
+		server:            server,
+		maxConnections:    config.MaxConnections,
+		redirectPlainHTTP: config.TLS != nil && config.PlainHTTP == PlainHTTPRedirect,
+		socket:            config.Socket,
+		socketMode:        config.socketMode(),
+	}
+	proxyServer.trackRequests()
+	
 	return &EnhancedProxyServer{
-		ProxyServer: &ProxyServer{
-			handler: handler,
-			server:  server,
-		},
-		dashboard: dashboardModel,
+		ProxyServer: proxyServer,
+		dashboard:   dashboardModel,
 	}
 }
 
@@ -59,8 +77,8 @@ type dashboardMiddleware struct {
 }
 
 func (m *dashboardMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Skip health checks and root endpoint
-	if r.URL.Path == "/health" || r.URL.Path == "/" {
+	// Skip health checks, metrics scrapes and root endpoint
+	if r.URL.Path == "/health" || r.URL.Path == "/metrics" || r.URL.Path == "/" {
 		m.handler.ServeHTTP(w, r)
 		return
 	}
@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// SessionHeader names the conversation a request continues. With the session store
+// enabled, clients send only their new messages and the proxy prepends the earlier turns.
+const SessionHeader = "X-Claude-Gate-Session"
+
+const (
+	// DefaultSessionTTL is how long an idle session is kept
+	DefaultSessionTTL = 30 * time.Minute
+	// DefaultSessionMaxTurns is how many turns a session keeps
+	DefaultSessionMaxTurns = 20
+
+	// maxSessionIDLength bounds the client-provided session IDs
+	maxSessionIDLength = 128
+	// maxSessionResponseBytes bounds the response recorded for a session turn
+	maxSessionResponseBytes = 8 << 20
+)
+
+// errSessionIDTooLong is returned for session IDs over maxSessionIDLength
+var errSessionIDTooLong = errors.New("session ID too long")
+
+// SessionStore keeps the turns of conversations in memory, keyed by SessionHeader.
+// Sessions are lost on restart and are not shared between proxy instances.
+type SessionStore struct {
+	ttl      time.Duration
+	maxTurns int
+
+	mu        sync.Mutex
+	sessions  map[string]*session
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+// session is the history of one conversation. Each turn holds the messages a client
+// sent followed by the assistant's reply, so the turns joined form a valid
+// Anthropic messages array.
+type session struct {
+	turns   [][]interface{}
+	updated time.Time
+}
+
+// NewSessionStore creates a store keeping sessions idle for up to ttl, with at most
+// maxTurns turns each; zero values use the defaults
+func NewSessionStore(ttl time.Duration, maxTurns int) *SessionStore {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	if maxTurns <= 0 {
+		maxTurns = DefaultSessionMaxTurns
+	}
+	return &SessionStore{
+		ttl:      ttl,
+		maxTurns: maxTurns,
+		sessions: make(map[string]*session),
+		now:      time.Now,
+	}
+}
+
+// History returns the messages of a session's earlier turns, or nil for an unknown
+// or expired session
+func (s *SessionStore) History(id string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	if s.now().Sub(sess.updated) > s.ttl {
+		delete(s.sessions, id)
+		return nil
+	}
+
+	var messages []interface{}
+	for _, turn := range sess.turns {
+		messages = append(messages, turn...)
+	}
+	return messages
+}
+
+// Append records a turn, dropping the oldest turns beyond the limit
+func (s *SessionStore) Append(id string, messages []interface{}, reply interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	sess, ok := s.sessions[id]
+	if !ok || now.Sub(sess.updated) > s.ttl {
+		sess = &session{}
+		s.sessions[id] = sess
+	}
+	turn := append(append([]interface{}{}, messages...), reply)
+	sess.turns = append(sess.turns, turn)
+	if len(sess.turns) > s.maxTurns {
+		sess.turns = sess.turns[len(sess.turns)-s.maxTurns:]
+	}
+	sess.updated = now
+}
+
+// sweep drops expired sessions, at most once a minute
+func (s *SessionStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for id, sess := range s.sessions {
+		if now.Sub(sess.updated) > s.ttl {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// continueSession prepends the history of a session to the messages of an Anthropic
+// request. It returns the new body and the messages the client sent, which make up
+// the session's next turn.
+func (s *SessionStore) continueSession(id string, body []byte) ([]byte, []interface{}, error) {
+	if len(id) > maxSessionIDLength {
+		return nil, nil, errSessionIDTooLong
+	}
+
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, nil, err
+	}
+	messages, _ := request["messages"].([]interface{})
+
+	history := s.History(id)
+	if len(history) == 0 {
+		return body, messages, nil
+	}
+	request["messages"] = append(history, messages...)
+	continued, err := json.Marshal(request)
+	return continued, messages, err
+}
+
+// sessionRecorder wraps a successful upstream response body, recording the
+// assistant's reply in the session when the body is closed. Replies that did not
+// complete, errors, and responses too large to buffer are not recorded.
+type sessionRecorder struct {
+	body      io.ReadCloser
+	streaming bool
+	buf       bytes.Buffer
+	overflow  bool
+	done      func(reply map[string]interface{})
+	closed    bool
+}
+
+// newSessionRecorder wraps body, handing the reply as an Anthropic message to done
+func newSessionRecorder(body io.ReadCloser, streaming bool, done func(reply map[string]interface{})) *sessionRecorder {
+	return &sessionRecorder{body: body, streaming: streaming, done: done}
+}
+
+func (r *sessionRecorder) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 && !r.overflow {
+		if r.buf.Len()+n > maxSessionResponseBytes {
+			r.overflow = true
+			r.buf.Reset()
+		} else {
+			r.buf.Write(p[:n])
+		}
+	}
+	return n, err
+}
+
+func (r *sessionRecorder) Close() error {
+	err := r.body.Close()
+	if r.closed {
+		return err
+	}
+	r.closed = true
+	if r.overflow {
+		return err
+	}
+
+	message := r.buf.Bytes()
+	if r.streaming {
+		aggregated, partial, aggErr := aggregateMessageStream(bytes.NewReader(message))
+		if aggErr != nil || partial {
+			return err
+		}
+		message = aggregated
+	}
+
+	var reply map[string]interface{}
+	if json.Unmarshal(message, &reply) != nil || reply["type"] != "message" {
+		return err
+	}
+	// Anthropic rejects assistant messages without content in later requests
+	if content, _ := reply["content"].([]interface{}); len(content) == 0 {
+		return err
+	}
+	r.done(map[string]interface{}{
+		"role":    "assistant",
+		"content": reply["content"],
+	})
+	return err
+}
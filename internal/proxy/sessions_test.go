@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStore(t *testing.T) {
+	user := func(text string) interface{} { return map[string]interface{}{"role": "user", "content": text} }
+	assistant := func(text string) interface{} { return map[string]interface{}{"role": "assistant", "content": text} }
+
+	t.Run("should return the turns of a session in order", func(t *testing.T) {
+		// Arrange
+		store := NewSessionStore(time.Hour, 10)
+
+		// Act
+		store.Append("s1", []interface{}{user("Hi")}, assistant("Hello"))
+		store.Append("s1", []interface{}{user("How are you?")}, assistant("Fine"))
+
+		// Assert
+		assert.Equal(t, []interface{}{user("Hi"), assistant("Hello"), user("How are you?"), assistant("Fine")}, store.History("s1"))
+		assert.Nil(t, store.History("s2"))
+	})
+
+	t.Run("should expire idle sessions", func(t *testing.T) {
+		now := time.Unix(1700000000, 0)
+		store := NewSessionStore(time.Minute, 10)
+		store.now = func() time.Time { return now }
+		store.Append("s1", []interface{}{user("Hi")}, assistant("Hello"))
+
+		now = now.Add(59 * time.Second)
+		assert.Len(t, store.History("s1"), 2)
+
+		now = now.Add(2 * time.Second)
+		assert.Nil(t, store.History("s1"))
+	})
+
+	t.Run("should start over when appending to an expired session", func(t *testing.T) {
+		now := time.Unix(1700000000, 0)
+		store := NewSessionStore(time.Minute, 10)
+		store.now = func() time.Time { return now }
+		store.Append("s1", []interface{}{user("Hi")}, assistant("Hello"))
+
+		now = now.Add(2 * time.Minute)
+		store.Append("s1", []interface{}{user("Again")}, assistant("Hello again"))
+
+		assert.Equal(t, []interface{}{user("Again"), assistant("Hello again")}, store.History("s1"))
+	})
+
+	t.Run("should keep only the latest turns", func(t *testing.T) {
+		store := NewSessionStore(time.Hour, 2)
+		for i := 1; i <= 3; i++ {
+			store.Append("s1", []interface{}{user(fmt.Sprint(i))}, assistant(fmt.Sprint(i)))
+		}
+
+		assert.Equal(t, []interface{}{user("2"), assistant("2"), user("3"), assistant("3")}, store.History("s1"))
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		store := NewSessionStore(time.Hour, 1000)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				store.Append("s1", []interface{}{user("Hi")}, assistant("Hello"))
+				store.History("s1")
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, store.History("s1"), 40)
+	})
+}
+
+func TestProxyHandler_Sessions(t *testing.T) {
+	// sessionUpstream answers with a reply numbered after the request, recording the
+	// messages each request carried
+	sessionUpstream := func(t *testing.T, streaming bool) (*httptest.Server, *[][]interface{}) {
+		var received [][]interface{}
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				Messages []interface{} `json:"messages"`
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+			received = append(received, request.Messages)
+			reply := fmt.Sprintf("Reply %d", len(received))
+
+			if streaming {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprintf(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"content\":[],\"model\":\"claude-sonnet-4-20250514\",\"usage\":{\"input_tokens\":5,\"output_tokens\":0}}}\n\n")
+				fmt.Fprintf(w, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")
+				fmt.Fprintf(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":%q}}\n\n", reply)
+				fmt.Fprintf(w, "event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n")
+				fmt.Fprintf(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":2}}\n\n")
+				fmt.Fprintf(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[{"type":"text","text":%q}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":2}}`, reply)
+		}))
+		t.Cleanup(upstream.Close)
+		return upstream, &received
+	}
+	send := func(handler http.Handler, session, text string, stream bool) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"model":"claude-sonnet-4-20250514","stream":%t,"messages":[{"role":"user","content":%q}]}`, stream, text)
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+		if session != "" {
+			req.Header.Set(SessionHeader, session)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	newHandler := func(upstreamURL string, sessions *SessionStore) *ProxyHandler {
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstreamURL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			Sessions:      sessions,
+		})
+	}
+	// texts returns the role and text of each message sent upstream
+	texts := func(messages []interface{}) []string {
+		var out []string
+		for _, m := range messages {
+			message := m.(map[string]interface{})
+			switch content := message["content"].(type) {
+			case string:
+				out = append(out, message["role"].(string)+": "+content)
+			case []interface{}:
+				block := content[0].(map[string]interface{})
+				out = append(out, message["role"].(string)+": "+block["text"].(string))
+			}
+		}
+		return out
+	}
+
+	for _, streaming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("should continue a session across turns (stream=%t)", streaming), func(t *testing.T) {
+			// Arrange
+			upstream, received := sessionUpstream(t, streaming)
+			handler := newHandler(upstream.URL, NewSessionStore(time.Hour, 10))
+
+			// Act
+			require.Equal(t, http.StatusOK, send(handler, "s1", "First", streaming).Code)
+			require.Equal(t, http.StatusOK, send(handler, "s1", "Second", streaming).Code)
+			require.Equal(t, http.StatusOK, send(handler, "s1", "Third", streaming).Code)
+
+			// Assert
+			require.Len(t, *received, 3)
+			assert.Equal(t, []string{"user: First"}, texts((*received)[0]))
+			assert.Equal(t, []string{"user: First", "assistant: Reply 1", "user: Second"}, texts((*received)[1]))
+			assert.Equal(t, []string{"user: First", "assistant: Reply 1", "user: Second", "assistant: Reply 2", "user: Third"}, texts((*received)[2]))
+		})
+	}
+
+	t.Run("should keep sessions apart", func(t *testing.T) {
+		upstream, received := sessionUpstream(t, false)
+		handler := newHandler(upstream.URL, NewSessionStore(time.Hour, 10))
+
+		send(handler, "s1", "First", false)
+		send(handler, "s2", "Other", false)
+
+		assert.Equal(t, []string{"user: Other"}, texts((*received)[1]))
+	})
+
+	t.Run("should forget an expired session", func(t *testing.T) {
+		// Arrange
+		now := time.Unix(1700000000, 0)
+		store := NewSessionStore(time.Minute, 10)
+		store.now = func() time.Time { return now }
+		upstream, received := sessionUpstream(t, false)
+		handler := newHandler(upstream.URL, store)
+		send(handler, "s1", "First", false)
+
+		// Act
+		now = now.Add(2 * time.Minute)
+		send(handler, "s1", "Second", false)
+
+		// Assert
+		assert.Equal(t, []string{"user: Second"}, texts((*received)[1]))
+	})
+
+	t.Run("should ignore the header when sessions are disabled", func(t *testing.T) {
+		upstream, received := sessionUpstream(t, false)
+		handler := newHandler(upstream.URL, nil)
+
+		send(handler, "s1", "First", false)
+		send(handler, "s1", "Second", false)
+
+		assert.Equal(t, []string{"user: Second"}, texts((*received)[1]))
+	})
+
+	t.Run("should reject overlong session IDs", func(t *testing.T) {
+		upstream, received := sessionUpstream(t, false)
+		handler := newHandler(upstream.URL, NewSessionStore(time.Hour, 10))
+
+		w := send(handler, strings.Repeat("s", maxSessionIDLength+1), "First", false)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, *received)
+	})
+}
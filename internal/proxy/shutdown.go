@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownGracePeriod is how long a shutdown waits for in-flight requests
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// shutdownCancelWait is how long requests cancelled at the grace deadline get to
+// return before their connections are closed
+const shutdownCancelWait = time.Second
+
+// ShutdownResult reports what happened to in-flight requests during a shutdown
+type ShutdownResult struct {
+	InFlight  int // Connections serving a request when the shutdown began
+	Drained   int // Of those, the ones that finished within the grace period
+	Cancelled int // Of those, the ones cancelled at the deadline, such as long streams
+}
+
+// connTracker follows the state of a server's connections
+type connTracker struct {
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{states: make(map[net.Conn]http.ConnState)}
+}
+
+// track is an http.Server ConnState hook
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state == http.StateClosed || state == http.StateHijacked {
+		delete(t.states, conn)
+		return
+	}
+	t.states[conn] = state
+}
+
+// active returns the number of connections serving a request
+func (t *connTracker) active() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, state := range t.states {
+		if state == http.StateActive {
+			n++
+		}
+	}
+	return n
+}
+
+// trackRequests hooks the server, before it serves, so Shutdown can count in-flight
+// requests and cancel the ones still running at the grace deadline
+func (s *ProxyServer) trackRequests() {
+	s.conns = newConnTracker()
+	s.server.ConnState = s.conns.track
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelRequests = cancel
+	s.server.BaseContext = func(net.Listener) context.Context { return ctx }
+}
+
+// Shutdown stops accepting connections and waits up to grace for in-flight requests,
+// including streams, to finish. Requests still running at the deadline are cancelled,
+// which ends their streams and aborts their upstream calls, and their connections are
+// closed.
+func (s *ProxyServer) Shutdown(grace time.Duration) (ShutdownResult, error) {
+	result := ShutdownResult{InFlight: s.conns.active()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		result.Drained = result.InFlight
+		return result, err
+	}
+
+	result.Cancelled = s.conns.active()
+	result.Drained = max(result.InFlight-result.Cancelled, 0)
+	if s.cancelRequests != nil {
+		s.cancelRequests()
+		deadline := time.Now().Add(shutdownCancelWait)
+		for s.conns.active() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return result, s.server.Close()
+}
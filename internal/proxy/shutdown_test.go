@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyServer_Shutdown(t *testing.T) {
+	// serve starts a server with handler and returns it with its URL
+	serve := func(t *testing.T, handler http.Handler) (*ProxyServer, string) {
+		t.Helper()
+		server := &ProxyServer{server: &http.Server{Handler: handler}}
+		server.trackRequests()
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.Serve(listener)
+		return server, "http://" + listener.Addr().String()
+	}
+
+	t.Run("should let in-flight requests finish within the grace period", func(t *testing.T) {
+		// Arrange
+		started := make(chan struct{})
+		server, url := serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte("done"))
+		}))
+		response := make(chan string, 1)
+		go func() {
+			resp, err := http.Get(url)
+			if err != nil {
+				response <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			response <- string(body)
+		}()
+		<-started
+
+		// Act
+		result, err := server.Shutdown(5 * time.Second)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, ShutdownResult{InFlight: 1, Drained: 1}, result)
+		assert.Equal(t, "done", <-response)
+	})
+
+	t.Run("should cancel streams still running at the deadline", func(t *testing.T) {
+		// Arrange
+		started := make(chan struct{})
+		cancelled := make(chan struct{})
+		server, url := serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: first\n\n"))
+			w.(http.Flusher).Flush()
+			close(started)
+			<-r.Context().Done()
+			close(cancelled)
+		}))
+		go func() {
+			if resp, err := http.Get(url); err == nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}()
+		<-started
+
+		// Act
+		begin := time.Now()
+		result, err := server.Shutdown(100 * time.Millisecond)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, ShutdownResult{InFlight: 1, Cancelled: 1}, result)
+		assert.Less(t, time.Since(begin), 2*time.Second)
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("stream request context was not cancelled")
+		}
+	})
+
+	t.Run("should report nothing when idle", func(t *testing.T) {
+		server, _ := serve(t, http.NotFoundHandler())
+
+		result, err := server.Shutdown(time.Second)
+
+		require.NoError(t, err)
+		assert.Equal(t, ShutdownResult{}, result)
+	})
+}
@@ -0,0 +1,25 @@
+package proxy
+
+import (
+	"errors"
+	"strings"
+)
+
+// StaticTokenProvider serves a pre-issued access token, for headless deployments
+// that cannot run the interactive login. The token is never refreshed.
+type StaticTokenProvider struct {
+	token string
+}
+
+// NewStaticTokenProvider creates a provider for token, typically CLAUDE_GATE_ACCESS_TOKEN
+func NewStaticTokenProvider(token string) *StaticTokenProvider {
+	return &StaticTokenProvider{token: strings.TrimSpace(token)}
+}
+
+// GetAccessToken returns the configured token
+func (p *StaticTokenProvider) GetAccessToken() (string, error) {
+	if p.token == "" {
+		return "", errors.New("no access token configured - set CLAUDE_GATE_ACCESS_TOKEN")
+	}
+	return p.token, nil
+}
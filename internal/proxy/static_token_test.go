@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticTokenProvider(t *testing.T) {
+	t.Run("should return the configured token", func(t *testing.T) {
+		token, err := NewStaticTokenProvider(" sk-ant-oat01-static\n").GetAccessToken()
+
+		require.NoError(t, err)
+		assert.Equal(t, "sk-ant-oat01-static", token)
+	})
+
+	t.Run("should explain a missing token", func(t *testing.T) {
+		_, err := NewStaticTokenProvider("").GetAccessToken()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLAUDE_GATE_ACCESS_TOKEN")
+	})
+
+	t.Run("should authenticate upstream requests", func(t *testing.T) {
+		// Arrange
+		var authorization string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		defer upstream.Close()
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: NewStaticTokenProvider("sk-ant-oat01-static"),
+			Transformer:   NewRequestTransformer(),
+		})
+		body := `{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Hi"}]}`
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "Bearer sk-ant-oat01-static", authorization)
+	})
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// streamCoalescer shares one upstream stream between identical in-flight requests.
+// The first request for a key leads the flight and makes the upstream call; requests
+// arriving while it runs join it and replay the stream from its first byte. The
+// upstream call is cancelled once every client has left.
+type streamCoalescer struct {
+	mu      sync.Mutex
+	flights map[string]*streamFlight
+}
+
+func newStreamCoalescer() *streamCoalescer {
+	return &streamCoalescer{flights: make(map[string]*streamFlight)}
+}
+
+// streamFlight is one shared upstream stream and the bytes received so far
+type streamFlight struct {
+	coalescer *streamCoalescer
+	key       string
+
+	// ctx bounds the upstream call; it outlives the leading request
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// ready is closed once the upstream responded or failed
+	ready  chan struct{}
+	status int
+	header http.Header
+	err    error
+
+	// subscribers is guarded by coalescer.mu
+	subscribers int
+
+	mu      sync.Mutex
+	buf     []byte
+	done    bool
+	bodyErr error
+	changed chan struct{}
+}
+
+// coalesceKey identifies requests that produce the same upstream stream. Only
+// streaming requests at temperature 0 qualify; others get "".
+func coalesceKey(upstreamBody []byte, headers http.Header) string {
+	var request struct {
+		Stream      bool     `json:"stream"`
+		Temperature *float64 `json:"temperature"`
+	}
+	if err := json.Unmarshal(upstreamBody, &request); err != nil {
+		return ""
+	}
+	if !request.Stream || request.Temperature == nil || *request.Temperature != 0 {
+		return ""
+	}
+
+	hash := sha256.New()
+	io.WriteString(hash, headers.Get("anthropic-version")+"\n"+headers.Get("anthropic-beta")+"\n")
+	hash.Write(upstreamBody)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Acquire joins the flight for key, or starts one when none is in progress. The
+// leader must call Start (or Fail); everyone then calls Response.
+func (c *streamCoalescer) Acquire(key string, deadline time.Time) (flight *streamFlight, leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if flight, ok := c.flights[key]; ok {
+		flight.subscribers++
+		return flight, false
+	}
+
+	flight = &streamFlight{
+		coalescer:   c,
+		key:         key,
+		ready:       make(chan struct{}),
+		changed:     make(chan struct{}),
+		subscribers: 1,
+	}
+	if deadline.IsZero() {
+		flight.ctx, flight.cancel = context.WithCancel(context.Background())
+	} else {
+		flight.ctx, flight.cancel = context.WithDeadline(context.Background(), deadline)
+	}
+	c.flights[key] = flight
+	return flight, true
+}
+
+// Context is the context the leader's upstream request must use
+func (f *streamFlight) Context() context.Context {
+	return f.ctx
+}
+
+// Start publishes the upstream response and copies its body into the flight
+func (f *streamFlight) Start(resp *http.Response, err error) {
+	if err != nil {
+		f.Fail(err)
+		return
+	}
+	f.status = resp.StatusCode
+	f.header = resp.Header.Clone()
+	close(f.ready)
+
+	go func() {
+		defer resp.Body.Close()
+		chunk := make([]byte, 32*1024)
+		for {
+			n, err := resp.Body.Read(chunk)
+			f.mu.Lock()
+			f.buf = append(f.buf, chunk[:n]...)
+			if err != nil {
+				f.done = true
+				if err != io.EOF {
+					f.bodyErr = err
+				}
+			}
+			close(f.changed)
+			f.changed = make(chan struct{})
+			f.mu.Unlock()
+			if err != nil {
+				break
+			}
+		}
+		// Finished flights take no new subscribers; later requests start afresh
+		f.coalescer.remove(f)
+	}()
+}
+
+// Fail ends a flight whose upstream call could not be made
+func (f *streamFlight) Fail(err error) {
+	f.err = err
+	close(f.ready)
+	f.coalescer.remove(f)
+	f.cancel()
+}
+
+// Response waits for the upstream response and returns this client's copy of it.
+// Reads from its body stop with ctx's error once ctx is done, and closing it
+// leaves the flight.
+func (f *streamFlight) Response(ctx context.Context) (*http.Response, error) {
+	select {
+	case <-f.ready:
+	case <-ctx.Done():
+		f.leave()
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &http.Response{
+		StatusCode: f.status,
+		Status:     strconv.Itoa(f.status) + " " + http.StatusText(f.status),
+		Header:     f.header.Clone(),
+		Body:       &flightReader{flight: f, ctx: ctx},
+	}, nil
+}
+
+// leave drops a subscriber, cancelling the upstream call when it was the last
+func (f *streamFlight) leave() {
+	c := f.coalescer
+	c.mu.Lock()
+	f.subscribers--
+	last := f.subscribers == 0
+	if last && c.flights[f.key] == f {
+		delete(c.flights, f.key)
+	}
+	c.mu.Unlock()
+
+	if last {
+		f.cancel()
+	}
+}
+
+// remove stops new requests from joining f
+func (c *streamCoalescer) remove(f *streamFlight) {
+	c.mu.Lock()
+	if c.flights[f.key] == f {
+		delete(c.flights, f.key)
+	}
+	c.mu.Unlock()
+}
+
+// flightReader replays a flight's stream to one client
+type flightReader struct {
+	flight *streamFlight
+	ctx    context.Context
+	offset int
+	closed sync.Once
+}
+
+func (r *flightReader) Read(p []byte) (int, error) {
+	f := r.flight
+	for {
+		f.mu.Lock()
+		if r.offset < len(f.buf) {
+			n := copy(p, f.buf[r.offset:])
+			r.offset += n
+			f.mu.Unlock()
+			return n, nil
+		}
+		if f.done {
+			err := f.bodyErr
+			f.mu.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+	}
+}
+
+func (r *flightReader) Close() error {
+	r.closed.Do(r.flight.leave)
+	return nil
+}
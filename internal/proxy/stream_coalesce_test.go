@@ -0,0 +1,217 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deterministicStream = `{"model":"claude-sonnet-4-20250514","stream":true,"temperature":0,"messages":[{"role":"user","content":"Hello"}]}`
+
+// newHeldStreamUpstream streams "Hello" straight away and ", world" once release is
+// closed. cancelled is closed if the upstream request is abandoned before that.
+func newHeldStreamUpstream(t *testing.T, release <-chan struct{}) (url string, calls *int32, cancelled <-chan struct{}) {
+	t.Helper()
+
+	var count int32
+	abandoned := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		// The request context only notices a dropped connection once the body is read
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := func(name, data string) {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+			w.(http.Flusher).Flush()
+		}
+
+		event("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","content":[],"usage":{"input_tokens":5,"output_tokens":0}}}`)
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`)
+
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			close(abandoned)
+			return
+		}
+		event("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}`)
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		event("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`)
+		event("message_stop", `{"type":"message_stop"}`)
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream.URL, &count, abandoned
+}
+
+// waitForSubscribers waits until the coalescer's only flight has n subscribers
+func waitForSubscribers(t *testing.T, coalescer *streamCoalescer, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		coalescer.mu.Lock()
+		defer coalescer.mu.Unlock()
+		for _, flight := range coalescer.flights {
+			return flight.subscribers == n
+		}
+		return false
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestProxyHandler_CoalesceStreams(t *testing.T) {
+	newHandler := func(upstreamURL string) *ProxyHandler {
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:     upstreamURL,
+			TokenProvider:   &mockTokenProvider{token: "test-token"},
+			Transformer:     NewRequestTransformer(),
+			CoalesceStreams: true,
+		})
+	}
+
+	t.Run("should share one upstream stream between identical requests", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		upstreamURL, calls, _ := newHeldStreamUpstream(t, release)
+		handler := newHandler(upstreamURL)
+		const clients = 5
+
+		// Act
+		bodies := make([]string, clients)
+		var wg sync.WaitGroup
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(deterministicStream)))
+				bodies[i] = w.Body.String()
+			}(i)
+		}
+		waitForSubscribers(t, handler.coalescer, clients)
+		close(release)
+		wg.Wait()
+
+		// Assert
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		for _, body := range bodies {
+			helpers.AssertStreamCompleted(t, helpers.ParseOpenAIStream(t, body), "Hello, world")
+		}
+		assert.Empty(t, handler.coalescer.flights)
+	})
+
+	t.Run("should keep streaming to the others when one client disconnects", func(t *testing.T) {
+		release := make(chan struct{})
+		upstreamURL, calls, cancelled := newHeldStreamUpstream(t, release)
+		handler := newHandler(upstreamURL)
+
+		ctx, disconnect := context.WithCancel(context.Background())
+		leaving := make(chan struct{})
+		go func() {
+			defer close(leaving)
+			req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(deterministicStream)).WithContext(ctx)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+		staying := make(chan string)
+		go func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(deterministicStream)))
+			staying <- w.Body.String()
+		}()
+		waitForSubscribers(t, handler.coalescer, 2)
+
+		disconnect()
+		<-leaving
+		waitForSubscribers(t, handler.coalescer, 1)
+		close(release)
+
+		helpers.AssertStreamCompleted(t, helpers.ParseOpenAIStream(t, <-staying), "Hello, world")
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		select {
+		case <-cancelled:
+			t.Fatal("upstream stream was cancelled while a client was still reading")
+		default:
+		}
+	})
+
+	t.Run("should cancel the upstream stream once every client has left", func(t *testing.T) {
+		upstreamURL, _, cancelled := newHeldStreamUpstream(t, make(chan struct{}))
+		handler := newHandler(upstreamURL)
+
+		var wg sync.WaitGroup
+		disconnects := make([]context.CancelFunc, 2)
+		for i := range disconnects {
+			ctx, disconnect := context.WithCancel(context.Background())
+			disconnects[i] = disconnect
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(deterministicStream)).WithContext(ctx)
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+		}
+		waitForSubscribers(t, handler.coalescer, 2)
+
+		for _, disconnect := range disconnects {
+			disconnect()
+		}
+		wg.Wait()
+
+		select {
+		case <-cancelled:
+		case <-time.After(2 * time.Second):
+			t.Fatal("upstream stream was not cancelled")
+		}
+	})
+
+	t.Run("should not coalesce requests with a non-zero temperature", func(t *testing.T) {
+		release := make(chan struct{})
+		close(release)
+		upstreamURL, calls, _ := newHeldStreamUpstream(t, release)
+		handler := newHandler(upstreamURL)
+		body := strings.Replace(deterministicStream, `"temperature":0`, `"temperature":0.7`, 1)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	})
+}
+
+func TestCoalesceKey(t *testing.T) {
+	headers := http.Header{"Anthropic-Version": {"2023-06-01"}}
+
+	t.Run("should match identical deterministic streams", func(t *testing.T) {
+		key := coalesceKey([]byte(deterministicStream), headers)
+
+		assert.NotEmpty(t, key)
+		assert.Equal(t, key, coalesceKey([]byte(deterministicStream), headers))
+	})
+
+	t.Run("should separate requests with different betas", func(t *testing.T) {
+		withBeta := http.Header{"Anthropic-Version": {"2023-06-01"}, "Anthropic-Beta": {BetaPromptCaching}}
+
+		assert.NotEqual(t, coalesceKey([]byte(deterministicStream), headers), coalesceKey([]byte(deterministicStream), withBeta))
+	})
+
+	t.Run("should skip requests without temperature 0 or stream", func(t *testing.T) {
+		assert.Empty(t, coalesceKey([]byte(`{"stream":true,"messages":[]}`), headers))
+		assert.Empty(t, coalesceKey([]byte(`{"stream":false,"temperature":0}`), headers))
+	})
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// deferredStreamWriter holds back a stream's status line and SSE headers until the
+// first byte is written. Until then the client has not switched to stream parsing,
+// so a failure can still be answered with a normal HTTP error status and JSON body.
+type deferredStreamWriter struct {
+	http.ResponseWriter
+	status    int
+	header    http.Header
+	committed bool
+}
+
+// newDeferredStreamWriter wraps w for a stream that will start with status
+func newDeferredStreamWriter(w http.ResponseWriter, status int) *deferredStreamWriter {
+	return &deferredStreamWriter{ResponseWriter: w, status: status, header: http.Header{}}
+}
+
+// StreamHeader returns the headers sent only once the stream starts
+func (w *deferredStreamWriter) StreamHeader() http.Header {
+	return w.header
+}
+
+// Committed reports whether any stream bytes, and so the status line, have been sent
+func (w *deferredStreamWriter) Committed() bool {
+	return w.committed
+}
+
+func (w *deferredStreamWriter) Write(p []byte) (int, error) {
+	if !w.committed {
+		w.committed = true
+		for key, values := range w.header {
+			for _, value := range values {
+				w.ResponseWriter.Header().Add(key, value)
+			}
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush flushes committed output; before the first write there is nothing to send
+func (w *deferredStreamWriter) Flush() {
+	if !w.committed {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// anthropicErrorStatus maps an Anthropic error type to the HTTP status Anthropic uses for it
+func anthropicErrorStatus(errorType string) int {
+	switch errorType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeStreamErrorEvent answers an error event that arrived before any stream output
+// with the matching HTTP status and an OpenAI error body
+func (h *ProxyHandler) writeStreamErrorEvent(w http.ResponseWriter, data string) {
+	var event struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	json.Unmarshal([]byte(data), &event)
+	errorType, _ := event.Error["type"].(string)
+
+	body, err := convertAnthropicErrorToOpenAI(event.Error)
+	if err != nil {
+		h.writeError(w, http.StatusBadGateway, "api_error", "Upstream stream failed before sending any data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(anthropicErrorStatus(errorType))
+	w.Write(body)
+}
@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// streamLimiter caps the number of concurrent streams each client may hold open
+type streamLimiter struct {
+	mu        sync.Mutex
+	maxPerKey int
+	active    map[string]int
+}
+
+// newStreamLimiter creates a limiter allowing maxPerKey concurrent streams per client
+func newStreamLimiter(maxPerKey int) *streamLimiter {
+	return &streamLimiter{
+		maxPerKey: maxPerKey,
+		active:    make(map[string]int),
+	}
+}
+
+// Acquire reserves a stream slot for the client, reporting false when the limit is reached
+func (l *streamLimiter) Acquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active[key] >= l.maxPerKey {
+		return false
+	}
+	l.active[key]++
+	return true
+}
+
+// Release frees a stream slot previously reserved with Acquire
+func (l *streamLimiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active[key] <= 1 {
+		delete(l.active, key)
+		return
+	}
+	l.active[key]--
+}
+
+// Active returns the number of streams the client currently holds open
+func (l *streamLimiter) Active(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[key]
+}
+
+// clientKey identifies the caller by its local API key once requireLocalKey has
+// validated it, otherwise by IP address. Unvalidated keys are not used: a client
+// could send a new one with every request to get a fresh allowance. API keys are
+// hashed so they are never kept in memory in the clear.
+func clientKey(r *http.Request) string {
+	if apiKey := validatedLocalKey(r); apiKey != "" {
+		return apiKeyClientKey(apiKey)
+	}
+
+	return "ip:" + clientIP(r)
+}
+
+// apiKeyClientKey returns the client key for an API key
+func apiKeyClientKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// clientIP returns the remote IP of a request without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SystemMergeStrategy selects how multiple OpenAI system messages become Anthropic system blocks
+type SystemMergeStrategy string
+
+const (
+	// SystemMergeBlocks keeps every system message, or content part, as its own text block
+	SystemMergeBlocks SystemMergeStrategy = "blocks"
+	// SystemMergeNewline joins all system text into one block separated by newlines
+	SystemMergeNewline SystemMergeStrategy = "newline"
+	// SystemMergeSpace joins all system text into one block separated by spaces
+	SystemMergeSpace SystemMergeStrategy = "space"
+)
+
+// ParseSystemMergeStrategy validates a system merge strategy name; empty means blocks
+func ParseSystemMergeStrategy(strategy string) (SystemMergeStrategy, error) {
+	switch s := SystemMergeStrategy(strings.ToLower(strings.TrimSpace(strategy))); s {
+	case "", SystemMergeBlocks:
+		return SystemMergeBlocks, nil
+	case SystemMergeNewline, SystemMergeSpace:
+		return s, nil
+	default:
+		return SystemMergeBlocks, fmt.Errorf("unknown system merge strategy %q (want blocks, newline or space)", strategy)
+	}
+}
+
+// systemTexts extracts the text of an OpenAI system message, which may be a
+// string or an array of content parts. Empty text and non-text parts are skipped.
+func systemTexts(content interface{}) []string {
+	var texts []string
+	switch v := content.(type) {
+	case string:
+		if strings.TrimSpace(v) != "" {
+			texts = append(texts, v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			switch part := item.(type) {
+			case string:
+				if strings.TrimSpace(part) != "" {
+					texts = append(texts, part)
+				}
+			case map[string]interface{}:
+				if partType, _ := part["type"].(string); partType != "text" && partType != "input_text" {
+					continue
+				}
+				if text, ok := part["text"].(string); ok && strings.TrimSpace(text) != "" {
+					texts = append(texts, text)
+				}
+			}
+		}
+	}
+	return texts
+}
+
+// Merge builds Anthropic system text blocks from the collected system texts
+func (s SystemMergeStrategy) Merge(texts []string) []interface{} {
+	if len(texts) == 0 {
+		return nil
+	}
+
+	separator := ""
+	switch s {
+	case SystemMergeNewline:
+		separator = "\n"
+	case SystemMergeSpace:
+		separator = " "
+	default:
+		blocks := make([]interface{}, 0, len(texts))
+		for _, text := range texts {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+		}
+		return blocks
+	}
+
+	trimmed := make([]string, 0, len(texts))
+	for _, text := range texts {
+		trimmed = append(trimmed, strings.TrimSpace(text))
+	}
+	return []interface{}{
+		map[string]interface{}{"type": "text", "text": strings.Join(trimmed, separator)},
+	}
+}
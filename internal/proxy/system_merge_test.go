@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mixedSystemRequest mixes string and content-part system messages with an empty one
+const mixedSystemRequest = `{
+	"model": "claude-3-5-sonnet-20241022",
+	"messages": [
+		{"role": "system", "content": "You are helpful."},
+		{"role": "system", "content": [
+			{"type": "text", "text": "Answer in French."},
+			{"type": "image_url", "image_url": {"url": "https://example.com/a.png"}},
+			{"type": "text", "text": "  "}
+		]},
+		{"role": "system", "content": ""},
+		{"role": "user", "content": "Hi"},
+		{"role": "system", "content": [{"type": "text", "text": "Be brief."}]}
+	]
+}`
+
+// systemBlockTexts converts a request with the given strategy and returns its system block texts
+func systemBlockTexts(t *testing.T, strategy SystemMergeStrategy) []string {
+	t.Helper()
+
+	transformer := NewRequestTransformer()
+	transformer.SetSystemMergeStrategy(strategy)
+
+	result, err := transformer.TransformRequestBody([]byte(mixedSystemRequest), "/v1/chat/completions")
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &request))
+
+	system, ok := request["system"].([]interface{})
+	require.True(t, ok, "system should be an array of blocks")
+
+	var texts []string
+	for _, item := range system {
+		block := item.(map[string]interface{})
+		require.Equal(t, "text", block["type"])
+		texts = append(texts, block["text"].(string))
+	}
+	assert.Len(t, request["messages"], 1, "system messages should be removed from messages")
+	return texts
+}
+
+func TestParseSystemMergeStrategy(t *testing.T) {
+	t.Run("should accept known strategies", func(t *testing.T) {
+		for input, want := range map[string]SystemMergeStrategy{
+			"":        SystemMergeBlocks,
+			"blocks":  SystemMergeBlocks,
+			"Newline": SystemMergeNewline,
+			"space":   SystemMergeSpace,
+		} {
+			got, err := ParseSystemMergeStrategy(input)
+			require.NoError(t, err, input)
+			assert.Equal(t, want, got, input)
+		}
+	})
+
+	t.Run("should reject unknown strategies", func(t *testing.T) {
+		_, err := ParseSystemMergeStrategy("comma")
+		assert.Error(t, err)
+	})
+}
+
+func TestSystemMerge(t *testing.T) {
+	t.Run("should keep each system text as a block by default", func(t *testing.T) {
+		texts := systemBlockTexts(t, "")
+
+		assert.Equal(t, []string{ClaudeCodePrompt, "You are helpful.", "Answer in French.", "Be brief."}, texts)
+	})
+
+	t.Run("should join system texts with newlines", func(t *testing.T) {
+		texts := systemBlockTexts(t, SystemMergeNewline)
+
+		assert.Equal(t, []string{ClaudeCodePrompt, "You are helpful.\nAnswer in French.\nBe brief."}, texts)
+	})
+
+	t.Run("should join system texts with spaces", func(t *testing.T) {
+		texts := systemBlockTexts(t, SystemMergeSpace)
+
+		assert.Equal(t, []string{ClaudeCodePrompt, "You are helpful. Answer in French. Be brief."}, texts)
+	})
+
+	t.Run("should only add the Claude Code prompt without system text", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetSystemMergeStrategy(SystemMergeNewline)
+
+		result, err := transformer.TransformRequestBody([]byte(`{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"system","content":[]},{"role":"user","content":"Hi"}]}`), "/v1/chat/completions")
+
+		require.NoError(t, err)
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &request))
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"type": "text", "text": ClaudeCodePrompt},
+		}, request["system"])
+	})
+}
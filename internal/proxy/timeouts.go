@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// requestTimeouts are the limits applied to one upstream request
+type requestTimeouts struct {
+	Deadline time.Duration // Whole request, from sending it to the last response byte (0 = none)
+	Idle     time.Duration // Longest wait between stream reads (0 = none)
+}
+
+// resolveTimeout computes the limits for a request to model. It is the only place
+// timeout settings are combined. The deadline is the first of these that is set:
+//
+//  1. StreamMaxDuration, for streaming requests only
+//  2. The per-model timeout whose prefix matches model, the longest match winning
+//  3. The global Timeout
+//
+// StreamIdleTimeout only applies to streams, and only when it is shorter than the
+// deadline, which would otherwise always fire first.
+func (c *ProxyConfig) resolveTimeout(model string, streaming bool) requestTimeouts {
+	var timeouts requestTimeouts
+
+	if streaming && c.StreamMaxDuration > 0 {
+		timeouts.Deadline = c.StreamMaxDuration
+	} else if modelTimeout := c.modelTimeout(model); modelTimeout > 0 {
+		timeouts.Deadline = modelTimeout
+	} else {
+		timeouts.Deadline = c.Timeout
+	}
+
+	if streaming && c.StreamIdleTimeout > 0 && (timeouts.Deadline == 0 || c.StreamIdleTimeout < timeouts.Deadline) {
+		timeouts.Idle = c.StreamIdleTimeout
+	}
+
+	return timeouts
+}
+
+// modelTimeout returns the per-model timeout for model, or 0 when none matches
+func (c *ProxyConfig) modelTimeout(model string) time.Duration {
+	timeout, matched := time.Duration(0), 0
+	for prefix, d := range c.ModelTimeouts {
+		if strings.HasPrefix(model, prefix) && len(prefix) > matched {
+			timeout, matched = d, len(prefix)
+		}
+	}
+	return timeout
+}
+
+// longestDeadline returns the longest deadline resolveTimeout can produce, so the
+// server's write timeout never cuts a response short first
+func (c *ProxyConfig) longestDeadline() time.Duration {
+	longest := c.Timeout
+	for _, d := range c.ModelTimeouts {
+		longest = max(longest, d)
+	}
+	return max(longest, c.StreamMaxDuration)
+}
+
+// ParseModelTimeouts parses MODEL=DURATION pairs into per-model timeouts
+func ParseModelTimeouts(pairs []string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(pairs))
+	for _, pair := range pairs {
+		model, value, ok := strings.Cut(pair, "=")
+		model, value = strings.TrimSpace(model), strings.TrimSpace(value)
+		if !ok || model == "" {
+			return nil, fmt.Errorf("invalid model timeout %q, expected MODEL=DURATION", pair)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid model timeout %q, expected a positive duration", pair)
+		}
+		timeouts[model] = d
+	}
+	return timeouts, nil
+}
+
+// withDeadline derives a context from parent carrying the request deadline, if any
+func (t requestTimeouts) withDeadline(parent context.Context) (context.Context, context.CancelFunc) {
+	if t.Deadline > 0 {
+		return context.WithTimeout(parent, t.Deadline)
+	}
+	return context.WithCancel(parent)
+}
+
+// idleTimeoutBody cancels a response whose reads stall for longer than idle
+type idleTimeoutBody struct {
+	io.ReadCloser
+	idle  time.Duration
+	timer *time.Timer
+}
+
+// newIdleTimeoutBody wraps body, calling cancel once no read has returned data for idle
+func newIdleTimeoutBody(body io.ReadCloser, idle time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	return &idleTimeoutBody{ReadCloser: body, idle: idle, timer: time.AfterFunc(idle, cancel)}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(b.idle)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyConfig_ResolveTimeout(t *testing.T) {
+	modelTimeouts := map[string]time.Duration{
+		"claude-opus-4":          20 * time.Minute,
+		"claude-opus-4-20250514": 30 * time.Minute,
+		"claude-3-5-haiku":       time.Minute,
+	}
+
+	tests := []struct {
+		name      string
+		config    ProxyConfig
+		model     string
+		streaming bool
+		expected  requestTimeouts
+	}{
+		{
+			name:     "should use the global timeout by default",
+			config:   ProxyConfig{Timeout: 10 * time.Minute},
+			model:    "claude-sonnet-4-20250514",
+			expected: requestTimeouts{Deadline: 10 * time.Minute},
+		},
+		{
+			name:     "should prefer a per-model timeout over the global timeout",
+			config:   ProxyConfig{Timeout: 10 * time.Minute, ModelTimeouts: modelTimeouts},
+			model:    "claude-3-5-haiku-20241022",
+			expected: requestTimeouts{Deadline: time.Minute},
+		},
+		{
+			name:     "should pick the longest matching model prefix",
+			config:   ProxyConfig{Timeout: 10 * time.Minute, ModelTimeouts: modelTimeouts},
+			model:    "claude-opus-4-20250514",
+			expected: requestTimeouts{Deadline: 30 * time.Minute},
+		},
+		{
+			name:     "should fall back to the global timeout for unmatched models",
+			config:   ProxyConfig{Timeout: 10 * time.Minute, ModelTimeouts: modelTimeouts},
+			model:    "claude-sonnet-4-20250514",
+			expected: requestTimeouts{Deadline: 10 * time.Minute},
+		},
+		{
+			name:      "should use the model timeout for streams without a max duration",
+			config:    ProxyConfig{Timeout: 10 * time.Minute, ModelTimeouts: modelTimeouts},
+			model:     "claude-3-5-haiku-20241022",
+			streaming: true,
+			expected:  requestTimeouts{Deadline: time.Minute},
+		},
+		{
+			name:      "should prefer the stream max duration over model and global timeouts",
+			config:    ProxyConfig{Timeout: 10 * time.Minute, ModelTimeouts: modelTimeouts, StreamMaxDuration: time.Hour},
+			model:     "claude-3-5-haiku-20241022",
+			streaming: true,
+			expected:  requestTimeouts{Deadline: time.Hour},
+		},
+		{
+			name:     "should ignore the stream max duration for non-streaming requests",
+			config:   ProxyConfig{Timeout: 10 * time.Minute, StreamMaxDuration: time.Hour},
+			model:    "claude-sonnet-4-20250514",
+			expected: requestTimeouts{Deadline: 10 * time.Minute},
+		},
+		{
+			name:      "should apply the idle timeout to streams",
+			config:    ProxyConfig{Timeout: 10 * time.Minute, StreamMaxDuration: time.Hour, StreamIdleTimeout: time.Minute},
+			model:     "claude-sonnet-4-20250514",
+			streaming: true,
+			expected:  requestTimeouts{Deadline: time.Hour, Idle: time.Minute},
+		},
+		{
+			name:     "should not apply the idle timeout to non-streaming requests",
+			config:   ProxyConfig{Timeout: 10 * time.Minute, StreamIdleTimeout: time.Minute},
+			model:    "claude-sonnet-4-20250514",
+			expected: requestTimeouts{Deadline: 10 * time.Minute},
+		},
+		{
+			name:      "should drop an idle timeout no shorter than the deadline",
+			config:    ProxyConfig{Timeout: 10 * time.Minute, ModelTimeouts: modelTimeouts, StreamIdleTimeout: 2 * time.Minute},
+			model:     "claude-3-5-haiku-20241022",
+			streaming: true,
+			expected:  requestTimeouts{Deadline: time.Minute},
+		},
+		{
+			name:      "should apply the idle timeout when there is no deadline",
+			config:    ProxyConfig{StreamIdleTimeout: time.Minute},
+			model:     "claude-sonnet-4-20250514",
+			streaming: true,
+			expected:  requestTimeouts{Idle: time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.resolveTimeout(tt.model, tt.streaming))
+		})
+	}
+}
+
+func TestProxyConfig_LongestDeadline(t *testing.T) {
+	config := ProxyConfig{
+		Timeout:           10 * time.Minute,
+		ModelTimeouts:     map[string]time.Duration{"claude-opus-4": 30 * time.Minute},
+		StreamMaxDuration: 20 * time.Minute,
+	}
+
+	assert.Equal(t, 30*time.Minute, config.longestDeadline())
+}
+
+func TestParseModelTimeouts(t *testing.T) {
+	t.Run("should parse model timeouts", func(t *testing.T) {
+		timeouts, err := ParseModelTimeouts([]string{"claude-opus-4=30m", " claude-3-5-haiku = 1m "})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]time.Duration{"claude-opus-4": 30 * time.Minute, "claude-3-5-haiku": time.Minute}, timeouts)
+	})
+
+	t.Run("should reject malformed pairs", func(t *testing.T) {
+		for _, pair := range []string{"claude-opus-4", "=30m", "claude-opus-4=soon", "claude-opus-4=0s"} {
+			_, err := ParseModelTimeouts([]string{pair})
+			assert.Error(t, err, pair)
+		}
+	})
+}
+
+func TestIdleTimeoutBody(t *testing.T) {
+	t.Run("should cancel a stalled body", func(t *testing.T) {
+		// Arrange
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		reader, writer := io.Pipe()
+		defer writer.Close()
+		body := newIdleTimeoutBody(reader, 20*time.Millisecond, cancel)
+		defer body.Close()
+
+		// Act
+		go writer.Write([]byte("data"))
+		buf := make([]byte, 4)
+		_, err := body.Read(buf)
+
+		// Assert
+		require.NoError(t, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected the stalled body to be cancelled")
+		}
+	})
+}
+
+func TestProxyHandler_Timeouts(t *testing.T) {
+	t.Run("should end a request at its model's deadline", func(t *testing.T) {
+		// Arrange
+		release := make(chan struct{})
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer upstream.Close()
+		defer close(release)
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			Timeout:       time.Minute,
+			ModelTimeouts: map[string]time.Duration{"claude-3-5-haiku": 50 * time.Millisecond},
+		})
+		body := `{"model":"claude-3-5-haiku-20241022","max_tokens":10,"messages":[{"role":"user","content":"Hi"}]}`
+		w := httptest.NewRecorder()
+
+		// Act
+		start := time.Now()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/messages", strings.NewReader(body)))
+
+		// Assert
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
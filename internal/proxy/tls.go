@@ -0,0 +1,245 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ways to answer plain HTTP requests sent to the TLS port
+const (
+	PlainHTTPReject   = "reject"   // 400 Bad Request, the net/http default
+	PlainHTTPRedirect = "redirect" // 308 Permanent Redirect to the https:// URL
+)
+
+// ParsePlainHTTP parses how plain HTTP on the TLS port is answered; empty means reject
+func ParsePlainHTTP(mode string) (string, error) {
+	switch m := strings.ToLower(strings.TrimSpace(mode)); m {
+	case "", PlainHTTPReject:
+		return PlainHTTPReject, nil
+	case PlainHTTPRedirect:
+		return m, nil
+	default:
+		return PlainHTTPReject, fmt.Errorf("unknown plain HTTP mode %q (want reject or redirect)", mode)
+	}
+}
+
+// selfSignedValidity is how long an auto-TLS certificate is valid
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// LoadTLSConfig returns the TLS configuration of the server: the certificate and key
+// files when given, otherwise with auto a self-signed certificate for hosts, and nil
+// when TLS is off
+func LoadTLSConfig(certFile, keyFile string, auto bool, hosts []string) (*tls.Config, error) {
+	switch {
+	case (certFile == "") != (keyFile == ""):
+		return nil, errors.New("TLS needs both a certificate and a key file")
+	case certFile != "" && auto:
+		return nil, errors.New("use either a TLS certificate or auto TLS, not both")
+	case certFile != "":
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+	case auto:
+		cert, err := SelfSignedCertificate(hosts...)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+	}
+	return nil, nil
+}
+
+// SelfSignedCertificate creates a certificate for the given host names and IP
+// addresses, for serving TLS on a local machine. Clients have to trust it explicitly.
+func SelfSignedCertificate(hosts ...string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate TLS key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate certificate serial: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Claude Gate"}, CommonName: "claude-gate"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create TLS certificate: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// CertificateFingerprint returns the SHA-256 fingerprint of the first certificate in
+// config, for pinning or trusting a self-signed certificate
+func CertificateFingerprint(config *tls.Config) string {
+	if config == nil || len(config.Certificates) == 0 || len(config.Certificates[0].Certificate) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(config.Certificates[0].Certificate[0])
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// plainHTTPListener shares the TLS port with plain HTTP: connections opening with a
+// TLS handshake are passed on to be served, plain HTTP requests are redirected to
+// https:// here and closed
+type plainHTTPListener struct {
+	net.Listener
+	conns     chan net.Conn
+	errs      chan error
+	done      chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+// tlsRecordHandshake is the first byte of every TLS connection
+const tlsRecordHandshake = 0x16
+
+// sniffTimeout bounds the wait for a new connection's first byte
+const sniffTimeout = 10 * time.Second
+
+func newPlainHTTPListener(l net.Listener) *plainHTTPListener {
+	return &plainHTTPListener{
+		Listener: l,
+		conns:    make(chan net.Conn),
+		errs:     make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// Accept returns the next TLS connection
+func (l *plainHTTPListener) Accept() (net.Conn, error) {
+	l.startOnce.Do(func() { go l.acceptLoop() })
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case err := <-l.errs:
+		return nil, err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *plainHTTPListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// acceptLoop sorts each new connection without holding up the next one. Temporary
+// errors, such as running out of file descriptors, are retried with a growing delay
+// as http.Server does; any other error ends the loop and is returned by Accept.
+func (l *plainHTTPListener) acceptLoop() {
+	var delay time.Duration
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Temporary() && !errors.Is(err, net.ErrClosed) {
+				delay = min(max(2*delay, 5*time.Millisecond), time.Second)
+				select {
+				case <-time.After(delay):
+					continue
+				case <-l.done:
+					return
+				}
+			}
+			l.errs <- err
+			return
+		}
+		delay = 0
+		go l.route(conn)
+	}
+}
+
+// route passes a TLS connection on and answers a plain HTTP one
+func (l *plainHTTPListener) route(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	first, err := reader.Peek(1)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	if first[0] == tlsRecordHandshake {
+		select {
+		case l.conns <- &peekedConn{Conn: conn, reader: reader}:
+		case <-l.done:
+			conn.Close()
+		}
+		return
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(sniffTimeout))
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return
+	}
+	redirectToHTTPS(req).Write(conn)
+}
+
+// redirectToHTTPS answers a plain HTTP request with a redirect to the same URL over
+// https; 308 keeps the method and body of API requests
+func redirectToHTTPS(req *http.Request) *http.Response {
+	target := "https://" + req.Host + req.URL.RequestURI()
+	body := fmt.Sprintf(`{"error":{"type":"invalid_request_error","message":"This server only accepts HTTPS; use %s"}}`+"\n", target)
+	return &http.Response{
+		StatusCode:    http.StatusPermanentRedirect,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       req,
+		Close:         true,
+		ContentLength: int64(len(body)),
+		Header: http.Header{
+			"Location":     {target},
+			"Content-Type": {"application/json"},
+		},
+		Body: io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// peekedConn is a connection whose first bytes were read ahead by reader
+type peekedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
@@ -0,0 +1,226 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfSignedCertificate(t *testing.T) {
+	t.Run("should cover the given host names and IP addresses", func(t *testing.T) {
+		// Act
+		cert, err := SelfSignedCertificate("localhost", "127.0.0.1", "::1", "gate.lan")
+
+		// Assert
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		assert.Equal(t, []string{"localhost", "gate.lan"}, leaf.DNSNames)
+		require.Len(t, leaf.IPAddresses, 2)
+		assert.True(t, leaf.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+		assert.NoError(t, leaf.VerifyHostname("localhost"))
+		assert.NoError(t, leaf.VerifyHostname("127.0.0.1"))
+		assert.Error(t, leaf.VerifyHostname("example.com"))
+		assert.True(t, leaf.NotAfter.After(time.Now().Add(300*24*time.Hour)))
+	})
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	t.Run("should return nil when TLS is off", func(t *testing.T) {
+		// Act
+		config, err := LoadTLSConfig("", "", false, nil)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("should generate a certificate for auto TLS", func(t *testing.T) {
+		// Act
+		config, err := LoadTLSConfig("", "", true, []string{"localhost"})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, config.Certificates, 1)
+		assert.Len(t, CertificateFingerprint(config), 64)
+	})
+
+	t.Run("should reject a certificate without a key and a certificate with auto TLS", func(t *testing.T) {
+		// Act
+		_, missingKey := LoadTLSConfig("tls.crt", "", false, nil)
+		_, both := LoadTLSConfig("tls.crt", "tls.key", true, nil)
+
+		// Assert
+		assert.ErrorContains(t, missingKey, "both a certificate and a key")
+		assert.ErrorContains(t, both, "not both")
+	})
+
+	t.Run("should report an unreadable certificate", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		cert := filepath.Join(dir, "tls.crt")
+		require.NoError(t, os.WriteFile(cert, []byte("not a certificate"), 0600))
+
+		// Act
+		_, err := LoadTLSConfig(cert, cert, false, nil)
+
+		// Assert
+		assert.ErrorContains(t, err, "failed to load TLS certificate")
+	})
+}
+
+func TestParsePlainHTTP(t *testing.T) {
+	t.Run("should default to reject and refuse unknown modes", func(t *testing.T) {
+		// Act
+		empty, emptyErr := ParsePlainHTTP("")
+		redirect, redirectErr := ParsePlainHTTP("Redirect")
+		_, unknownErr := ParsePlainHTTP("allow")
+
+		// Assert
+		require.NoError(t, emptyErr)
+		require.NoError(t, redirectErr)
+		assert.Equal(t, PlainHTTPReject, empty)
+		assert.Equal(t, PlainHTTPRedirect, redirect)
+		assert.ErrorContains(t, unknownErr, "unknown plain HTTP mode")
+	})
+}
+
+func TestProxyServer_TLS(t *testing.T) {
+	serveTLS := func(t *testing.T, plainHTTP string) (string, *http.Client) {
+		tlsConfig, err := LoadTLSConfig("", "", true, []string{"127.0.0.1"})
+		require.NoError(t, err)
+		server := &ProxyServer{
+			redirectPlainHTTP: plainHTTP == PlainHTTPRedirect,
+			server: &http.Server{
+				TLSConfig: tlsConfig,
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					io.WriteString(w, "secure "+r.URL.Path)
+				}),
+			},
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.Serve(listener)
+		t.Cleanup(func() { server.Stop(time.Second) })
+
+		pool := x509.NewCertPool()
+		leaf, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+		require.NoError(t, err)
+		pool.AddCert(leaf)
+		client := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		return listener.Addr().String(), client
+	}
+
+	t.Run("should serve HTTPS and reject plain HTTP by default", func(t *testing.T) {
+		// Arrange
+		addr, client := serveTLS(t, PlainHTTPReject)
+
+		// Act
+		secure, err := client.Get("https://" + addr + "/v1/models")
+		require.NoError(t, err)
+		defer secure.Body.Close()
+		plain, err := client.Get("http://" + addr + "/v1/models")
+		require.NoError(t, err)
+		defer plain.Body.Close()
+
+		// Assert
+		body, _ := io.ReadAll(secure.Body)
+		assert.Equal(t, "secure /v1/models", string(body))
+		assert.Equal(t, http.StatusBadRequest, plain.StatusCode)
+	})
+
+	t.Run("should redirect plain HTTP to HTTPS on the same port", func(t *testing.T) {
+		// Arrange
+		addr, client := serveTLS(t, PlainHTTPRedirect)
+
+		// Act
+		plain, err := client.Post("http://"+addr+"/v1/chat/completions?x=1", "application/json", strings.NewReader("{}"))
+		require.NoError(t, err)
+		defer plain.Body.Close()
+		secure, err := client.Get("https://" + addr + "/health")
+		require.NoError(t, err)
+		defer secure.Body.Close()
+
+		// Assert
+		assert.Equal(t, http.StatusPermanentRedirect, plain.StatusCode)
+		assert.Equal(t, "https://"+addr+"/v1/chat/completions?x=1", plain.Header.Get("Location"))
+		body, _ := io.ReadAll(secure.Body)
+		assert.Equal(t, "secure /health", string(body))
+	})
+}
+
+// temporaryError is an Accept error worth retrying, like EMFILE
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "too many open files" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// scriptedListener returns its errors and connections in order, then net.ErrClosed
+type scriptedListener struct {
+	net.Listener
+	results chan any
+}
+
+func (l *scriptedListener) Accept() (net.Conn, error) {
+	result, ok := <-l.results
+	if !ok {
+		return nil, net.ErrClosed
+	}
+	if err, isErr := result.(error); isErr {
+		return nil, err
+	}
+	return result.(net.Conn), nil
+}
+
+func (l *scriptedListener) Close() error { return nil }
+
+func TestPlainHTTPListener_AcceptErrors(t *testing.T) {
+	t.Run("should keep accepting after temporary errors", func(t *testing.T) {
+		// Arrange
+		server, client := net.Pipe()
+		defer client.Close()
+		inner := &scriptedListener{results: make(chan any, 3)}
+		inner.results <- temporaryError{}
+		inner.results <- temporaryError{}
+		inner.results <- server
+		listener := newPlainHTTPListener(inner)
+		defer listener.Close()
+		go client.Write([]byte{tlsRecordHandshake})
+
+		// Act
+		conn, err := listener.Accept()
+
+		// Assert
+		require.NoError(t, err)
+		assert.IsType(t, &peekedConn{}, conn)
+	})
+
+	t.Run("should return the error once the listener is closed", func(t *testing.T) {
+		// Arrange
+		inner := &scriptedListener{results: make(chan any)}
+		close(inner.results)
+		listener := newPlainHTTPListener(inner)
+
+		// Act
+		_, err := listener.Accept()
+
+		// Assert
+		assert.ErrorIs(t, err, net.ErrClosed)
+	})
+}
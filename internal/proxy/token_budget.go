@@ -0,0 +1,232 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenBudgetPeriod is the budget period used when a budget does not name one
+const DefaultTokenBudgetPeriod = 30 * 24 * time.Hour
+
+// TokenBudget limits the input plus output tokens a client key may use per period
+type TokenBudget struct {
+	Tokens int64
+	Period time.Duration
+}
+
+// ParseTokenBudgets parses KEY=TOKENS or KEY=TOKENS/PERIOD specs into budgets by
+// client key, as identified by budgetClientKey
+func ParseTokenBudgets(specs []string, defaultPeriod time.Duration) (map[string]TokenBudget, error) {
+	if defaultPeriod <= 0 {
+		defaultPeriod = DefaultTokenBudgetPeriod
+	}
+
+	budgets := make(map[string]TokenBudget, len(specs))
+	for _, spec := range specs {
+		key, limit, ok := strings.Cut(spec, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid token budget, expected KEY=TOKENS[/PERIOD]")
+		}
+
+		budget := TokenBudget{Period: defaultPeriod}
+		if tokens, period, hasPeriod := strings.Cut(limit, "/"); hasPeriod {
+			d, err := time.ParseDuration(period)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid token budget period %q", period)
+			}
+			budget.Period = d
+			limit = tokens
+		}
+		tokens, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || tokens <= 0 {
+			return nil, fmt.Errorf("invalid token budget %q, expected a positive token count", limit)
+		}
+		budget.Tokens = tokens
+
+		budgets[apiKeyClientKey(key)] = budget
+	}
+	return budgets, nil
+}
+
+// budgetClientKey identifies the caller by the API key it sends, validated or not,
+// otherwise by IP address. Budgets only restrict the keys they name, so sending
+// another key gains nothing that leaving the key out would not.
+func budgetClientKey(r *http.Request) string {
+	if apiKey := requestAPIKey(r); apiKey != "" {
+		return apiKeyClientKey(apiKey)
+	}
+	return "ip:" + clientIP(r)
+}
+
+// budgetTracker accounts token usage per client key against its budget. Usage lives in
+// memory only, so a restart starts every key with a fresh period.
+type budgetTracker struct {
+	mu      sync.Mutex
+	budgets map[string]TokenBudget
+	usage   map[string]*budgetUsage
+	now     func() time.Time
+}
+
+// budgetUsage is a key's usage in its current period
+type budgetUsage struct {
+	periodStart time.Time
+	tokens      int64
+}
+
+// newBudgetTracker creates a tracker for budgets keyed by client key
+func newBudgetTracker(budgets map[string]TokenBudget) *budgetTracker {
+	return &budgetTracker{
+		budgets: budgets,
+		usage:   make(map[string]*budgetUsage),
+		now:     time.Now,
+	}
+}
+
+// current returns the key's usage, starting a new period when the last one has ended.
+// Callers hold mu.
+func (b *budgetTracker) current(key string, budget TokenBudget) *budgetUsage {
+	now := b.now()
+	usage, ok := b.usage[key]
+	if !ok || now.Sub(usage.periodStart) >= budget.Period {
+		usage = &budgetUsage{periodStart: now}
+		b.usage[key] = usage
+	}
+	return usage
+}
+
+// Allow reports whether the key may send another request. When it may not, it also
+// returns how long until its budget resets. Keys without a budget are unlimited.
+func (b *budgetTracker) Allow(key string) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	budget, ok := b.budgets[key]
+	if !ok {
+		return true, 0
+	}
+	usage := b.current(key, budget)
+	if usage.tokens < budget.Tokens {
+		return true, 0
+	}
+	return false, usage.periodStart.Add(budget.Period).Sub(b.now())
+}
+
+// Record adds tokens used by the key to its current period
+func (b *budgetTracker) Record(key string, tokens int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	budget, ok := b.budgets[key]
+	if !ok || tokens <= 0 {
+		return
+	}
+	b.current(key, budget).tokens += tokens
+}
+
+// Limited reports whether the key has a budget
+func (b *budgetTracker) Limited(key string) bool {
+	_, ok := b.budgets[key]
+	return ok
+}
+
+// writeBudgetExhausted answers a client whose token budget is used up, telling it when
+// the budget resets
+func (h *ProxyHandler) writeBudgetExhausted(w http.ResponseWriter, resetIn time.Duration) {
+	seconds := int64(math.Ceil(resetIn.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "insufficient_quota",
+			"message": fmt.Sprintf("Token budget exhausted for this API key; it resets in %s", resetIn.Round(time.Second)),
+			"param":   nil,
+			"code":    "token_budget_exceeded",
+		},
+	})
+}
+
+// usageReader reads Anthropic usage from a response body as it is passed on, calling
+// done with the total input and output tokens when the body is closed. SSE bodies are
+// scanned line by line; JSON bodies are parsed whole.
+type usageReader struct {
+	body      io.ReadCloser
+	streaming bool
+	buf       []byte
+	tokens    map[string]int64
+	done      func(tokens int64)
+	closed    bool
+}
+
+// newUsageReader wraps body, reporting its usage to done on Close
+func newUsageReader(body io.ReadCloser, streaming bool, done func(tokens int64)) *usageReader {
+	return &usageReader{body: body, streaming: streaming, tokens: make(map[string]int64), done: done}
+}
+
+func (u *usageReader) Read(p []byte) (int, error) {
+	n, err := u.body.Read(p)
+	if n > 0 {
+		u.buf = append(u.buf, p[:n]...)
+		if u.streaming {
+			u.scanLines()
+		}
+	}
+	return n, err
+}
+
+// scanLines records usage from complete SSE data lines, keeping any partial line
+func (u *usageReader) scanLines() {
+	for {
+		i := bytes.IndexByte(u.buf, '\n')
+		if i < 0 {
+			return
+		}
+		line := bytes.TrimSpace(u.buf[:i])
+		u.buf = u.buf[i+1:]
+		if data, ok := bytes.CutPrefix(line, []byte("data: ")); ok && bytes.Contains(data, []byte(`"usage"`)) {
+			u.recordUsage(data)
+		}
+	}
+}
+
+// recordUsage keeps the latest input and output token counts from an event or response.
+// message_start carries input tokens, message_delta the cumulative output tokens.
+func (u *usageReader) recordUsage(data []byte) {
+	var payload struct {
+		Usage   map[string]int64 `json:"usage"`
+		Message struct {
+			Usage map[string]int64 `json:"usage"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(data, &payload) != nil {
+		return
+	}
+	for _, usage := range []map[string]int64{payload.Message.Usage, payload.Usage} {
+		for _, field := range []string{"input_tokens", "output_tokens"} {
+			if count, ok := usage[field]; ok {
+				u.tokens[field] = count
+			}
+		}
+	}
+}
+
+func (u *usageReader) Close() error {
+	if !u.closed {
+		u.closed = true
+		if !u.streaming {
+			u.recordUsage(u.buf)
+		}
+		u.buf = nil
+		u.done(u.tokens["input_tokens"] + u.tokens["output_tokens"])
+	}
+	return u.body.Close()
+}
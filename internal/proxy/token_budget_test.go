@@ -0,0 +1,172 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTokenBudgets(t *testing.T) {
+	t.Run("should parse budgets with and without a period", func(t *testing.T) {
+		budgets, err := ParseTokenBudgets([]string{"sk-a=1000", "sk-b=50/24h"}, time.Hour)
+
+		require.NoError(t, err)
+		assert.Equal(t, TokenBudget{Tokens: 1000, Period: time.Hour}, budgets[apiKeyClientKey("sk-a")])
+		assert.Equal(t, TokenBudget{Tokens: 50, Period: 24 * time.Hour}, budgets[apiKeyClientKey("sk-b")])
+	})
+
+	t.Run("should default the period to 30 days", func(t *testing.T) {
+		budgets, err := ParseTokenBudgets([]string{"sk-a=1000"}, 0)
+
+		require.NoError(t, err)
+		assert.Equal(t, DefaultTokenBudgetPeriod, budgets[apiKeyClientKey("sk-a")].Period)
+	})
+
+	t.Run("should reject malformed budgets", func(t *testing.T) {
+		for _, spec := range []string{"sk-a", "=100", "sk-a=lots", "sk-a=0", "sk-a=100/soon", "sk-a=100/-1h"} {
+			_, err := ParseTokenBudgets([]string{spec}, time.Hour)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestBudgetTracker(t *testing.T) {
+	t.Run("should refuse a key once its budget is used and reset after the period", func(t *testing.T) {
+		// Arrange
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		tracker := newBudgetTracker(map[string]TokenBudget{"key:a": {Tokens: 100, Period: time.Hour}})
+		tracker.now = func() time.Time { return now }
+
+		// Act & Assert
+		allowed, _ := tracker.Allow("key:a")
+		assert.True(t, allowed)
+
+		tracker.Record("key:a", 60)
+		allowed, _ = tracker.Allow("key:a")
+		assert.True(t, allowed, "under budget")
+
+		tracker.Record("key:a", 60)
+		now = now.Add(15 * time.Minute)
+		allowed, resetIn := tracker.Allow("key:a")
+		assert.False(t, allowed)
+		assert.Equal(t, 45*time.Minute, resetIn)
+
+		now = now.Add(45 * time.Minute)
+		allowed, _ = tracker.Allow("key:a")
+		assert.True(t, allowed, "a new period starts with a fresh budget")
+	})
+
+	t.Run("should not limit keys without a budget", func(t *testing.T) {
+		tracker := newBudgetTracker(map[string]TokenBudget{"key:a": {Tokens: 1, Period: time.Hour}})
+
+		tracker.Record("key:b", 1000)
+		allowed, _ := tracker.Allow("key:b")
+
+		assert.True(t, allowed)
+		assert.False(t, tracker.Limited("key:b"))
+	})
+}
+
+func TestUsageReader(t *testing.T) {
+	t.Run("should total usage from a JSON response", func(t *testing.T) {
+		var total int64
+		body := `{"type":"message","usage":{"input_tokens":12,"output_tokens":30}}`
+		reader := newUsageReader(io.NopCloser(strings.NewReader(body)), false, func(tokens int64) { total = tokens })
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, reader.Close())
+
+		require.NoError(t, err)
+		assert.Equal(t, body, string(data))
+		assert.Equal(t, int64(42), total)
+	})
+
+	t.Run("should total usage from stream events", func(t *testing.T) {
+		var total int64
+		body := "event: message_start\n" +
+			`data: {"type":"message_start","message":{"usage":{"input_tokens":20,"output_tokens":1}}}` + "\n\n" +
+			"event: content_block_delta\n" +
+			`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"Hi"}}` + "\n\n" +
+			"event: message_delta\n" +
+			`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}` + "\n\n"
+		reader := newUsageReader(io.NopCloser(strings.NewReader(body)), true, func(tokens int64) { total = tokens })
+
+		_, err := io.Copy(io.Discard, io.LimitReader(reader, int64(len(body))))
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+
+		assert.Equal(t, int64(35), total)
+	})
+}
+
+func TestProxyHandler_TokenBudgets(t *testing.T) {
+	newHandler := func(t *testing.T, specs ...string) (*ProxyHandler, *int32) {
+		var upstreamCalls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","model":"claude-3-5-sonnet-20241022","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":40,"output_tokens":20}}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		budgets, err := ParseTokenBudgets(specs, time.Hour)
+		require.NoError(t, err)
+		return NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			TokenBudgets:  budgets,
+		}), &upstreamCalls
+	}
+	chat := func(handler http.Handler, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(conversation(1)))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject a key once its responses have used its budget", func(t *testing.T) {
+		// Arrange
+		handler, upstreamCalls := newHandler(t, "sk-team=100")
+
+		// Act
+		first := chat(handler, "sk-team")
+		second := chat(handler, "sk-team")
+		third := chat(handler, "sk-team")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, http.StatusOK, second.Code, "60 of 100 tokens used")
+		assert.Equal(t, http.StatusTooManyRequests, third.Code, "120 of 100 tokens used")
+		assert.Equal(t, int32(2), atomic.LoadInt32(upstreamCalls))
+
+		var response struct {
+			Error struct {
+				Type string `json:"type"`
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(third.Body.Bytes(), &response))
+		assert.Equal(t, "insufficient_quota", response.Error.Type)
+		assert.Equal(t, "token_budget_exceeded", response.Error.Code)
+		assert.Equal(t, fmt.Sprint(int(time.Hour.Seconds())), third.Header().Get("Retry-After"))
+	})
+
+	t.Run("should not limit other keys", func(t *testing.T) {
+		handler, _ := newHandler(t, "sk-team=10")
+		chat(handler, "sk-team")
+
+		assert.Equal(t, http.StatusTooManyRequests, chat(handler, "sk-team").Code)
+		assert.Equal(t, http.StatusOK, chat(handler, "sk-other").Code)
+	})
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SetRepairToolArguments toggles repair of streamed tool call arguments that end as
+// truncated JSON. Invalid arguments are reported with an error chunk either way.
+func (t *RequestTransformer) SetRepairToolArguments(repair bool) {
+	t.repairToolArgs = repair
+}
+
+// EnableToolArgumentsRepair completes truncated tool call arguments at the end of
+// their block, when closing open strings, arrays and objects makes them valid
+func (c *SSEConverter) EnableToolArgumentsRepair() {
+	c.repairToolArgs = true
+}
+
+// finishToolCall validates the arguments streamed for a tool call. Valid arguments
+// need no chunk. Repairable ones get a last delta with the missing suffix, so the
+// concatenation clients build is valid JSON. Anything else gets an error chunk, since
+// malformed arguments break the agent frameworks parsing them.
+func (c *SSEConverter) finishToolCall(toolInfo map[string]interface{}) map[string]interface{} {
+	arguments := ""
+	if builder, ok := toolInfo["arguments"].(*strings.Builder); ok {
+		arguments = builder.String()
+	}
+	// Tools without parameters stream no arguments at all
+	if strings.TrimSpace(arguments) == "" || json.Valid([]byte(arguments)) {
+		return nil
+	}
+
+	toolIndex := toolInfo["toolIndex"].(int)
+	toolID, _ := toolInfo["id"].(string)
+	toolName, _ := toolInfo["name"].(string)
+
+	if c.repairToolArgs {
+		if suffix, ok := repairJSONSuffix(arguments); ok {
+			if c.logger != nil {
+				c.logger.Warn("repaired truncated tool call arguments", "tool", toolName, "suffix", suffix)
+			}
+			return c.deltaChunk(map[string]interface{}{
+				"tool_calls": []interface{}{
+					map[string]interface{}{
+						"index": toolIndex,
+						"id":    toolID,
+						"function": map[string]interface{}{
+							"arguments": suffix,
+						},
+					},
+				},
+			})
+		}
+	}
+
+	if c.logger != nil {
+		c.logger.Warn("invalid tool call arguments from upstream", "tool", toolName, "length", len(arguments))
+	}
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": fmt.Sprintf("Anthropic streamed invalid JSON arguments for tool call %s (%s)", toolID, toolName),
+			"type":    "server_error",
+			"param":   nil,
+			"code":    "invalid_tool_arguments",
+		},
+	}
+}
+
+// repairJSONSuffix returns the text that completes truncated JSON: a closing quote
+// for an open string, null for a key without a value, and the closing brackets of
+// open arrays and objects. It fails when appending cannot make the JSON valid, e.g.
+// after a trailing comma or inside a literal.
+func repairJSONSuffix(truncated string) (string, bool) {
+	var open []byte
+	inString, escaped := false, false
+	for i := 0; i < len(truncated); i++ {
+		ch := truncated[i]
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case inString && ch == '"':
+			inString = false
+		case inString:
+		case ch == '"':
+			inString = true
+		case ch == '{':
+			open = append(open, '}')
+		case ch == '[':
+			open = append(open, ']')
+		case ch == '}' || ch == ']':
+			if len(open) == 0 || open[len(open)-1] != ch {
+				return "", false
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if escaped {
+		return "", false
+	}
+
+	var suffix strings.Builder
+	if inString {
+		suffix.WriteByte('"')
+	} else if strings.HasSuffix(strings.TrimSpace(truncated), ":") {
+		suffix.WriteString("null")
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		suffix.WriteByte(open[i])
+	}
+
+	if suffix.Len() == 0 || !json.Valid([]byte(truncated+suffix.String())) {
+		return "", false
+	}
+	return suffix.String(), true
+}
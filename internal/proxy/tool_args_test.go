@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolCallEvents returns the Anthropic events streaming one tool call with the given
+// argument fragments
+func toolCallEvents(fragments ...string) [][2]string {
+	events := [][2]string{
+		{"message_start", `{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-20250514"}}`},
+		{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`},
+	}
+	for _, fragment := range fragments {
+		partial, _ := json.Marshal(fragment)
+		events = append(events, [2]string{"content_block_delta", fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":%s}}`, partial)})
+	}
+	return append(events,
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		[2]string{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+}
+
+func TestSSEConverter_ToolArguments(t *testing.T) {
+	// convert runs the events through a converter and parses the resulting stream
+	convert := func(t *testing.T, repair bool, events [][2]string) *helpers.OpenAIStream {
+		t.Helper()
+		converter := NewSSEConverter("chatcmpl-1", "claude-sonnet-4-20250514", 1700000000, nil)
+		if repair {
+			converter.EnableToolArgumentsRepair()
+		}
+		var out strings.Builder
+		for _, event := range events {
+			chunk, err := converter.Convert(event[0], event[1])
+			require.NoError(t, err)
+			out.WriteString(chunk)
+		}
+		return helpers.ParseOpenAIStream(t, out.String())
+	}
+	// arguments concatenates the streamed arguments of the first tool call
+	arguments := func(stream *helpers.OpenAIStream) string {
+		var args strings.Builder
+		for _, chunk := range stream.Chunks {
+			choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+			delta, _ := choice["delta"].(map[string]interface{})
+			calls, _ := delta["tool_calls"].([]interface{})
+			for _, call := range calls {
+				function := call.(map[string]interface{})["function"].(map[string]interface{})
+				args.WriteString(function["arguments"].(string))
+			}
+		}
+		return args.String()
+	}
+
+	t.Run("should pass valid arguments without extra chunks", func(t *testing.T) {
+		stream := convert(t, false, toolCallEvents(`{"location":`, ` "Paris"}`))
+
+		assert.Empty(t, stream.Errors)
+		assert.JSONEq(t, `{"location":"Paris"}`, arguments(stream))
+	})
+
+	t.Run("should accept tools without arguments", func(t *testing.T) {
+		stream := convert(t, false, toolCallEvents())
+
+		assert.Empty(t, stream.Errors)
+		assert.Equal(t, "", arguments(stream))
+	})
+
+	t.Run("should report invalid arguments with an error chunk", func(t *testing.T) {
+		// Act
+		stream := convert(t, false, toolCallEvents(`{"location":`, ` "Paris"`))
+
+		// Assert
+		require.Len(t, stream.Errors, 1)
+		assert.Equal(t, "invalid_tool_arguments", stream.Errors[0]["code"])
+		assert.Contains(t, stream.Errors[0]["message"], "toolu_1")
+	})
+
+	t.Run("should complete truncated arguments when repair is enabled", func(t *testing.T) {
+		// Act
+		stream := convert(t, true, toolCallEvents(`{"location":`, ` "Par`))
+
+		// Assert
+		assert.Empty(t, stream.Errors)
+		assert.JSONEq(t, `{"location":"Par"}`, arguments(stream))
+	})
+
+	t.Run("should report arguments repair cannot fix", func(t *testing.T) {
+		stream := convert(t, true, toolCallEvents(`{"location": "Paris"}}`))
+
+		require.Len(t, stream.Errors, 1)
+		assert.Equal(t, "invalid_tool_arguments", stream.Errors[0]["code"])
+	})
+}
+
+func TestRepairJSONSuffix(t *testing.T) {
+	tests := []struct {
+		truncated string
+		suffix    string
+		ok        bool
+	}{
+		{`{"a": "b`, `"}`, true},
+		{`{"a": [1, 2`, `]}`, true},
+		{`{"a": {"b": "c\"`, `"}}`, true},
+		{`{"a":`, `null}`, true},
+		{`{"a": "b"}`, "", false},
+		{`{"a": "b",`, "", false},
+		{`{"a": tru`, "", false},
+		{`{"a": "b\`, "", false},
+		{`{"a": ]`, "", false},
+	}
+
+	for _, tt := range tests {
+		suffix, ok := repairJSONSuffix(tt.truncated)
+		assert.Equal(t, tt.ok, ok, tt.truncated)
+		assert.Equal(t, tt.suffix, suffix, tt.truncated)
+	}
+}
+
+func TestProxyHandler_InvalidStreamedToolArguments(t *testing.T) {
+	t.Run("should end a stream with invalid tool arguments with an error event", func(t *testing.T) {
+		// Arrange
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, event := range toolCallEvents(`{"location": "Paris", "unit": `, `celsius"}`) {
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event[0], event[1])
+			}
+		}))
+		defer upstream.Close()
+
+		// Act
+		stream := streamChatCompletion(t, upstream.URL)
+
+		// Assert
+		require.Len(t, stream.Errors, 1)
+		assert.Equal(t, "invalid_tool_arguments", stream.Errors[0]["code"])
+		assert.Equal(t, "server_error", stream.Errors[0]["type"])
+	})
+}
@@ -0,0 +1,32 @@
+package proxy
+
+// SetCacheTools toggles marking the tool definitions of translated OpenAI requests as
+// cacheable, so a large tool set reused across requests is billed at the cache rate
+func (t *RequestTransformer) SetCacheTools(enabled bool) {
+	t.cacheTools = enabled
+}
+
+// cacheToolDefinitions adds an ephemeral cache_control to the last tool, which caches
+// every tool definition before it. Requests that already place a cache_control on a
+// tool are left alone to respect the client's breakpoints. It reports whether it
+// changed the request.
+func cacheToolDefinitions(data map[string]interface{}) bool {
+	tools, _ := data["tools"].([]interface{})
+	if len(tools) == 0 {
+		return false
+	}
+	for _, tool := range tools {
+		if toolMap, ok := tool.(map[string]interface{}); ok {
+			if _, has := toolMap["cache_control"]; has {
+				return false
+			}
+		}
+	}
+
+	last, ok := tools[len(tools)-1].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	last["cache_control"] = map[string]interface{}{"type": "ephemeral"}
+	return true
+}
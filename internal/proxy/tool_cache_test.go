@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const toolsRequest = `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"}],"tools":[
+	{"name":"get_weather","description":"Weather","input_schema":{"type":"object"}},
+	{"name":"get_time","description":"Time","input_schema":{"type":"object"}}]}`
+
+// upstreamTools decodes the tools of a transformed request
+func upstreamTools(t *testing.T, body []byte) []map[string]interface{} {
+	t.Helper()
+	var request struct {
+		Tools []map[string]interface{} `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(body, &request))
+	return request.Tools
+}
+
+func TestRequestTransformer_CacheTools(t *testing.T) {
+	t.Run("should mark only the last tool cacheable", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+		transformer.SetCacheTools(true)
+
+		// Act
+		body, report, err := transformer.TransformRequestBodyWithReport([]byte(toolsRequest), "/v1/chat/completions")
+
+		// Assert
+		require.NoError(t, err)
+		tools := upstreamTools(t, body)
+		require.Len(t, tools, 2)
+		assert.NotContains(t, tools[0], "cache_control")
+		assert.Equal(t, map[string]interface{}{"type": "ephemeral"}, tools[1]["cache_control"])
+		assert.True(t, report.CachedTools)
+		assert.Contains(t, DetectBetas(body), BetaPromptCaching)
+	})
+
+	t.Run("should keep cache_control the client already placed", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetCacheTools(true)
+		request := strings.Replace(toolsRequest, `"description":"Weather",`, `"description":"Weather","cache_control":{"type":"ephemeral"},`, 1)
+
+		body, report, err := transformer.TransformRequestBodyWithReport([]byte(request), "/v1/chat/completions")
+
+		require.NoError(t, err)
+		tools := upstreamTools(t, body)
+		assert.Contains(t, tools[0], "cache_control")
+		assert.NotContains(t, tools[1], "cache_control")
+		assert.False(t, report.CachedTools)
+	})
+
+	t.Run("should leave tools alone when disabled", func(t *testing.T) {
+		body, err := NewRequestTransformer().TransformRequestBody([]byte(toolsRequest), "/v1/chat/completions")
+
+		require.NoError(t, err)
+		for _, tool := range upstreamTools(t, body) {
+			assert.NotContains(t, tool, "cache_control")
+		}
+	})
+
+	t.Run("should not change native messages requests", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetCacheTools(true)
+
+		body, err := transformer.TransformRequestBody([]byte(toolsRequest), "/v1/messages")
+
+		require.NoError(t, err)
+		for _, tool := range upstreamTools(t, body) {
+			assert.NotContains(t, tool, "cache_control")
+		}
+	})
+}
+
+func TestProxyHandler_CacheTools(t *testing.T) {
+	t.Run("should send the prompt caching beta with cached tools", func(t *testing.T) {
+		// Arrange
+		var betaHeader string
+		var upstreamBody []byte
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betaHeader = r.Header.Get("anthropic-beta")
+			upstreamBody, _ = io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`))
+		}))
+		defer upstream.Close()
+
+		transformer := NewRequestTransformer()
+		transformer.SetCacheTools(true)
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   transformer,
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(toolsRequest)))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "oauth-2025-04-20,"+BetaPromptCaching, betaHeader)
+		tools := upstreamTools(t, upstreamBody)
+		assert.Contains(t, tools[len(tools)-1], "cache_control")
+	})
+}
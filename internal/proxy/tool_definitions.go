@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// convertOpenAITools converts OpenAI function tool definitions into Anthropic tools.
+// Both the chat completions shape, with the function nested under "function", and the
+// flat Responses API shape are accepted. Tools already in Anthropic's shape, including
+// server tools such as web search, pass through unchanged, as does any cache_control.
+func convertOpenAITools(tools []interface{}) []interface{} {
+	converted := make([]interface{}, 0, len(tools))
+	for _, item := range tools {
+		tool, ok := item.(map[string]interface{})
+		if !ok || tool["type"] != "function" {
+			converted = append(converted, item)
+			continue
+		}
+
+		function, nested := tool["function"].(map[string]interface{})
+		if !nested {
+			function = tool
+		}
+		anthropicTool := map[string]interface{}{
+			"name":         function["name"],
+			"input_schema": toolInputSchema(function["parameters"]),
+		}
+		if description, ok := function["description"].(string); ok && description != "" {
+			anthropicTool["description"] = description
+		}
+		if cacheControl, ok := tool["cache_control"]; ok {
+			anthropicTool["cache_control"] = cacheControl
+		}
+		converted = append(converted, anthropicTool)
+	}
+	return converted
+}
+
+// toolInputSchema returns the JSON schema of a tool's parameters. Anthropic requires
+// an object schema, which OpenAI lets functions without parameters omit.
+func toolInputSchema(parameters interface{}) map[string]interface{} {
+	schema, ok := parameters.(map[string]interface{})
+	if !ok || len(schema) == 0 {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	if _, ok := schema["type"]; !ok {
+		schema["type"] = "object"
+	}
+	return schema
+}
+
+// convertOpenAIToolChoice converts OpenAI's tool_choice and parallel_tool_calls into
+// Anthropic's tool_choice, or nil when neither asks for anything:
+//
+//	"auto"                                  -> {"type": "auto"}
+//	"none"                                  -> {"type": "none"}
+//	"required"                              -> {"type": "any"}
+//	{"type": "function", "function": {...}} -> {"type": "tool", "name": ...}
+//
+// parallel_tool_calls false becomes disable_parallel_tool_use. Choices already in
+// Anthropic's shape pass through.
+func convertOpenAIToolChoice(toolChoice, parallelToolCalls interface{}, logger *slog.Logger) map[string]interface{} {
+	var choice map[string]interface{}
+	switch v := toolChoice.(type) {
+	case string:
+		switch v {
+		case "auto":
+			choice = map[string]interface{}{"type": "auto"}
+		case "none":
+			choice = map[string]interface{}{"type": "none"}
+		case "required", "any":
+			choice = map[string]interface{}{"type": "any"}
+		default:
+			if logger != nil {
+				logger.Warn("ignoring unknown tool_choice", "tool_choice", v)
+			}
+		}
+	case map[string]interface{}:
+		if v["type"] == "function" {
+			name, _ := v["name"].(string) // Responses API shape
+			if function, ok := v["function"].(map[string]interface{}); ok {
+				name, _ = function["name"].(string)
+			}
+			choice = map[string]interface{}{"type": "tool", "name": name}
+		} else {
+			choice = v
+		}
+	}
+
+	if parallel, ok := parallelToolCalls.(bool); ok && !parallel {
+		if choice == nil {
+			choice = map[string]interface{}{"type": "auto"}
+		}
+		// Anthropic rejects the flag when tools are disabled
+		if choice["type"] != "none" {
+			choice["disable_parallel_tool_use"] = true
+		}
+	}
+	return choice
+}
+
+// openAIToolCalls converts the tool_use blocks of an Anthropic response into OpenAI
+// tool_calls, with each input encoded as the JSON arguments string
+func openAIToolCalls(content []interface{}) []interface{} {
+	var toolCalls []interface{}
+	for _, item := range content {
+		block, ok := item.(map[string]interface{})
+		if !ok || block["type"] != "tool_use" {
+			continue
+		}
+		arguments := "{}"
+		if input, ok := block["input"]; ok && input != nil {
+			if encoded, err := json.Marshal(input); err == nil {
+				arguments = string(encoded)
+			}
+		}
+		toolCalls = append(toolCalls, map[string]interface{}{
+			"id":   block["id"],
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":      block["name"],
+				"arguments": arguments,
+			},
+		})
+	}
+	return toolCalls
+}
@@ -0,0 +1,215 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ml0-1337/claude-gate/internal/test/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// convertRequest translates an OpenAI request and decodes the Anthropic result
+func convertRequest(t *testing.T, request string) map[string]interface{} {
+	t.Helper()
+	result, err := ConvertOpenAIToAnthropic([]byte(request))
+	require.NoError(t, err)
+	var anthropicRequest map[string]interface{}
+	require.NoError(t, json.Unmarshal(result, &anthropicRequest))
+	return anthropicRequest
+}
+
+func TestConvertOpenAITools(t *testing.T) {
+	t.Run("should translate function tools to Anthropic tools", func(t *testing.T) {
+		// Act
+		request := convertRequest(t, `{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Weather?"}],
+			"tools":[{"type":"function","function":{"name":"get_weather","description":"Current weather",
+				"parameters":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]},"strict":true}}]}`)
+
+		// Assert
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"name":        "get_weather",
+				"description": "Current weather",
+				"input_schema": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+					"required":   []interface{}{"city"},
+				},
+			},
+		}, request["tools"])
+	})
+
+	t.Run("should accept the flat Responses API shape", func(t *testing.T) {
+		tools := convertOpenAITools([]interface{}{
+			map[string]interface{}{"type": "function", "name": "lookup", "parameters": map[string]interface{}{"type": "object"}},
+		})
+
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": "lookup", "input_schema": map[string]interface{}{"type": "object"}},
+		}, tools)
+	})
+
+	t.Run("should keep the cache_control of a tool", func(t *testing.T) {
+		tools := convertOpenAITools([]interface{}{
+			map[string]interface{}{
+				"type":          "function",
+				"function":      map[string]interface{}{"name": "lookup"},
+				"cache_control": map[string]interface{}{"type": "ephemeral"},
+			},
+		})
+
+		assert.Equal(t, map[string]interface{}{"type": "ephemeral"}, tools[0].(map[string]interface{})["cache_control"])
+	})
+
+	t.Run("should pass Anthropic tools through", func(t *testing.T) {
+		anthropicTools := []interface{}{
+			map[string]interface{}{"name": "lookup", "input_schema": map[string]interface{}{"type": "object"}},
+			map[string]interface{}{"type": "web_search_20250305", "name": "web_search"},
+		}
+
+		assert.Equal(t, anthropicTools, convertOpenAITools(anthropicTools))
+	})
+}
+
+func TestConvertOpenAIToolChoice(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  string
+		expected interface{}
+	}{
+		{"auto", `"tool_choice":"auto"`, map[string]interface{}{"type": "auto"}},
+		{"none", `"tool_choice":"none"`, map[string]interface{}{"type": "none"}},
+		{"required", `"tool_choice":"required"`, map[string]interface{}{"type": "any"}},
+		{"named function", `"tool_choice":{"type":"function","function":{"name":"get_weather"}}`, map[string]interface{}{"type": "tool", "name": "get_weather"}},
+		{"parallel calls disabled", `"tool_choice":"required","parallel_tool_calls":false`, map[string]interface{}{"type": "any", "disable_parallel_tool_use": true}},
+		{"parallel calls disabled alone", `"parallel_tool_calls":false`, map[string]interface{}{"type": "auto", "disable_parallel_tool_use": true}},
+		{"parallel calls enabled alone", `"parallel_tool_calls":true`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("should translate "+tt.name, func(t *testing.T) {
+			request := convertRequest(t, `{"model":"claude-sonnet-4-20250514","messages":[{"role":"user","content":"Hi"}],
+				"tools":[{"type":"function","function":{"name":"get_weather"}}],`+tt.request+`}`)
+
+			if tt.expected == nil {
+				assert.NotContains(t, request, "tool_choice")
+			} else {
+				assert.Equal(t, tt.expected, request["tool_choice"])
+			}
+			assert.NotContains(t, request, "parallel_tool_calls")
+		})
+	}
+}
+
+func TestConvertAnthropicToolUse(t *testing.T) {
+	t.Run("should return tool_use blocks as tool_calls", func(t *testing.T) {
+		// Arrange
+		response := `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514",
+			"content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}},
+				{"type":"tool_use","id":"toolu_2","name":"get_time","input":{}}],
+			"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":20}}`
+
+		// Act
+		result, err := ConvertAnthropicToOpenAI([]byte(response))
+
+		// Assert
+		require.NoError(t, err)
+		var completion map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &completion))
+		choice := completion["choices"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "tool_calls", choice["finish_reason"])
+		message := choice["message"].(map[string]interface{})
+		assert.Nil(t, message["content"])
+		assert.NotContains(t, message, ContentBlocksField)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"id": "toolu_1", "type": "function", "function": map[string]interface{}{"name": "get_weather", "arguments": `{"city":"Paris"}`}},
+			map[string]interface{}{"id": "toolu_2", "type": "function", "function": map[string]interface{}{"name": "get_time", "arguments": `{}`}},
+		}, message["tool_calls"])
+	})
+
+	t.Run("should keep text alongside tool calls", func(t *testing.T) {
+		response := `{"id":"msg_1","type":"message","content":[{"type":"text","text":"Checking."},
+			{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}],"stop_reason":"tool_use"}`
+
+		result, err := ConvertAnthropicToOpenAI([]byte(response))
+
+		require.NoError(t, err)
+		var completion map[string]interface{}
+		require.NoError(t, json.Unmarshal(result, &completion))
+		message := completion["choices"].([]interface{})[0].(map[string]interface{})["message"].(map[string]interface{})
+		assert.Equal(t, "Checking.", message["content"])
+		assert.Len(t, message["tool_calls"], 1)
+	})
+}
+
+func TestProxyHandler_ToolCallRoundTrip(t *testing.T) {
+	t.Run("should translate tools, results and streamed tool calls", func(t *testing.T) {
+		// Arrange
+		var upstreamRequest map[string]interface{}
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&upstreamRequest))
+			w.Header().Set("Content-Type", "text/event-stream")
+			for _, event := range toolCallEvents(`{"city":`, ` "Paris"}`) {
+				w.Write([]byte("event: " + event[0] + "\ndata: " + event[1] + "\n\n"))
+			}
+		}))
+		defer upstream.Close()
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		body := `{"model":"claude-sonnet-4-20250514","stream":true,
+			"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}}],
+			"tool_choice":"auto",
+			"messages":[
+				{"role":"user","content":"Weather in Lyon, then Paris?"},
+				{"role":"assistant","content":null,"tool_calls":[{"id":"toolu_0","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Lyon\"}"}}]},
+				{"role":"tool","tool_call_id":"toolu_0","content":"18C, sunny"}]}`
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body)))
+
+		// Assert the request
+		tool := upstreamRequest["tools"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "get_weather", tool["name"])
+		assert.Contains(t, tool, "input_schema")
+		assert.Equal(t, map[string]interface{}{"type": "auto"}, upstreamRequest["tool_choice"])
+		messages := upstreamRequest["messages"].([]interface{})
+		require.Len(t, messages, 3)
+		toolUse := messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "tool_use", toolUse["type"])
+		assert.Equal(t, map[string]interface{}{"city": "Lyon"}, toolUse["input"])
+		toolResult := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "tool_result", toolResult["type"])
+		assert.Equal(t, "toolu_0", toolResult["tool_use_id"])
+
+		// Assert the streamed response
+		stream := helpers.ParseOpenAIStream(t, w.Body.String())
+		var name, arguments string
+		var finishReason interface{}
+		for _, chunk := range stream.Chunks {
+			choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+			if finishReason == nil {
+				finishReason = choice["finish_reason"]
+			}
+			delta, _ := choice["delta"].(map[string]interface{})
+			calls, _ := delta["tool_calls"].([]interface{})
+			for _, call := range calls {
+				function := call.(map[string]interface{})["function"].(map[string]interface{})
+				if n, ok := function["name"].(string); ok {
+					name += n
+				}
+				arguments += function["arguments"].(string)
+			}
+		}
+		assert.Equal(t, "get_weather", name)
+		assert.JSONEq(t, `{"city":"Paris"}`, arguments)
+		assert.Equal(t, "tool_calls", finishReason)
+	})
+}
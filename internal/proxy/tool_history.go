@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// assistantToolUseContent converts a replayed OpenAI assistant message with tool_calls
+// into Anthropic content: any text first, then one tool_use block per call, keeping the
+// call IDs so later tool results still refer to them
+func assistantToolUseContent(content interface{}, toolCalls []interface{}, logger *slog.Logger) []interface{} {
+	blocks := []interface{}{}
+	for _, text := range systemTexts(content) {
+		blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+	}
+
+	for _, item := range toolCalls {
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, _ := call["function"].(map[string]interface{})
+		id, _ := call["id"].(string)
+		name, _ := function["name"].(string)
+
+		blocks = append(blocks, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    id,
+			"name":  name,
+			"input": toolCallInput(function["arguments"], logger),
+		})
+	}
+	return blocks
+}
+
+// toolCallInput parses OpenAI's JSON-encoded arguments string into a tool_use input
+// object. Anthropic requires an object, so empty or malformed arguments become {}.
+func toolCallInput(arguments interface{}, logger *slog.Logger) map[string]interface{} {
+	input := map[string]interface{}{}
+	switch v := arguments.(type) {
+	case string:
+		if v == "" {
+			return input
+		}
+		if err := json.Unmarshal([]byte(v), &input); err != nil {
+			if logger != nil {
+				logger.Warn("replacing malformed tool call arguments with an empty object", "error", err)
+			}
+			return map[string]interface{}{}
+		}
+	case map[string]interface{}:
+		input = v
+	}
+	return input
+}
+
+// appendToolResult adds an OpenAI tool message as a tool_result block. Consecutive tool
+// results share one user turn, as Anthropic expects results for parallel calls together.
+func appendToolResult(messages []interface{}, msg map[string]interface{}) []interface{} {
+	toolCallID, _ := msg["tool_call_id"].(string)
+	block := map[string]interface{}{
+		"type":        "tool_result",
+		"tool_use_id": toolCallID,
+	}
+	if texts := systemTexts(msg["content"]); len(texts) > 0 {
+		content := make([]interface{}, 0, len(texts))
+		for _, text := range texts {
+			content = append(content, map[string]interface{}{"type": "text", "text": text})
+		}
+		block["content"] = content
+	}
+
+	if len(messages) > 0 {
+		if last, ok := messages[len(messages)-1].(map[string]interface{}); ok && isToolResultTurn(last) {
+			last["content"] = append(last["content"].([]interface{}), block)
+			return messages
+		}
+	}
+	return append(messages, map[string]interface{}{
+		"role":    "user",
+		"content": []interface{}{block},
+	})
+}
+
+// isToolResultTurn reports whether an Anthropic message is a user turn of tool results
+func isToolResultTurn(msg map[string]interface{}) bool {
+	if msg["role"] != "user" {
+		return false
+	}
+	blocks, ok := msg["content"].([]interface{})
+	if !ok || len(blocks) == 0 {
+		return false
+	}
+	for _, item := range blocks {
+		block, ok := item.(map[string]interface{})
+		if !ok || block["type"] != "tool_result" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertOpenAIToAnthropic_ToolCallHistory(t *testing.T) {
+	t.Run("should convert a multi-turn conversation with historical tool calls", func(t *testing.T) {
+		// Arrange
+		body := `{
+			"model": "claude-3-5-sonnet-20241022",
+			"messages": [
+				{"role": "user", "content": "What's the weather in Paris and London?"},
+				{"role": "assistant", "content": null, "tool_calls": [
+					{"id": "call_paris", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}},
+					{"id": "call_london", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"London\"}"}}
+				]},
+				{"role": "tool", "tool_call_id": "call_paris", "content": "18°C, sunny"},
+				{"role": "tool", "tool_call_id": "call_london", "content": [{"type": "text", "text": "12°C, rain"}]},
+				{"role": "assistant", "content": "Paris is sunny, London is rainy. Want a forecast?"},
+				{"role": "user", "content": "Yes, for Paris."},
+				{"role": "assistant", "content": "Checking.", "tool_calls": [
+					{"id": "call_forecast", "type": "function", "function": {"name": "get_forecast", "arguments": ""}}
+				]},
+				{"role": "tool", "tool_call_id": "call_forecast", "content": "Sunny all week"}
+			]
+		}`
+
+		// Act
+		result, err := ConvertOpenAIToAnthropic([]byte(body))
+
+		// Assert
+		require.NoError(t, err)
+		var converted struct {
+			Messages []struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.Unmarshal(result, &converted))
+		require.Len(t, converted.Messages, 7)
+
+		roles := make([]string, len(converted.Messages))
+		for i, msg := range converted.Messages {
+			roles[i] = msg.Role
+		}
+		assert.Equal(t, []string{"user", "assistant", "user", "assistant", "user", "assistant", "user"}, roles)
+
+		assert.JSONEq(t, `[
+			{"type": "tool_use", "id": "call_paris", "name": "get_weather", "input": {"city": "Paris"}},
+			{"type": "tool_use", "id": "call_london", "name": "get_weather", "input": {"city": "London"}}
+		]`, string(converted.Messages[1].Content))
+		assert.JSONEq(t, `[
+			{"type": "tool_result", "tool_use_id": "call_paris", "content": [{"type": "text", "text": "18°C, sunny"}]},
+			{"type": "tool_result", "tool_use_id": "call_london", "content": [{"type": "text", "text": "12°C, rain"}]}
+		]`, string(converted.Messages[2].Content), "parallel results share one user turn")
+		assert.JSONEq(t, `"Paris is sunny, London is rainy. Want a forecast?"`, string(converted.Messages[3].Content))
+		assert.JSONEq(t, `[
+			{"type": "text", "text": "Checking."},
+			{"type": "tool_use", "id": "call_forecast", "name": "get_forecast", "input": {}}
+		]`, string(converted.Messages[5].Content))
+		assert.JSONEq(t, `[
+			{"type": "tool_result", "tool_use_id": "call_forecast", "content": [{"type": "text", "text": "Sunny all week"}]}
+		]`, string(converted.Messages[6].Content))
+	})
+
+	t.Run("should replace malformed arguments with an empty input", func(t *testing.T) {
+		input := toolCallInput(`{"city":`, nil)
+
+		assert.Equal(t, map[string]interface{}{}, input)
+	})
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DebugHeader is the request header asking for a summary of applied transformations
+const DebugHeader = "X-Claude-Gate-Debug"
+
+// Response headers summarizing the transformations applied to a request
+const (
+	TransformFormatHeader        = "X-Claude-Gate-Transform-Format"
+	TransformModelHeader         = "X-Claude-Gate-Transform-Model"
+	TransformDroppedParamsHeader = "X-Claude-Gate-Transform-Dropped-Params"
+	TransformSystemHeader        = "X-Claude-Gate-Transform-System"
+	TransformBetasHeader         = "X-Claude-Gate-Transform-Betas"
+)
+
+// TransformReport records what the transformer changed in a request
+type TransformReport struct {
+	ConvertedFromOpenAI bool
+	OriginalModel       string
+	Model               string
+	DroppedParams       []string
+	SystemTexts         int                 // OpenAI system texts merged into the system field
+	SystemMerge         SystemMergeStrategy // Strategy used to merge them
+	Betas               []string            // Betas added to anthropic-beta for this request
+	CachedTools         bool                // Tool definitions were marked cacheable
+	Warnings            []string            // Adjustments worth telling the client about
+}
+
+// wantsTransformDebug reports whether the request asked for transform debug headers
+func wantsTransformDebug(r *http.Request) bool {
+	for _, value := range strings.Split(r.Header.Get(DebugHeader), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), "transform") {
+			return true
+		}
+	}
+	return false
+}
+
+// dropParam records an OpenAI parameter dropped during conversion; safe on a nil report
+func (r *TransformReport) dropParam(param string) {
+	if r != nil {
+		r.DroppedParams = append(r.DroppedParams, param)
+		r.warn(fmt.Sprintf("dropped unsupported parameter %q", param))
+	}
+}
+
+// warn records an adjustment the client should know about; safe on a nil report
+func (r *TransformReport) warn(message string) {
+	if r != nil {
+		r.Warnings = append(r.Warnings, message)
+	}
+}
+
+// WriteHeaders adds the transform summary headers to a response
+func (r *TransformReport) WriteHeaders(headers http.Header) {
+	if r.ConvertedFromOpenAI {
+		headers.Set(TransformFormatHeader, "openai->anthropic")
+	} else {
+		headers.Set(TransformFormatHeader, "none")
+	}
+
+	if r.OriginalModel != r.Model {
+		headers.Set(TransformModelHeader, r.OriginalModel+"->"+r.Model)
+	}
+
+	if len(r.DroppedParams) > 0 {
+		dropped := append([]string(nil), r.DroppedParams...)
+		sort.Strings(dropped)
+		headers.Set(TransformDroppedParamsHeader, strings.Join(dropped, ","))
+	}
+
+	if r.ConvertedFromOpenAI {
+		headers.Set(TransformSystemHeader, fmt.Sprintf("merged=%d; strategy=%s", r.SystemTexts, r.SystemMerge))
+	}
+
+	if len(r.Betas) > 0 {
+		headers.Set(TransformBetasHeader, strings.Join(r.Betas, ","))
+	}
+}
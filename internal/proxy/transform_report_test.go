@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// debugRequest sends an OpenAI request through a proxy, optionally asking for transform debug headers
+func debugRequest(t *testing.T, allowDebug bool, debugValue string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-opus-20240229","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":1}}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	transformer := NewRequestTransformer()
+	transformer.SetSystemMergeStrategy(SystemMergeNewline)
+	transformer.SetAllowBetaHeader(true)
+	handler := NewProxyHandler(&ProxyConfig{
+		UpstreamURL:       upstream.URL,
+		TokenProvider:     &mockTokenProvider{token: "test-token"},
+		Transformer:       transformer,
+		AllowDebugHeaders: allowDebug,
+	})
+
+	body := `{
+		"model": "claude-3-opus-latest",
+		"presence_penalty": 0.5,
+		"logit_bias": {"50256": -100},
+		"messages": [
+			{"role": "system", "content": [{"type": "text", "text": "You are helpful."}]},
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": "Hi"}
+		]
+	}`
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set(BetaOverrideHeader, BetaComputerUse)
+	if debugValue != "" {
+		req.Header.Set(DebugHeader, debugValue)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	return w
+}
+
+func TestTransformDebugHeaders(t *testing.T) {
+	t.Run("should summarize the applied transforms when requested", func(t *testing.T) {
+		w := debugRequest(t, true, "transform")
+
+		assert.Equal(t, "openai->anthropic", w.Header().Get(TransformFormatHeader))
+		assert.Equal(t, "claude-3-opus-latest->claude-3-opus-20240229", w.Header().Get(TransformModelHeader))
+		assert.Equal(t, "logit_bias,presence_penalty", w.Header().Get(TransformDroppedParamsHeader))
+		assert.Equal(t, "merged=2; strategy=newline", w.Header().Get(TransformSystemHeader))
+		assert.Equal(t, BetaComputerUse, w.Header().Get(TransformBetasHeader))
+	})
+
+	t.Run("should omit the headers when not requested", func(t *testing.T) {
+		w := debugRequest(t, true, "")
+
+		assert.Empty(t, w.Header().Get(TransformFormatHeader))
+	})
+
+	t.Run("should ignore the request header unless enabled", func(t *testing.T) {
+		w := debugRequest(t, false, "transform")
+
+		assert.Empty(t, w.Header().Get(TransformFormatHeader))
+		assert.Empty(t, w.Header().Get(TransformModelHeader))
+	})
+
+	t.Run("should report native requests as untranslated", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+
+		_, report, err := transformer.TransformRequestBodyWithReport([]byte(`{"model":"claude-3-5-sonnet-20241022","max_tokens":10,"messages":[]}`), "/v1/messages")
+		require.NoError(t, err)
+		headers := http.Header{}
+		report.WriteHeaders(headers)
+
+		assert.Equal(t, "none", headers.Get(TransformFormatHeader))
+		assert.Empty(t, headers.Get(TransformModelHeader))
+		assert.Empty(t, headers.Get(TransformSystemHeader))
+		assert.Empty(t, headers.Get(TransformDroppedParamsHeader))
+	})
+}
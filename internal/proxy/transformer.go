@@ -3,7 +3,9 @@ package proxy
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -20,13 +22,68 @@ var ModelAliases = map[string]string{
 }
 
 // RequestTransformer handles request body and header transformations
-type RequestTransformer struct{}
+type RequestTransformer struct {
+	logger      *slog.Logger
+	postProcess FinishReasonPostProcess
+	
+	// allowedBetas limits auto-enabled betas; nil means DefaultAllowedBetas
+	allowedBetas          map[string]bool
+	rejectDisallowedBetas bool
+	
+	// allowBetaHeader honors the per-request BetaOverrideHeader
+	allowBetaHeader bool
+	
+	// autoRouter resolves AutoModel; nil means NewAutoModelRouter defaults
+	autoRouter *AutoModelRouter
+	
+	// systemMerge controls how OpenAI system messages are combined
+	systemMerge SystemMergeStrategy
+	
+	// trimWhitespace trims the ends of OpenAI response content
+	trimWhitespace bool
+	
+	// repairToolArgs completes truncated streamed tool call arguments
+	repairToolArgs bool
+	
+	// contentFilterFinish reports refusals with finish_reason "content_filter"
+	contentFilterFinish bool
+	
+	// anthropicVersion overrides DefaultAnthropicVersion; modelVersions override it per model prefix
+	anthropicVersion string
+	modelVersions    map[string]string
+	
+	// locale is the default response language, overridable per request with LocaleHeader
+	locale string
+	
+	// cacheTools marks the tools of translated OpenAI requests as cacheable
+	cacheTools bool
+}
 
 // NewRequestTransformer creates a new request transformer
 func NewRequestTransformer() *RequestTransformer {
 	return &RequestTransformer{}
 }
 
+// SetLogger sets the logger used to report translation details
+func (t *RequestTransformer) SetLogger(logger *slog.Logger) {
+	t.logger = logger
+}
+
+// SetFinishReasonPostProcess sets how truncated OpenAI responses are post-processed
+func (t *RequestTransformer) SetFinishReasonPostProcess(mode FinishReasonPostProcess) {
+	t.postProcess = mode
+}
+
+// SetSystemMergeStrategy sets how OpenAI system messages are merged into the Anthropic system field
+func (t *RequestTransformer) SetSystemMergeStrategy(strategy SystemMergeStrategy) {
+	t.systemMerge = strategy
+}
+
+// SetAutoModelRouter sets the router used to resolve the AutoModel alias
+func (t *RequestTransformer) SetAutoModelRouter(router *AutoModelRouter) {
+	t.autoRouter = router
+}
+
 // TransformSystemPrompt modifies the system prompt to ensure Claude Code identification comes first
 func (t *RequestTransformer) TransformSystemPrompt(body []byte) ([]byte, error) {
 	var data map[string]interface{}
@@ -85,18 +142,86 @@ func (t *RequestTransformer) MapModelAlias(model string) string {
 	return model
 }
 
+// routeAutoModel picks the model for an AutoModel request by estimated prompt size
+func (t *RequestTransformer) routeAutoModel(data map[string]interface{}) string {
+	router := t.autoRouter
+	if router == nil {
+		router = NewAutoModelRouter()
+	}
+	
+	estimated := EstimateInputTokens(data)
+	model := router.Route(estimated)
+	
+	// A smaller model may not support the output limit the client asked for
+	if maxTokens, ok := data["max_tokens"].(float64); ok {
+		if caps, known := LookupModelCapabilities(model); known && int(maxTokens) > caps.MaxOutputTokens {
+			data["max_tokens"] = caps.MaxOutputTokens
+		}
+	}
+	
+	if t.logger != nil {
+		t.logger.Debug("routed auto model", "estimated_input_tokens", estimated, "model", model)
+	}
+	return model
+}
+
 // TransformRequestBody applies all necessary transformations to the request body
 func (t *RequestTransformer) TransformRequestBody(body []byte, path string) ([]byte, error) {
+	transformed, _, err := t.TransformRequestBodyWithReport(body, path)
+	return transformed, err
+}
+
+// TransformRequestBodyWithReport transforms the request body like TransformRequestBody
+// and reports which transformations were applied
+func (t *RequestTransformer) TransformRequestBodyWithReport(body []byte, path string) ([]byte, *TransformReport, error) {
+	report := &TransformReport{}
+	transformed, err := t.transformRequestBody(body, path, report)
+	return transformed, report, err
+}
+
+// transformRequestBody applies the transformations, recording them in report
+func (t *RequestTransformer) transformRequestBody(body []byte, path string, report *TransformReport) ([]byte, error) {
 	// Handle OpenAI chat completions endpoint
 	if path == "/v1/chat/completions" {
+		systemMerge := t.systemMerge
+		if systemMerge == "" {
+			systemMerge = SystemMergeBlocks
+		}
+		report.ConvertedFromOpenAI = true
+		report.SystemMerge = systemMerge
+		report.OriginalModel = requestModel(body)
+		
 		// Convert OpenAI format to Anthropic format
-		convertedBody, err := ConvertOpenAIToAnthropic(body)
+		convertedBody, err := convertOpenAIToAnthropic(body, t.logger, systemMerge, report)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert OpenAI format: %w", err)
 		}
 		
 		// Apply standard transformations to the converted body
-		return t.TransformRequestBody(convertedBody, "/v1/messages")
+		return t.transformRequestBody(convertedBody, "/v1/messages", report)
+	}
+	
+	// Responses API requests are translated like the equivalent chat completion
+	if path == ResponsesPath {
+		chatBody, err := responsesToChatCompletion(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert Responses API request: %w", err)
+		}
+		return t.transformRequestBody(chatBody, "/v1/chat/completions", report)
+	}
+	
+	// Legacy completion requests are translated like a chat completion of their prompt
+	if path == CompletionsPath {
+		chatBody, err := completionToChatCompletion(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert completions request: %w", err)
+		}
+		return t.transformRequestBody(chatBody, "/v1/chat/completions", report)
+	}
+	
+	// Each request in a new batch is a messages request
+	if path == BatchesPath {
+		return t.transformBatchRequest(body)
 	}
 	
 	// Only transform messages endpoint
@@ -122,19 +247,50 @@ func (t *RequestTransformer) TransformRequestBody(body []byte, path string) ([]b
 	
 	// Map model alias if present
 	if model, ok := data["model"].(string); ok {
+		if !report.ConvertedFromOpenAI {
+			report.OriginalModel = model
+		}
+		if model == AutoModel {
+			model = t.routeAutoModel(data)
+		}
 		data["model"] = t.MapModelAlias(model)
+		report.Model = data["model"].(string)
+		if report.Model != report.OriginalModel {
+			report.warn(fmt.Sprintf("model %q was substituted with %q", report.OriginalModel, report.Model))
+		}
+	}
+	
+	// Cache translated tool definitions; the prompt caching beta follows from cache_control
+	if t.cacheTools && report.ConvertedFromOpenAI {
+		report.CachedTools = cacheToolDefinitions(data)
 	}
 	
 	return json.Marshal(data)
 }
 
+// requestModel returns the model named in a JSON request body, or ""
+func requestModel(body []byte) string {
+	var data struct {
+		Model string `json:"model"`
+	}
+	json.Unmarshal(body, &data)
+	return data.Model
+}
+
 // InjectHeaders creates new headers with OAuth authentication and strips problematic ones
 func (t *RequestTransformer) InjectHeaders(headers map[string][]string, accessToken string) http.Header {
 	// Create fresh headers with only necessary ones
 	newHeaders := http.Header{}
 	newHeaders.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	newHeaders.Set("anthropic-beta", "oauth-2025-04-20")
-	newHeaders.Set("anthropic-version", "2023-06-01")
+	newHeaders.Set("anthropic-beta", oauthBeta)
+	newHeaders.Set("anthropic-version", t.AnthropicVersion(""))
+	
+	// Let trusted clients append betas for this request only, with their own
+	// anthropic-beta header or the override header
+	if t.allowBetaHeader {
+		addBetaHeader(newHeaders, strings.Split(getHeader(headers, "anthropic-beta"), ",")...)
+		addBetaHeader(newHeaders, strings.Split(getHeader(headers, BetaOverrideHeader), ",")...)
+	}
 	
 	// Preserve content headers with defaults
 	if contentType := getHeader(headers, "Content-Type"); contentType != "" {
@@ -181,9 +337,36 @@ func getHeader(headers map[string][]string, key string) string {
 
 // TransformResponseBody transforms response body based on the endpoint
 func (t *RequestTransformer) TransformResponseBody(body []byte, path string) ([]byte, error) {
+	return t.TransformResponseBodyWithID(body, path, "")
+}
+
+// TransformResponseBodyWithID transforms response body based on the endpoint,
+// replacing the upstream message ID with responseID when one is given
+func (t *RequestTransformer) TransformResponseBodyWithID(body []byte, path string, responseID string) ([]byte, error) {
 	if path == "/v1/chat/completions" {
 		// Convert Anthropic response to OpenAI format
-		return ConvertAnthropicToOpenAI(body)
+		converted, err := ConvertAnthropicToOpenAIWithID(body, responseID)
+		if err != nil {
+			return nil, err
+		}
+		processed, err := t.postProcess.applyToResponse(converted)
+		if err == nil && t.contentFilterFinish {
+			processed, err = applyContentFilterFinishReason(processed)
+		}
+		if err != nil || !t.trimWhitespace {
+			return processed, err
+		}
+		return trimResponseWhitespace(processed)
+	}
+	if path == ResponsesPath {
+		return convertAnthropicToResponses(body, responseID)
+	}
+	if path == CompletionsPath {
+		chat, err := t.TransformResponseBodyWithID(body, "/v1/chat/completions", responseID)
+		if err != nil {
+			return nil, err
+		}
+		return chatCompletionToCompletion(chat)
 	}
 	return body, nil
 }
\ No newline at end of file
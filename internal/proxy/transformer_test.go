@@ -236,4 +236,52 @@ func TestHeaderInjection(t *testing.T) {
 		assert.Equal(t, "Bearer test-access-token", result.Get("Authorization"))
 		assert.Equal(t, "oauth-2025-04-20", result.Get("anthropic-beta"))
 	})
+	
+	t.Run("ignores the beta override header by default", func(t *testing.T) {
+		headers := map[string][]string{
+			"X-Claude-Gate-Beta": {"files-api-2025-04-14"},
+		}
+		
+		result := transformer.InjectHeaders(headers, "test-access-token")
+		
+		assert.Equal(t, "oauth-2025-04-20", result.Get("anthropic-beta"))
+		assert.Empty(t, result.Get("X-Claude-Gate-Beta"))
+	})
+	
+	t.Run("appends betas from the override header when allowed", func(t *testing.T) {
+		allowing := NewRequestTransformer()
+		allowing.SetAllowBetaHeader(true)
+		headers := map[string][]string{
+			"X-Claude-Gate-Beta": {"files-api-2025-04-14, oauth-2025-04-20,mcp-client-2025-04-04"},
+		}
+		
+		result := allowing.InjectHeaders(headers, "test-access-token")
+		
+		assert.Equal(t, "oauth-2025-04-20,files-api-2025-04-14,mcp-client-2025-04-04", result.Get("anthropic-beta"))
+		assert.Empty(t, result.Get("X-Claude-Gate-Beta"))
+	})
+	
+	t.Run("merges the client's anthropic-beta header when allowed", func(t *testing.T) {
+		allowing := NewRequestTransformer()
+		allowing.SetAllowBetaHeader(true)
+		headers := map[string][]string{
+			"Anthropic-Beta":     {"files-api-2025-04-14,oauth-2025-04-20"},
+			"X-Claude-Gate-Beta": {"files-api-2025-04-14"},
+		}
+		
+		allowed := allowing.InjectHeaders(headers, "test-access-token")
+		ignored := transformer.InjectHeaders(headers, "test-access-token")
+		
+		assert.Equal(t, "oauth-2025-04-20,files-api-2025-04-14", allowed.Get("anthropic-beta"))
+		assert.Equal(t, "oauth-2025-04-20", ignored.Get("anthropic-beta"))
+	})
+	
+	t.Run("leaves the managed beta header alone without an override", func(t *testing.T) {
+		allowing := NewRequestTransformer()
+		allowing.SetAllowBetaHeader(true)
+		
+		result := allowing.InjectHeaders(map[string][]string{}, "test-access-token")
+		
+		assert.Equal(t, "oauth-2025-04-20", result.Get("anthropic-beta"))
+	})
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSocketMode lets the owner and group of the proxy connect to its socket
+const DefaultSocketMode os.FileMode = 0660
+
+// ParseSocketMode parses the octal file permissions of the Unix socket, such as
+// "0660"; empty means DefaultSocketMode
+func ParseSocketMode(mode string) (os.FileMode, error) {
+	mode = strings.TrimSpace(mode)
+	if mode == "" {
+		return DefaultSocketMode, nil
+	}
+	n, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || n > 0777 {
+		return DefaultSocketMode, fmt.Errorf("invalid socket mode %q (want octal permissions such as 0660)", mode)
+	}
+	return os.FileMode(n), nil
+}
+
+// ListenUnix creates a Unix domain socket at path with the given permissions. A
+// socket left behind by a server that exited without cleaning up is replaced, but a
+// socket a server still answers on, or any other kind of file, is an error. The
+// socket file is removed when the listener is closed, as on shutdown.
+func ListenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// removeStaleSocket clears path for a new socket if it holds a dead one
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns a short socket path; the limit on socket paths is about 100
+// bytes, which a test's temp directory may exceed
+func socketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "gate")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "gate.sock")
+}
+
+func TestParseSocketMode(t *testing.T) {
+	t.Run("should parse octal permissions and default when empty", func(t *testing.T) {
+		// Act
+		empty, emptyErr := ParseSocketMode("")
+		private, privateErr := ParseSocketMode("0600")
+		_, decimalErr := ParseSocketMode("660x")
+		_, tooLargeErr := ParseSocketMode("7777")
+
+		// Assert
+		require.NoError(t, emptyErr)
+		require.NoError(t, privateErr)
+		assert.Equal(t, DefaultSocketMode, empty)
+		assert.Equal(t, os.FileMode(0600), private)
+		assert.ErrorContains(t, decimalErr, "invalid socket mode")
+		assert.ErrorContains(t, tooLargeErr, "invalid socket mode")
+	})
+}
+
+func TestListenUnix(t *testing.T) {
+	t.Run("should create the socket with the given permissions and remove it on close", func(t *testing.T) {
+		// Arrange
+		path := socketPath(t)
+
+		// Act
+		listener, err := ListenUnix(path, 0600)
+		require.NoError(t, err)
+		info, statErr := os.Stat(path)
+		closeErr := listener.Close()
+
+		// Assert
+		require.NoError(t, statErr)
+		require.NoError(t, closeErr)
+		assert.NotZero(t, info.Mode()&os.ModeSocket)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("should replace a stale socket", func(t *testing.T) {
+		// Arrange
+		path := socketPath(t)
+		stale, err := net.Listen("unix", path)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		// Act
+		listener, err := ListenUnix(path, DefaultSocketMode)
+
+		// Assert
+		require.NoError(t, err)
+		listener.Close()
+	})
+
+	t.Run("should refuse a socket in use and a file that is not a socket", func(t *testing.T) {
+		// Arrange
+		path := socketPath(t)
+		live, err := ListenUnix(path, DefaultSocketMode)
+		require.NoError(t, err)
+		defer live.Close()
+		file := filepath.Join(filepath.Dir(path), "regular")
+		require.NoError(t, os.WriteFile(file, nil, 0600))
+
+		// Act
+		_, inUseErr := ListenUnix(path, DefaultSocketMode)
+		_, notSocketErr := ListenUnix(file, DefaultSocketMode)
+
+		// Assert
+		assert.ErrorContains(t, inUseErr, "in use")
+		assert.ErrorContains(t, notSocketErr, "not a socket")
+		assert.FileExists(t, file)
+	})
+}
+
+func TestProxyServer_Socket(t *testing.T) {
+	t.Run("should serve on the socket and remove it on shutdown", func(t *testing.T) {
+		// Arrange
+		path := socketPath(t)
+		server := &ProxyServer{
+			socket:     path,
+			socketMode: DefaultSocketMode,
+			server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "over the socket")
+			})},
+		}
+		server.trackRequests()
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- server.Start() }()
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(path)
+			return err == nil
+		}, time.Second, 5*time.Millisecond)
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}}
+
+		// Act
+		resp, err := client.Get("http://localhost/v1/models")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		client.CloseIdleConnections()
+		_, shutdownErr := server.Shutdown(time.Second)
+
+		// Assert
+		assert.Equal(t, "over the socket", string(body))
+		require.NoError(t, shutdownErr)
+		assert.ErrorIs(t, <-serveErr, http.ErrServerClosed)
+		assert.NoFileExists(t, path)
+	})
+}
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// unsupportedContentParts maps OpenAI content part types Anthropic cannot accept on
+// this path to the content type named in the error
+var unsupportedContentParts = map[string]string{
+	"input_audio": "audio",
+}
+
+// UnsupportedContentError reports OpenAI content that has no Anthropic equivalent.
+// Dropping it would silently change what the model is answering, so the request fails.
+type UnsupportedContentError struct {
+	ContentType string // Kind of content, e.g. "audio"
+	Param       string // Where it appeared, e.g. "messages[1].content[0]"
+}
+
+func (e *UnsupportedContentError) Error() string {
+	return fmt.Sprintf("unsupported content type: %s (%s); Anthropic does not accept %s input", e.ContentType, e.Param, e.ContentType)
+}
+
+// checkUnsupportedContent returns an UnsupportedContentError for the first message
+// carrying content the translation cannot represent
+func checkUnsupportedContent(messages []interface{}) error {
+	for i, msg := range messages {
+		msgMap, ok := msg.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Assistant messages can refer back to an earlier audio response
+		if audio, ok := msgMap["audio"]; ok && audio != nil {
+			return &UnsupportedContentError{ContentType: "audio", Param: fmt.Sprintf("messages[%d].audio", i)}
+		}
+		parts, _ := msgMap["content"].([]interface{})
+		for j, part := range parts {
+			partMap, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			partType, _ := partMap["type"].(string)
+			if contentType, unsupported := unsupportedContentParts[partType]; unsupported {
+				return &UnsupportedContentError{ContentType: contentType, Param: fmt.Sprintf("messages[%d].content[%d]", i, j)}
+			}
+		}
+	}
+	return nil
+}
+
+// writeUnsupportedContent writes the 400 for a request with untranslatable content
+func (h *ProxyHandler) writeUnsupportedContent(w http.ResponseWriter, err *UnsupportedContentError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":    "invalid_request_error",
+			"message": err.Error(),
+			"param":   err.Param,
+			"code":    "unsupported_content_type",
+		},
+	})
+}
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const audioRequest = `{"model":"claude-3-5-sonnet-20241022","messages":[
+	{"role":"system","content":"Transcribe"},
+	{"role":"user","content":[{"type":"text","text":"What is said here?"},{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}]}]}`
+
+func TestConvertOpenAIToAnthropic_UnsupportedContent(t *testing.T) {
+	t.Run("should reject an input_audio content part", func(t *testing.T) {
+		// Act
+		_, err := ConvertOpenAIToAnthropic([]byte(audioRequest))
+
+		// Assert
+		var unsupported *UnsupportedContentError
+		require.True(t, errors.As(err, &unsupported), "got %v", err)
+		assert.Equal(t, "audio", unsupported.ContentType)
+		assert.Equal(t, "messages[1].content[1]", unsupported.Param)
+		assert.Contains(t, err.Error(), "unsupported content type: audio")
+	})
+
+	t.Run("should reject an assistant audio reference", func(t *testing.T) {
+		body := `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":"Hi"},{"role":"assistant","audio":{"id":"audio_1"}},{"role":"user","content":"Again"}]}`
+
+		_, err := ConvertOpenAIToAnthropic([]byte(body))
+
+		var unsupported *UnsupportedContentError
+		require.True(t, errors.As(err, &unsupported), "got %v", err)
+		assert.Equal(t, "messages[1].audio", unsupported.Param)
+	})
+
+	t.Run("should accept text and image parts", func(t *testing.T) {
+		body := `{"model":"claude-3-5-sonnet-20241022","messages":[{"role":"user","content":[{"type":"text","text":"Hi"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`
+
+		_, err := ConvertOpenAIToAnthropic([]byte(body))
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestProxyHandler_UnsupportedContent(t *testing.T) {
+	t.Run("should answer audio content with a specific 400 without calling upstream", func(t *testing.T) {
+		// Arrange
+		var upstreamCalls int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&upstreamCalls, 1)
+		}))
+		defer upstream.Close()
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+		w := httptest.NewRecorder()
+
+		// Act
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(audioRequest)))
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Zero(t, atomic.LoadInt32(&upstreamCalls))
+		var response struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+				Param   string `json:"param"`
+				Code    string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "invalid_request_error", response.Error.Type)
+		assert.Equal(t, "unsupported_content_type", response.Error.Code)
+		assert.Equal(t, "messages[1].content[1]", response.Error.Param)
+		assert.Contains(t, response.Error.Message, "unsupported content type: audio")
+	})
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ParseUpstreamProxy validates the URL of an HTTP(S) proxy for upstream requests.
+// Empty means no explicit proxy, leaving HTTPS_PROXY/NO_PROXY in charge.
+func ParseUpstreamProxy(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream proxy: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid upstream proxy %q, expected an http, https or socks5 URL", proxyURL.Redacted())
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid upstream proxy %q, missing host", proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
+
+// upstreamProxyFunc chooses the proxy of upstream requests: proxyURL when set,
+// otherwise the one from the environment
+func upstreamProxyFunc(proxyURL *url.URL) func(*http.Request) (*url.URL, error) {
+	if proxyURL != nil {
+		return http.ProxyURL(proxyURL)
+	}
+	return http.ProxyFromEnvironment
+}
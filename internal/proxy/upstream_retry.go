@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// DefaultUpstreamRetryDelay is the first backoff delay when retries are enabled
+// without a delay
+const DefaultUpstreamRetryDelay = 500 * time.Millisecond
+
+// maxUpstreamRetryDelay caps the backoff delay, and the Retry-After a retry waits out
+const maxUpstreamRetryDelay = 30 * time.Second
+
+// retryTransport retries upstream requests that fail transiently. Idempotent
+// requests (GET, HEAD) are retried on connection errors, 429 and 5xx responses;
+// other requests only on connection errors, where the upstream never answered, so a
+// completion is never generated twice.
+type retryTransport struct {
+	next      http.RoundTripper
+	retries   int
+	baseDelay time.Duration
+}
+
+// withRetries returns a copy of client that retries failed requests up to retries
+// times, with exponential backoff from baseDelay. client is returned unchanged when
+// retries is 0.
+func withRetries(client *http.Client, retries int, baseDelay time.Duration) *http.Client {
+	if retries <= 0 {
+		return client
+	}
+	if baseDelay <= 0 {
+		baseDelay = DefaultUpstreamRetryDelay
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	retrying := *client
+	retrying.Transport = &retryTransport{next: next, retries: retries, baseDelay: baseDelay}
+	return &retrying
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.retries || req.Context().Err() != nil || !replayable(req) {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+		case idempotent && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500):
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > maxUpstreamRetryDelay {
+					return resp, nil
+				}
+				wait = retryAfter
+			}
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if wait == 0 {
+			wait = t.backoff(attempt)
+		}
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		next, err := rewindRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		req = next
+	}
+}
+
+// backoff is the delay before retry attempt+1: baseDelay doubled per attempt, capped,
+// with up to half of it taken off at random so clients do not retry in lockstep
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := min(t.baseDelay<<attempt, maxUpstreamRetryDelay)
+	if delay <= 0 {
+		delay = maxUpstreamRetryDelay // the shift overflowed
+	}
+	return delay - rand.N(delay/2+1)
+}
+
+// replayable reports whether req can be sent again
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindRequest returns req ready to be sent again, with a fresh body
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, nil
+}
+
+// sleepContext waits for d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
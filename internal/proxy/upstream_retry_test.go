@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyUpstream fails the first failures requests with fail, then answers 200 OK.
+// It returns the server and a pointer to its request count.
+func newFlakyUpstream(t *testing.T, failures int32, fail func(w http.ResponseWriter)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if requests.Add(1) <= failures {
+			fail(w)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream, &requests
+}
+
+func serviceUnavailable(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// dropConnection closes the connection without answering
+func dropConnection(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	t.Run("should retry idempotent requests on 5xx", func(t *testing.T) {
+		// Arrange
+		upstream, requests := newFlakyUpstream(t, 2, serviceUnavailable)
+		client := withRetries(&http.Client{}, 2, time.Millisecond)
+
+		// Act
+		resp, err := client.Get(upstream.URL)
+
+		// Assert
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("should return the last failure when retries run out", func(t *testing.T) {
+		upstream, requests := newFlakyUpstream(t, 10, serviceUnavailable)
+		client := withRetries(&http.Client{}, 2, time.Millisecond)
+
+		resp, err := client.Get(upstream.URL)
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("should wait out a short Retry-After", func(t *testing.T) {
+		upstream, requests := newFlakyUpstream(t, 1, func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		client := withRetries(&http.Client{}, 2, time.Millisecond)
+
+		start := time.Now()
+		resp, err := client.Get(upstream.URL)
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, int32(2), requests.Load())
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("should not wait out a Retry-After beyond the backoff cap", func(t *testing.T) {
+		upstream, requests := newFlakyUpstream(t, 1, func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		client := withRetries(&http.Client{}, 2, time.Millisecond)
+
+		resp, err := client.Get(upstream.URL)
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("should not retry a POST answered with 5xx", func(t *testing.T) {
+		upstream, requests := newFlakyUpstream(t, 1, serviceUnavailable)
+		client := withRetries(&http.Client{}, 2, time.Millisecond)
+
+		resp, err := client.Post(upstream.URL, "application/json", strings.NewReader(`{}`))
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("should retry a POST on a connection error with its body", func(t *testing.T) {
+		var bodies []string
+		var requests atomic.Int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if requests.Add(1) == 1 {
+				dropConnection(w)
+				return
+			}
+			w.Write([]byte(`{"ok":true}`))
+		}))
+		defer upstream.Close()
+		client := withRetries(&http.Client{}, 2, time.Millisecond)
+
+		resp, err := client.Post(upstream.URL, "application/json", strings.NewReader(`{"n":1}`))
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{`{"n":1}`, `{"n":1}`}, bodies)
+	})
+
+	t.Run("should leave the client alone without retries", func(t *testing.T) {
+		client := &http.Client{}
+
+		assert.Same(t, client, withRetries(client, 0, time.Second))
+	})
+}
+
+func TestModelsHandler_Retries(t *testing.T) {
+	t.Run("should retry a failed model list fetch", func(t *testing.T) {
+		// Arrange
+		var requests atomic.Int32
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"data":[{"id":"claude-sonnet-4-20250514"}]}`))
+		}))
+		defer upstream.Close()
+		handler := NewModelsHandlerWithTTL(&mockTokenProvider{token: "test-token"}, upstream.URL, time.Hour)
+		handler.SetRetries(1, time.Millisecond)
+
+		// Act
+		models, err := handler.fetchModelsFromAnthropic()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, models["data"], 1)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+}
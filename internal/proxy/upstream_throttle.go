@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errThrottleQueueFull is returned when a request would wait longer than the queue timeout
+var errThrottleQueueFull = errors.New("upstream request rate limit reached")
+
+// upstreamThrottle spaces all upstream requests evenly so the proxy never
+// exceeds a fixed rate toward Anthropic, however many clients it serves.
+// Requests over the rate queue for their slot instead of failing outright.
+type upstreamThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	maxWait  time.Duration
+	next     time.Time // earliest time the next request may start
+}
+
+// newUpstreamThrottle creates a throttle allowing requestsPerSecond upstream
+// requests, queueing each for at most maxWait
+func newUpstreamThrottle(requestsPerSecond float64, maxWait time.Duration) *upstreamThrottle {
+	return &upstreamThrottle{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+		maxWait:  maxWait,
+	}
+}
+
+// Wait blocks until the request may be sent upstream. It fails immediately
+// when the queue is longer than maxWait, or when ctx is done first.
+func (t *upstreamThrottle) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	wait := slot.Sub(now)
+	if wait > t.maxWait {
+		t.mu.Unlock()
+		return errThrottleQueueFull
+	}
+	t.next = slot.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamThrottle(t *testing.T) {
+	t.Run("should space requests by the configured rate", func(t *testing.T) {
+		throttle := newUpstreamThrottle(100, time.Second)
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			require.NoError(t, throttle.Wait(context.Background()))
+		}
+
+		// The first request goes immediately, the other four wait 10ms each
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("should reject requests that would queue past the timeout", func(t *testing.T) {
+		throttle := newUpstreamThrottle(1, 500*time.Millisecond)
+
+		require.NoError(t, throttle.Wait(context.Background()))
+		err := throttle.Wait(context.Background())
+
+		assert.ErrorIs(t, err, errThrottleQueueFull)
+	})
+
+	t.Run("should stop waiting when the request is cancelled", func(t *testing.T) {
+		throttle := newUpstreamThrottle(1, 5*time.Second)
+		require.NoError(t, throttle.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := throttle.Wait(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestProxyHandler_UpstreamThrottle(t *testing.T) {
+	t.Run("should keep outbound requests under the cap", func(t *testing.T) {
+		// Arrange
+		const rps = 20
+		const requests = 10
+
+		var mu sync.Mutex
+		var arrivals []time.Time
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			arrivals = append(arrivals, time.Now())
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","content":[]}`))
+		}))
+		defer upstream.Close()
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   upstream.URL,
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+			UpstreamRPS:   rps,
+		})
+
+		// Act
+		var wg sync.WaitGroup
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"model":"claude-3-5-haiku-20241022","max_tokens":10,"messages":[]}`))
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+		}
+		wg.Wait()
+
+		// Assert
+		require.Len(t, arrivals, requests)
+		sort.Slice(arrivals, func(i, j int) bool { return arrivals[i].Before(arrivals[j]) })
+		span := arrivals[len(arrivals)-1].Sub(arrivals[0])
+		observedRPS := float64(requests-1) / span.Seconds()
+		assert.LessOrEqual(t, observedRPS, rps*1.1, "outbound rate %.1f/s exceeded the cap", observedRPS)
+	})
+
+	t.Run("should reject with 429 when the queue wait is too long", func(t *testing.T) {
+		// Arrange
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"msg_1","type":"message","content":[]}`))
+		}))
+		defer upstream.Close()
+
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:          upstream.URL,
+			TokenProvider:        &mockTokenProvider{token: "test-token"},
+			Transformer:          NewRequestTransformer(),
+			UpstreamRPS:          0.5,
+			UpstreamQueueTimeout: 100 * time.Millisecond,
+		})
+		send := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/v1/messages", strings.NewReader(`{"model":"claude-3-5-haiku-20241022","max_tokens":10,"messages":[]}`))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		// Act
+		first := send()
+		second := send()
+
+		// Assert
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, http.StatusTooManyRequests, second.Code)
+		assert.Equal(t, "1", second.Header().Get("Retry-After"))
+		assert.Contains(t, second.Body.String(), "rate_limit_error")
+	})
+}
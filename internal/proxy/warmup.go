@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// warmupTimeout bounds the startup warmup request
+const warmupTimeout = 10 * time.Second
+
+// Warmup primes the upstream connection pool with a cheap authenticated GET of the
+// model list, so the first real request does not pay for DNS, TCP and TLS setup. The
+// body is drained so the connection goes back to the pool. An error response still
+// leaves a warm connection and is not treated as a failure.
+func (h *ProxyHandler) Warmup(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.config.upstream().BaseURL+"/v1/models?limit=1", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create warmup request: %w", err)
+	}
+	if token, err := h.config.TokenProvider.GetAccessToken(); err == nil {
+		req.Header = h.config.Transformer.InjectHeaders(http.Header{}, token)
+		req.Header.Set("anthropic-version", h.anthropicVersion(""))
+	}
+
+	start := time.Now()
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("warmup request failed: %w", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	h.logger.Info("upstream connection warmed up", "status", resp.StatusCode, "duration", time.Since(start))
+	return nil
+}
+
+// warmup runs Warmup in the background, logging failures
+func (h *ProxyHandler) warmup() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), warmupTimeout)
+		defer cancel()
+		if err := h.Warmup(ctx); err != nil {
+			h.logger.Warn("upstream warmup failed", "error", err)
+		}
+	}()
+}
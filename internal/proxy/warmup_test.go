@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyServer_Warmup(t *testing.T) {
+	newServer := func(t *testing.T, warmup bool) (*ProxyServer, *int32, chan *http.Request) {
+		var calls int32
+		requests := make(chan *http.Request, 1)
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				requests <- r
+			}
+			w.Write([]byte(`{"data":[]}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		config := &ProxyConfig{
+			UpstreamURL:    upstream.URL,
+			TokenProvider:  &mockTokenProvider{token: "test-token"},
+			Transformer:    NewRequestTransformer(),
+			WarmupUpstream: warmup,
+		}
+		server := NewProxyServer(config, "127.0.0.1:0", nil)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.Serve(listener)
+		t.Cleanup(func() { server.Stop(time.Second) })
+		return server, &calls, requests
+	}
+
+	t.Run("should call the upstream at startup when enabled", func(t *testing.T) {
+		// Arrange & Act
+		_, _, requests := newServer(t, true)
+
+		// Assert
+		select {
+		case r := <-requests:
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "/v1/models", r.URL.Path)
+			assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		case <-time.After(2 * time.Second):
+			t.Fatal("no warmup request reached the upstream")
+		}
+	})
+
+	t.Run("should not call the upstream by default", func(t *testing.T) {
+		_, calls, _ := newServer(t, false)
+
+		time.Sleep(100 * time.Millisecond)
+
+		assert.Zero(t, atomic.LoadInt32(calls))
+	})
+}
+
+func TestProxyHandler_Warmup(t *testing.T) {
+	t.Run("should report an unreachable upstream", func(t *testing.T) {
+		handler := NewProxyHandler(&ProxyConfig{
+			UpstreamURL:   "http://127.0.0.1:1",
+			TokenProvider: &mockTokenProvider{token: "test-token"},
+			Transformer:   NewRequestTransformer(),
+		})
+
+		err := handler.Warmup(context.Background())
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// SetTrimWhitespace toggles trimming of leading and trailing whitespace from OpenAI
+// response content. Only the ends of the content change, so JSON output stays valid.
+func (t *RequestTransformer) SetTrimWhitespace(trim bool) {
+	t.trimWhitespace = trim
+}
+
+// trimResponseWhitespace trims the message content of every choice in an OpenAI chat completion
+func trimResponseWhitespace(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	choices, _ := response["choices"].([]interface{})
+	changed := false
+	for _, c := range choices {
+		choice, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message, ok := choice["message"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if content, ok := message["content"].(string); ok {
+			if trimmed := strings.TrimSpace(content); trimmed != content {
+				message["content"] = trimmed
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return body, nil
+	}
+	return json.Marshal(response)
+}
+
+// whitespaceTrimmer trims streamed text as a whole. Leading whitespace is dropped until
+// the first visible character; trailing whitespace is held back until more text follows,
+// so it is never sent if the stream ends with it.
+type whitespaceTrimmer struct {
+	started bool
+	pending string
+}
+
+// Process returns the part of the next text delta that can be sent now
+func (w *whitespaceTrimmer) Process(text string) string {
+	if !w.started {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		if text == "" {
+			return ""
+		}
+		w.started = true
+	}
+
+	combined := w.pending + text
+	trimmed := strings.TrimRightFunc(combined, unicode.IsSpace)
+	w.pending = combined[len(trimmed):]
+	return trimmed
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// anthropicTextResponse builds a non-streaming Anthropic response with the given text
+func anthropicTextResponse(t *testing.T, text string) []byte {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-3-5-sonnet-20241022",
+		"content":     []map[string]interface{}{{"type": "text", "text": text}},
+		"stop_reason": "end_turn",
+	})
+	require.NoError(t, err)
+	return body
+}
+
+// responseContent returns the message content of the first choice
+func responseContent(t *testing.T, body []byte) string {
+	t.Helper()
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &response))
+	message := response["choices"].([]interface{})[0].(map[string]interface{})["message"].(map[string]interface{})
+	return message["content"].(string)
+}
+
+func TestTrimWhitespace_NonStreaming(t *testing.T) {
+	t.Run("should trim leading and trailing whitespace when enabled", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+		transformer.SetTrimWhitespace(true)
+
+		result, err := transformer.TransformResponseBody(anthropicTextResponse(t, "\n  Hello, world!\n\n "), "/v1/chat/completions")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Hello, world!", responseContent(t, result))
+	})
+
+	t.Run("should leave content untouched by default", func(t *testing.T) {
+		transformer := NewRequestTransformer()
+
+		result, err := transformer.TransformResponseBody(anthropicTextResponse(t, "Hello\n"), "/v1/chat/completions")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Hello\n", responseContent(t, result))
+	})
+
+	t.Run("should keep JSON output intact", func(t *testing.T) {
+		// Arrange
+		transformer := NewRequestTransformer()
+		transformer.SetTrimWhitespace(true)
+		jsonOutput := "{\n  \"items\": [\"a \", \" b\"],\n  \"note\": \"ends with space \"\n}"
+
+		// Act
+		result, err := transformer.TransformResponseBody(anthropicTextResponse(t, "\n"+jsonOutput+"\n\n"), "/v1/chat/completions")
+
+		// Assert
+		require.NoError(t, err)
+		content := responseContent(t, result)
+		assert.Equal(t, jsonOutput, content)
+
+		var parsed map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(content), &parsed))
+		assert.Equal(t, []interface{}{"a ", " b"}, parsed["items"])
+		assert.Equal(t, "ends with space ", parsed["note"])
+	})
+}
+
+func TestTrimWhitespace_Streaming(t *testing.T) {
+	// streamContent runs text deltas through a trimming converter and returns the streamed content
+	streamContent := func(t *testing.T, deltas ...string) string {
+		t.Helper()
+
+		converter := NewSSEConverter("chatcmpl-test", "claude-3-5-sonnet-20241022", 1719331200, nil)
+		converter.EnableWhitespaceTrim()
+
+		_, err := converter.Convert("message_start", `{"type":"message_start","message":{"model":"claude-3-5-sonnet-20241022"}}`)
+		require.NoError(t, err)
+
+		var content strings.Builder
+		for _, text := range deltas {
+			data, _ := json.Marshal(map[string]interface{}{
+				"type": "content_block_delta", "index": 0,
+				"delta": map[string]interface{}{"type": "text_delta", "text": text},
+			})
+			result, err := converter.Convert("content_block_delta", string(data))
+			require.NoError(t, err)
+			if result == "" {
+				continue
+			}
+
+			var chunk map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(result, "data: "))), &chunk))
+			delta := chunk["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+			content.WriteString(delta["content"].(string))
+		}
+		return content.String()
+	}
+
+	t.Run("should trim the accumulated stream", func(t *testing.T) {
+		content := streamContent(t, "\n\n", "  Hello", ",  ", "\n", "world!", "\n", "  \n")
+
+		assert.Equal(t, "Hello,  \nworld!", content)
+	})
+
+	t.Run("should keep whitespace inside streamed JSON", func(t *testing.T) {
+		content := streamContent(t, "\n", "{\"a\": ", "\"x \"", ",\n \"b\": 1", "}", "\n\n")
+
+		assert.Equal(t, "{\"a\": \"x \",\n \"b\": 1}", content)
+		var parsed map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(content), &parsed))
+		assert.Equal(t, "x ", parsed["a"])
+	})
+
+	t.Run("should send nothing for a whitespace-only stream", func(t *testing.T) {
+		assert.Empty(t, streamContent(t, " ", "\n", "\t"))
+	})
+}
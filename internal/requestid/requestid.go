@@ -0,0 +1,86 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Header is the HTTP header used to expose the request ID to clients
+const Header = "X-Request-Id"
+
+// maxIncomingLength caps the length of a client-supplied request ID
+const maxIncomingLength = 128
+
+// contextKey keys the request ID in a request context
+type contextKey struct{}
+
+// New returns a collision-resistant request ID made of 128 random bits,
+// hex encoded
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand should never fail; fall back to a time-based ID just in case
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// ChatCompletionID returns the OpenAI-style completion ID for a request ID
+func ChatCompletionID(requestID string) string {
+	return "chatcmpl-" + requestID
+}
+
+// CompletionID returns the OpenAI legacy text completion ID for a request ID
+func CompletionID(requestID string) string {
+	return "cmpl-" + requestID
+}
+
+// ResponseID returns the OpenAI Responses API ID for a request ID
+func ResponseID(requestID string) string {
+	return "resp_" + requestID
+}
+
+// WithContext returns a copy of ctx carrying the request ID
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored by Middleware, or "" when there is none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Middleware gives every request an ID: the client's X-Request-Id when it is a short,
+// URL-safe string, otherwise a new one. The ID is stored in the request context for
+// FromContext and echoed in the response header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(WithContext(r.Context(), id)))
+	})
+}
+
+// valid reports whether a client-supplied ID is safe to log, echo and embed in
+// completion IDs
+func valid(id string) bool {
+	if id == "" || len(id) > maxIncomingLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should return 32 hex characters", func(t *testing.T) {
+		id := New()
+
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), id)
+	})
+
+	t.Run("should not repeat IDs", func(t *testing.T) {
+		seen := make(map[string]bool)
+		for i := 0; i < 10000; i++ {
+			id := New()
+			assert.False(t, seen[id], "duplicate request ID %s", id)
+			seen[id] = true
+		}
+	})
+}
+
+func TestChatCompletionID(t *testing.T) {
+	t.Run("should prefix the request ID", func(t *testing.T) {
+		assert.Equal(t, "chatcmpl-abc123", ChatCompletionID("abc123"))
+	})
+}
+
+func TestCompletionID(t *testing.T) {
+	t.Run("should prefix the request ID", func(t *testing.T) {
+		assert.Equal(t, "cmpl-abc123", CompletionID("abc123"))
+	})
+}
+
+func TestResponseID(t *testing.T) {
+	t.Run("should prefix the request ID", func(t *testing.T) {
+		assert.Equal(t, "resp_abc123", ResponseID("abc123"))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	serve := func(incoming string) (contextID, responseID string) {
+		handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contextID = FromContext(r.Context())
+		}))
+		req := httptest.NewRequest("GET", "/", nil)
+		if incoming != "" {
+			req.Header.Set(Header, incoming)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return contextID, w.Header().Get(Header)
+	}
+
+	t.Run("should keep the client's request ID", func(t *testing.T) {
+		// Act
+		contextID, responseID := serve("client-trace_01.a")
+
+		// Assert
+		assert.Equal(t, "client-trace_01.a", contextID)
+		assert.Equal(t, "client-trace_01.a", responseID)
+	})
+
+	t.Run("should generate an ID when the client sends none", func(t *testing.T) {
+		contextID, responseID := serve("")
+
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), contextID)
+		assert.Equal(t, contextID, responseID)
+	})
+
+	t.Run("should replace IDs that are not short and URL-safe", func(t *testing.T) {
+		for _, incoming := range []string{"bad id", "a/b", "<script>", strings.Repeat("a", 129)} {
+			contextID, responseID := serve(incoming)
+
+			assert.NotEqual(t, incoming, contextID)
+			assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), contextID, incoming)
+			assert.Equal(t, contextID, responseID)
+		}
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("should be empty without an ID", func(t *testing.T) {
+		assert.Empty(t, FromContext(httptest.NewRequest("GET", "/", nil).Context()))
+	})
+}
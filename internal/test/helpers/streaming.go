@@ -0,0 +1,234 @@
+package helpers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// StreamFailure selects how a mock streaming upstream ends its stream
+type StreamFailure int
+
+const (
+	// StreamComplete sends the whole stream, ending with message_stop
+	StreamComplete StreamFailure = iota
+	// StreamErrorEvent sends the text deltas, then an Anthropic error event
+	StreamErrorEvent
+	// StreamAbruptClose sends the text deltas, then drops the connection
+	StreamAbruptClose
+	// StreamErrorFirst sends only an Anthropic error event, before any other event
+	StreamErrorFirst
+	// StreamEmpty ends the stream without sending any event
+	StreamEmpty
+)
+
+// MockStreamOptions configures a mock Anthropic streaming server
+type MockStreamOptions struct {
+	Model   string        // Model reported in message_start
+	Deltas  []string      // Text deltas sent before the stream ends or fails
+	Failure StreamFailure // How the stream ends
+
+	// Error sent with StreamErrorEvent
+	ErrorType    string
+	ErrorMessage string
+}
+
+// CreateMockStreamingServer creates a mock Anthropic API server that answers
+// every request with an SSE stream, optionally failing part way through
+func CreateMockStreamingServer(t *testing.T, opts MockStreamOptions) *httptest.Server {
+	t.Helper()
+
+	if opts.Model == "" {
+		opts.Model = "claude-sonnet-4-20250514"
+	}
+	if opts.ErrorType == "" {
+		opts.ErrorType = "overloaded_error"
+	}
+	if opts.ErrorMessage == "" {
+		opts.ErrorMessage = "Overloaded"
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		writeEvent := func(event string, data map[string]interface{}) {
+			payload, _ := json.Marshal(data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+
+		switch opts.Failure {
+		case StreamErrorFirst:
+			writeEvent("error", map[string]interface{}{
+				"type":  "error",
+				"error": map[string]interface{}{"type": opts.ErrorType, "message": opts.ErrorMessage},
+			})
+			return
+		case StreamEmpty:
+			return
+		}
+
+		writeEvent("message_start", map[string]interface{}{
+			"type": "message_start",
+			"message": map[string]interface{}{
+				"id": "msg_stream", "type": "message", "role": "assistant", "model": opts.Model,
+				"content": []interface{}{}, "usage": map[string]interface{}{"input_tokens": 10, "output_tokens": 1},
+			},
+		})
+		writeEvent("content_block_start", map[string]interface{}{
+			"type": "content_block_start", "index": 0,
+			"content_block": map[string]interface{}{"type": "text", "text": ""},
+		})
+		for _, text := range opts.Deltas {
+			writeEvent("content_block_delta", map[string]interface{}{
+				"type": "content_block_delta", "index": 0,
+				"delta": map[string]interface{}{"type": "text_delta", "text": text},
+			})
+		}
+
+		switch opts.Failure {
+		case StreamErrorEvent:
+			writeEvent("error", map[string]interface{}{
+				"type":  "error",
+				"error": map[string]interface{}{"type": opts.ErrorType, "message": opts.ErrorMessage},
+			})
+			return
+		case StreamAbruptClose:
+			// Abort without terminating the chunked response, like a dropped connection
+			panic(http.ErrAbortHandler)
+		}
+
+		writeEvent("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": 0})
+		writeEvent("message_delta", map[string]interface{}{
+			"type":  "message_delta",
+			"delta": map[string]interface{}{"stop_reason": "end_turn"},
+			"usage": map[string]interface{}{"output_tokens": len(opts.Deltas)},
+		})
+		writeEvent("message_stop", map[string]interface{}{"type": "message_stop"})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// OpenAIStream is a parsed OpenAI chat completion stream as a client sees it
+type OpenAIStream struct {
+	Chunks []map[string]interface{} // Completion chunks in order
+	Errors []map[string]interface{} // Error objects sent in the stream
+	Done   bool                     // Whether the stream ended with [DONE]
+}
+
+// ParseOpenAIStream parses the body of an OpenAI SSE response
+func ParseOpenAIStream(t *testing.T, body string) *OpenAIStream {
+	t.Helper()
+
+	stream := &OpenAIStream{}
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		require.False(t, stream.Done, "stream has data after [DONE]: %s", data)
+
+		if data == "[DONE]" {
+			stream.Done = true
+			continue
+		}
+
+		var chunk map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk), "stream chunk is not JSON: %s", data)
+		if errorObj, ok := chunk["error"].(map[string]interface{}); ok {
+			stream.Errors = append(stream.Errors, errorObj)
+			continue
+		}
+		stream.Chunks = append(stream.Chunks, chunk)
+	}
+	require.NoError(t, scanner.Err())
+
+	return stream
+}
+
+// Content returns the concatenated delta content of all chunks
+func (s *OpenAIStream) Content() string {
+	var content strings.Builder
+	for _, chunk := range s.Chunks {
+		for _, choice := range chunkChoices(chunk) {
+			if delta, ok := choice["delta"].(map[string]interface{}); ok {
+				if text, ok := delta["content"].(string); ok {
+					content.WriteString(text)
+				}
+			}
+		}
+	}
+	return content.String()
+}
+
+// FinishReason returns the last finish_reason sent in the stream, or ""
+func (s *OpenAIStream) FinishReason() string {
+	reason := ""
+	for _, chunk := range s.Chunks {
+		for _, choice := range chunkChoices(chunk) {
+			if r, ok := choice["finish_reason"].(string); ok {
+				reason = r
+			}
+		}
+	}
+	return reason
+}
+
+// AssertStreamCompleted checks that the stream finished normally with the given content
+func AssertStreamCompleted(t *testing.T, stream *OpenAIStream, content string) {
+	t.Helper()
+
+	assert.Empty(t, stream.Errors, "stream should not contain errors")
+	assert.Equal(t, content, stream.Content())
+	assert.NotEmpty(t, stream.FinishReason(), "stream should send a finish_reason")
+	assert.True(t, stream.Done, "stream should end with [DONE]")
+}
+
+// AssertStreamErrored checks that the stream delivered the partial content,
+// then a single error of the given type, and was not marked as done
+func AssertStreamErrored(t *testing.T, stream *OpenAIStream, partialContent, errorType string) {
+	t.Helper()
+
+	assert.Equal(t, partialContent, stream.Content())
+	if assert.Len(t, stream.Errors, 1, "stream should contain exactly one error") {
+		assert.Equal(t, errorType, stream.Errors[0]["type"])
+		assert.NotEmpty(t, stream.Errors[0]["message"])
+	}
+	assert.Empty(t, stream.FinishReason(), "an errored stream should not report a finish_reason")
+	assert.False(t, stream.Done, "an errored stream should not end with [DONE]")
+}
+
+// AssertStreamTruncated checks that the stream delivered the partial content,
+// then stopped without an error, a finish_reason, or [DONE]
+func AssertStreamTruncated(t *testing.T, stream *OpenAIStream, partialContent string) {
+	t.Helper()
+
+	assert.Equal(t, partialContent, stream.Content())
+	assert.Empty(t, stream.FinishReason(), "a truncated stream should not report a finish_reason")
+	assert.False(t, stream.Done, "a truncated stream should not end with [DONE]")
+}
+
+// chunkChoices returns the choices of a completion chunk
+func chunkChoices(chunk map[string]interface{}) []map[string]interface{} {
+	raw, _ := chunk["choices"].([]interface{})
+	choices := make([]map[string]interface{}, 0, len(raw))
+	for _, item := range raw {
+		if choice, ok := item.(map[string]interface{}); ok {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
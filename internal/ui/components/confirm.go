@@ -1,7 +1,11 @@
 package components
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ml0-1337/claude-gate/internal/ui/styles"
@@ -114,6 +118,33 @@ func ConfirmWithDefault(question string, defaultYes bool) bool {
 	return finalModel.(*ConfirmDefaultModel).answer
 }
 
+// ConfirmPhrase asks the user to type requiredPhrase to confirm a destructive action
+// and returns true only when it is typed exactly. Interactively a mismatch keeps the
+// prompt open until the phrase is typed or the prompt is cancelled; without a TTY
+// the phrase must be piped in and anything else is rejected.
+func ConfirmPhrase(question, requiredPhrase string) bool {
+	_, err := TextInput(phraseInputConfig(question, requiredPhrase))
+	return err == nil
+}
+
+// phraseInputConfig builds the text input behind ConfirmPhrase
+func phraseInputConfig(question, requiredPhrase string) TextInputConfig {
+	return TextInputConfig{
+		Prompt:      fmt.Sprintf("%s Type %s to confirm:", question, styles.CodeStyle.Render(requiredPhrase)),
+		Placeholder: requiredPhrase,
+		Validate: func(value string) error {
+			if value != requiredPhrase {
+				return fmt.Errorf("type %q exactly to confirm", requiredPhrase)
+			}
+			return nil
+		},
+	}
+}
+
+// stdinReader is shared by every non-interactive prompt so that lines buffered
+// ahead from piped input are not lost between prompts
+var stdinReader = bufio.NewReader(os.Stdin)
+
 // confirmNonInteractive handles confirmation without TTY
 func confirmNonInteractive(question string, defaultYes bool) bool {
 	suffix := "(y/N)"
@@ -123,16 +154,22 @@ func confirmNonInteractive(question string, defaultYes bool) bool {
 	
 	fmt.Printf("%s %s ", question, suffix)
 	
-	var response string
-	if _, err := fmt.Scanln(&response); err != nil {
-		// On error or empty input, return the default
+	return readConfirmAnswer(stdinReader, defaultYes)
+}
+
+// readConfirmAnswer reads one full line from r and interprets it as a yes/no
+// answer. Blank lines, unrecognized answers and read errors give the default.
+func readConfirmAnswer(r *bufio.Reader, defaultYes bool) bool {
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		// Nothing left to read (e.g. EOF on an empty pipe)
 		return defaultYes
 	}
 	
-	switch response {
-	case "y", "Y", "yes", "Yes", "YES":
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
 		return true
-	case "n", "N", "no", "No", "NO":
+	case "n", "no":
 		return false
 	default:
 		// Empty response or anything else, return default
@@ -170,4 +207,93 @@ func (m *ConfirmDefaultModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 	return m, nil
-}
\ No newline at end of file
+}
+
+// confirmTickMsg counts down a ConfirmTimeoutModel
+type confirmTickMsg struct{}
+
+// ConfirmTimeoutModel extends ConfirmDefaultModel with a countdown that picks the
+// default answer when it runs out
+type ConfirmTimeoutModel struct {
+	ConfirmDefaultModel
+	remaining time.Duration
+}
+
+// confirmTick schedules the next countdown step, at most a second away
+func confirmTick(remaining time.Duration) tea.Cmd {
+	step := time.Second
+	if remaining < step {
+		step = remaining
+	}
+	return tea.Tick(step, func(time.Time) tea.Msg {
+		return confirmTickMsg{}
+	})
+}
+
+// Init starts the countdown
+func (m ConfirmTimeoutModel) Init() tea.Cmd {
+	return confirmTick(m.remaining)
+}
+
+// Update handles confirmation updates and the countdown
+func (m ConfirmTimeoutModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(confirmTickMsg); ok {
+		if m.answered {
+			return m, nil
+		}
+		m.remaining -= time.Second
+		if m.remaining <= 0 {
+			m.answer = m.defaultYes
+			m.answered = true
+			return m, tea.Quit
+		}
+		return m, confirmTick(m.remaining)
+	}
+	
+	_, cmd := m.ConfirmDefaultModel.Update(msg)
+	return m, cmd
+}
+
+// View renders the confirmation prompt with the time left before the default is picked
+func (m ConfirmTimeoutModel) View() string {
+	if m.answered {
+		return m.ConfirmModel.View()
+	}
+	
+	countdown := fmt.Sprintf("[%ds]", int((m.remaining+time.Second-1)/time.Second))
+	return fmt.Sprintf("%s %s ", m.question, styles.HelpStyle.Render(countdown))
+}
+
+// ConfirmWithTimeout shows a confirmation prompt that picks the default answer once d
+// has passed without one, so unattended scripts do not hang on it
+func ConfirmWithTimeout(question string, defaultYes bool, d time.Duration) bool {
+	// Check if we have a TTY available
+	if !utils.IsInteractive() {
+		return confirmNonInteractive(question, defaultYes)
+	}
+	
+	suffix := "(y/N)"
+	if defaultYes {
+		suffix = "(Y/n)"
+	}
+	
+	model := ConfirmTimeoutModel{
+		ConfirmDefaultModel: ConfirmDefaultModel{
+			ConfirmModel: ConfirmModel{
+				question: fmt.Sprintf("%s %s", question, styles.HelpStyle.Render(suffix)),
+				answer:   defaultYes,
+			},
+			defaultYes: defaultYes,
+		},
+		remaining: d,
+	}
+	
+	p := tea.NewProgram(model)
+	
+	finalModel, err := p.Run()
+	if err != nil {
+		return false
+	}
+	
+	return finalModel.(ConfirmTimeoutModel).answer
+}
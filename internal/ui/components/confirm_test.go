@@ -0,0 +1,139 @@
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConfirmAnswer(t *testing.T) {
+	t.Run("should accept yes and no answers regardless of case and spacing", func(t *testing.T) {
+		cases := map[string]bool{
+			"y\n":       true,
+			"Yes\n":     true,
+			"  YES  \n": true,
+			"y\r\n":     true,
+			"n\n":       false,
+			"No\n":      false,
+			"\tno\n":    false,
+		}
+
+		for input, want := range cases {
+			assert.Equal(t, want, readConfirmAnswer(bufio.NewReader(strings.NewReader(input)), !want), "input %q", input)
+		}
+	})
+
+	t.Run("should return the default for blank lines", func(t *testing.T) {
+		assert.True(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("\n")), true))
+		assert.False(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("   \n")), false))
+	})
+
+	t.Run("should return the default for empty input", func(t *testing.T) {
+		assert.True(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("")), true))
+		assert.False(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("")), false))
+	})
+
+	t.Run("should read a final line without a newline", func(t *testing.T) {
+		assert.True(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("y")), false))
+	})
+
+	t.Run("should return the default for unrecognized answers", func(t *testing.T) {
+		assert.False(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("y please\n")), false))
+		assert.True(t, readConfirmAnswer(bufio.NewReader(strings.NewReader("maybe\n")), true))
+	})
+
+	t.Run("should answer successive prompts from successive lines", func(t *testing.T) {
+		// Arrange
+		input := bufio.NewReader(strings.NewReader("y\n\nn\nextra\n"))
+
+		// Act
+		answers := []bool{
+			readConfirmAnswer(input, false),
+			readConfirmAnswer(input, true),
+			readConfirmAnswer(input, true),
+		}
+
+		// Assert
+		assert.Equal(t, []bool{true, true, false}, answers)
+	})
+}
+
+
+func TestConfirmTimeoutModel(t *testing.T) {
+	newModel := func(defaultYes bool, d time.Duration) ConfirmTimeoutModel {
+		return ConfirmTimeoutModel{
+			ConfirmDefaultModel: ConfirmDefaultModel{
+				ConfirmModel: ConfirmModel{question: "Deploy?", answer: defaultYes},
+				defaultYes:   defaultYes,
+			},
+			remaining: d,
+		}
+	}
+
+	t.Run("should count down and pick the default when time runs out", func(t *testing.T) {
+		// Arrange
+		var model tea.Model = newModel(true, 2*time.Second)
+		assert.Contains(t, model.View(), "[2s]")
+
+		// Act
+		model, cmd := model.Update(confirmTickMsg{})
+		assert.NotNil(t, cmd)
+		assert.Contains(t, model.View(), "[1s]")
+		model, cmd = model.Update(confirmTickMsg{})
+
+		// Assert
+		assert.NotNil(t, cmd)
+		assert.True(t, model.(ConfirmTimeoutModel).answered)
+		assert.True(t, model.(ConfirmTimeoutModel).answer)
+		assert.Contains(t, model.View(), "Yes")
+	})
+
+	t.Run("should round a partial second up in the countdown", func(t *testing.T) {
+		assert.Contains(t, newModel(false, 1500*time.Millisecond).View(), "[2s]")
+	})
+
+	t.Run("should take an answer typed before the timeout", func(t *testing.T) {
+		// Act
+		model, cmd := newModel(false, 10*time.Second).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+		// Assert
+		assert.NotNil(t, cmd)
+		assert.True(t, model.(ConfirmTimeoutModel).answer)
+
+		model, cmd = model.Update(confirmTickMsg{})
+		assert.Nil(t, cmd)
+		assert.True(t, model.(ConfirmTimeoutModel).answer)
+	})
+}
+
+func TestConfirmPhrase(t *testing.T) {
+	t.Run("should accept only the exact phrase", func(t *testing.T) {
+		// Arrange
+		config := phraseInputConfig("Log out every account?", "work")
+
+		// Act
+		_, matched := textInputNonInteractive(&bytes.Buffer{}, bufio.NewReader(strings.NewReader("work\n")), config)
+		_, mismatched := textInputNonInteractive(&bytes.Buffer{}, bufio.NewReader(strings.NewReader("Work\n")), config)
+		_, empty := textInputNonInteractive(&bytes.Buffer{}, bufio.NewReader(strings.NewReader("")), config)
+
+		// Assert
+		assert.NoError(t, matched)
+		assert.Error(t, mismatched)
+		assert.Error(t, empty)
+	})
+
+	t.Run("should keep the interactive prompt open on a mismatch", func(t *testing.T) {
+		var model tea.Model = NewTextInput(phraseInputConfig("Log out every account?", "work"))
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Nil(t, cmd)
+		assert.Contains(t, model.View(), `type "work" exactly to confirm`)
+	})
+}
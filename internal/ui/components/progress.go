@@ -125,6 +125,14 @@ func (t *ProgressTracker) Increment(title string) {
 	})
 }
 
+// SetPercent sets the progress directly, for work measured in bytes rather than steps
+func (t *ProgressTracker) SetPercent(percent float64, title string) {
+	t.program.Send(ProgressMsg{
+		Percent: percent,
+		Title:   title,
+	})
+}
+
 // Finish completes the progress
 func (t *ProgressTracker) Finish() {
 	t.program.Send(ProgressMsg{Percent: 1.0})
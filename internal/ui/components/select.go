@@ -0,0 +1,155 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ml0-1337/claude-gate/internal/ui/styles"
+	"github.com/ml0-1337/claude-gate/internal/ui/utils"
+)
+
+// SelectOption is one entry of a selectable list
+type SelectOption struct {
+	Label       string
+	Description string // Optional detail shown after the label
+}
+
+// SelectModel represents a list the user picks one option from
+type SelectModel struct {
+	title     string
+	options   []SelectOption
+	cursor    int
+	chosen    bool
+	cancelled bool
+}
+
+// NewSelect creates a new selectable list with the cursor on the first option
+func NewSelect(title string, options []SelectOption) SelectModel {
+	return SelectModel{
+		title:   title,
+		options: options,
+	}
+}
+
+// Init initializes the list
+func (m SelectModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles list navigation
+func (m SelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.options)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if len(m.options) > 0 {
+				m.chosen = true
+				return m, tea.Quit
+			}
+		case "esc", "q", "ctrl+c":
+			m.cancelled = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// View renders the list
+func (m SelectModel) View() string {
+	if m.chosen {
+		return fmt.Sprintf("%s %s\n", m.title, styles.InfoStyle.Render(m.options[m.cursor].Label))
+	}
+	if m.cancelled {
+		return fmt.Sprintf("%s %s\n", m.title, styles.HelpStyle.Render("cancelled"))
+	}
+
+	var b strings.Builder
+	b.WriteString(m.title + "\n")
+	for i, option := range m.options {
+		line := option.Label
+		if option.Description != "" {
+			line += " " + styles.DescriptionStyle.Render(option.Description)
+		}
+		if i == m.cursor {
+			b.WriteString(styles.SelectedListItemStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString(styles.ListItemStyle.Render(line) + "\n")
+		}
+	}
+	b.WriteString(styles.HelpStyle.Render("↑/↓ to move, enter to select, esc to cancel") + "\n")
+	return b.String()
+}
+
+// Selected returns the chosen index, or false when the list was cancelled or is
+// still open
+func (m SelectModel) Selected() (int, bool) {
+	if !m.chosen {
+		return -1, false
+	}
+	return m.cursor, true
+}
+
+// Select shows a selectable list and returns the index of the chosen option, or false
+// when the user cancelled
+func Select(title string, options []SelectOption) (int, bool) {
+	if len(options) == 0 {
+		return -1, false
+	}
+
+	// Check if we have a TTY available
+	if !utils.IsInteractive() {
+		return selectNonInteractive(os.Stdout, stdinReader, title, options)
+	}
+
+	p := tea.NewProgram(NewSelect(title, options))
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return -1, false
+	}
+
+	return finalModel.(SelectModel).Selected()
+}
+
+// selectNonInteractive lists the options as numbers on out and reads the choice from r
+func selectNonInteractive(out io.Writer, r *bufio.Reader, title string, options []SelectOption) (int, bool) {
+	fmt.Fprintln(out, title)
+	for i, option := range options {
+		if option.Description != "" {
+			fmt.Fprintf(out, "  %d) %s %s\n", i+1, option.Label, option.Description)
+		} else {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, option.Label)
+		}
+	}
+	fmt.Fprintf(out, "Enter a number (1-%d): ", len(options))
+
+	return readSelection(r, len(options))
+}
+
+// readSelection reads one line from r as a 1-based option number. Blank lines,
+// numbers out of range, other text and read errors cancel the selection.
+func readSelection(r *bufio.Reader, count int) (int, bool) {
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		return -1, false
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > count {
+		return -1, false
+	}
+	return n - 1, true
+}
@@ -0,0 +1,95 @@
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectModel(t *testing.T) {
+	options := []SelectOption{
+		{Label: "default"},
+		{Label: "work", Description: "(expires in 2h)"},
+		{Label: "personal"},
+	}
+	press := func(m tea.Model, keys ...tea.KeyType) (tea.Model, tea.Cmd) {
+		var cmd tea.Cmd
+		for _, key := range keys {
+			m, cmd = m.Update(tea.KeyMsg{Type: key})
+		}
+		return m, cmd
+	}
+
+	t.Run("should select the option under the cursor on enter", func(t *testing.T) {
+		// Act
+		final, cmd := press(NewSelect("Account:", options), tea.KeyDown, tea.KeyDown, tea.KeyUp, tea.KeyEnter)
+
+		// Assert
+		assert.NotNil(t, cmd)
+		index, ok := final.(SelectModel).Selected()
+		assert.True(t, ok)
+		assert.Equal(t, 1, index)
+		assert.Contains(t, final.View(), "work")
+	})
+
+	t.Run("should keep the cursor within the list", func(t *testing.T) {
+		final, _ := press(NewSelect("Account:", options), tea.KeyUp, tea.KeyDown, tea.KeyDown, tea.KeyDown, tea.KeyDown, tea.KeyEnter)
+
+		index, ok := final.(SelectModel).Selected()
+		assert.True(t, ok)
+		assert.Equal(t, 2, index)
+	})
+
+	t.Run("should cancel on esc", func(t *testing.T) {
+		final, cmd := press(NewSelect("Account:", options), tea.KeyDown, tea.KeyEsc)
+
+		assert.NotNil(t, cmd)
+		_, ok := final.(SelectModel).Selected()
+		assert.False(t, ok)
+	})
+
+	t.Run("should render every option with the cursor on the first", func(t *testing.T) {
+		view := NewSelect("Account:", options).View()
+
+		assert.Contains(t, view, "Account:")
+		assert.Contains(t, view, "> default")
+		assert.Contains(t, view, "(expires in 2h)")
+		assert.Contains(t, view, "personal")
+	})
+}
+
+func TestSelectNonInteractive(t *testing.T) {
+	options := []SelectOption{{Label: "default"}, {Label: "work", Description: "(expires in 2h)"}}
+
+	t.Run("should list numbered options and read the choice", func(t *testing.T) {
+		// Arrange
+		var out bytes.Buffer
+
+		// Act
+		index, ok := selectNonInteractive(&out, bufio.NewReader(strings.NewReader("2\n")), "Account:", options)
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, 1, index)
+		assert.Equal(t, "Account:\n  1) default\n  2) work (expires in 2h)\nEnter a number (1-2): ", out.String())
+	})
+
+	t.Run("should cancel on invalid input", func(t *testing.T) {
+		for _, input := range []string{"", "\n", "0\n", "3\n", "work\n"} {
+			_, ok := readSelection(bufio.NewReader(strings.NewReader(input)), 2)
+
+			assert.False(t, ok, "input %q", input)
+		}
+	})
+
+	t.Run("should read a final line without a newline", func(t *testing.T) {
+		index, ok := readSelection(bufio.NewReader(strings.NewReader(" 1 ")), 2)
+
+		assert.True(t, ok)
+		assert.Equal(t, 0, index)
+	})
+}
@@ -2,6 +2,8 @@ package components
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -30,6 +32,42 @@ func NewSpinner(message string) SpinnerModel {
 	}
 }
 
+// SetMessage replaces the message shown next to the spinner
+func (m *SpinnerModel) SetMessage(message string) {
+	m.message = message
+}
+
+// Succeed resolves the spinner to the success state with a final message
+func (m *SpinnerModel) Succeed(message string) {
+	m.resolve("success", message)
+}
+
+// Fail resolves the spinner to the error state with a final message
+func (m *SpinnerModel) Fail(message string) {
+	m.resolve("error", message)
+}
+
+func (m *SpinnerModel) resolve(status, message string) {
+	m.status = status
+	m.message = message
+	m.quitting = true
+}
+
+// Resolved reports whether the spinner reached the success or error state
+func (m SpinnerModel) Resolved() bool {
+	return m.status != "loading"
+}
+
+// Succeeded reports whether the spinner resolved to the success state
+func (m SpinnerModel) Succeeded() bool {
+	return m.status == "success"
+}
+
+// Message returns the current message
+func (m SpinnerModel) Message() string {
+	return m.message
+}
+
 // Init initializes the spinner
 func (m SpinnerModel) Init() tea.Cmd {
 	return m.spinner.Tick
@@ -52,6 +90,10 @@ func (m SpinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 
+	case MessageMsg:
+		m.SetMessage(msg.Message)
+		return m, nil
+
 	case StatusMsg:
 		m.status = msg.Status
 		m.message = msg.Message
@@ -80,11 +122,28 @@ type StatusMsg struct {
 	Message string
 }
 
+// MessageMsg replaces the message of a running spinner
+type MessageMsg struct {
+	Message string
+}
+
 // RunSpinner runs a spinner while executing a function
 func RunSpinner(message string, fn func() error) error {
+	return RunSpinnerWithMessages(message, func(func(string)) error {
+		return fn()
+	})
+}
+
+// RunSpinnerWithMessages runs a spinner while executing a function that can update the
+// spinner's message as it progresses, for example from "Refreshing token..." to
+// "Saving token...". Without a TTY it prints a single line and message updates are
+// dropped.
+func RunSpinnerWithMessages(message string, fn func(setMessage func(string)) error) error {
 	// Check if we have a TTY available
 	if !utils.IsInteractive() {
-		return runSpinnerNonInteractive(message, fn)
+		return runSpinnerNonInteractive(os.Stdout, message, func() error {
+			return fn(func(string) {})
+		})
 	}
 
 	// Create the spinner model
@@ -98,7 +157,9 @@ func RunSpinner(message string, fn func() error) error {
 	
 	// Run the function in a goroutine
 	go func() {
-		err := fn()
+		err := fn(func(message string) {
+			p.Send(MessageMsg{Message: message})
+		})
 		done <- err
 		
 		// Send status update to the spinner
@@ -129,15 +190,15 @@ func RunSpinner(message string, fn func() error) error {
 	}
 }
 
-// runSpinnerNonInteractive runs the function without TTY spinner
-func runSpinnerNonInteractive(message string, fn func() error) error {
-	fmt.Printf("%s ", message)
+// runSpinnerNonInteractive runs the function without TTY spinner, writing one line to out
+func runSpinnerNonInteractive(out io.Writer, message string, fn func() error) error {
+	fmt.Fprintf(out, "%s ", message)
 	err := fn()
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+		fmt.Fprintf(out, "Error: %v\n", err)
 		return err
 	}
-	fmt.Printf("Done!\n")
+	fmt.Fprintf(out, "Done!\n")
 	return nil
 }
 
@@ -0,0 +1,75 @@
+package components
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpinnerModel(t *testing.T) {
+	t.Run("should show the message while loading", func(t *testing.T) {
+		model := NewSpinner("Refreshing token...")
+
+		assert.False(t, model.Resolved())
+		assert.Contains(t, model.View(), "Refreshing token...")
+	})
+
+	t.Run("should replace the message", func(t *testing.T) {
+		// Arrange
+		model := NewSpinner("Refreshing token...")
+
+		// Act
+		model.SetMessage("Saving token...")
+		updated, cmd := model.Update(MessageMsg{Message: "Almost done..."})
+
+		// Assert
+		assert.Nil(t, cmd)
+		assert.Equal(t, "Almost done...", updated.(SpinnerModel).Message())
+		assert.Contains(t, updated.View(), "Almost done...")
+	})
+
+	t.Run("should resolve to success or failure", func(t *testing.T) {
+		succeeded := NewSpinner("Exchanging code...")
+		failed := NewSpinner("Exchanging code...")
+
+		succeeded.Succeed("Logged in")
+		failed.Fail("Invalid code")
+
+		assert.True(t, succeeded.Resolved())
+		assert.True(t, succeeded.Succeeded())
+		assert.Contains(t, succeeded.View(), "Logged in")
+		assert.True(t, failed.Resolved())
+		assert.False(t, failed.Succeeded())
+		assert.Contains(t, failed.View(), "Invalid code")
+	})
+
+	t.Run("should quit when a status message resolves it", func(t *testing.T) {
+		updated, cmd := NewSpinner("Working...").Update(StatusMsg{Status: "error", Message: "boom"})
+
+		assert.NotNil(t, cmd)
+		assert.True(t, updated.(SpinnerModel).Resolved())
+		assert.False(t, updated.(SpinnerModel).Succeeded())
+	})
+}
+
+func TestRunSpinnerNonInteractive(t *testing.T) {
+	t.Run("should print a single line on success", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := runSpinnerNonInteractive(&out, "Checking for updates...", func() error { return nil })
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Checking for updates... Done!\n", out.String())
+	})
+
+	t.Run("should print a single line with the error on failure", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := runSpinnerNonInteractive(&out, "Checking for updates...", func() error { return errors.New("offline") })
+
+		assert.EqualError(t, err, "offline")
+		assert.Equal(t, "Checking for updates... Error: offline\n", out.String())
+	})
+}
@@ -0,0 +1,176 @@
+package components
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ml0-1337/claude-gate/internal/ui/styles"
+	"github.com/ml0-1337/claude-gate/internal/ui/utils"
+)
+
+var (
+	// ErrInputCancelled is returned when the user leaves a text input with esc
+	ErrInputCancelled = errors.New("input cancelled")
+	// ErrInputAborted is returned when the user leaves a text input with ctrl+c
+	ErrInputAborted = errors.New("input aborted")
+)
+
+// TextInputConfig configures a text input prompt
+type TextInputConfig struct {
+	Prompt      string
+	Placeholder string
+	Mask        bool                     // Hide the typed value, for secrets such as tokens
+	Validate    func(value string) error // Optional; an error keeps the prompt open
+}
+
+// TextInputModel represents a single line text input prompt
+type TextInputModel struct {
+	config    TextInputConfig
+	input     textinput.Model
+	err       error
+	submitted bool
+	cancelled bool
+	aborted   bool
+}
+
+// NewTextInput creates a new focused text input prompt
+func NewTextInput(config TextInputConfig) TextInputModel {
+	ti := textinput.New()
+	ti.Placeholder = config.Placeholder
+	ti.Prompt = ""
+	ti.Width = 50
+	if config.Mask {
+		ti.EchoMode = textinput.EchoPassword
+		ti.EchoCharacter = '•'
+	}
+	ti.Focus()
+
+	return TextInputModel{
+		config: config,
+		input:  ti,
+	}
+}
+
+// Init initializes the text input
+func (m TextInputModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles text input updates
+func (m TextInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEnter:
+			value := strings.TrimSpace(m.input.Value())
+			if m.config.Validate != nil {
+				if err := m.config.Validate(value); err != nil {
+					m.err = err
+					return m, nil
+				}
+			}
+			m.submitted = true
+			return m, tea.Quit
+		case tea.KeyEsc:
+			m.cancelled = true
+			return m, tea.Quit
+		case tea.KeyCtrlC:
+			m.aborted = true
+			return m, tea.Quit
+		}
+		// Editing clears the last validation error
+		m.err = nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// View renders the text input
+func (m TextInputModel) View() string {
+	if m.submitted {
+		shown := m.Value()
+		if m.config.Mask {
+			shown = strings.Repeat("•", len([]rune(shown)))
+		}
+		return fmt.Sprintf("%s %s\n", m.config.Prompt, styles.InfoStyle.Render(shown))
+	}
+	if m.cancelled || m.aborted {
+		return fmt.Sprintf("%s %s\n", m.config.Prompt, styles.HelpStyle.Render("cancelled"))
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s\n", m.config.Prompt, m.input.View()))
+	if m.err != nil {
+		b.WriteString(styles.ErrorStyle.Render("✗ "+m.err.Error()) + "\n")
+	}
+	b.WriteString(styles.HelpStyle.Render("enter to submit, esc to cancel") + "\n")
+	return b.String()
+}
+
+// Value returns the trimmed text typed so far
+func (m TextInputModel) Value() string {
+	return strings.TrimSpace(m.input.Value())
+}
+
+// Err returns the outcome of the prompt: nil once a value was submitted,
+// ErrInputCancelled or ErrInputAborted when the user left it
+func (m TextInputModel) Err() error {
+	switch {
+	case m.aborted:
+		return ErrInputAborted
+	case m.cancelled || !m.submitted:
+		return ErrInputCancelled
+	}
+	return nil
+}
+
+// TextInput shows a text input prompt and returns the submitted value
+func TextInput(config TextInputConfig) (string, error) {
+	// Check if we have a TTY available
+	if !utils.IsInteractive() {
+		return textInputNonInteractive(os.Stdout, stdinReader, config)
+	}
+
+	p := tea.NewProgram(NewTextInput(config))
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return "", err
+	}
+
+	model := finalModel.(TextInputModel)
+	if err := model.Err(); err != nil {
+		return "", err
+	}
+	return model.Value(), nil
+}
+
+// textInputNonInteractive prints the prompt on out and reads the value as one line
+// from r, so scripts can pipe it in. Like confirmNonInteractive it reads from the
+// shared stdinReader rather than a bufio.Scanner of its own, which would swallow
+// lines buffered ahead for later prompts.
+func textInputNonInteractive(out io.Writer, r *bufio.Reader, config TextInputConfig) (string, error) {
+	fmt.Fprintf(out, "%s ", config.Prompt)
+
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		// Nothing left to read (e.g. EOF on an empty pipe)
+		fmt.Fprintln(out)
+		return "", ErrInputCancelled
+	}
+
+	value := strings.TrimSpace(line)
+	if config.Validate != nil {
+		if err := config.Validate(value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,105 @@
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextInputModel(t *testing.T) {
+	typeText := func(m tea.Model, text string) tea.Model {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+		return m
+	}
+
+	t.Run("should submit the typed value on enter", func(t *testing.T) {
+		// Arrange
+		model := typeText(NewTextInput(TextInputConfig{Prompt: "Code:"}), " abc123 ")
+
+		// Act
+		final, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		// Assert
+		assert.NotNil(t, cmd)
+		assert.NoError(t, final.(TextInputModel).Err())
+		assert.Equal(t, "abc123", final.(TextInputModel).Value())
+	})
+
+	t.Run("should mask a secret value", func(t *testing.T) {
+		model := typeText(NewTextInput(TextInputConfig{Prompt: "Token:", Mask: true}), "sk-secret")
+
+		assert.NotContains(t, model.View(), "sk-secret")
+
+		final, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotContains(t, final.View(), "sk-secret")
+		assert.Equal(t, "sk-secret", final.(TextInputModel).Value())
+	})
+
+	t.Run("should keep the prompt open while validation fails", func(t *testing.T) {
+		// Arrange
+		config := TextInputConfig{Prompt: "Code:", Validate: func(value string) error {
+			if value == "" {
+				return errors.New("code is required")
+			}
+			return nil
+		}}
+
+		// Act
+		model, cmd := NewTextInput(config).Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		// Assert
+		assert.Nil(t, cmd)
+		assert.Contains(t, model.View(), "code is required")
+		assert.ErrorIs(t, model.(TextInputModel).Err(), ErrInputCancelled)
+
+		model = typeText(model, "abc")
+		assert.NotContains(t, model.View(), "code is required")
+		final, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NoError(t, final.(TextInputModel).Err())
+	})
+
+	t.Run("should tell cancelling from aborting", func(t *testing.T) {
+		cancelled, _ := NewTextInput(TextInputConfig{}).Update(tea.KeyMsg{Type: tea.KeyEsc})
+		aborted, _ := NewTextInput(TextInputConfig{}).Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+
+		assert.ErrorIs(t, cancelled.(TextInputModel).Err(), ErrInputCancelled)
+		assert.ErrorIs(t, aborted.(TextInputModel).Err(), ErrInputAborted)
+	})
+}
+
+func TestTextInputNonInteractive(t *testing.T) {
+	t.Run("should read a piped value", func(t *testing.T) {
+		// Arrange
+		var out bytes.Buffer
+		r := bufio.NewReader(strings.NewReader("abc123\nnext\n"))
+
+		// Act
+		value, err := textInputNonInteractive(&out, r, TextInputConfig{Prompt: "Code:"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "abc123", value)
+		assert.Equal(t, "Code: ", out.String())
+		rest, _ := r.ReadString('\n')
+		assert.Equal(t, "next\n", rest)
+	})
+
+	t.Run("should return the validation error", func(t *testing.T) {
+		config := TextInputConfig{Prompt: "Code:", Validate: func(string) error { return errors.New("invalid code") }}
+
+		_, err := textInputNonInteractive(&bytes.Buffer{}, bufio.NewReader(strings.NewReader("abc\n")), config)
+
+		assert.EqualError(t, err, "invalid code")
+	})
+
+	t.Run("should cancel on an empty pipe", func(t *testing.T) {
+		_, err := textInputNonInteractive(&bytes.Buffer{}, bufio.NewReader(strings.NewReader("")), TextInputConfig{Prompt: "Code:"})
+
+		assert.ErrorIs(t, err, ErrInputCancelled)
+	})
+}
@@ -0,0 +1,196 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ml0-1337/claude-gate/internal/pricing"
+	"github.com/ml0-1337/claude-gate/internal/ui/styles"
+	"github.com/ml0-1337/claude-gate/internal/ui/utils"
+)
+
+// TokenDeltaMsg reports streamed output. Tokens is the exact count when known;
+// otherwise it is estimated from Text at about four characters per token.
+type TokenDeltaMsg struct {
+	Text   string
+	Tokens int
+}
+
+// TokenUsageMsg carries the exact usage reported by the upstream, replacing estimates
+type TokenUsageMsg struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// StreamDoneMsg ends the stream
+type StreamDoneMsg struct{}
+
+// TokenMeterModel shows a running output token count and cost estimate for a stream
+type TokenMeterModel struct {
+	model        string
+	price        pricing.Price
+	priced       bool
+	inputTokens  int
+	outputTokens int
+	pendingChars int
+	started      time.Time
+	elapsed      time.Duration
+	done         bool
+	updates      <-chan tea.Msg
+}
+
+// NewTokenMeter creates a token meter for a stream of updates. inputTokens is the
+// prompt size estimate, later replaced by a TokenUsageMsg if one arrives.
+func NewTokenMeter(model string, inputTokens int, updates <-chan tea.Msg) TokenMeterModel {
+	price, priced := pricing.Default().Lookup(model)
+	return TokenMeterModel{
+		model:       model,
+		price:       price,
+		priced:      priced,
+		inputTokens: inputTokens,
+		started:     time.Now(),
+		updates:     updates,
+	}
+}
+
+// Init starts listening for stream updates
+func (m TokenMeterModel) Init() tea.Cmd {
+	return waitForTokenUpdate(m.updates)
+}
+
+// waitForTokenUpdate reads the next update, ending the stream when the channel closes
+func waitForTokenUpdate(updates <-chan tea.Msg) tea.Cmd {
+	if updates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return StreamDoneMsg{}
+		}
+		return msg
+	}
+}
+
+// Update handles stream updates
+func (m TokenMeterModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.finish()
+			return m, tea.Quit
+		}
+
+	case TokenDeltaMsg:
+		m.addDelta(msg)
+		return m, waitForTokenUpdate(m.updates)
+
+	case TokenUsageMsg:
+		if msg.InputTokens > 0 {
+			m.inputTokens = msg.InputTokens
+		}
+		if msg.OutputTokens > 0 {
+			m.outputTokens = msg.OutputTokens
+			m.pendingChars = 0
+		}
+		return m, waitForTokenUpdate(m.updates)
+
+	case StreamDoneMsg:
+		m.finish()
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// addDelta counts the output of one streamed chunk
+func (m *TokenMeterModel) addDelta(msg TokenDeltaMsg) {
+	if msg.Tokens > 0 {
+		m.outputTokens += msg.Tokens
+		return
+	}
+	m.pendingChars += len(msg.Text)
+	m.outputTokens += m.pendingChars / 4
+	m.pendingChars %= 4
+}
+
+// finish freezes the elapsed time
+func (m *TokenMeterModel) finish() {
+	if !m.done {
+		m.done = true
+		m.elapsed = time.Since(m.started)
+	}
+}
+
+// OutputTokens returns the output token count so far
+func (m TokenMeterModel) OutputTokens() int {
+	return m.outputTokens
+}
+
+// Cost returns the estimated cost so far, and false for models without a known price
+func (m TokenMeterModel) Cost() (float64, bool) {
+	return m.price.Cost(m.inputTokens, m.outputTokens), m.priced
+}
+
+// costText formats the cost estimate
+func (m TokenMeterModel) costText() string {
+	cost, ok := m.Cost()
+	if !ok {
+		return "cost unknown"
+	}
+	return fmt.Sprintf("~$%.4f", cost)
+}
+
+// View renders the status line while streaming and the summary once done
+func (m TokenMeterModel) View() string {
+	if m.done {
+		return m.Summary() + "\n"
+	}
+	return fmt.Sprintf("%s %s %s\n",
+		styles.InfoStyle.Render(fmt.Sprintf("%d tokens", m.outputTokens)),
+		styles.DescriptionStyle.Render("·"),
+		styles.DescriptionStyle.Render(m.costText()),
+	)
+}
+
+// Summary describes the finished stream in one line
+func (m TokenMeterModel) Summary() string {
+	elapsed := m.elapsed
+	if !m.done {
+		elapsed = time.Since(m.started)
+	}
+	return fmt.Sprintf("%s: %d input + %d output tokens, %s in %s",
+		m.model, m.inputTokens, m.outputTokens, m.costText(), elapsed.Round(100*time.Millisecond))
+}
+
+// RunTokenMeter shows the live meter until updates closes or a StreamDoneMsg arrives.
+// Without a TTY it only prints the final summary.
+func RunTokenMeter(model string, inputTokens int, updates <-chan tea.Msg) (TokenMeterModel, error) {
+	meter := NewTokenMeter(model, inputTokens, updates)
+
+	if !utils.IsInteractive() {
+		meter = drainTokenMeter(meter, updates)
+		fmt.Println(meter.Summary())
+		return meter, nil
+	}
+
+	finalModel, err := tea.NewProgram(meter).Run()
+	if err != nil {
+		return meter, err
+	}
+	return finalModel.(TokenMeterModel), nil
+}
+
+// drainTokenMeter applies every update without rendering
+func drainTokenMeter(meter TokenMeterModel, updates <-chan tea.Msg) TokenMeterModel {
+	for msg := range updates {
+		model, _ := meter.Update(msg)
+		meter = model.(TokenMeterModel)
+		if meter.done {
+			return meter
+		}
+	}
+	meter.finish()
+	return meter
+}
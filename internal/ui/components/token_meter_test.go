@@ -0,0 +1,112 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendAll applies messages to a meter in order
+func sendAll(meter TokenMeterModel, msgs ...tea.Msg) TokenMeterModel {
+	for _, msg := range msgs {
+		model, _ := meter.Update(msg)
+		meter = model.(TokenMeterModel)
+	}
+	return meter
+}
+
+func TestTokenMeter(t *testing.T) {
+	t.Run("should count streamed token deltas", func(t *testing.T) {
+		meter := NewTokenMeter("claude-sonnet-4-20250514", 1000, nil)
+
+		meter = sendAll(meter, TokenDeltaMsg{Tokens: 5}, TokenDeltaMsg{Tokens: 7})
+
+		assert.Equal(t, 12, meter.OutputTokens())
+		assert.Contains(t, meter.View(), "12 tokens")
+	})
+
+	t.Run("should estimate tokens from text", func(t *testing.T) {
+		meter := NewTokenMeter("claude-sonnet-4-20250514", 0, nil)
+
+		meter = sendAll(meter, TokenDeltaMsg{Text: "Hel"}, TokenDeltaMsg{Text: "lo, wo"}, TokenDeltaMsg{Text: "rld"})
+
+		assert.Equal(t, 3, meter.OutputTokens(), "12 characters is about 3 tokens")
+	})
+
+	t.Run("should update the cost estimate as chunks arrive", func(t *testing.T) {
+		// Arrange
+		meter := NewTokenMeter("claude-sonnet-4-20250514", 1000, nil)
+
+		// Act
+		before, _ := meter.Cost()
+		meter = sendAll(meter, TokenDeltaMsg{Tokens: 1000})
+		after, priced := meter.Cost()
+
+		// Assert
+		require.True(t, priced)
+		assert.InDelta(t, 0.003, before, 1e-9, "1000 input tokens at $3/MTok")
+		assert.InDelta(t, 0.018, after, 1e-9, "plus 1000 output tokens at $15/MTok")
+		assert.Contains(t, meter.View(), "~$0.0180")
+	})
+
+	t.Run("should replace estimates with reported usage", func(t *testing.T) {
+		meter := NewTokenMeter("claude-3-5-haiku-20241022", 10, nil)
+
+		meter = sendAll(meter, TokenDeltaMsg{Text: "some text"}, TokenUsageMsg{InputTokens: 42, OutputTokens: 17})
+
+		assert.Equal(t, 17, meter.OutputTokens())
+		assert.Contains(t, meter.Summary(), "42 input + 17 output tokens")
+	})
+
+	t.Run("should report unknown pricing", func(t *testing.T) {
+		meter := NewTokenMeter("gpt-4o", 10, nil)
+
+		_, priced := meter.Cost()
+
+		assert.False(t, priced)
+		assert.Contains(t, meter.View(), "cost unknown")
+	})
+
+	t.Run("should quit with a summary when the stream ends", func(t *testing.T) {
+		meter := NewTokenMeter("claude-opus-4-20250514", 100, nil)
+
+		model, cmd := meter.Update(StreamDoneMsg{})
+
+		require.NotNil(t, cmd)
+		view := model.View()
+		assert.True(t, strings.HasPrefix(view, "claude-opus-4-20250514: 100 input + 0 output tokens"), view)
+	})
+
+	t.Run("should subscribe to the update channel", func(t *testing.T) {
+		// Arrange
+		updates := make(chan tea.Msg, 2)
+		updates <- TokenDeltaMsg{Tokens: 3}
+		close(updates)
+		meter := NewTokenMeter("claude-sonnet-4-20250514", 0, updates)
+
+		// Act
+		first := meter.Init()()
+		model, next := meter.Update(first)
+		last := next()
+
+		// Assert
+		assert.Equal(t, TokenDeltaMsg{Tokens: 3}, first)
+		assert.Equal(t, 3, model.(TokenMeterModel).OutputTokens())
+		assert.Equal(t, StreamDoneMsg{}, last, "a closed channel ends the stream")
+	})
+
+	t.Run("should summarize a drained stream", func(t *testing.T) {
+		updates := make(chan tea.Msg, 3)
+		updates <- TokenDeltaMsg{Tokens: 4}
+		updates <- TokenUsageMsg{OutputTokens: 6}
+		close(updates)
+
+		meter := drainTokenMeter(NewTokenMeter("claude-sonnet-4-20250514", 0, updates), updates)
+
+		assert.Equal(t, 6, meter.OutputTokens())
+		assert.Contains(t, meter.Summary(), "0 input + 6 output tokens")
+	})
+}
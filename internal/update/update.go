@@ -0,0 +1,305 @@
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAPIURL is the GitHub API used to look up releases
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultRepo is the GitHub repository releases are published to
+	DefaultRepo = "ml0-1337/claude-gate"
+
+	binaryName    = "claude-gate"
+	checksumsName = "checksums.txt"
+)
+
+// Release is the subset of a GitHub release needed to update
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Version returns the release version without the leading "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// Asset is a file attached to a GitHub release
+type Asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+}
+
+// ProgressFunc reports download progress; total is -1 when unknown
+type ProgressFunc func(downloaded, total int64)
+
+// Updater finds, downloads and verifies claude-gate releases
+type Updater struct {
+	APIURL     string
+	Repo       string
+	GOOS       string
+	GOARCH     string
+	HTTPClient *http.Client
+}
+
+// NewUpdater creates an updater for the current platform
+func NewUpdater() *Updater {
+	return &Updater{
+		APIURL:     DefaultAPIURL,
+		Repo:       DefaultRepo,
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		HTTPClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// LatestRelease fetches the latest published release
+func (u *Updater) LatestRelease() (*Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(u.APIURL, "/"), u.Repo)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := u.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch latest release: status %d", resp.StatusCode)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// ArchiveName returns the release archive name for the updater's platform,
+// matching the GoReleaser name template
+func (u *Updater) ArchiveName(version string) string {
+	arch := u.GOARCH
+	if arch == "amd64" {
+		arch = "x86_64"
+	}
+	osName := u.GOOS
+	if osName != "" {
+		osName = strings.ToUpper(osName[:1]) + osName[1:]
+	}
+	return fmt.Sprintf("%s_%s_%s_%s.tar.gz", binaryName, version, osName, arch)
+}
+
+// Download fetches the release archive for this platform, verifies it against the
+// release checksums and returns the extracted binary
+func (u *Updater) Download(release *Release, progress ProgressFunc) ([]byte, error) {
+	archiveName := u.ArchiveName(release.Version())
+	archiveAsset := findAsset(release, archiveName)
+	if archiveAsset == nil {
+		return nil, fmt.Errorf("release %s has no build for %s/%s", release.TagName, u.GOOS, u.GOARCH)
+	}
+	checksumsAsset := findAsset(release, checksumsName)
+	if checksumsAsset == nil {
+		return nil, fmt.Errorf("release %s has no %s, refusing to install an unverified binary", release.TagName, checksumsName)
+	}
+
+	checksums, err := u.fetch(checksumsAsset, nil)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := findChecksum(checksums, archiveName)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := u.fetch(archiveAsset, progress)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(archive)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", archiveName, expected, actual)
+	}
+
+	return extractBinary(archive)
+}
+
+// fetch downloads an asset, reporting progress as bytes arrive
+func (u *Updater) fetch(asset *Asset, progress ProgressFunc) ([]byte, error) {
+	resp, err := u.HTTPClient.Get(asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", asset.Name, resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = &progressReader{reader: resp.Body, total: resp.ContentLength, progress: progress}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	return data, nil
+}
+
+// ReplaceExecutable atomically replaces the binary at path. The new binary is written
+// next to it and renamed into place so a failed update never leaves a partial file.
+func ReplaceExecutable(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat current executable: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once the rename succeeded
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0111); err != nil {
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+	return nil
+}
+
+// IsNewer reports whether latest is a newer version than current. Versions that
+// cannot be parsed (such as development builds) are always considered outdated.
+func IsNewer(current, latest string) bool {
+	latestParts, latestPre, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	currentParts, currentPre, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+
+	for i := range latestParts {
+		if latestParts[i] != currentParts[i] {
+			return latestParts[i] > currentParts[i]
+		}
+	}
+	// A release outranks a pre-release of the same version
+	return currentPre && !latestPre
+}
+
+// parseVersion parses "v1.2.3" or "1.2.3-rc1" into its numeric parts
+func parseVersion(version string) ([3]int, bool, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	preRelease := false
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		preRelease = version[i] == '-'
+		version = version[:i]
+	}
+
+	fields := strings.Split(version, ".")
+	if len(fields) != 3 {
+		return parts, false, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false, false
+		}
+		parts[i] = n
+	}
+	return parts, preRelease, true
+}
+
+func findAsset(release *Release, name string) *Asset {
+	for i := range release.Assets {
+		if release.Assets[i].Name == name {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// findChecksum looks up a file in a sha256sum-style checksums file
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found for %s", name)
+}
+
+// extractBinary returns the claude-gate binary from a release tar.gz archive
+func extractBinary(archive []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("archive does not contain %s", binaryName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binaryName {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// progressReader reports the number of bytes read so far
+type progressReader struct {
+	reader     io.Reader
+	total      int64
+	downloaded int64
+	progress   ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.downloaded += int64(n)
+	r.progress(r.downloaded, r.total)
+	return n, err
+}
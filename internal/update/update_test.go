@@ -0,0 +1,196 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReleaseServer serves a GitHub-style latest release for linux/amd64
+type fakeReleaseServer struct {
+	*httptest.Server
+	archive   []byte
+	checksums string
+}
+
+func newFakeReleaseServer(t *testing.T, version string, binary []byte) *fakeReleaseServer {
+	t.Helper()
+
+	archiveName := fmt.Sprintf("claude-gate_%s_Linux_x86_64.tar.gz", version)
+	archive := buildArchive(t, fmt.Sprintf("claude-gate_%s_Linux_x86_64/claude-gate", version), binary)
+	sum := sha256.Sum256(archive)
+
+	fake := &fakeReleaseServer{
+		archive:   archive,
+		checksums: fmt.Sprintf("%s  %s\n%s  claude-gate_%s_Darwin_arm64.tar.gz\n", hex.EncodeToString(sum[:]), archiveName, hex.EncodeToString(make([]byte, 32)), version),
+	}
+
+	mux := http.NewServeMux()
+	fake.Server = httptest.NewServer(mux)
+	mux.HandleFunc("/repos/ml0-1337/claude-gate/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Release{
+			TagName: "v" + version,
+			Assets: []Asset{
+				{Name: archiveName, BrowserDownloadURL: fake.URL + "/download/" + archiveName},
+				{Name: "checksums.txt", BrowserDownloadURL: fake.URL + "/download/checksums.txt"},
+			},
+		})
+	})
+	mux.HandleFunc("/download/"+archiveName, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(fake.archive)
+	})
+	mux.HandleFunc("/download/checksums.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fake.checksums))
+	})
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+func buildArchive(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 2, Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte("hi"))
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err = tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func newTestUpdater(apiURL string) *Updater {
+	updater := NewUpdater()
+	updater.APIURL = apiURL
+	updater.GOOS = "linux"
+	updater.GOARCH = "amd64"
+	return updater
+}
+
+func TestUpdater(t *testing.T) {
+	t.Run("should download, verify and install the latest release", func(t *testing.T) {
+		// Arrange
+		server := newFakeReleaseServer(t, "1.2.0", []byte("new binary"))
+		updater := newTestUpdater(server.URL)
+
+		executable := filepath.Join(t.TempDir(), "claude-gate")
+		require.NoError(t, os.WriteFile(executable, []byte("old binary"), 0755))
+
+		// Act
+		release, err := updater.LatestRelease()
+		require.NoError(t, err)
+
+		var lastDownloaded int64
+		binary, err := updater.Download(release, func(downloaded, total int64) {
+			lastDownloaded = downloaded
+		})
+		require.NoError(t, err)
+		err = ReplaceExecutable(executable, binary)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.0", release.Version())
+		assert.Equal(t, int64(len(server.archive)), lastDownloaded)
+
+		installed, err := os.ReadFile(executable)
+		require.NoError(t, err)
+		assert.Equal(t, "new binary", string(installed))
+
+		info, err := os.Stat(executable)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		entries, err := os.ReadDir(filepath.Dir(executable))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files should be cleaned up")
+	})
+
+	t.Run("should reject an archive with a bad checksum", func(t *testing.T) {
+		server := newFakeReleaseServer(t, "1.2.0", []byte("new binary"))
+		server.archive = buildArchive(t, "claude-gate", []byte("tampered binary"))
+		updater := newTestUpdater(server.URL)
+
+		release, err := updater.LatestRelease()
+		require.NoError(t, err)
+
+		_, err = updater.Download(release, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch")
+	})
+
+	t.Run("should refuse releases without checksums", func(t *testing.T) {
+		server := newFakeReleaseServer(t, "1.2.0", []byte("new binary"))
+		updater := newTestUpdater(server.URL)
+
+		release, err := updater.LatestRelease()
+		require.NoError(t, err)
+		release.Assets = release.Assets[:1]
+
+		_, err = updater.Download(release, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksums.txt")
+	})
+
+	t.Run("should report missing builds for the platform", func(t *testing.T) {
+		server := newFakeReleaseServer(t, "1.2.0", []byte("new binary"))
+		updater := newTestUpdater(server.URL)
+		updater.GOOS = "freebsd"
+
+		release, err := updater.LatestRelease()
+		require.NoError(t, err)
+
+		_, err = updater.Download(release, nil)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "freebsd/amd64")
+	})
+}
+
+func TestArchiveName(t *testing.T) {
+	updater := &Updater{GOOS: "darwin", GOARCH: "arm64"}
+	assert.Equal(t, "claude-gate_1.0.0_Darwin_arm64.tar.gz", updater.ArchiveName("1.0.0"))
+
+	updater = &Updater{GOOS: "linux", GOARCH: "amd64"}
+	assert.Equal(t, "claude-gate_1.0.0_Linux_x86_64.tar.gz", updater.ArchiveName("1.0.0"))
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		current string
+		latest  string
+		want    bool
+	}{
+		{"0.1.0", "v0.2.0", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"1.10.0", "1.9.9", false},
+		{"1.2.3-rc1", "1.2.3", true},
+		{"1.2.3", "1.2.4-rc1", true},
+		{"dev", "1.0.0", true},
+		{"1.0.0", "garbage", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.current+"->"+tt.latest, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsNewer(tt.current, tt.latest))
+		})
+	}
+}